@@ -0,0 +1,255 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	defaultName         = "app"
+	defaultStartTimeout = 15 * time.Second
+	defaultStopTimeout  = 30 * time.Second
+)
+
+var (
+	ErrStarted = fmt.Errorf("app already started")
+)
+
+// Hook is a component started and stopped with the app.
+// Start must not block, the long-running work goes to goroutines stopped by Stop
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+type App interface {
+	// Append adds hook, the hooks are started in the order appended and stopped in reverse order.
+	Append(hook Hook) error
+	// Run starts the hooks, waits for SIGINT/SIGTERM, ctx done or Shutdown, then stops the hooks
+	// and flushes the logs. the errors of starting and stopping are returned.
+	Run(ctx context.Context) error
+	// Shutdown makes Run stop the hooks and return, e.g. on a fatal error of a component.
+	Shutdown()
+}
+
+type Opt func(a *app)
+
+// WithLogger configures the default logger before the hooks are started
+func WithLogger(cfg logger.Config) Opt {
+	return func(a *app) {
+		a.logCfg = &cfg
+	}
+}
+
+// WithTrafficLog configures the traffic logger before the hooks are started
+func WithTrafficLog(cfg logger.TrafficLogConfig) Opt {
+	return func(a *app) {
+		a.trafficCfg = &cfg
+	}
+}
+
+// WithAuditLog configures the audit logger before the hooks are started
+func WithAuditLog(cfg logger.AuditLogConfig) Opt {
+	return func(a *app) {
+		a.auditCfg = &cfg
+	}
+}
+
+// WithSignals overrides the signals stopping the app, SIGINT and SIGTERM by default
+func WithSignals(signals ...os.Signal) Opt {
+	return func(a *app) {
+		a.signals = signals
+	}
+}
+
+type app struct {
+	cfg        *Config
+	logCfg     *logger.Config
+	trafficCfg *logger.TrafficLogConfig
+	auditCfg   *logger.AuditLogConfig
+	signals    []os.Signal
+
+	lock      sync.Mutex
+	hooks     []Hook
+	started   bool
+	shutdownC chan struct{}
+	once      sync.Once
+}
+
+// NewApp creates an app with cfg, the hooks are appended before Run
+func NewApp(cfg *Config, opts ...Opt) App {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.Name == "" {
+		cfg.Name = defaultName
+	}
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = defaultStartTimeout
+	}
+	if cfg.StopTimeout <= 0 {
+		cfg.StopTimeout = defaultStopTimeout
+	}
+
+	a := &app{
+		cfg:       cfg,
+		signals:   []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		shutdownC: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+func (a *app) Append(hook Hook) error {
+	if hook.Start == nil && hook.Stop == nil {
+		return fmt.Errorf("hook %s has neither start nor stop", hook.Name)
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.started {
+		return fmt.Errorf("%w: append hook %s", ErrStarted, hook.Name)
+	}
+	a.hooks = append(a.hooks, hook)
+	return nil
+}
+
+func (a *app) Shutdown() {
+	a.once.Do(func() {
+		close(a.shutdownC)
+	})
+}
+
+func (a *app) Run(ctx context.Context) (err error) {
+	a.lock.Lock()
+	if a.started {
+		a.lock.Unlock()
+		return ErrStarted
+	}
+	a.started = true
+	hooks := a.hooks
+	a.lock.Unlock()
+
+	if a.logCfg != nil {
+		logger.Configure(*a.logCfg)
+	}
+	if a.trafficCfg != nil {
+		logger.ConfigureTrafficLog(*a.trafficCfg)
+	}
+	if a.auditCfg != nil {
+		logger.ConfigureAuditLog(*a.auditCfg)
+	}
+	defer logger.Close()
+
+	ctx = monitor.InitSingleFlight(ctx, a.cfg.Name)
+	le := logger.WithFields(logger.Fields{
+		"app": a.cfg.Name,
+	})
+
+	started, err := a.start(ctx, hooks)
+	if err == nil {
+		le.Info("app started")
+		a.wait(ctx, le)
+	}
+
+	// the stop timeout is not bounded by ctx, which may be done already
+	stopCtx, cancel := context.WithTimeout(monitor.CopyToContext(ctx, context.Background()), a.cfg.StopTimeout)
+	defer cancel()
+
+	err = common.Append(err, a.stop(stopCtx, hooks[:started])).ErrorOrNil()
+	if err != nil {
+		le.WithError(err).Error("app stopped with error")
+	} else {
+		le.Info("app stopped")
+	}
+	return err
+}
+
+// start starts the hooks in order until one fails, returns the number of started hooks
+func (a *app) start(ctx context.Context, hooks []Hook) (started int, err error) {
+	startCtx, cancel := context.WithTimeout(ctx, a.cfg.StartTimeout)
+	defer cancel()
+
+	for _, hook := range hooks {
+		if hook.Start != nil {
+			if err = a.call(startCtx, "start_"+hook.Name, hook.Start); err != nil {
+				return started, fmt.Errorf("start %s error: %w", hook.Name, err)
+			}
+		}
+		started++
+	}
+	return started, nil
+}
+
+// stop stops the hooks in reverse order, all of them are stopped even if some fail
+func (a *app) stop(ctx context.Context, hooks []Hook) error {
+	var errs *common.MultiError
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.Stop == nil {
+			continue
+		}
+		if err := a.call(ctx, "stop_"+hook.Name, hook.Stop); err != nil {
+			errs = common.Append(errs, fmt.Errorf("stop %s error: %w", hook.Name, err))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// call runs fn with metrics, returns when fn returns or ctx is done
+func (a *app) call(ctx context.Context, dsCmd string, fn func(ctx context.Context) error) (err error) {
+	rec := monitor.BeginRecord(ctx, dsCmd)
+	defer func() {
+		rec.EndWithError(err)
+	}()
+
+	errC := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errC <- common.Internal(fmt.Errorf("panic: %v", r))
+			}
+		}()
+		errC <- fn(ctx)
+	}()
+
+	select {
+	case err = <-errC:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wait blocks until a signal, ctx done or Shutdown
+func (a *app) wait(ctx context.Context, le logger.Entry) {
+	sigC := make(chan os.Signal, 1)
+	if len(a.signals) > 0 {
+		signal.Notify(sigC, a.signals...)
+		defer signal.Stop(sigC)
+	}
+
+	select {
+	case sig := <-sigC:
+		le.WithFields(logger.Fields{
+			"signal": sig.String(),
+		}).Info("app stopping on signal")
+	case <-ctx.Done():
+		le.WithError(ctx.Err()).Info("app stopping on context done")
+	case <-a.shutdownC:
+		le.Info("app stopping on shutdown")
+	}
+}
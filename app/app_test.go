@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	lock  sync.Mutex
+	calls []string
+}
+
+func (r *recorder) hook(name string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			r.add("start_" + name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) error {
+			r.add("stop_" + name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) add(call string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func TestApp_Run(t *testing.T) {
+	t.Run("when shutdown then stop hooks in reverse order", func(t *testing.T) {
+		rec := &recorder{}
+		a := NewApp(&Config{}, WithSignals())
+		_ = a.Append(rec.hook("a", nil))
+		_ = a.Append(rec.hook("b", nil))
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			a.Shutdown()
+		}()
+
+		if err := a.Run(context.Background()); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		want := []string{"start_a", "start_b", "stop_b", "stop_a"}
+		if !reflect.DeepEqual(rec.calls, want) {
+			t.Errorf("calls = %v, want %v", rec.calls, want)
+		}
+	})
+
+	t.Run("when hook fails to start then stop the started ones", func(t *testing.T) {
+		rec := &recorder{}
+		a := NewApp(&Config{}, WithSignals())
+		_ = a.Append(rec.hook("a", nil))
+		_ = a.Append(rec.hook("b", errors.New("boom")))
+		_ = a.Append(rec.hook("c", nil))
+
+		if err := a.Run(context.Background()); err == nil {
+			t.Fatalf("Run() error = nil, want error")
+		}
+		want := []string{"start_a", "start_b", "stop_a"}
+		if !reflect.DeepEqual(rec.calls, want) {
+			t.Errorf("calls = %v, want %v", rec.calls, want)
+		}
+	})
+
+	t.Run("when hook stops slowly then return after stop timeout", func(t *testing.T) {
+		a := NewApp(&Config{StopTimeout: 10 * time.Millisecond}, WithSignals())
+		_ = a.Append(Hook{
+			Name: "slow",
+			Stop: func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		begin := time.Now()
+		err := a.Run(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Run() error = %v, want DeadlineExceeded", err)
+		}
+		if time.Since(begin) > 500*time.Millisecond {
+			t.Errorf("Run() took %v, want bounded by stop timeout", time.Since(begin))
+		}
+	})
+
+	t.Run("when run twice then ErrStarted", func(t *testing.T) {
+		a := NewApp(&Config{}, WithSignals())
+		a.Shutdown()
+		_ = a.Run(context.Background())
+
+		if err := a.Run(context.Background()); !errors.Is(err, ErrStarted) {
+			t.Errorf("Run() error = %v, want ErrStarted", err)
+		}
+		if err := a.Append(Hook{Name: "late", Stop: func(ctx context.Context) error { return nil }}); !errors.Is(err, ErrStarted) {
+			t.Errorf("Append() error = %v, want ErrStarted", err)
+		}
+	})
+}
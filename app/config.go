@@ -0,0 +1,12 @@
+package app
+
+import "time"
+
+type Config struct {
+	// Name is the monitor cmd of the hooks, e.g. the service name
+	Name string `yaml:"name" json:"name" default:"app"`
+	// StartTimeout bounds starting all the hooks
+	StartTimeout time.Duration `yaml:"start_timeout" json:"start_timeout" default:"15s"`
+	// StopTimeout bounds stopping all the hooks, e.g. draining http requests and queued tasks
+	StopTimeout time.Duration `yaml:"stop_timeout" json:"stop_timeout" default:"30s"`
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/tenz-io/trackingo/httpgin"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/workerpool"
+)
+
+// Service is a background component started and stopped with the app, e.g. cron.Runner and outbox.Relay
+type Service interface {
+	Start()
+	Stop(ctx context.Context) error
+}
+
+// ServiceHook starts and stops s with the app
+func ServiceHook(name string, s Service) Hook {
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			s.Start()
+			return nil
+		},
+		Stop: s.Stop,
+	}
+}
+
+// WorkerPoolHook drains p when the app stops
+func WorkerPoolHook(name string, p workerpool.Pool) Hook {
+	return Hook{
+		Name: name,
+		Stop: p.Close,
+	}
+}
+
+// CloserHook calls closeFn when the app stops, e.g. dborm.ShardedManager.Close
+func CloserHook(name string, closeFn func()) Hook {
+	return Hook{
+		Name: name,
+		Stop: func(ctx context.Context) error {
+			closeFn()
+			return nil
+		},
+	}
+}
+
+// HTTPHook serves m at addr once started, the listen error fails the start.
+// the server is shut down gracefully, the in-flight requests are drained until the stop timeout.
+// a serving error after start shuts down the app
+func HTTPHook(a App, addr string, m httpgin.Manager) Hook {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: m.Handler(),
+	}
+
+	return Hook{
+		Name: "http",
+		Start: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("listen %s error: %w", addr, err)
+			}
+
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.WithError(err).WithFields(logger.Fields{
+						"addr": addr,
+					}).Error("http server error")
+					a.Shutdown()
+				}
+			}()
+			return nil
+		},
+		Stop: srv.Shutdown,
+	}
+}
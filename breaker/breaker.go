@@ -0,0 +1,242 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenProbes   = 1
+)
+
+var (
+	// ErrOpen is returned without calling the protected function while the breaker is open
+	ErrOpen = fmt.Errorf("circuit breaker is open")
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+type Breaker interface {
+	// Do calls fn if the breaker of key allows, ErrOpen otherwise, and records its result.
+	Do(ctx context.Context, key string, fn func(ctx context.Context) error) error
+	// Allow returns ErrOpen if the breaker of key rejects the call,
+	// otherwise the caller must Record the result of the call.
+	Allow(key string) error
+	// Record records the result of a call allowed by Allow, ErrOpen is ignored.
+	Record(key string, err error)
+	// State returns the state of the breaker of key.
+	State(key string) State
+}
+
+type Opt func(b *breaker)
+
+// WithIsFailure decides which errors count as failures, all but context.Canceled by default,
+// e.g. a not found error should not open the breaker
+func WithIsFailure(isFailure func(err error) bool) Opt {
+	return func(b *breaker) {
+		b.isFailure = isFailure
+	}
+}
+
+type circuit struct {
+	state    State
+	failures int
+	probes   int
+	passed   int
+	openedAt time.Time
+}
+
+type breaker struct {
+	name      string
+	cfg       *Config
+	isFailure func(err error) bool
+	mon       monitor.SingleFlight
+	lock      sync.Mutex
+	circuits  map[string]*circuit
+	now       func() time.Time
+}
+
+// NewBreaker creates the per-key breakers of name, e.g. one breaker per downstream host.
+// the state changes are logged and exported as breaker_<name> gauges with the key as opt
+func NewBreaker(name string, cfg *Config, opts ...Opt) Breaker {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultOpenTimeout
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = defaultHalfOpenProbes
+	}
+
+	b := &breaker{
+		name:      name,
+		cfg:       cfg,
+		isFailure: isFailure,
+		mon:       monitor.NewSingleFlight("breaker"),
+		circuits:  make(map[string]*circuit),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func (b *breaker) Do(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	if err := b.Allow(key); err != nil {
+		return err
+	}
+
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			b.Record(key, fmt.Errorf("panic: %v", r))
+			panic(r)
+		}
+		b.Record(key, err)
+	}()
+
+	err = fn(ctx)
+	return err
+}
+
+func (b *breaker) Allow(key string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	c := b.circuitOf(key)
+	switch c.state {
+	case StateOpen:
+		if b.now().Sub(c.openedAt) < b.cfg.OpenTimeout {
+			return fmt.Errorf("%w: %s", ErrOpen, key)
+		}
+		b.setState(key, c, StateHalfOpen)
+		fallthrough
+	case StateHalfOpen:
+		if c.probes >= b.cfg.HalfOpenProbes {
+			return fmt.Errorf("%w: %s", ErrOpen, key)
+		}
+		c.probes++
+	}
+	return nil
+}
+
+func (b *breaker) Record(key string, err error) {
+	if errors.Is(err, ErrOpen) {
+		return
+	}
+	failed := err != nil && b.isFailure(err)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	c := b.circuitOf(key)
+	switch c.state {
+	case StateClosed:
+		if !failed {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= b.cfg.FailureThreshold {
+			b.setState(key, c, StateOpen)
+		}
+	case StateHalfOpen:
+		if failed {
+			b.setState(key, c, StateOpen)
+			return
+		}
+		c.passed++
+		if c.passed >= b.cfg.HalfOpenProbes {
+			b.setState(key, c, StateClosed)
+		}
+	default:
+		// the calls allowed before opening finish late
+	}
+}
+
+func (b *breaker) State(key string) State {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		return StateClosed
+	}
+	if c.state == StateOpen && b.now().Sub(c.openedAt) >= b.cfg.OpenTimeout {
+		return StateHalfOpen
+	}
+	return c.state
+}
+
+func (b *breaker) circuitOf(key string) *circuit {
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// setState moves c to state and resets the counters, the lock must be held
+func (b *breaker) setState(key string, c *circuit, state State) {
+	from := c.state
+	c.state = state
+	c.failures = 0
+	c.probes = 0
+	c.passed = 0
+	if state == StateOpen {
+		c.openedAt = b.now()
+	}
+
+	le := logger.WithFields(logger.Fields{
+		"breaker": b.name,
+		"key":     key,
+		"from":    from.String(),
+		"to":      state.String(),
+	})
+	if state == StateOpen {
+		le.Warn("circuit breaker state changed")
+	} else {
+		le.Info("circuit breaker state changed")
+	}
+
+	ctx := context.Background()
+	b.mon.Set(ctx, "breaker_"+b.name, 0, float64(state), key)
+	b.mon.Count(ctx, "breaker_"+b.name+"_"+state.String(), 0, key)
+}
+
+func isFailure(err error) bool {
+	return !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,101 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(now *time.Time) *breaker {
+	b := NewBreaker("test", &Config{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		HalfOpenProbes:   1,
+	}).(*breaker)
+	b.now = func() time.Time {
+		return *now
+	}
+	return b
+}
+
+func TestBreaker(t *testing.T) {
+	var (
+		now     = time.Now()
+		b       = newTestBreaker(&now)
+		errDown = errors.New("down")
+		ctx     = context.Background()
+		fail    = func(ctx context.Context) error { return errDown }
+		pass    = func(ctx context.Context) error { return nil }
+	)
+
+	t.Run("when failures reach threshold then open", func(t *testing.T) {
+		_ = b.Do(ctx, "a", fail)
+		if got := b.State("a"); got != StateClosed {
+			t.Fatalf("State() = %v, want closed", got)
+		}
+		_ = b.Do(ctx, "a", fail)
+		if got := b.State("a"); got != StateOpen {
+			t.Fatalf("State() = %v, want open", got)
+		}
+	})
+
+	t.Run("when open then reject without calling", func(t *testing.T) {
+		called := false
+		err := b.Do(ctx, "a", func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+		if !errors.Is(err, ErrOpen) || called {
+			t.Errorf("Do() error = %v, called = %v, want ErrOpen without call", err, called)
+		}
+	})
+
+	t.Run("when other key then not affected", func(t *testing.T) {
+		if err := b.Do(ctx, "b", pass); err != nil {
+			t.Errorf("Do() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("when open timeout passed then allow one probe", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if got := b.State("a"); got != StateHalfOpen {
+			t.Fatalf("State() = %v, want half_open", got)
+		}
+		if err := b.Allow("a"); err != nil {
+			t.Fatalf("Allow() error = %v, want nil", err)
+		}
+		if err := b.Allow("a"); !errors.Is(err, ErrOpen) {
+			t.Errorf("Allow() error = %v, want ErrOpen for the second probe", err)
+		}
+	})
+
+	t.Run("when probe fails then open again", func(t *testing.T) {
+		b.Record("a", errDown)
+		if got := b.State("a"); got != StateOpen {
+			t.Fatalf("State() = %v, want open", got)
+		}
+	})
+
+	t.Run("when probe succeeds then close", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if err := b.Do(ctx, "a", pass); err != nil {
+			t.Fatalf("Do() error = %v, want nil", err)
+		}
+		if got := b.State("a"); got != StateClosed {
+			t.Errorf("State() = %v, want closed", got)
+		}
+	})
+}
+
+func TestBreaker_IsFailure(t *testing.T) {
+	errNotFound := errors.New("not found")
+	b := NewBreaker("test", &Config{FailureThreshold: 1}, WithIsFailure(func(err error) bool {
+		return !errors.Is(err, errNotFound)
+	}))
+
+	_ = b.Do(context.Background(), "a", func(ctx context.Context) error { return errNotFound })
+	if got := b.State("a"); got != StateClosed {
+		t.Errorf("State() = %v, want closed", got)
+	}
+}
@@ -0,0 +1,13 @@
+package breaker
+
+import "time"
+
+type Config struct {
+	// FailureThreshold is the number of consecutive failures opening the breaker of a key
+	FailureThreshold int `yaml:"failure_threshold" json:"failure_threshold" default:"5"`
+	// OpenTimeout is how long the breaker stays open before letting probes through
+	OpenTimeout time.Duration `yaml:"open_timeout" json:"open_timeout" default:"30s"`
+	// HalfOpenProbes is the number of probes allowed while half open,
+	// the breaker closes once they all succeed and opens again on any failure
+	HalfOpenProbes int `yaml:"half_open_probes" json:"half_open_probes" default:"1"`
+}
@@ -14,6 +14,54 @@ type MockManager struct {
 	mock.Mock
 }
 
+// Decr provides a mock function with given fields: ctx, key, expire
+func (_m *MockManager) Decr(ctx context.Context, key string, expire time.Duration) (int64, error) {
+	ret := _m.Called(ctx, key, expire)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) (int64, error)); ok {
+		return rf(ctx, key, expire)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) int64); ok {
+		r0 = rf(ctx, key, expire)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, expire)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecrBy provides a mock function with given fields: ctx, key, delta, expire
+func (_m *MockManager) DecrBy(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	ret := _m.Called(ctx, key, delta, expire)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, time.Duration) (int64, error)); ok {
+		return rf(ctx, key, delta, expire)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, time.Duration) int64); ok {
+		r0 = rf(ctx, key, delta, expire)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, time.Duration) error); ok {
+		r1 = rf(ctx, key, delta, expire)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Del provides a mock function with given fields: ctx, key
 func (_m *MockManager) Del(ctx context.Context, key string) error {
 	ret := _m.Called(ctx, key)
@@ -28,6 +76,30 @@ func (_m *MockManager) Del(ctx context.Context, key string) error {
 	return r0
 }
 
+// DelPattern provides a mock function with given fields: ctx, pattern
+func (_m *MockManager) DelPattern(ctx context.Context, pattern string) (int64, error) {
+	ret := _m.Called(ctx, pattern)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, pattern)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, pattern)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, pattern)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Eval provides a mock function with given fields: ctx, script, keys, args
 func (_m *MockManager) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
 	var _ca []interface{}
@@ -57,6 +129,30 @@ func (_m *MockManager) Eval(ctx context.Context, script string, keys []string, a
 	return r0, r1
 }
 
+// Exists provides a mock function with given fields: ctx, key
+func (_m *MockManager) Exists(ctx context.Context, key string) (bool, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Expire provides a mock function with given fields: ctx, key, expire
 func (_m *MockManager) Expire(ctx context.Context, key string, expire time.Duration) error {
 	ret := _m.Called(ctx, key, expire)
@@ -109,13 +205,116 @@ func (_m *MockManager) GetBlob(ctx context.Context, key string, output interface
 	return r0
 }
 
-// Set provides a mock function with given fields: ctx, key, raw, expire
-func (_m *MockManager) Set(ctx context.Context, key string, raw string, expire time.Duration) error {
+// GetDel provides a mock function with given fields: ctx, key
+func (_m *MockManager) GetDel(ctx context.Context, key string) (string, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEx provides a mock function with given fields: ctx, key, expire
+func (_m *MockManager) GetEx(ctx context.Context, key string, expire time.Duration) (string, error) {
+	ret := _m.Called(ctx, key, expire)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) (string, error)); ok {
+		return rf(ctx, key, expire)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) string); ok {
+		r0 = rf(ctx, key, expire)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, expire)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrLoad provides a mock function with given fields: ctx, key, expire, loader
+func (_m *MockManager) GetOrLoad(ctx context.Context, key string, expire time.Duration, loader Loader) (string, error) {
+	ret := _m.Called(ctx, key, expire, loader)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration, Loader) (string, error)); ok {
+		return rf(ctx, key, expire, loader)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration, Loader) string); ok {
+		r0 = rf(ctx, key, expire, loader)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration, Loader) error); ok {
+		r1 = rf(ctx, key, expire, loader)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSet provides a mock function with given fields: ctx, key, raw, expire
+func (_m *MockManager) GetSet(ctx context.Context, key string, raw string, expire time.Duration) (string, error) {
 	ret := _m.Called(ctx, key, raw, expire)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) error); ok {
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) (string, error)); ok {
+		return rf(ctx, key, raw, expire)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) string); ok {
 		r0 = rf(ctx, key, raw, expire)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, raw, expire)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HDel provides a mock function with given fields: ctx, key, fields
+func (_m *MockManager) HDel(ctx context.Context, key string, fields ...string) error {
+	_va := make([]interface{}, len(fields))
+	for _i := range fields {
+		_va[_i] = fields[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) error); ok {
+		r0 = rf(ctx, key, fields...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -123,13 +322,87 @@ func (_m *MockManager) Set(ctx context.Context, key string, raw string, expire t
 	return r0
 }
 
-// SetBlob provides a mock function with given fields: ctx, key, val, expire
-func (_m *MockManager) SetBlob(ctx context.Context, key string, val interface{}, expire time.Duration) error {
-	ret := _m.Called(ctx, key, val, expire)
+// HGet provides a mock function with given fields: ctx, key, field
+func (_m *MockManager) HGet(ctx context.Context, key string, field string) (string, error) {
+	ret := _m.Called(ctx, key, field)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, key, field)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, key, field)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, key, field)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HGetAll provides a mock function with given fields: ctx, key
+func (_m *MockManager) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 map[string]string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (map[string]string, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) map[string]string); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HIncrBy provides a mock function with given fields: ctx, key, field, delta
+func (_m *MockManager) HIncrBy(ctx context.Context, key string, field string, delta int64) (int64, error) {
+	ret := _m.Called(ctx, key, field, delta)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) (int64, error)); ok {
+		return rf(ctx, key, field, delta)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) int64); ok {
+		r0 = rf(ctx, key, field, delta)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64) error); ok {
+		r1 = rf(ctx, key, field, delta)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HSet provides a mock function with given fields: ctx, key, fields
+func (_m *MockManager) HSet(ctx context.Context, key string, fields map[string]string) error {
+	ret := _m.Called(ctx, key, fields)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, time.Duration) error); ok {
-		r0 = rf(ctx, key, val, expire)
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) error); ok {
+		r0 = rf(ctx, key, fields)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -137,23 +410,561 @@ func (_m *MockManager) SetBlob(ctx context.Context, key string, val interface{},
 	return r0
 }
 
-// SetNx provides a mock function with given fields: ctx, key, raw, expire
-func (_m *MockManager) SetNx(ctx context.Context, key string, raw string, expire time.Duration) (bool, error) {
-	ret := _m.Called(ctx, key, raw, expire)
+// Incr provides a mock function with given fields: ctx, key, expire
+func (_m *MockManager) Incr(ctx context.Context, key string, expire time.Duration) (int64, error) {
+	ret := _m.Called(ctx, key, expire)
 
-	var r0 bool
+	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) (bool, error)); ok {
-		return rf(ctx, key, raw, expire)
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) (int64, error)); ok {
+		return rf(ctx, key, expire)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) bool); ok {
-		r0 = rf(ctx, key, raw, expire)
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) int64); ok {
+		r0 = rf(ctx, key, expire)
 	} else {
-		r0 = ret.Get(0).(bool)
+		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Duration) error); ok {
-		r1 = rf(ctx, key, raw, expire)
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, expire)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrBy provides a mock function with given fields: ctx, key, delta, expire
+func (_m *MockManager) IncrBy(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	ret := _m.Called(ctx, key, delta, expire)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, time.Duration) (int64, error)); ok {
+		return rf(ctx, key, delta, expire)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, time.Duration) int64); ok {
+		r0 = rf(ctx, key, delta, expire)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, time.Duration) error); ok {
+		r1 = rf(ctx, key, delta, expire)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MGet provides a mock function with given fields: ctx, keys
+func (_m *MockManager) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	_va := make([]interface{}, len(keys))
+	for _i := range keys {
+		_va[_i] = keys[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 map[string]string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) (map[string]string, error)); ok {
+		return rf(ctx, keys...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) map[string]string); ok {
+		r0 = rf(ctx, keys...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ...string) error); ok {
+		r1 = rf(ctx, keys...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MSet provides a mock function with given fields: ctx, kvs, expire
+func (_m *MockManager) MSet(ctx context.Context, kvs map[string]string, expire time.Duration) error {
+	ret := _m.Called(ctx, kvs, expire)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, map[string]string, time.Duration) error); ok {
+		r0 = rf(ctx, kvs, expire)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Pipeline provides a mock function with given fields: ctx, fn
+func (_m *MockManager) Pipeline(ctx context.Context, fn func(Pipeliner) error) error {
+	ret := _m.Called(ctx, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(Pipeliner) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publish provides a mock function with given fields: ctx, channel, payload
+func (_m *MockManager) Publish(ctx context.Context, channel string, payload string) error {
+	ret := _m.Called(ctx, channel, payload)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, channel, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SAdd provides a mock function with given fields: ctx, key, members
+func (_m *MockManager) SAdd(ctx context.Context, key string, members ...string) error {
+	_va := make([]interface{}, len(members))
+	for _i := range members {
+		_va[_i] = members[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) error); ok {
+		r0 = rf(ctx, key, members...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SCard provides a mock function with given fields: ctx, key
+func (_m *MockManager) SCard(ctx context.Context, key string) (int64, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SIsMember provides a mock function with given fields: ctx, key, member
+func (_m *MockManager) SIsMember(ctx context.Context, key string, member string) (bool, error) {
+	ret := _m.Called(ctx, key, member)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return rf(ctx, key, member)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, key, member)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, key, member)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SMembers provides a mock function with given fields: ctx, key
+func (_m *MockManager) SMembers(ctx context.Context, key string) ([]string, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SRem provides a mock function with given fields: ctx, key, members
+func (_m *MockManager) SRem(ctx context.Context, key string, members ...string) error {
+	_va := make([]interface{}, len(members))
+	for _i := range members {
+		_va[_i] = members[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) error); ok {
+		r0 = rf(ctx, key, members...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Scan provides a mock function with given fields: ctx, pattern, count
+func (_m *MockManager) Scan(ctx context.Context, pattern string, count int64) KeyIterator {
+	ret := _m.Called(ctx, pattern, count)
+
+	var r0 KeyIterator
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) KeyIterator); ok {
+		r0 = rf(ctx, pattern, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(KeyIterator)
+		}
+	}
+
+	return r0
+}
+
+// Set provides a mock function with given fields: ctx, key, raw, expire
+func (_m *MockManager) Set(ctx context.Context, key string, raw string, expire time.Duration) error {
+	ret := _m.Called(ctx, key, raw, expire)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) error); ok {
+		r0 = rf(ctx, key, raw, expire)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetBlob provides a mock function with given fields: ctx, key, val, expire
+func (_m *MockManager) SetBlob(ctx context.Context, key string, val interface{}, expire time.Duration) error {
+	ret := _m.Called(ctx, key, val, expire)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, time.Duration) error); ok {
+		r0 = rf(ctx, key, val, expire)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetNx provides a mock function with given fields: ctx, key, raw, expire
+func (_m *MockManager) SetNx(ctx context.Context, key string, raw string, expire time.Duration) (bool, error) {
+	ret := _m.Called(ctx, key, raw, expire)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) (bool, error)); ok {
+		return rf(ctx, key, raw, expire)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) bool); ok {
+		r0 = rf(ctx, key, raw, expire)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, raw, expire)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Subscribe provides a mock function with given fields: ctx, channel, handler
+func (_m *MockManager) Subscribe(ctx context.Context, channel string, handler MessageHandler) error {
+	ret := _m.Called(ctx, channel, handler)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, MessageHandler) error); ok {
+		r0 = rf(ctx, channel, handler)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TTL provides a mock function with given fields: ctx, key
+func (_m *MockManager) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 time.Duration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (time.Duration, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) time.Duration); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TxPipeline provides a mock function with given fields: ctx, fn
+func (_m *MockManager) TxPipeline(ctx context.Context, fn func(Pipeliner) error) error {
+	ret := _m.Called(ctx, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(Pipeliner) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// XAck provides a mock function with given fields: ctx, stream, group, ids
+func (_m *MockManager) XAck(ctx context.Context, stream string, group string, ids ...string) error {
+	_va := make([]interface{}, len(ids))
+	for _i := range ids {
+		_va[_i] = ids[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, stream, group)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, ...string) error); ok {
+		r0 = rf(ctx, stream, group, ids...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// XAdd provides a mock function with given fields: ctx, stream, values
+func (_m *MockManager) XAdd(ctx context.Context, stream string, values map[string]string) (string, error) {
+	ret := _m.Called(ctx, stream, values)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) (string, error)); ok {
+		return rf(ctx, stream, values)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) string); ok {
+		r0 = rf(ctx, stream, values)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, map[string]string) error); ok {
+		r1 = rf(ctx, stream, values)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// XGroupCreate provides a mock function with given fields: ctx, stream, group, start
+func (_m *MockManager) XGroupCreate(ctx context.Context, stream string, group string, start string) error {
+	ret := _m.Called(ctx, stream, group, start)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, stream, group, start)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// XReadGroup provides a mock function with given fields: ctx, stream, group, consumer, count, block
+func (_m *MockManager) XReadGroup(ctx context.Context, stream string, group string, consumer string, count int64, block time.Duration) ([]XMessage, error) {
+	ret := _m.Called(ctx, stream, group, consumer, count, block)
+
+	var r0 []XMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64, time.Duration) ([]XMessage, error)); ok {
+		return rf(ctx, stream, group, consumer, count, block)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64, time.Duration) []XMessage); ok {
+		r0 = rf(ctx, stream, group, consumer, count, block)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]XMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, int64, time.Duration) error); ok {
+		r1 = rf(ctx, stream, group, consumer, count, block)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ZAdd provides a mock function with given fields: ctx, key, members
+func (_m *MockManager) ZAdd(ctx context.Context, key string, members ...Z) error {
+	_va := make([]interface{}, len(members))
+	for _i := range members {
+		_va[_i] = members[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...Z) error); ok {
+		r0 = rf(ctx, key, members...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ZIncrBy provides a mock function with given fields: ctx, key, member, delta
+func (_m *MockManager) ZIncrBy(ctx context.Context, key string, member string, delta float64) (float64, error) {
+	ret := _m.Called(ctx, key, member, delta)
+
+	var r0 float64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, float64) (float64, error)); ok {
+		return rf(ctx, key, member, delta)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, float64) float64); ok {
+		r0 = rf(ctx, key, member, delta)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, float64) error); ok {
+		r1 = rf(ctx, key, member, delta)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ZRangeByScore provides a mock function with given fields: ctx, key, min, max
+func (_m *MockManager) ZRangeByScore(ctx context.Context, key string, min float64, max float64) ([]Z, error) {
+	ret := _m.Called(ctx, key, min, max)
+
+	var r0 []Z
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, float64, float64) ([]Z, error)); ok {
+		return rf(ctx, key, min, max)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, float64, float64) []Z); ok {
+		r0 = rf(ctx, key, min, max)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Z)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, float64, float64) error); ok {
+		r1 = rf(ctx, key, min, max)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ZRem provides a mock function with given fields: ctx, key, members
+func (_m *MockManager) ZRem(ctx context.Context, key string, members ...string) error {
+	_va := make([]interface{}, len(members))
+	for _i := range members {
+		_va[_i] = members[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) error); ok {
+		r0 = rf(ctx, key, members...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ZRevRange provides a mock function with given fields: ctx, key, start, stop
+func (_m *MockManager) ZRevRange(ctx context.Context, key string, start int64, stop int64) ([]Z, error) {
+	ret := _m.Called(ctx, key, start, stop)
+
+	var r0 []Z
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int64) ([]Z, error)); ok {
+		return rf(ctx, key, start, stop)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int64) []Z); ok {
+		r0 = rf(ctx, key, start, stop)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Z)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, int64) error); ok {
+		r1 = rf(ctx, key, start, stop)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/breaker"
+)
+
+// WithBreaker guards the redis commands with b, keyed by the redis address.
+// commands fail fast with breaker.ErrOpen while redis keeps failing, a missing key is not a failure
+func WithBreaker(b breaker.Breaker) Opt {
+	return func(m *manager) {
+		if !m.active() || b == nil {
+			return
+		}
+		m.client.AddHook(&breakerHook{
+			b:   b,
+			key: "redis:" + clientAddr(m.client),
+		})
+	}
+}
+
+// clientAddr returns the address of client, the addresses joined by ',' for the cluster
+func clientAddr(client redis.UniversalClient) string {
+	switch c := client.(type) {
+	case *redis.Client:
+		return c.Options().Addr
+	case *redis.ClusterClient:
+		return strings.Join(c.Options().Addrs, ",")
+	default:
+		return "universal"
+	}
+}
+
+type breakerHook struct {
+	b   breaker.Breaker
+	key string
+}
+
+func (h *breakerHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.b.Allow(h.key); err != nil {
+			return err
+		}
+		// the error is set to cmd after the hooks
+		err := next(ctx, cmd)
+		h.b.Record(h.key, failure(err))
+		return err
+	}
+}
+
+func (h *breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.b.Allow(h.key); err != nil {
+			// the cmds of the pipeline return their own errors
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		err := next(ctx, cmds)
+		cmdsErr := failure(err)
+		for _, cmd := range cmds {
+			if cmdsErr != nil {
+				break
+			}
+			cmdsErr = failure(cmd.Err())
+		}
+		h.b.Record(h.key, cmdsErr)
+		return err
+	}
+}
+
+// failure returns err unless it's nil or a missing key
+func failure(err error) error {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return nil
+}
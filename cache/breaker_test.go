@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/breaker"
+)
+
+func TestWithBreaker(t *testing.T) {
+	// nothing listens on the port, the dial fails at once
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	defer client.Close()
+
+	b := breaker.NewBreaker("cache_test", &breaker.Config{FailureThreshold: 1})
+	m := NewManager(client, Options{WithBreaker(b)})
+	ctx := context.Background()
+
+	t.Run("when redis is down then the error is returned and the breaker opens", func(t *testing.T) {
+		if _, err := m.Get(ctx, "k"); err == nil || errors.Is(err, breaker.ErrOpen) {
+			t.Fatalf("Get() error = %v, want the dial error", err)
+		}
+		if got := b.State("redis:127.0.0.1:1"); got != breaker.StateOpen {
+			t.Errorf("State() = %v, want open", got)
+		}
+	})
+
+	t.Run("when the breaker is open then fail fast", func(t *testing.T) {
+		if _, err := m.Get(ctx, "k"); !errors.Is(err, breaker.ErrOpen) {
+			t.Errorf("Get() error = %v, want ErrOpen", err)
+		}
+	})
+}
@@ -9,8 +9,41 @@ import (
 var (
 	ErrNotFound = errors.New("cache: key not found")
 	ErrInActive = errors.New("cache: inactive")
+	// ErrNotSupported is returned by the operations the manager does not support, e.g. Eval of the local cache
+	ErrNotSupported = errors.New("cache: not supported")
+	ErrNotInt       = errors.New("cache: value is not an integer")
+	// ErrWrongType is returned by the operations of a type against the key holding another type
+	ErrWrongType = errors.New("cache: wrong type of value")
 )
 
+// Z is a member of a sorted set with its score
+type Z struct {
+	Member string
+	Score  float64
+}
+
+// MessageHandler handles a message of the subscribed channel
+type MessageHandler func(ctx context.Context, channel string, payload string) error
+
+// XMessage is a message of a stream
+type XMessage struct {
+	ID     string
+	Values map[string]string
+}
+
+// Loader loads the value of a missing key
+type Loader func(ctx context.Context) (string, error)
+
+// KeyIterator iterates the keys of Scan
+type KeyIterator interface {
+	// Next advances to the next key, false if done or failed
+	Next(ctx context.Context) bool
+	// Key returns the current key
+	Key() string
+	// Err returns the error stopping the iteration
+	Err() error
+}
+
 //go:generate mockery --name Manager --filename Manager_mock.go --inpackage
 type Manager interface {
 	// Get returns the value associated with the given key.
@@ -33,4 +66,89 @@ type Manager interface {
 	Expire(ctx context.Context, key string, expire time.Duration) (err error)
 	// Eval evaluates the given script with the given keys and arguments.
 	Eval(ctx context.Context, script string, keys []string, args ...any) (val any, err error)
+	// MGet returns the values associated with the given keys, the missing keys are not in vals.
+	MGet(ctx context.Context, keys ...string) (vals map[string]string, err error)
+	// MSet stores the given values with their keys.
+	// if expire is 0, then the keys will not expire.
+	MSet(ctx context.Context, kvs map[string]string, expire time.Duration) (err error)
+	// Incr increments the counter of the given key by 1 and returns the new value.
+	// the key is created with 0 and expires in expire if not exists, if expire is 0, then the key will not expire.
+	Incr(ctx context.Context, key string, expire time.Duration) (val int64, err error)
+	// IncrBy increments the counter of the given key by delta and returns the new value, see Incr.
+	IncrBy(ctx context.Context, key string, delta int64, expire time.Duration) (val int64, err error)
+	// Decr decrements the counter of the given key by 1 and returns the new value, see Incr.
+	Decr(ctx context.Context, key string, expire time.Duration) (val int64, err error)
+	// DecrBy decrements the counter of the given key by delta and returns the new value, see Incr.
+	DecrBy(ctx context.Context, key string, delta int64, expire time.Duration) (val int64, err error)
+	// HSet stores the given fields in the hash of the given key.
+	HSet(ctx context.Context, key string, fields map[string]string) (err error)
+	// HGet returns the value of the given field in the hash of the given key.
+	HGet(ctx context.Context, key string, field string) (raw string, err error)
+	// HGetAll returns all the fields of the hash of the given key, empty if the key does not exist.
+	HGetAll(ctx context.Context, key string) (fields map[string]string, err error)
+	// HDel deletes the given fields from the hash of the given key.
+	HDel(ctx context.Context, key string, fields ...string) (err error)
+	// HIncrBy increments the counter of the given field in the hash of the given key by delta and returns the new value.
+	HIncrBy(ctx context.Context, key string, field string, delta int64) (val int64, err error)
+	// SAdd adds the given members to the set of the given key.
+	SAdd(ctx context.Context, key string, members ...string) (err error)
+	// SRem removes the given members from the set of the given key.
+	SRem(ctx context.Context, key string, members ...string) (err error)
+	// SIsMember reports whether member is in the set of the given key.
+	SIsMember(ctx context.Context, key string, member string) (ok bool, err error)
+	// SMembers returns the members of the set of the given key, empty if the key does not exist.
+	SMembers(ctx context.Context, key string) (members []string, err error)
+	// SCard returns the number of the members of the set of the given key.
+	SCard(ctx context.Context, key string) (n int64, err error)
+	// ZAdd adds the given members to the sorted set of the given key, the scores of the existing ones are updated.
+	ZAdd(ctx context.Context, key string, members ...Z) (err error)
+	// ZRangeByScore returns the members of the sorted set of the given key with min <= score <= max, by the ascending scores.
+	// use math.Inf for the unbounded ranges.
+	ZRangeByScore(ctx context.Context, key string, min, max float64) (members []Z, err error)
+	// ZRevRange returns the members ranked from start to stop of the sorted set of the given key, by the descending scores.
+	// the negative ranks count from the lowest, e.g. -1 is the lowest.
+	ZRevRange(ctx context.Context, key string, start, stop int64) (members []Z, err error)
+	// ZRem removes the given members from the sorted set of the given key.
+	ZRem(ctx context.Context, key string, members ...string) (err error)
+	// ZIncrBy increments the score of the given member in the sorted set of the given key by delta and returns the new score.
+	ZIncrBy(ctx context.Context, key string, member string, delta float64) (score float64, err error)
+	// Pipeline runs the commands queued by fn in one round trip, the results are read from the queued commands.
+	// the missing keys of the commands are not the errors, see redis.Nil of the commands.
+	Pipeline(ctx context.Context, fn func(Pipeliner) error) (err error)
+	// TxPipeline is the same as Pipeline but the commands are wrapped in MULTI/EXEC.
+	TxPipeline(ctx context.Context, fn func(Pipeliner) error) (err error)
+	// Publish sends payload to the subscribers of the given channel.
+	Publish(ctx context.Context, channel string, payload string) (err error)
+	// Subscribe calls handler with the messages of the given channel until ctx is done.
+	// it returns once subscribed, the lost connections are resubscribed and the handler panics are recovered.
+	Subscribe(ctx context.Context, channel string, handler MessageHandler) (err error)
+	// XAdd appends a message of values to the given stream and returns its id.
+	XAdd(ctx context.Context, stream string, values map[string]string) (id string, err error)
+	// XGroupCreate creates the consumer group of the given stream reading from start, e.g. "$" for the new messages only.
+	// the stream is created if missing, and an existing group is not an error.
+	XGroupCreate(ctx context.Context, stream string, group string, start string) (err error)
+	// XReadGroup reads at most count new messages of the given stream for the consumer of the group,
+	// waiting at most block for them if block > 0, empty if none.
+	XReadGroup(ctx context.Context, stream string, group string, consumer string, count int64, block time.Duration) (msgs []XMessage, err error)
+	// XAck acknowledges the given messages of the group.
+	XAck(ctx context.Context, stream string, group string, ids ...string) (err error)
+	// GetOrLoad returns the value of the given key, or the value loaded by loader and stored with expire on a miss.
+	// the concurrent misses of the same key share one load.
+	GetOrLoad(ctx context.Context, key string, expire time.Duration, loader Loader) (raw string, err error)
+	// Exists reports whether the given key exists.
+	Exists(ctx context.Context, key string) (ok bool, err error)
+	// TTL returns the remaining time to live of the given key, 0 if the key does not expire.
+	TTL(ctx context.Context, key string) (ttl time.Duration, err error)
+	// GetDel returns the value of the given key and deletes it atomically, e.g. for the one-time tokens.
+	GetDel(ctx context.Context, key string) (raw string, err error)
+	// GetSet stores the given value with the given key and returns the old value, ErrNotFound if there was none.
+	// expire 0 means no expire.
+	GetSet(ctx context.Context, key string, raw string, expire time.Duration) (old string, err error)
+	// GetEx returns the value of the given key and resets its expire, expire 0 removes the expire.
+	GetEx(ctx context.Context, key string, expire time.Duration) (raw string, err error)
+	// Scan iterates the keys matching the glob pattern by SCAN, count is the hint of the keys per round trip.
+	// the keys added or deleted during the scan may be missed, and a key may be returned more than once.
+	Scan(ctx context.Context, pattern string, count int64) (iter KeyIterator)
+	// DelPattern deletes the keys matching the glob pattern by SCAN and returns the number of the deleted keys.
+	DelPattern(ctx context.Context, pattern string) (n int64, err error)
 }
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// clusterSlots is the number of the hash slots of the redis cluster
+const clusterSlots = 16384
+
+// ErrCrossSlot is returned by the multi-key commands of the cluster manager with the keys in different slots
+var ErrCrossSlot = errors.New("cache: keys in different hash slots")
+
+// NewClusterManager returns the Manager of the redis cluster, the keys of the multi-key commands
+// must be in the same hash slot, see HashTag
+func NewClusterManager(
+	client *redis.ClusterClient,
+	opts Options,
+) Manager {
+	m := &manager{
+		client:  client,
+		cluster: true,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// HashTag returns key in the hash slot of tag, e.g. HashTag("user:1", "profile") = "{user:1}:profile",
+// so that the keys of the same tag can be used together by the multi-key commands of the cluster
+func HashTag(tag, key string) string {
+	return "{" + tag + "}:" + key
+}
+
+// KeySlot returns the hash slot of key in the cluster, of its hash tag if any
+func KeySlot(key string) int {
+	return int(crc16(hashTagOf(key)) % clusterSlots)
+}
+
+// SameSlot reports whether keys are all in the same hash slot
+func SameSlot(keys ...string) bool {
+	for i := 1; i < len(keys); i++ {
+		if KeySlot(keys[i]) != KeySlot(keys[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// hashTagOf returns the hash tag of key, the part between the first { and the next },
+// or key if none or empty
+func hashTagOf(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+// crc16 is the CRC16-CCITT (XMODEM) of the redis cluster
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{key: "123456789", want: 12739},
+		{key: "foo", want: 12182},
+		{key: "{foo}:bar", want: 12182},
+		{key: HashTag("foo", "baz"), want: 12182},
+	}
+	for _, tt := range tests {
+		t.Run("when key is "+tt.key+" then slot of its hash tag", func(t *testing.T) {
+			if got := KeySlot(tt.key); got != tt.want {
+				t.Errorf("KeySlot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("when the hash tag is empty then the whole key is hashed", func(t *testing.T) {
+		if KeySlot("{}foo") == KeySlot("{}bar") {
+			t.Errorf("KeySlot() of {}foo and {}bar are equal, want the whole keys hashed")
+		}
+	})
+}
+
+func TestSameSlot(t *testing.T) {
+	t.Run("when the keys share the hash tag then same slot", func(t *testing.T) {
+		if !SameSlot(HashTag("user:1", "profile"), HashTag("user:1", "orders")) {
+			t.Errorf("SameSlot() = false, want true")
+		}
+	})
+
+	t.Run("when the keys have no hash tag then different slots", func(t *testing.T) {
+		if SameSlot("foo", "bar") {
+			t.Errorf("SameSlot() = true, want false")
+		}
+	})
+}
+
+func TestNewClusterManager(t *testing.T) {
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"127.0.0.1:1"}})
+	defer client.Close()
+
+	m := NewClusterManager(client, nil)
+
+	t.Run("when eval the keys in different slots then ErrCrossSlot", func(t *testing.T) {
+		_, err := m.Eval(context.Background(), "return 1", []string{"foo", "bar"})
+		if !errors.Is(err, ErrCrossSlot) {
+			t.Errorf("Eval() error = %v, want ErrCrossSlot", err)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// the content types prefixing the blobs of the codecs, in 0x80-0xf7 which never starts a gob stream,
+// so that the legacy blobs of gob without prefix are still decoded
+const (
+	ContentGob     byte = 0x81
+	ContentJSON    byte = 0x82
+	ContentMsgpack byte = 0x83
+	ContentProto   byte = 0x84
+)
+
+var ErrNotProto = errors.New("cache: value is not a proto message")
+
+// Codec encodes the values of SetBlob and decodes the ones of GetBlob
+type Codec interface {
+	// ContentType is the byte prefixing the encoded blobs, unique per codec
+	ContentType() byte
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	GobCodec     Codec = gobCodec{}
+	JSONCodec    Codec = jsonCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+	// ProtoCodec encodes the proto.Message values only
+	ProtoCodec Codec = protoCodec{}
+)
+
+var codecs = map[byte]Codec{
+	ContentGob:     GobCodec,
+	ContentJSON:    JSONCodec,
+	ContentMsgpack: MsgpackCodec,
+	ContentProto:   ProtoCodec,
+}
+
+// WithCodec encodes the blobs with codec prefixed by its content type,
+// the blobs are encoded by gob without prefix by default, as the older versions do.
+// the blobs of all the codecs are decoded whatever the codec is, so switch it after all the readers upgraded
+func WithCodec(codec Codec) Opt {
+	return func(m *manager) {
+		m.codec = codec
+	}
+}
+
+// encodeBlob encodes val by codec prefixed by its content type, or by gob without prefix if codec is nil
+func encodeBlob(codec Codec, val any) ([]byte, error) {
+	if codec == nil {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(val); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	data, err := codec.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{codec.ContentType()}, data...), nil
+}
+
+// decodeBlob decodes data by the codec of its content type, or by gob if not prefixed
+func decodeBlob(data []byte, output any) error {
+	if len(data) > 0 {
+		if codec, ok := codecs[data[0]]; ok {
+			return codec.Unmarshal(data[1:], output)
+		}
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(output)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) ContentType() byte { return ContentGob }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() byte { return ContentJSON }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() byte { return ContentMsgpack }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type protoCodec struct{}
+
+func (protoCodec) ContentType() byte { return ContentProto }
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrNotProto, v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrNotProto, v)
+	}
+	return proto.Unmarshal(data, msg)
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type codecUser struct {
+	Id   int    `json:"id" msgpack:"id"`
+	Name string `json:"name" msgpack:"name"`
+}
+
+func TestCodec(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+	want := codecUser{Id: 1, Name: "alice"}
+
+	legacy := NewManager(client, nil)
+	for _, codec := range []Codec{GobCodec, JSONCodec, MsgpackCodec} {
+		m := NewManager(client, Options{WithCodec(codec)})
+
+		t.Run("when encoded by the codec then prefixed by its content type", func(t *testing.T) {
+			if err := m.SetBlob(ctx, "u", want, time.Minute); err != nil {
+				t.Fatalf("SetBlob() error = %v", err)
+			}
+			if raw, _ := mr.Get("u"); raw[0] != codec.ContentType() {
+				t.Errorf("prefix = %#x, want %#x", raw[0], codec.ContentType())
+			}
+		})
+
+		t.Run("when prefixed then decoded by any manager", func(t *testing.T) {
+			var got codecUser
+			if err := legacy.GetBlob(ctx, "u", &got); err != nil || got != want {
+				t.Errorf("GetBlob() = %v, %v, want %v", got, err, want)
+			}
+		})
+
+		t.Run("when the legacy gob blob then still decoded", func(t *testing.T) {
+			_ = legacy.SetBlob(ctx, "legacy", want, time.Minute)
+			var got codecUser
+			if err := m.GetBlob(ctx, "legacy", &got); err != nil || got != want {
+				t.Errorf("GetBlob() = %v, %v, want %v", got, err, want)
+			}
+		})
+	}
+
+	t.Run("when ProtoCodec then the proto messages are encoded", func(t *testing.T) {
+		m := NewManager(client, Options{WithCodec(ProtoCodec)})
+		if err := m.SetBlob(ctx, "p", wrapperspb.String("hello"), time.Minute); err != nil {
+			t.Fatalf("SetBlob() error = %v", err)
+		}
+		got := &wrapperspb.StringValue{}
+		if err := m.GetBlob(ctx, "p", got); err != nil || got.GetValue() != "hello" {
+			t.Errorf("GetBlob() = %v, %v, want hello", got, err)
+		}
+		if err := m.SetBlob(ctx, "p", want, time.Minute); !errors.Is(err, ErrNotProto) {
+			t.Errorf("SetBlob() error = %v, want ErrNotProto", err)
+		}
+	})
+}
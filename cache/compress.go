@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+// Compression is the algorithm compressing the large values
+type Compression byte
+
+const (
+	Gzip   Compression = 0x01
+	Snappy Compression = 0x02
+)
+
+// compressedMagic starts the compressed values followed by the Compression,
+// the pair is not valid utf-8, so the text values are never taken as compressed
+const compressedMagic byte = 0xc5
+
+const (
+	compressMetricCmd = "cache_compress"
+	compressRatio     = "compress_ratio"
+)
+
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Snappy:
+		return "snappy"
+	default:
+		return fmt.Sprintf("compression(%d)", byte(c))
+	}
+}
+
+// WithCompression compresses the values of Set, SetNx, MSet and SetBlob longer than threshold bytes by algo,
+// the compressed values are decompressed by Get, MGet and GetBlob whether the compression is set or not
+func WithCompression(threshold int, algo Compression) Opt {
+	return func(m *manager) {
+		m.compressThreshold = threshold
+		m.compression = algo
+	}
+}
+
+// compress returns data compressed with the header if it's long enough and gets smaller
+func (m *manager) compress(ctx context.Context, data []byte) []byte {
+	if m.compression == 0 || len(data) < m.compressThreshold {
+		return data
+	}
+
+	compressed, err := compressWith(m.compression, data)
+	if err != nil || len(compressed)+2 >= len(data) {
+		return data
+	}
+	monitor.NewSingleFlight(compressMetricCmd).Sample(ctx, compressRatio, 0,
+		float64(len(compressed)+2)/float64(len(data)), m.compression.String())
+	return append([]byte{compressedMagic, byte(m.compression)}, compressed...)
+}
+
+func (m *manager) compressString(ctx context.Context, raw string) string {
+	if m.compression == 0 || len(raw) < m.compressThreshold {
+		return raw
+	}
+	return string(m.compress(ctx, []byte(raw)))
+}
+
+// decompress returns data decompressed if it has the header, or data as is
+func decompress(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != compressedMagic {
+		return data, nil
+	}
+
+	algo := Compression(data[1])
+	switch algo {
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data[2:]))
+		if err != nil {
+			return nil, fmt.Errorf("decompress %s error: %w", algo, err)
+		}
+		defer r.Close()
+		res, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("decompress %s error: %w", algo, err)
+		}
+		return res, nil
+	case Snappy:
+		res, err := snappy.Decode(nil, data[2:])
+		if err != nil {
+			return nil, fmt.Errorf("decompress %s error: %w", algo, err)
+		}
+		return res, nil
+	default:
+		return data, nil
+	}
+}
+
+func decompressString(raw string) (string, error) {
+	if len(raw) < 2 || raw[0] != compressedMagic {
+		return raw, nil
+	}
+	res, err := decompress([]byte(raw))
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+func compressWith(algo Compression, data []byte) ([]byte, error) {
+	switch algo {
+	case Gzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Snappy:
+		return snappy.Encode(nil, data), nil
+	default:
+		return nil, fmt.Errorf("unknown %s", algo)
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCompression(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+	plain := NewManager(client, nil)
+	large := strings.Repeat("hello world ", 1000)
+
+	for _, algo := range []Compression{Gzip, Snappy} {
+		m := NewManager(client, Options{WithCompression(1024, algo)})
+
+		t.Run("when the value is over the threshold then it's stored compressed", func(t *testing.T) {
+			if err := m.Set(ctx, "large", large, time.Minute); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			stored, _ := mr.Get("large")
+			if len(stored) >= len(large) || stored[0] != compressedMagic || Compression(stored[1]) != algo {
+				t.Errorf("stored %d bytes with header %#x, want compressed by %s", len(stored), stored[:2], algo)
+			}
+			for _, reader := range []Manager{m, plain} {
+				if got, err := reader.Get(ctx, "large"); err != nil || got != large {
+					t.Errorf("Get() = %d bytes, %v, want the original", len(got), err)
+				}
+			}
+		})
+
+		t.Run("when the value is under the threshold then it's stored as is", func(t *testing.T) {
+			_ = m.Set(ctx, "small", "hello", time.Minute)
+			if stored, _ := mr.Get("small"); stored != "hello" {
+				t.Errorf("stored = %q, want hello", stored)
+			}
+		})
+
+		t.Run("when the blob is large then it's compressed too", func(t *testing.T) {
+			want := []string{large, large}
+			if err := m.SetBlob(ctx, "blob", want, time.Minute); err != nil {
+				t.Fatalf("SetBlob() error = %v", err)
+			}
+			if stored, _ := mr.Get("blob"); stored[0] != compressedMagic {
+				t.Errorf("header = %#x, want compressed", stored[0])
+			}
+			var got []string
+			if err := plain.GetBlob(ctx, "blob", &got); err != nil || len(got) != 2 || got[0] != large {
+				t.Errorf("GetBlob() error = %v, want the original", err)
+			}
+		})
+
+		t.Run("when MSet then MGet returns the originals", func(t *testing.T) {
+			_ = m.MSet(ctx, map[string]string{"m1": large, "m2": "small"}, time.Minute)
+			got, err := plain.MGet(ctx, "m1", "m2")
+			if err != nil || got["m1"] != large || got["m2"] != "small" {
+				t.Errorf("MGet() error = %v, want the originals", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNoAddrs = errors.New("cache: no redis addrs")
+
+// Config is the config of the redis of NewManagerFromConfig, one of the standalone redis,
+// the masters of sentinel if MasterName or the cluster if Cluster
+type Config struct {
+	// Addrs the address of the standalone redis, the addresses of the sentinels if MasterName,
+	// or the addresses of the seed nodes if Cluster
+	Addrs []string `yaml:"addrs" json:"addrs" required:"true"`
+	// MasterName the name of the master monitored by the sentinels, the client follows the failover of it
+	MasterName string `yaml:"master_name" json:"master_name"`
+	// SentinelPassword the password of the sentinels if different from the one of redis
+	SentinelPassword string `yaml:"sentinel_password" json:"sentinel_password"`
+	// Cluster connects the redis cluster of Addrs
+	Cluster  bool   `yaml:"cluster" json:"cluster"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+	// DB the database of the standalone redis or of sentinel, the cluster has only db 0
+	DB           int           `yaml:"db" json:"db"`
+	PoolSize     int           `yaml:"pool_size" json:"pool_size"`
+	DialTimeout  time.Duration `yaml:"dial_timeout" json:"dial_timeout" default:"5s"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" json:"read_timeout" default:"3s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" json:"write_timeout" default:"3s"`
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	redisMetricCmd        = "redis"
+	redisReconnectsMetric = "redis_reconnect"
+)
+
+// NewManagerFromConfig returns the Manager of the redis of cfg, the client of sentinel follows
+// the failover of the master. the reconnects are counted by the redis_reconnect metric with the address as opt
+func NewManagerFromConfig(cfg *Config, opts Options) (Manager, error) {
+	if cfg == nil || len(cfg.Addrs) == 0 {
+		return nil, ErrNoAddrs
+	}
+
+	var client redis.UniversalClient
+	switch {
+	case cfg.MasterName != "":
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.Addrs,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			PoolSize:         cfg.PoolSize,
+			DialTimeout:      cfg.DialTimeout,
+			ReadTimeout:      cfg.ReadTimeout,
+			WriteTimeout:     cfg.WriteTimeout,
+		})
+	case cfg.Cluster:
+		cluster := redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.Addrs,
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			PoolSize:     cfg.PoolSize,
+			DialTimeout:  cfg.DialTimeout,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+		})
+		// the dials are made by the clients of the nodes, the dial hook of the cluster is never called
+		cluster.OnNewNode(func(node *redis.Client) {
+			node.AddHook(&reconnectHook{})
+		})
+		return NewClusterManager(cluster, opts), nil
+	default:
+		client = redis.NewClient(&redis.Options{
+			Addr:         cfg.Addrs[0],
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			DB:           cfg.DB,
+			PoolSize:     cfg.PoolSize,
+			DialTimeout:  cfg.DialTimeout,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+		})
+	}
+	client.AddHook(&reconnectHook{})
+
+	return NewManager(client, opts), nil
+}
+
+// reconnectHook counts the failed dials and the dials succeeded after a failure or to another address,
+// e.g. the new master after the failover, labeled cmd of redis, dsCmd of redis_reconnect,
+// code 1 if failed and opt of the address
+type reconnectHook struct {
+	failed   atomic.Bool
+	lastAddr atomic.Value
+}
+
+func (h *reconnectHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			h.failed.Store(true)
+			countReconnect(1, addr)
+			return conn, err
+		}
+
+		last, _ := h.lastAddr.Swap(addr).(string)
+		if h.failed.Swap(false) || (last != "" && last != addr) {
+			countReconnect(0, addr)
+		}
+		return conn, nil
+	}
+}
+
+func countReconnect(code int, addr string) {
+	monitor.NewSingleFlight(redisMetricCmd).Count(context.Background(), redisReconnectsMetric, code, addr)
+}
+
+func (h *reconnectHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return next
+}
+
+func (h *reconnectHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func counterValue(t *testing.T, cmd, dsCmd, code, opt string) float64 {
+	return metricValue(t, "trackingo_flight_singleFlightC", cmd, dsCmd, code, opt)
+}
+
+func gaugeValue(t *testing.T, cmd, dsCmd, code, opt string) float64 {
+	return metricValue(t, "trackingo_flight_singleFlightG", cmd, dsCmd, code, opt)
+}
+
+// metricValue returns the value of the counter or the gauge of name with the labels
+func metricValue(t *testing.T, name, cmd, dsCmd, code, opt string) float64 {
+	t.Helper()
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather metrics error = %v", err)
+	}
+
+	want := map[string]string{"cmd": cmd, "dsCmd": dsCmd, "code": code, "opt": opt}
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			matched := 0
+			for _, l := range m.GetLabel() {
+				if v, ok := want[l.GetName()]; ok && v == l.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(want) {
+				return m.GetCounter().GetValue() + m.GetGauge().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestNewManagerFromConfig(t *testing.T) {
+	t.Run("when no addrs then ErrNoAddrs", func(t *testing.T) {
+		if _, err := NewManagerFromConfig(&Config{}, nil); !errors.Is(err, ErrNoAddrs) {
+			t.Errorf("NewManagerFromConfig() error = %v, want ErrNoAddrs", err)
+		}
+	})
+
+	t.Run("when cluster then the cluster manager", func(t *testing.T) {
+		m, err := NewManagerFromConfig(&Config{Addrs: []string{"127.0.0.1:1"}, Cluster: true}, nil)
+		if err != nil {
+			t.Fatalf("NewManagerFromConfig() error = %v", err)
+		}
+		if _, err = m.Eval(context.Background(), "return 1", []string{"foo", "bar"}); !errors.Is(err, ErrCrossSlot) {
+			t.Errorf("Eval() error = %v, want ErrCrossSlot", err)
+		}
+	})
+
+	t.Run("when the dial to a cluster node fails then the reconnect is counted", func(t *testing.T) {
+		addr := "127.0.0.1:2"
+		m, err := NewManagerFromConfig(&Config{Addrs: []string{addr}, Cluster: true, DialTimeout: 100 * time.Millisecond}, nil)
+		if err != nil {
+			t.Fatalf("NewManagerFromConfig() error = %v", err)
+		}
+
+		before := counterValue(t, redisMetricCmd, redisReconnectsMetric, "1", addr)
+		if _, err = m.Get(context.Background(), "k"); err == nil {
+			t.Fatalf("Get() error = nil, want the dial error")
+		}
+		if got := counterValue(t, redisMetricCmd, redisReconnectsMetric, "1", addr); got <= before {
+			t.Errorf("redis_reconnect = %v, want > %v", got, before)
+		}
+	})
+
+	t.Run("when the dial fails then the reconnect is counted", func(t *testing.T) {
+		// nothing listens on the port, the dial fails at once
+		addr := "127.0.0.1:1"
+		m, err := NewManagerFromConfig(&Config{Addrs: []string{addr}, DialTimeout: 100 * time.Millisecond}, nil)
+		if err != nil {
+			t.Fatalf("NewManagerFromConfig() error = %v", err)
+		}
+
+		before := counterValue(t, redisMetricCmd, redisReconnectsMetric, "1", addr)
+		if _, err = m.Get(context.Background(), "k"); err == nil {
+			t.Fatalf("Get() error = nil, want the dial error")
+		}
+		if got := counterValue(t, redisMetricCmd, redisReconnectsMetric, "1", addr); got <= before {
+			t.Errorf("redis_reconnect = %v, want > %v", got, before)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// WithTTLJitter randomizes the expire of Set, SetBlob and MSet by ±fraction, e.g. 0.1 for ±10%,
+// so that the keys set together don't expire together, fraction is capped to [0, 1),
+// SetNx is not randomized since its expire bounds the locks
+func WithTTLJitter(fraction float64) Opt {
+	return func(m *manager) {
+		switch {
+		case fraction < 0:
+			fraction = 0
+		case fraction >= 1:
+			fraction = 0.99
+		}
+		m.ttlJitter = fraction
+	}
+}
+
+// jitter returns expire randomized by ±ttlJitter, the expire <= 0 is kept as is
+func (m *manager) jitter(expire time.Duration) time.Duration {
+	if m.ttlJitter <= 0 || expire <= 0 {
+		return expire
+	}
+
+	delta := time.Duration((rand.Float64()*2 - 1) * m.ttlJitter * float64(expire))
+	if jittered := expire + delta; jittered > 0 {
+		return jittered
+	}
+	return expire
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestTTLJitter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	m := NewManager(client, Options{WithTTLJitter(0.1)})
+	ctx := context.Background()
+
+	t.Run("when Set then the ttls spread within the fraction", func(t *testing.T) {
+		ttls := map[time.Duration]bool{}
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprintf("k%d", i)
+			_ = m.Set(ctx, key, "v", time.Hour)
+			ttl := mr.TTL(key)
+			if ttl < 54*time.Minute || ttl > 66*time.Minute {
+				t.Errorf("ttl = %v, want within ±10%% of 1h", ttl)
+			}
+			ttls[ttl] = true
+		}
+		if len(ttls) < 2 {
+			t.Errorf("ttls = %v, want spread", ttls)
+		}
+	})
+
+	t.Run("when SetNx then the ttl is exact", func(t *testing.T) {
+		_, _ = m.SetNx(ctx, "lock", "v", time.Hour)
+		if ttl := mr.TTL("lock"); ttl != time.Hour {
+			t.Errorf("ttl = %v, want 1h", ttl)
+		}
+	})
+
+	t.Run("when no expire then none is set", func(t *testing.T) {
+		_ = m.Set(ctx, "forever", "v", 0)
+		if ttl := mr.TTL("forever"); ttl != 0 {
+			t.Errorf("ttl = %v, want none", ttl)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+)
+
+// Leaderboard ranks the members by the scores in the sorted set of a key
+type Leaderboard struct {
+	m   Manager
+	key string
+}
+
+func NewLeaderboard(m Manager, key string) *Leaderboard {
+	return &Leaderboard{
+		m:   m,
+		key: key,
+	}
+}
+
+// Incr adds delta to the score of member and returns the new score
+func (lb *Leaderboard) Incr(ctx context.Context, member string, delta float64) (score float64, err error) {
+	return lb.m.ZIncrBy(ctx, lb.key, member, delta)
+}
+
+// Top returns the first n members by the descending scores
+func (lb *Leaderboard) Top(ctx context.Context, n int) (members []Z, err error) {
+	if n <= 0 {
+		return []Z{}, nil
+	}
+	return lb.m.ZRevRange(ctx, lb.key, 0, int64(n-1))
+}
+
+// Remove removes the given members from the leaderboard
+func (lb *Leaderboard) Remove(ctx context.Context, members ...string) (err error) {
+	return lb.m.ZRem(ctx, lb.key, members...)
+}
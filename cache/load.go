@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	loadMetricCmd = "cache_load"
+	hitMetric     = "cache_hit"
+	missMetric    = "cache_miss"
+	loadMetric    = "cache_load"
+)
+
+// blobLoads are the loads of GetOrLoadBlob by key
+var blobLoads singleflight.Group
+
+func (m *manager) GetOrLoad(ctx context.Context, key string, expire time.Duration, loader Loader) (raw string, err error) {
+	return getOrLoad(ctx, &m.loads, key, func() (string, error) {
+		return m.Get(ctx, key)
+	}, loader, func(raw string) error {
+		return m.Set(ctx, key, raw, expire)
+	})
+}
+
+func (l *local) GetOrLoad(ctx context.Context, key string, expire time.Duration, loader Loader) (raw string, err error) {
+	return getOrLoad(ctx, &l.loads, key, func() (string, error) {
+		return l.Get(ctx, key)
+	}, loader, func(raw string) error {
+		return l.Set(ctx, key, raw, expire)
+	})
+}
+
+// GetOrLoadBlob is GetOrLoad of the values stored by SetBlob,
+// the loads are shared by the key and T, so use the distinct keys for the distinct managers
+func GetOrLoadBlob[T any](ctx context.Context, m Manager, key string, expire time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	flight := reflect.TypeOf((*T)(nil)).Elem().String() + ":" + key
+	return getOrLoad(ctx, &blobLoads, flight, func() (T, error) {
+		var val T
+		err := m.GetBlob(ctx, key, &val)
+		return val, err
+	}, loader, func(val T) error {
+		return m.SetBlob(ctx, key, val, expire)
+	})
+}
+
+// getOrLoad returns the value of get, or the value of load stored by set on a miss,
+// the loads of the same flight in group are shared, the errors of the cache fall back to load
+func getOrLoad[T any](
+	ctx context.Context,
+	group *singleflight.Group,
+	flight string,
+	get func() (T, error),
+	load func(ctx context.Context) (T, error),
+	set func(val T) error,
+) (T, error) {
+	sf := monitor.NewSingleFlight(loadMetricCmd)
+
+	val, err := get()
+	if err == nil {
+		sf.Count(ctx, hitMetric, 0, "")
+		return val, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		logger.FromContext(ctx).WithError(err).WithField("key", flight).Warn("get cache error, load instead")
+	}
+	sf.Count(ctx, missMetric, 0, "")
+
+	res, err, _ := group.Do(flight, func() (any, error) {
+		rec := sf.BeginRecord(ctx, loadMetric)
+		val, err := load(ctx)
+		rec.EndWithError(err)
+		if err != nil {
+			return val, err
+		}
+		if err := set(val); err != nil {
+			logger.FromContext(ctx).WithError(err).WithField("key", flight).Warn("set cache error")
+		}
+		return val, nil
+	})
+	val, _ = res.(T)
+	return val, err
+}
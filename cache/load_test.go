@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_manager_GetOrLoad(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+
+	t.Run("when the concurrent misses then one load is shared and stored", func(t *testing.T) {
+		var loads atomic.Int32
+		release := make(chan struct{})
+		loader := func(ctx context.Context) (string, error) {
+			loads.Add(1)
+			<-release
+			return "loaded", nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]string, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = m.GetOrLoad(ctx, "k", time.Minute, loader)
+			}(i)
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if n := loads.Load(); n != 1 {
+			t.Errorf("loads = %v, want 1", n)
+		}
+		for _, got := range results {
+			if got != "loaded" {
+				t.Errorf("GetOrLoad() = %v, want loaded", got)
+			}
+		}
+		if got, _ := mr.Get("k"); got != "loaded" {
+			t.Errorf("k = %v, want loaded", got)
+		}
+	})
+
+	t.Run("when hit then the loader is not called", func(t *testing.T) {
+		before := counterValue(t, loadMetricCmd, hitMetric, "0", "NA")
+		got, err := m.GetOrLoad(ctx, "k", time.Minute, func(ctx context.Context) (string, error) {
+			t.Fatalf("loader called on hit")
+			return "", nil
+		})
+		if err != nil || got != "loaded" {
+			t.Errorf("GetOrLoad() = %v, %v, want loaded", got, err)
+		}
+		if after := counterValue(t, loadMetricCmd, hitMetric, "0", "NA"); after != before+1 {
+			t.Errorf("hits = %v, want %v", after, before+1)
+		}
+	})
+
+	t.Run("when the loader fails then nothing is stored", func(t *testing.T) {
+		wantErr := errors.New("db down")
+		_, err := m.GetOrLoad(ctx, "failed", time.Minute, func(ctx context.Context) (string, error) {
+			return "", wantErr
+		})
+		if !errors.Is(err, wantErr) || mr.Exists("failed") {
+			t.Errorf("GetOrLoad() error = %v, stored = %v, want db down and nothing stored", err, mr.Exists("failed"))
+		}
+	})
+}
+
+func TestGetOrLoadBlob(t *testing.T) {
+	type user struct {
+		Id   int
+		Name string
+	}
+	m := NewLocal()
+	ctx := context.Background()
+
+	var loads int
+	loader := func(ctx context.Context) (user, error) {
+		loads++
+		return user{Id: 1, Name: "alice"}, nil
+	}
+
+	t.Run("when loaded then the next get is a hit", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			got, err := GetOrLoadBlob(ctx, m, "user:1", time.Minute, loader)
+			if err != nil || got.Name != "alice" {
+				t.Errorf("GetOrLoadBlob() = %v, %v, want alice", got, err)
+			}
+		}
+		if loads != 1 {
+			t.Errorf("loads = %v, want 1", loads)
+		}
+	})
+}
@@ -2,31 +2,73 @@ package cache
 
 import (
 	"bytes"
+	"container/list"
 	"context"
 	"encoding/gob"
 	"fmt"
+	"io"
+	"strconv"
 	"sync"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 type item struct {
 	raw    []byte
-	expire int64
+	hash   map[string]string   // the fields of a hash, nil for the others
+	set    map[string]struct{} // the members of a set, nil for the others
+	zset   map[string]float64  // the scores of a sorted set, nil for the others
+	expire int64               // the deadline in unix nanoseconds, 0 for no expire
+	elem   *list.Element       // the element of the key in the lru list
+	size   int64               // the estimated bytes of the key and the value
+}
+
+// isString reports whether it holds a string rather than a hash, a set or a sorted set
+func (it *item) isString() bool {
+	return it.hash == nil && it.set == nil && it.zset == nil
 }
 
 type local struct {
-	m       map[string]*item
-	nowFunc func() time.Time
-	lock    sync.RWMutex
+	m          map[string]*item
+	nowFunc    func() time.Time
+	lock       sync.RWMutex
+	loads      singleflight.Group // the loads of GetOrLoad by key
+	lru        *list.List         // the keys from the most to the least recently used
+	bytes      int64              // the estimated bytes of all the items
+	maxEntries int
+	maxBytes   int64
+
+	sweepInterval time.Duration
+	sweepBatch    int
+	stop          chan struct{} // closed by Close to stop the janitor
+	done          chan struct{} // closed when the janitor is stopped
+	closeOnce     sync.Once
 }
 
-func NewLocal() Manager {
+// LocalManager is the Manager of NewLocal, Close stops its janitor
+type LocalManager interface {
+	Manager
+	io.Closer
+}
+
+// NewLocal returns the Manager of an in-process map, bounded by WithMaxEntries and WithMaxBytes if set,
+// the expired items are swept in background every minute or WithSweepInterval,
+// callers must Close it when done to stop the janitor
+func NewLocal(opts ...LocalOpt) LocalManager {
 	lm := &local{
-		m:       make(map[string]*item),
-		nowFunc: time.Now,
+		m:             make(map[string]*item),
+		nowFunc:       time.Now,
+		lru:           list.New(),
+		sweepInterval: defaultSweepInterval,
+		sweepBatch:    defaultSweepBatch,
+	}
+
+	for _, opt := range opts {
+		opt(lm)
 	}
 
-	lm.startEvict(5 * time.Minute)
+	lm.startSweep()
 
 	return lm
 }
@@ -38,71 +80,22 @@ func (l *local) active() bool {
 	return true
 }
 
-// startEvict evict expired with interval
-func (l *local) startEvict(interval time.Duration) {
-	if !l.active() {
-		return
-	}
-
-	go func() {
-		for {
-			l.evict()
-			time.Sleep(interval)
-		}
-	}()
-}
-
-// evict expired items
-func (l *local) evict() {
-	if !l.active() {
-		return
-	}
-
-	l.lock.Lock()
-	defer l.lock.Unlock()
-
-	now := l.nowFunc().Unix()
-	for k, v := range l.m {
-		if v.expire != 0 && now > v.expire {
-			delete(l.m, k)
-		}
-	}
-}
-
 func (l *local) Get(ctx context.Context, key string) (raw string, err error) {
 	if !l.active() {
 		return "", ErrInActive
 	}
 
-	l.lock.RLock()
-
-	it, found := l.m[key]
-	if !found {
-		defer l.lock.RUnlock()
-		return "", ErrNotFound
-	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
 
+	it := l.lookup(key)
 	if it == nil {
-		l.lock.RUnlock()
-
-		l.lock.Lock()
-		defer l.lock.Unlock()
-		delete(l.m, key)
 		return "", ErrNotFound
 	}
-
-	if it.expire == 0 || l.nowFunc().Unix() < it.expire {
-		defer l.lock.RUnlock()
-		return string(it.raw), nil
-	} else {
-		l.lock.RUnlock()
-
-		l.lock.Lock()
-		defer l.lock.Unlock()
-		delete(l.m, key)
-		return "", ErrNotFound
+	if !it.isString() {
+		return "", fmt.Errorf("%w: %s", ErrWrongType, key)
 	}
-
+	return string(it.raw), nil
 }
 
 func (l *local) Set(ctx context.Context, key string, raw string, expire time.Duration) (err error) {
@@ -113,10 +106,10 @@ func (l *local) Set(ctx context.Context, key string, raw string, expire time.Dur
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
-	l.m[key] = &item{
+	l.store(key, &item{
 		raw:    []byte(raw),
 		expire: l.expireAt(expire),
-	}
+	})
 	return nil
 }
 
@@ -128,13 +121,13 @@ func (l *local) SetNx(ctx context.Context, key string, raw string, expire time.D
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
-	if _, ok := l.m[key]; ok {
+	if it := l.lookup(key); it != nil {
 		return true, nil
 	} else {
-		l.m[key] = &item{
+		l.store(key, &item{
 			raw:    []byte(raw),
 			expire: l.expireAt(expire),
-		}
+		})
 		return false, nil
 	}
 }
@@ -144,40 +137,23 @@ func (l *local) GetBlob(ctx context.Context, key string, output any) (err error)
 		return ErrInActive
 	}
 
-	l.lock.RLock()
-	it, found := l.m[key]
-	if !found {
-		defer l.lock.RUnlock()
-		return ErrNotFound
-	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
 
+	it := l.lookup(key)
 	if it == nil {
-		l.lock.RUnlock()
-
-		l.lock.Lock()
-		defer l.lock.Unlock()
-		delete(l.m, key)
 		return ErrNotFound
 	}
-
-	if it.expire == 0 || l.nowFunc().Unix() < it.expire {
-		defer l.lock.RUnlock()
-
-		r := bytes.NewReader(it.raw)
-		decoder := gob.NewDecoder(r)
-		if err = decoder.Decode(output); err != nil {
-			return fmt.Errorf("decode error: %w", err)
-		}
-		return nil
-	} else {
-		l.lock.RUnlock()
-
-		l.lock.Lock()
-		defer l.lock.Unlock()
-		delete(l.m, key)
-		return ErrNotFound
+	if !it.isString() {
+		return fmt.Errorf("%w: %s", ErrWrongType, key)
 	}
 
+	r := bytes.NewReader(it.raw)
+	decoder := gob.NewDecoder(r)
+	if err = decoder.Decode(output); err != nil {
+		return fmt.Errorf("decode error: %w", err)
+	}
+	return nil
 }
 
 func (l *local) SetBlob(ctx context.Context, key string, val any, expire time.Duration) (err error) {
@@ -194,10 +170,10 @@ func (l *local) SetBlob(ctx context.Context, key string, val any, expire time.Du
 		return fmt.Errorf("encode error: %w", err)
 	}
 
-	l.m[key] = &item{
+	l.store(key, &item{
 		raw:    buf.Bytes(),
 		expire: l.expireAt(expire),
-	}
+	})
 	return nil
 
 }
@@ -210,9 +186,7 @@ func (l *local) Del(ctx context.Context, key string) (err error) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
-	if _, ok := l.m[key]; ok {
-		delete(l.m, key)
-	}
+	l.remove(key)
 	return nil
 }
 
@@ -223,7 +197,7 @@ func (l *local) Expire(ctx context.Context, key string, expire time.Duration) (e
 
 	l.lock.Lock()
 	defer l.lock.Unlock()
-	if it, ok := l.m[key]; ok && it != nil {
+	if it := l.lookup(key); it != nil {
 		it.expire = l.expireAt(expire)
 		return nil
 	} else {
@@ -234,13 +208,230 @@ func (l *local) Expire(ctx context.Context, key string, expire time.Duration) (e
 
 func (l *local) Eval(ctx context.Context, script string, keys []string, args ...any) (val any, err error) {
 	// ignore
-	return nil, fmt.Errorf("not support")
+	return nil, ErrNotSupported
+}
+
+func (l *local) MGet(ctx context.Context, keys ...string) (vals map[string]string, err error) {
+	if !l.active() {
+		return nil, ErrInActive
+	}
+
+	vals = make(map[string]string, len(keys))
+	for _, key := range keys {
+		raw, err := l.Get(ctx, key)
+		if err != nil {
+			continue
+		}
+		vals[key] = raw
+	}
+	return vals, nil
+}
+
+func (l *local) MSet(ctx context.Context, kvs map[string]string, expire time.Duration) (err error) {
+	if !l.active() {
+		return ErrInActive
+	}
+
+	for key, raw := range kvs {
+		if err = l.Set(ctx, key, raw, expire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *local) Exists(ctx context.Context, key string) (ok bool, err error) {
+	if !l.active() {
+		return false, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.lookup(key) != nil, nil
+}
+
+func (l *local) TTL(ctx context.Context, key string) (ttl time.Duration, err error) {
+	if !l.active() {
+		return 0, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it := l.lookup(key)
+	if it == nil {
+		return 0, ErrNotFound
+	}
+	if it.expire == 0 {
+		return 0, nil
+	}
+	return time.Unix(0, it.expire).Sub(l.nowFunc()), nil
+}
+
+func (l *local) GetDel(ctx context.Context, key string) (raw string, err error) {
+	if !l.active() {
+		return "", ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it := l.lookup(key)
+	if it == nil {
+		return "", ErrNotFound
+	}
+	if !it.isString() {
+		return "", fmt.Errorf("%w: %s", ErrWrongType, key)
+	}
+	l.remove(key)
+	return string(it.raw), nil
+}
+
+func (l *local) GetSet(ctx context.Context, key string, raw string, expire time.Duration) (old string, err error) {
+	if !l.active() {
+		return "", ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it := l.lookup(key)
+	if it != nil && !it.isString() {
+		return "", fmt.Errorf("%w: %s", ErrWrongType, key)
+	}
+	l.store(key, &item{
+		raw:    []byte(raw),
+		expire: l.expireAt(expire),
+	})
+	if it == nil {
+		return "", ErrNotFound
+	}
+	return string(it.raw), nil
+}
+
+func (l *local) GetEx(ctx context.Context, key string, expire time.Duration) (raw string, err error) {
+	if !l.active() {
+		return "", ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it := l.lookup(key)
+	if it == nil {
+		return "", ErrNotFound
+	}
+	if !it.isString() {
+		return "", fmt.Errorf("%w: %s", ErrWrongType, key)
+	}
+	it.expire = l.expireAt(expire)
+	return string(it.raw), nil
+}
+
+func (l *local) Incr(ctx context.Context, key string, expire time.Duration) (val int64, err error) {
+	return l.IncrBy(ctx, key, 1, expire)
+}
+
+func (l *local) IncrBy(ctx context.Context, key string, delta int64, expire time.Duration) (val int64, err error) {
+	if !l.active() {
+		return 0, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it := l.lookup(key)
+	if it == nil {
+		// the ttl is set on the first set only
+		it = &item{
+			raw:    []byte("0"),
+			expire: l.expireAt(expire),
+		}
+		l.store(key, it)
+	}
+	if !it.isString() {
+		return 0, fmt.Errorf("%w: %s", ErrWrongType, key)
+	}
+
+	val, err = strconv.ParseInt(string(it.raw), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrNotInt, key)
+	}
+	val += delta
+	it.raw = []byte(strconv.FormatInt(val, 10))
+	l.resize(key, it)
+	return val, nil
+}
+
+func (l *local) Decr(ctx context.Context, key string, expire time.Duration) (val int64, err error) {
+	return l.IncrBy(ctx, key, -1, expire)
+}
+
+func (l *local) DecrBy(ctx context.Context, key string, delta int64, expire time.Duration) (val int64, err error) {
+	return l.IncrBy(ctx, key, -delta, expire)
+}
+
+// lookup returns the live item of key as the most recently used, the expired one is deleted, the lock must be held
+func (l *local) lookup(key string) *item {
+	it, ok := l.m[key]
+	if !ok {
+		return nil
+	}
+	if it == nil || (it.expire != 0 && l.nowFunc().UnixNano() >= it.expire) {
+		l.remove(key)
+		return nil
+	}
+	if it.elem != nil {
+		l.lru.MoveToFront(it.elem)
+	}
+	return it
 }
 
 func (l *local) expireAt(expire time.Duration) int64 {
 	if expire == 0 {
 		return 0
 	} else {
-		return l.nowFunc().Add(expire).Unix()
+		return l.nowFunc().Add(expire).UnixNano()
 	}
 }
+
+func (l *local) Pipeline(ctx context.Context, fn func(Pipeliner) error) (err error) {
+	// ignore
+	return ErrNotSupported
+}
+
+func (l *local) TxPipeline(ctx context.Context, fn func(Pipeliner) error) (err error) {
+	// ignore
+	return ErrNotSupported
+}
+
+func (l *local) Publish(ctx context.Context, channel string, payload string) (err error) {
+	// ignore
+	return ErrNotSupported
+}
+
+func (l *local) Subscribe(ctx context.Context, channel string, handler MessageHandler) (err error) {
+	// ignore
+	return ErrNotSupported
+}
+
+func (l *local) XAdd(ctx context.Context, stream string, values map[string]string) (id string, err error) {
+	// ignore
+	return "", ErrNotSupported
+}
+
+func (l *local) XGroupCreate(ctx context.Context, stream string, group string, start string) (err error) {
+	// ignore
+	return ErrNotSupported
+}
+
+func (l *local) XReadGroup(ctx context.Context, stream string, group string, consumer string, count int64, block time.Duration) (msgs []XMessage, err error) {
+	// ignore
+	return nil, ErrNotSupported
+}
+
+func (l *local) XAck(ctx context.Context, stream string, group string, ids ...string) (err error) {
+	// ignore
+	return ErrNotSupported
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+func (l *local) HSet(ctx context.Context, key string, fields map[string]string) (err error) {
+	if !l.active() {
+		return ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.hashOf(key, true)
+	if err != nil {
+		return err
+	}
+	for field, raw := range fields {
+		it.hash[field] = raw
+	}
+	l.resize(key, it)
+	return nil
+}
+
+func (l *local) HGet(ctx context.Context, key string, field string) (raw string, err error) {
+	if !l.active() {
+		return "", ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.hashOf(key, false)
+	if err != nil {
+		return "", err
+	}
+	if it == nil {
+		return "", ErrNotFound
+	}
+	raw, ok := it.hash[field]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return raw, nil
+}
+
+func (l *local) HGetAll(ctx context.Context, key string) (fields map[string]string, err error) {
+	if !l.active() {
+		return nil, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.hashOf(key, false)
+	if err != nil {
+		return nil, err
+	}
+	fields = make(map[string]string)
+	if it != nil {
+		for field, raw := range it.hash {
+			fields[field] = raw
+		}
+	}
+	return fields, nil
+}
+
+func (l *local) HDel(ctx context.Context, key string, fields ...string) (err error) {
+	if !l.active() {
+		return ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.hashOf(key, false)
+	if err != nil || it == nil {
+		return err
+	}
+	for _, field := range fields {
+		delete(it.hash, field)
+	}
+	if len(it.hash) == 0 {
+		l.remove(key)
+	} else {
+		l.resize(key, it)
+	}
+	return nil
+}
+
+func (l *local) HIncrBy(ctx context.Context, key string, field string, delta int64) (val int64, err error) {
+	if !l.active() {
+		return 0, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.hashOf(key, true)
+	if err != nil {
+		return 0, err
+	}
+	if raw, ok := it.hash[field]; ok {
+		if val, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrNotInt, key)
+		}
+	}
+	val += delta
+	it.hash[field] = strconv.FormatInt(val, 10)
+	l.resize(key, it)
+	return val, nil
+}
+
+// hashOf returns the hash item of key, created if missing and create is set, the lock must be held
+func (l *local) hashOf(key string, create bool) (*item, error) {
+	it := l.lookup(key)
+	if it == nil {
+		if !create {
+			return nil, nil
+		}
+		it = &item{hash: make(map[string]string)}
+		l.store(key, it)
+	}
+	if it.hash == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWrongType, key)
+	}
+	return it, nil
+}
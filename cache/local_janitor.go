@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	defaultSweepInterval = time.Minute
+	defaultSweepBatch    = 1000
+
+	evictByExpired = "expired"
+)
+
+// WithSweepInterval sweeps the expired items every interval in background, every minute by default,
+// <= 0 disables the sweeps and the expired items are deleted only when read. the sweeps run until Close
+func WithSweepInterval(interval time.Duration) LocalOpt {
+	return func(l *local) {
+		l.sweepInterval = interval
+	}
+}
+
+// WithSweepBatch sets the number of the items checked per lock hold of a sweep, 1000 by default,
+// so that the sweeps of the large caches don't block the other operations for long, <= 0 checks all at once
+func WithSweepBatch(n int) LocalOpt {
+	return func(l *local) {
+		l.sweepBatch = n
+	}
+}
+
+// Close stops the sweeps of the janitor
+func (l *local) Close() error {
+	l.closeOnce.Do(func() {
+		if l.stop == nil {
+			return
+		}
+		close(l.stop)
+		<-l.done
+	})
+	return nil
+}
+
+// startSweep sweeps the expired items every sweepInterval until Close
+func (l *local) startSweep() {
+	if !l.active() || l.sweepInterval <= 0 {
+		return
+	}
+
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go func() {
+		defer close(l.done)
+
+		ticker := time.NewTicker(l.sweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				l.sweep()
+			}
+		}
+	}()
+}
+
+// sweep deletes the expired items, checking sweepBatch items per lock hold in the random order of the map,
+// it goes on while at least a quarter of the checked items are expired, as the active expiry of redis,
+// so the sweeps of the large caches with few expired items stay short
+func (l *local) sweep() {
+	if !l.active() {
+		return
+	}
+
+	swept := 0
+	for {
+		checked, expired := l.sweepBatchOnce()
+		swept += expired
+		if expired == 0 || l.sweepBatch <= 0 || checked < l.sweepBatch || expired*4 < checked {
+			break
+		}
+	}
+
+	if swept > 0 {
+		monitor.NewSingleFlight(localMetricCmd).CountDelta(context.Background(), evictMetric, 0, swept, evictByExpired)
+	}
+}
+
+// sweepBatchOnce checks up to sweepBatch items, all if <= 0, and deletes the expired ones
+func (l *local) sweepBatchOnce() (checked, expired int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.nowFunc().UnixNano()
+	for key, it := range l.m {
+		if l.sweepBatch > 0 && checked >= l.sweepBatch {
+			break
+		}
+		checked++
+		if it == nil || (it.expire != 0 && now >= it.expire) {
+			l.remove(key)
+			expired++
+		}
+	}
+	return checked, expired
+}
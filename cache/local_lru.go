@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	localMetricCmd = "cache_local"
+	evictMetric    = "local_evict"
+
+	evictByEntries = "entries"
+	evictByBytes   = "bytes"
+)
+
+// itemOverhead is the estimated bytes of an item besides its key and value
+const itemOverhead = 64
+
+type LocalOpt func(l *local)
+
+// WithMaxEntries bounds the local cache to n keys, the least recently used ones are evicted
+func WithMaxEntries(n int) LocalOpt {
+	return func(l *local) {
+		l.maxEntries = n
+	}
+}
+
+// WithMaxBytes bounds the estimated bytes of the local cache, the least recently used keys are evicted
+func WithMaxBytes(n int64) LocalOpt {
+	return func(l *local) {
+		l.maxBytes = n
+	}
+}
+
+// store puts it as the most recently used item of key, the lock must be held
+func (l *local) store(key string, it *item) {
+	l.remove(key)
+	if l.lru == nil {
+		l.lru = list.New()
+	}
+	it.elem = l.lru.PushFront(key)
+	l.m[key] = it
+	l.resize(key, it)
+}
+
+// remove deletes the item of key, the lock must be held
+func (l *local) remove(key string) {
+	it, ok := l.m[key]
+	if !ok {
+		return
+	}
+	delete(l.m, key)
+	if it == nil {
+		return
+	}
+	if it.elem != nil {
+		l.lru.Remove(it.elem)
+		it.elem = nil
+	}
+	l.bytes -= it.size
+}
+
+// resize updates the size of it changed in place and evicts the least recently used items over the bounds,
+// the lock must be held
+func (l *local) resize(key string, it *item) {
+	size := sizeOf(key, it)
+	l.bytes += size - it.size
+	it.size = size
+	l.evictOver(key)
+}
+
+// evictOver evicts the least recently used items until within the bounds, except keep
+func (l *local) evictOver(keep string) {
+	for l.lru != nil && l.lru.Len() > 0 {
+		var reason string
+		switch {
+		case l.maxEntries > 0 && len(l.m) > l.maxEntries:
+			reason = evictByEntries
+		case l.maxBytes > 0 && l.bytes > l.maxBytes:
+			reason = evictByBytes
+		default:
+			return
+		}
+
+		key := l.lru.Back().Value.(string)
+		if key == keep {
+			return
+		}
+		l.remove(key)
+		monitor.NewSingleFlight(localMetricCmd).Count(context.Background(), evictMetric, 0, reason)
+	}
+}
+
+// sizeOf estimates the bytes of key and it
+func sizeOf(key string, it *item) int64 {
+	size := int64(itemOverhead + len(key) + len(it.raw))
+	for field, raw := range it.hash {
+		size += int64(len(field) + len(raw))
+	}
+	for member := range it.set {
+		size += int64(len(member))
+	}
+	for member := range it.zset {
+		size += int64(len(member) + 8)
+	}
+	return size
+}
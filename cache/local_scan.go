@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"sort"
+)
+
+func (l *local) Scan(ctx context.Context, pattern string, count int64) (iter KeyIterator) {
+	it := &sliceIterator{}
+	if !l.active() {
+		it.err = ErrInActive
+		return it
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.nowFunc().UnixNano()
+	for key, item := range l.m {
+		if item == nil || (item.expire != 0 && now >= item.expire) {
+			continue
+		}
+		if globMatch(pattern, key) {
+			it.keys = append(it.keys, key)
+		}
+	}
+	sort.Strings(it.keys)
+	return it
+}
+
+func (l *local) DelPattern(ctx context.Context, pattern string) (n int64, err error) {
+	if !l.active() {
+		return 0, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for key := range l.m {
+		if globMatch(pattern, key) {
+			l.remove(key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// sliceIterator iterates the keys collected at once
+type sliceIterator struct {
+	keys []string
+	pos  int
+	err  error
+}
+
+func (it *sliceIterator) Next(ctx context.Context) bool {
+	if it.err != nil || it.pos >= len(it.keys) {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+func (it *sliceIterator) Key() string {
+	if it.pos == 0 {
+		return ""
+	}
+	return it.keys[it.pos-1]
+}
+
+func (it *sliceIterator) Err() error {
+	return it.err
+}
+
+// globMatch reports whether s matches the glob pattern of redis, i.e. *, ?, [abc], [^a-z] and \ escaping
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			matched, rest, ok := matchClass(pattern[1:], s[0])
+			if !ok {
+				// no closing ], take [ literally
+				if s[0] != '[' {
+					return false
+				}
+			} else {
+				if !matched {
+					return false
+				}
+				pattern = rest
+				s = s[1:]
+				continue
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return len(s) == 0
+}
+
+// matchClass matches c against the class after [, and returns the pattern after ], ok is false if not closed
+func matchClass(class string, c byte) (matched bool, rest string, ok bool) {
+	negate := false
+	if len(class) > 0 && class[0] == '^' {
+		negate = true
+		class = class[1:]
+	}
+	for i := 0; i < len(class); i++ {
+		switch {
+		case class[i] == ']':
+			return matched != negate, class[i+1:], true
+		case class[i] == '\\' && i+1 < len(class):
+			i++
+			if class[i] == c {
+				matched = true
+			}
+		case i+2 < len(class) && class[i+1] == '-' && class[i+2] != ']':
+			lo, hi := class[i], class[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			i += 2
+		default:
+			if class[i] == c {
+				matched = true
+			}
+		}
+	}
+	return false, "", false
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+func (l *local) SAdd(ctx context.Context, key string, members ...string) (err error) {
+	if !l.active() {
+		return ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.setOf(key, true)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		it.set[member] = struct{}{}
+	}
+	l.resize(key, it)
+	return nil
+}
+
+func (l *local) SRem(ctx context.Context, key string, members ...string) (err error) {
+	if !l.active() {
+		return ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.setOf(key, false)
+	if err != nil || it == nil {
+		return err
+	}
+	for _, member := range members {
+		delete(it.set, member)
+	}
+	if len(it.set) == 0 {
+		l.remove(key)
+	} else {
+		l.resize(key, it)
+	}
+	return nil
+}
+
+func (l *local) SIsMember(ctx context.Context, key string, member string) (ok bool, err error) {
+	if !l.active() {
+		return false, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.setOf(key, false)
+	if err != nil || it == nil {
+		return false, err
+	}
+	_, ok = it.set[member]
+	return ok, nil
+}
+
+func (l *local) SMembers(ctx context.Context, key string) (members []string, err error) {
+	if !l.active() {
+		return nil, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.setOf(key, false)
+	if err != nil {
+		return nil, err
+	}
+	members = []string{}
+	if it != nil {
+		for member := range it.set {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+	}
+	return members, nil
+}
+
+func (l *local) SCard(ctx context.Context, key string) (n int64, err error) {
+	if !l.active() {
+		return 0, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.setOf(key, false)
+	if err != nil || it == nil {
+		return 0, err
+	}
+	return int64(len(it.set)), nil
+}
+
+// setOf returns the set item of key, created if missing and create is set, the lock must be held
+func (l *local) setOf(key string, create bool) (*item, error) {
+	it := l.lookup(key)
+	if it == nil {
+		if !create {
+			return nil, nil
+		}
+		it = &item{set: make(map[string]struct{})}
+		l.store(key, it)
+	}
+	if it.set == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWrongType, key)
+	}
+	return it, nil
+}
@@ -2,6 +2,10 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -43,7 +47,7 @@ func Test_local_Get(t *testing.T) {
 				m: map[string]*item{
 					"abc": {
 						raw:    []byte("123"),
-						expire: time.Now().Unix() - 100000,
+						expire: time.Now().Add(-100000 * time.Second).UnixNano(),
 					},
 				},
 				nowFunc: func() time.Time {
@@ -63,7 +67,7 @@ func Test_local_Get(t *testing.T) {
 				m: map[string]*item{
 					"abc": {
 						raw:    []byte("123"),
-						expire: time.Now().Unix() + 100000,
+						expire: time.Now().Add(100000 * time.Second).UnixNano(),
 					},
 				},
 				nowFunc: func() time.Time {
@@ -95,3 +99,489 @@ func Test_local_Get(t *testing.T) {
 		})
 	}
 }
+
+func Test_local_MGet_MSet(t *testing.T) {
+	l := NewLocal()
+	ctx := context.Background()
+
+	if err := l.MSet(ctx, map[string]string{"a": "1", "b": "2"}, time.Minute); err != nil {
+		t.Fatalf("MSet() error = %v", err)
+	}
+
+	t.Run("when some keys are missing then only the found ones are returned", func(t *testing.T) {
+		got, err := l.MGet(ctx, "a", "missing", "b")
+		if err != nil {
+			t.Fatalf("MGet() error = %v", err)
+		}
+		if len(got) != 2 || got["a"] != "1" || got["b"] != "2" {
+			t.Errorf("MGet() = %v, want a and b", got)
+		}
+	})
+}
+
+func Test_local_IncrBy(t *testing.T) {
+	now := time.Now()
+	l := &local{
+		m:       map[string]*item{},
+		nowFunc: func() time.Time { return now },
+	}
+	ctx := context.Background()
+
+	t.Run("when the counter is new then the ttl is set", func(t *testing.T) {
+		val, err := l.Incr(ctx, "c", time.Minute)
+		if err != nil || val != 1 {
+			t.Fatalf("Incr() = %v, %v, want 1", val, err)
+		}
+	})
+
+	t.Run("when the counter exists then the ttl is kept", func(t *testing.T) {
+		val, err := l.IncrBy(ctx, "c", 5, time.Hour)
+		if err != nil || val != 6 {
+			t.Fatalf("IncrBy() = %v, %v, want 6", val, err)
+		}
+		if want := now.Add(time.Minute).UnixNano(); l.m["c"].expire != want {
+			t.Errorf("expire = %v, want %v", l.m["c"].expire, want)
+		}
+	})
+
+	t.Run("when the counter expired then it restarts", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		val, err := l.DecrBy(ctx, "c", 2, 0)
+		if err != nil || val != -2 {
+			t.Errorf("DecrBy() = %v, %v, want -2", val, err)
+		}
+	})
+
+	t.Run("when the value is not an integer then ErrNotInt", func(t *testing.T) {
+		_ = l.Set(ctx, "s", "abc", 0)
+		if _, err := l.Decr(ctx, "s", 0); !errors.Is(err, ErrNotInt) {
+			t.Errorf("Decr() error = %v, want ErrNotInt", err)
+		}
+	})
+}
+
+func Test_local_Hash(t *testing.T) {
+	l := NewLocal()
+	ctx := context.Background()
+
+	if err := l.HSet(ctx, "h", map[string]string{"a": "1", "b": "2"}); err != nil {
+		t.Fatalf("HSet() error = %v", err)
+	}
+
+	t.Run("when the field is missing then ErrNotFound", func(t *testing.T) {
+		if _, err := l.HGet(ctx, "h", "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("HGet() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("when HIncrBy then the field is counted", func(t *testing.T) {
+		if got, err := l.HIncrBy(ctx, "h", "b", 3); err != nil || got != 5 {
+			t.Errorf("HIncrBy() = %v, %v, want 5", got, err)
+		}
+	})
+
+	t.Run("when HDel then HGetAll returns the rest", func(t *testing.T) {
+		_ = l.HDel(ctx, "h", "a")
+		got, err := l.HGetAll(ctx, "h")
+		if err != nil || len(got) != 1 || got["b"] != "5" {
+			t.Errorf("HGetAll() = %v, %v, want b=5", got, err)
+		}
+	})
+
+	t.Run("when the key is a string then ErrWrongType", func(t *testing.T) {
+		_ = l.Set(ctx, "s", "abc", 0)
+		if _, err := l.HGet(ctx, "s", "a"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("HGet() error = %v, want ErrWrongType", err)
+		}
+		if _, err := l.Get(ctx, "h"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Get() error = %v, want ErrWrongType", err)
+		}
+	})
+}
+
+func Test_local_Set(t *testing.T) {
+	l := NewLocal()
+	ctx := context.Background()
+
+	if err := l.SAdd(ctx, "s", "b", "a", "c"); err != nil {
+		t.Fatalf("SAdd() error = %v", err)
+	}
+
+	t.Run("when SRem then the rest are the members", func(t *testing.T) {
+		_ = l.SRem(ctx, "s", "b")
+		got, err := l.SMembers(ctx, "s")
+		if err != nil || len(got) != 2 || got[0] != "a" || got[1] != "c" {
+			t.Errorf("SMembers() = %v, %v, want [a c]", got, err)
+		}
+		if ok, _ := l.SIsMember(ctx, "s", "b"); ok {
+			t.Errorf("SIsMember() = true, want false")
+		}
+		if n, _ := l.SCard(ctx, "s"); n != 2 {
+			t.Errorf("SCard() = %v, want 2", n)
+		}
+	})
+
+	t.Run("when the key is a hash then ErrWrongType", func(t *testing.T) {
+		_ = l.HSet(ctx, "h", map[string]string{"a": "1"})
+		if err := l.SAdd(ctx, "h", "a"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("SAdd() error = %v, want ErrWrongType", err)
+		}
+		if _, err := l.Get(ctx, "s"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Get() error = %v, want ErrWrongType", err)
+		}
+	})
+}
+
+func Test_local_SortedSet(t *testing.T) {
+	l := NewLocal()
+	ctx := context.Background()
+
+	_ = l.ZAdd(ctx, "z", Z{Member: "a", Score: 1}, Z{Member: "b", Score: 2}, Z{Member: "c", Score: 3})
+
+	t.Run("when ZRangeByScore then the members in range are ascending", func(t *testing.T) {
+		got, err := l.ZRangeByScore(ctx, "z", math.Inf(-1), 2)
+		if want := []Z{{Member: "a", Score: 1}, {Member: "b", Score: 2}}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ZRangeByScore() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("when ZRevRange with the negative ranks then they count from the lowest", func(t *testing.T) {
+		got, err := l.ZRevRange(ctx, "z", 1, -1)
+		if want := []Z{{Member: "b", Score: 2}, {Member: "a", Score: 1}}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ZRevRange() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("when the key is a set then ErrWrongType", func(t *testing.T) {
+		_ = l.SAdd(ctx, "s", "a")
+		if err := l.ZAdd(ctx, "s", Z{Member: "a"}); !errors.Is(err, ErrWrongType) {
+			t.Errorf("ZAdd() error = %v, want ErrWrongType", err)
+		}
+	})
+}
+
+func TestLeaderboard(t *testing.T) {
+	lb := NewLeaderboard(NewLocal(), "board")
+	ctx := context.Background()
+
+	_, _ = lb.Incr(ctx, "alice", 10)
+	_, _ = lb.Incr(ctx, "bob", 30)
+	_, _ = lb.Incr(ctx, "carol", 20)
+	_, _ = lb.Incr(ctx, "alice", 15)
+
+	t.Run("when Top then the highest scores come first", func(t *testing.T) {
+		got, err := lb.Top(ctx, 2)
+		if want := []Z{{Member: "bob", Score: 30}, {Member: "alice", Score: 25}}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Top() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("when Remove then the member is not ranked", func(t *testing.T) {
+		_ = lb.Remove(ctx, "bob")
+		got, _ := lb.Top(ctx, 1)
+		if len(got) != 1 || got[0].Member != "alice" {
+			t.Errorf("Top() = %v, want alice", got)
+		}
+	})
+}
+
+func Test_local_LRU(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("when over max entries then the least recently used is evicted", func(t *testing.T) {
+		l := NewLocal(WithMaxEntries(2))
+		before := counterValue(t, localMetricCmd, evictMetric, "0", evictByEntries)
+		_ = l.Set(ctx, "a", "1", 0)
+		_ = l.Set(ctx, "b", "2", 0)
+		_, _ = l.Get(ctx, "a")
+		_ = l.Set(ctx, "c", "3", 0)
+
+		if _, err := l.Get(ctx, "b"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(b) error = %v, want ErrNotFound", err)
+		}
+		for _, key := range []string{"a", "c"} {
+			if _, err := l.Get(ctx, key); err != nil {
+				t.Errorf("Get(%s) error = %v, want found", key, err)
+			}
+		}
+		if got := counterValue(t, localMetricCmd, evictMetric, "0", evictByEntries); got != before+1 {
+			t.Errorf("evictions = %v, want %v", got, before+1)
+		}
+	})
+
+	t.Run("when over max bytes then the least recently used are evicted", func(t *testing.T) {
+		l := NewLocal(WithMaxBytes(3 * (itemOverhead + 10))).(*local)
+		for _, key := range []string{"k1", "k2", "k3", "k4"} {
+			_ = l.Set(ctx, key, "12345678", 0)
+		}
+		if n := len(l.m); n != 3 {
+			t.Errorf("entries = %v, want 3", n)
+		}
+		if _, err := l.Get(ctx, "k1"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(k1) error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("when the values grow in place then the bytes are tracked", func(t *testing.T) {
+		l := NewLocal().(*local)
+		_ = l.HSet(ctx, "h", map[string]string{"f": "v"})
+		_ = l.HSet(ctx, "h", map[string]string{"g": "w"})
+		_ = l.Set(ctx, "s", "v", 0)
+		_ = l.Del(ctx, "s")
+		if want := int64(itemOverhead + len("h") + 4); l.bytes != want {
+			t.Errorf("bytes = %v, want %v", l.bytes, want)
+		}
+	})
+}
+
+func Test_local_Sweep(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("when swept in batches then only the expired items are deleted", func(t *testing.T) {
+		now := time.Now()
+		l := &local{
+			m:          map[string]*item{},
+			nowFunc:    func() time.Time { return now },
+			sweepBatch: 2,
+		}
+		for _, key := range []string{"a", "b", "c", "d", "e"} {
+			_ = l.Set(ctx, key, "1", time.Minute)
+		}
+		_ = l.Set(ctx, "forever", "1", 0)
+
+		now = now.Add(2 * time.Minute)
+		before := counterValue(t, localMetricCmd, evictMetric, "0", evictByExpired)
+		l.sweep()
+
+		if len(l.m) != 1 || l.lru.Len() != 1 {
+			t.Errorf("entries = %v, want only forever", len(l.m))
+		}
+		if got := counterValue(t, localMetricCmd, evictMetric, "0", evictByExpired); got != before+5 {
+			t.Errorf("swept = %v, want %v", got, before+5)
+		}
+	})
+
+	t.Run("when the janitor runs then the untouched expired items are deleted", func(t *testing.T) {
+		l := NewLocal(WithSweepInterval(10 * time.Millisecond)).(*local)
+		defer l.Close()
+		_ = l.Set(ctx, "expired", "1", -time.Second)
+
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			l.lock.Lock()
+			n := len(l.m)
+			l.lock.Unlock()
+			if n == 0 {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Errorf("expired item not swept")
+	})
+
+	t.Run("when closed then the janitor stops", func(t *testing.T) {
+		l := NewLocal(WithSweepInterval(time.Millisecond)).(*local)
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		select {
+		case <-l.done:
+		default:
+			t.Errorf("janitor not stopped")
+		}
+		_ = l.Close()
+	})
+
+	t.Run("when default then the janitor sweeps every minute", func(t *testing.T) {
+		l := NewLocal().(*local)
+		if l.stop == nil || l.sweepInterval != defaultSweepInterval {
+			t.Errorf("janitor not started every %v, interval = %v", defaultSweepInterval, l.sweepInterval)
+		}
+		if err := l.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	t.Run("when the sweep interval is 0 then no janitor", func(t *testing.T) {
+		l := NewLocal(WithSweepInterval(0)).(*local)
+		if l.stop != nil {
+			t.Errorf("janitor started")
+		}
+		if err := l.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	t.Run("when few items are expired then a sweep checks one batch per lock hold", func(t *testing.T) {
+		now := time.Now()
+		l := &local{
+			m:          map[string]*item{},
+			nowFunc:    func() time.Time { return now },
+			sweepBatch: 10,
+		}
+		for i := 0; i < 100; i++ {
+			_ = l.Set(ctx, strconv.Itoa(i), "1", 0)
+		}
+
+		if checked, expired := l.sweepBatchOnce(); checked != 10 || expired != 0 {
+			t.Errorf("sweepBatchOnce() = %v, %v, want 10 checked and none expired", checked, expired)
+		}
+		l.sweep()
+		if len(l.m) != 100 {
+			t.Errorf("entries = %v, want 100", len(l.m))
+		}
+	})
+}
+
+func Test_local_Exists_TTL(t *testing.T) {
+	now := time.Now()
+	l := &local{
+		m:       map[string]*item{},
+		nowFunc: func() time.Time { return now },
+	}
+	ctx := context.Background()
+	_ = l.Set(ctx, "forever", "1", 0)
+	_ = l.Set(ctx, "expiring", "1", time.Minute)
+
+	t.Run("when the key exists then true", func(t *testing.T) {
+		if ok, _ := l.Exists(ctx, "expiring"); !ok {
+			t.Errorf("Exists() = false, want true")
+		}
+		if ok, _ := l.Exists(ctx, "missing"); ok {
+			t.Errorf("Exists() = true, want false")
+		}
+	})
+
+	t.Run("when TTL then the remaining time, 0 for no expire and ErrNotFound for missing", func(t *testing.T) {
+		if ttl, err := l.TTL(ctx, "expiring"); err != nil || ttl != time.Minute {
+			t.Errorf("TTL() = %v, %v, want 1m", ttl, err)
+		}
+		if ttl, err := l.TTL(ctx, "forever"); err != nil || ttl != 0 {
+			t.Errorf("TTL() = %v, %v, want 0", ttl, err)
+		}
+		now = now.Add(2 * time.Minute)
+		if _, err := l.TTL(ctx, "expiring"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("TTL() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("when less than 1s left then the exact remaining time, never 0", func(t *testing.T) {
+		_ = l.Set(ctx, "short", "1", 300*time.Millisecond)
+		now = now.Add(100 * time.Millisecond)
+		if ttl, err := l.TTL(ctx, "short"); err != nil || ttl != 200*time.Millisecond {
+			t.Errorf("TTL() = %v, %v, want 200ms", ttl, err)
+		}
+		now = now.Add(250 * time.Millisecond)
+		if _, err := l.TTL(ctx, "short"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("TTL() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func Test_local_GetDel_GetSet_GetEx(t *testing.T) {
+	now := time.Now()
+	l := &local{
+		m:       map[string]*item{},
+		nowFunc: func() time.Time { return now },
+	}
+	ctx := context.Background()
+	_ = l.Set(ctx, "token", "t1", 0)
+	_ = l.Set(ctx, "counter", "1", 0)
+	_ = l.Set(ctx, "session", "s1", time.Minute)
+	_ = l.HSet(ctx, "hash", map[string]string{"f": "v"})
+
+	t.Run("when GetDel then the value is returned once", func(t *testing.T) {
+		if raw, err := l.GetDel(ctx, "token"); err != nil || raw != "t1" {
+			t.Errorf("GetDel() = %v, %v, want t1", raw, err)
+		}
+		if _, err := l.GetDel(ctx, "token"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetDel() error = %v, want ErrNotFound", err)
+		}
+		if _, err := l.GetDel(ctx, "hash"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("GetDel() error = %v, want ErrWrongType", err)
+		}
+	})
+
+	t.Run("when GetSet then the old value is returned and the new one stored", func(t *testing.T) {
+		if old, err := l.GetSet(ctx, "counter", "2", time.Minute); err != nil || old != "1" {
+			t.Errorf("GetSet() = %v, %v, want 1", old, err)
+		}
+		if raw, _ := l.Get(ctx, "counter"); raw != "2" {
+			t.Errorf("Get() = %v, want 2", raw)
+		}
+		if _, err := l.GetSet(ctx, "fresh", "1", 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetSet() error = %v, want ErrNotFound", err)
+		}
+		if ok, _ := l.Exists(ctx, "fresh"); !ok {
+			t.Errorf("fresh not stored")
+		}
+	})
+
+	t.Run("when GetEx then the value is returned and the expire reset", func(t *testing.T) {
+		if raw, err := l.GetEx(ctx, "session", time.Hour); err != nil || raw != "s1" {
+			t.Errorf("GetEx() = %v, %v, want s1", raw, err)
+		}
+		now = now.Add(2 * time.Minute)
+		if raw, err := l.Get(ctx, "session"); err != nil || raw != "s1" {
+			t.Errorf("Get() = %v, %v, want s1 kept by the new expire", raw, err)
+		}
+		if _, err := l.GetEx(ctx, "missing", time.Hour); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetEx() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func Test_globMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"user:*", "user:1/2", true},
+		{"user:*", "order:1", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"a\\*b", "a*b", true},
+		{"a\\*b", "axb", false},
+		{"*:*:end", "a:b:end", true},
+		{"[abc", "[abc", true},
+	}
+	for _, tt := range tests {
+		t.Run("when "+tt.pattern+" against "+tt.s, func(t *testing.T) {
+			if got := globMatch(tt.pattern, tt.s); got != tt.want {
+				t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_local_Scan_DelPattern(t *testing.T) {
+	l := NewLocal()
+	ctx := context.Background()
+	_ = l.Set(ctx, "user:1", "1", 0)
+	_ = l.Set(ctx, "user:2", "1", 0)
+	_ = l.Set(ctx, "order:1", "1", 0)
+
+	t.Run("when Scan then the matching keys are iterated", func(t *testing.T) {
+		var keys []string
+		it := l.Scan(ctx, "user:*", 0)
+		for it.Next(ctx) {
+			keys = append(keys, it.Key())
+		}
+		if !reflect.DeepEqual(keys, []string{"user:1", "user:2"}) || it.Err() != nil {
+			t.Errorf("Scan() = %v, %v, want user:1 and user:2", keys, it.Err())
+		}
+	})
+
+	t.Run("when DelPattern then the matching keys are deleted", func(t *testing.T) {
+		if n, err := l.DelPattern(ctx, "user:*"); err != nil || n != 2 {
+			t.Errorf("DelPattern() = %v, %v, want 2", n, err)
+		}
+		if ok, _ := l.Exists(ctx, "order:1"); !ok {
+			t.Errorf("order:1 deleted")
+		}
+	})
+}
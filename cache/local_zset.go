@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+func (l *local) ZAdd(ctx context.Context, key string, members ...Z) (err error) {
+	if !l.active() {
+		return ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.zsetOf(key, true)
+	if err != nil {
+		return err
+	}
+	for _, z := range members {
+		it.zset[z.Member] = z.Score
+	}
+	l.resize(key, it)
+	return nil
+}
+
+func (l *local) ZRangeByScore(ctx context.Context, key string, min, max float64) (members []Z, err error) {
+	if !l.active() {
+		return nil, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.zsetOf(key, false)
+	if err != nil {
+		return nil, err
+	}
+	members = []Z{}
+	for _, z := range sortedZ(it) {
+		if z.Score >= min && z.Score <= max {
+			members = append(members, z)
+		}
+	}
+	return members, nil
+}
+
+func (l *local) ZRevRange(ctx context.Context, key string, start, stop int64) (members []Z, err error) {
+	if !l.active() {
+		return nil, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.zsetOf(key, false)
+	if err != nil {
+		return nil, err
+	}
+	zs := sortedZ(it)
+	n := int64(len(zs))
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	members = []Z{}
+	for i := start; i <= stop; i++ {
+		members = append(members, zs[n-1-i])
+	}
+	return members, nil
+}
+
+func (l *local) ZRem(ctx context.Context, key string, members ...string) (err error) {
+	if !l.active() {
+		return ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.zsetOf(key, false)
+	if err != nil || it == nil {
+		return err
+	}
+	for _, member := range members {
+		delete(it.zset, member)
+	}
+	if len(it.zset) == 0 {
+		l.remove(key)
+	} else {
+		l.resize(key, it)
+	}
+	return nil
+}
+
+func (l *local) ZIncrBy(ctx context.Context, key string, member string, delta float64) (score float64, err error) {
+	if !l.active() {
+		return 0, ErrInActive
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	it, err := l.zsetOf(key, true)
+	if err != nil {
+		return 0, err
+	}
+	it.zset[member] += delta
+	score = it.zset[member]
+	l.resize(key, it)
+	return score, nil
+}
+
+// zsetOf returns the sorted set item of key, created if missing and create is set, the lock must be held
+func (l *local) zsetOf(key string, create bool) (*item, error) {
+	it := l.lookup(key)
+	if it == nil {
+		if !create {
+			return nil, nil
+		}
+		it = &item{zset: make(map[string]float64)}
+		l.store(key, it)
+	}
+	if it.zset == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWrongType, key)
+	}
+	return it, nil
+}
+
+// sortedZ returns the members of it by the ascending scores, then the members as redis does
+func sortedZ(it *item) []Z {
+	if it == nil {
+		return nil
+	}
+	zs := make([]Z, 0, len(it.zset))
+	for member, score := range it.zset {
+		zs = append(zs, Z{Member: member, Score: score})
+	}
+	sort.Slice(zs, func(i, j int) bool {
+		if zs[i].Score != zs[j].Score {
+			return zs[i].Score < zs[j].Score
+		}
+		return zs[i].Member < zs[j].Member
+	})
+	return zs
+}
@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+const (
+	lockMetricCmd     = "cache_lock"
+	lockAcquireMetric = "lock_acquire"
+	lockHoldMetric    = "lock_hold"
+
+	// codeLockContended is the metrics code of an acquire failed by the lock held by another
+	codeLockContended = 1
+	// codeLockError is the metrics code of an acquire failed by the error of the cache
+	codeLockError = 2
+)
+
+var (
+	ErrNotAcquired = errors.New("cache: lock held by another")
+	ErrLockLost    = errors.New("cache: lock lost")
+)
+
+// acquireScript sets the lock to the token if it's not held, for ARGV[2] milliseconds if positive
+const acquireScript = `local ok
+if tonumber(ARGV[2]) > 0 then
+	ok = redis.call("set", KEYS[1], ARGV[1], "NX", "PX", ARGV[2])
+else
+	ok = redis.call("set", KEYS[1], ARGV[1], "NX")
+end
+if ok then return 1 else return 0 end`
+
+// releaseScript deletes the lock only if it's still held by the token
+const releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+// refreshScript resets the ttl of the lock only if it's still held by the token
+const refreshScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+
+type LockerOpt func(l *Locker)
+
+// WithAutoRenew renews the acquired locks every ttl/3 until released,
+// so that the holders running longer than ttl keep them
+func WithAutoRenew(enable bool) LockerOpt {
+	return func(l *Locker) {
+		l.autoRenew = enable
+	}
+}
+
+// WithLockPrefix prefixes the keys of the locks
+func WithLockPrefix(prefix string) LockerOpt {
+	return func(l *Locker) {
+		l.prefix = prefix
+	}
+}
+
+// Locker acquires the locks held by a token in the cache,
+// the metrics of the contention and the hold time are labeled with the keys, so keep them bounded
+type Locker struct {
+	m         Manager
+	prefix    string
+	autoRenew bool
+}
+
+func NewLocker(m Manager, opts ...LockerOpt) *Locker {
+	l := &Locker{
+		m: m,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Acquire takes the lock of key for ttl, ErrNotAcquired if it's held by another
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	key = l.prefix + key
+	token := tracking.NewRequestId()
+	sf := monitor.NewSingleFlight(lockMetricCmd)
+
+	acquired, err := l.acquire(ctx, key, token, ttl)
+	if err != nil {
+		sf.Count(ctx, lockAcquireMetric, codeLockError, key)
+		return nil, fmt.Errorf("acquire lock %s error: %w", key, err)
+	}
+	if !acquired {
+		sf.Count(ctx, lockAcquireMetric, codeLockContended, key)
+		return nil, fmt.Errorf("%w: %s", ErrNotAcquired, key)
+	}
+	sf.Count(ctx, lockAcquireMetric, 0, key)
+
+	lk := &Lock{
+		m:        l.m,
+		key:      key,
+		token:    token,
+		ttl:      ttl,
+		acquired: time.Now(),
+		done:     make(chan struct{}),
+		lost:     make(chan struct{}),
+	}
+	if l.autoRenew && ttl > 0 {
+		go lk.renew(logger.Detach(ctx))
+	}
+	return lk, nil
+}
+
+// acquire sets the lock of key to token if it's not held, by SetNx if the cache does not support scripts,
+// e.g. the local cache, which reports whether the key exists
+func (l *Locker) acquire(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	val, err := l.m.Eval(ctx, acquireScript, []string{key}, token, ttl.Milliseconds())
+	if err == nil {
+		n, _ := val.(int64)
+		return n == 1, nil
+	}
+	if !errors.Is(err, ErrNotSupported) {
+		return false, err
+	}
+
+	existing, err := l.m.SetNx(ctx, key, token, ttl)
+	if err != nil {
+		return false, err
+	}
+	return !existing, nil
+}
+
+// Lock is a lock held by its token until released or expired
+type Lock struct {
+	m        Manager
+	key      string
+	token    string
+	ttl      time.Duration
+	acquired time.Time
+
+	once     sync.Once
+	done     chan struct{} // closed by Release
+	lostOnce sync.Once
+	lost     chan struct{} // closed when the renew finds the lock lost
+}
+
+func (lk *Lock) Key() string {
+	return lk.key
+}
+
+func (lk *Lock) Token() string {
+	return lk.token
+}
+
+// Lost is closed when the auto renew finds the lock expired or taken by another
+func (lk *Lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// Release deletes the lock if it's still held by the token, ErrLockLost if not
+func (lk *Lock) Release(ctx context.Context) (err error) {
+	lk.once.Do(func() {
+		close(lk.done)
+		monitor.NewSingleFlight(lockMetricCmd).Sample(ctx, lockHoldMetric, 0, float64(time.Since(lk.acquired).Milliseconds()), lk.key)
+	})
+
+	held, err := lk.eval(ctx, releaseScript, func() error {
+		return lk.m.Del(ctx, lk.key)
+	})
+	if err != nil {
+		return fmt.Errorf("release lock %s error: %w", lk.key, err)
+	}
+	if !held {
+		return fmt.Errorf("%w: %s", ErrLockLost, lk.key)
+	}
+	return nil
+}
+
+// Refresh resets the ttl of the lock if it's still held by the token, ErrLockLost if not
+func (lk *Lock) Refresh(ctx context.Context) (err error) {
+	held, err := lk.eval(ctx, refreshScript, func() error {
+		return lk.m.Expire(ctx, lk.key, lk.ttl)
+	}, lk.ttl.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("refresh lock %s error: %w", lk.key, err)
+	}
+	if !held {
+		lk.lostOnce.Do(func() { close(lk.lost) })
+		return fmt.Errorf("%w: %s", ErrLockLost, lk.key)
+	}
+	return nil
+}
+
+// eval runs script against the lock and reports whether it's held by the token,
+// apply is called instead if the cache does not support scripts, e.g. the local cache
+func (lk *Lock) eval(ctx context.Context, script string, apply func() error, args ...any) (bool, error) {
+	val, err := lk.m.Eval(ctx, script, []string{lk.key}, append([]any{lk.token}, args...)...)
+	if err == nil {
+		n, _ := val.(int64)
+		return n == 1, nil
+	}
+	if !errors.Is(err, ErrNotSupported) {
+		return false, err
+	}
+
+	holder, err := lk.m.Get(ctx, lk.key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if holder != lk.token {
+		return false, nil
+	}
+	return true, apply()
+}
+
+// renew refreshes the lock every ttl/3 until released or lost
+func (lk *Lock) renew(ctx context.Context) {
+	ticker := time.NewTicker(lk.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lk.done:
+			return
+		case <-ticker.C:
+		}
+
+		err := lk.Refresh(ctx)
+		if errors.Is(err, ErrLockLost) {
+			logger.FromContext(ctx).WithField("key", lk.key).Warn("lock lost, stop renewing")
+			return
+		}
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).WithField("key", lk.key).Warn("renew lock error")
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLocker(t *testing.T) {
+	m, mr := newTestManager(t)
+	locker := NewLocker(m, WithLockPrefix("lock:"))
+	ctx := context.Background()
+
+	lk, err := locker.Acquire(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	t.Run("when the lock is held then the token is stored", func(t *testing.T) {
+		if got, _ := mr.Get("lock:job"); got != lk.Token() {
+			t.Errorf("lock:job = %v, want %v", got, lk.Token())
+		}
+	})
+
+	t.Run("when the lock is held then Acquire is ErrNotAcquired", func(t *testing.T) {
+		before := counterValue(t, lockMetricCmd, lockAcquireMetric, "1", "lock:job")
+		if _, err := locker.Acquire(ctx, "job", time.Minute); !errors.Is(err, ErrNotAcquired) {
+			t.Errorf("Acquire() error = %v, want ErrNotAcquired", err)
+		}
+		if got := counterValue(t, lockMetricCmd, lockAcquireMetric, "1", "lock:job"); got != before+1 {
+			t.Errorf("contention = %v, want %v", got, before+1)
+		}
+	})
+
+	t.Run("when released then the lock can be acquired again", func(t *testing.T) {
+		if err := lk.Release(ctx); err != nil {
+			t.Fatalf("Release() error = %v", err)
+		}
+		again, err := locker.Acquire(ctx, "job", time.Minute)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		_ = again.Release(ctx)
+	})
+
+	t.Run("when the lock expired and was taken then Release is ErrLockLost", func(t *testing.T) {
+		lk, _ := locker.Acquire(ctx, "job", time.Second)
+		mr.FastForward(2 * time.Second)
+		other, err := locker.Acquire(ctx, "job", time.Minute)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		if err := lk.Release(ctx); !errors.Is(err, ErrLockLost) {
+			t.Errorf("Release() error = %v, want ErrLockLost", err)
+		}
+		if got, _ := mr.Get("lock:job"); got != other.Token() {
+			t.Errorf("lock:job = %v, want the other token", got)
+		}
+		_ = other.Release(ctx)
+	})
+}
+
+func TestLocker_AutoRenew(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	locker := NewLocker(NewManager(client, nil), WithAutoRenew(true))
+	ctx := context.Background()
+
+	lk, err := locker.Acquire(ctx, "job", 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	t.Run("when held then the ttl is renewed", func(t *testing.T) {
+		mr.FastForward(200 * time.Millisecond)
+		deadline := time.Now().Add(time.Second)
+		for mr.TTL("job") <= 100*time.Millisecond && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := mr.TTL("job"); got <= 100*time.Millisecond {
+			t.Errorf("ttl = %v, want renewed", got)
+		}
+	})
+
+	t.Run("when taken by another then Lost is closed", func(t *testing.T) {
+		_ = mr.Set("job", "other")
+		select {
+		case <-lk.Lost():
+		case <-time.After(time.Second):
+			t.Fatalf("Lost() not closed")
+		}
+	})
+}
+
+func TestLocker_Local(t *testing.T) {
+	locker := NewLocker(NewLocal())
+	ctx := context.Background()
+
+	t.Run("when the cache does not support scripts then the lock is still released", func(t *testing.T) {
+		lk, err := locker.Acquire(ctx, "job", time.Minute)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		if _, err := locker.Acquire(ctx, "job", time.Minute); !errors.Is(err, ErrNotAcquired) {
+			t.Errorf("Acquire() error = %v, want ErrNotAcquired", err)
+		}
+		if err := lk.Refresh(ctx); err != nil {
+			t.Errorf("Refresh() error = %v", err)
+		}
+		if err := lk.Release(ctx); err != nil {
+			t.Errorf("Release() error = %v", err)
+		}
+		if err := lk.Release(ctx); !errors.Is(err, ErrLockLost) {
+			t.Errorf("Release() error = %v, want ErrLockLost", err)
+		}
+	})
+}
+
+// failingEval fails the scripts as a redis timeout once failing, and does not support them before
+type failingEval struct {
+	Manager
+	failing bool
+}
+
+func (m *failingEval) Eval(context.Context, string, []string, ...any) (any, error) {
+	if m.failing {
+		return nil, errors.New("i/o timeout")
+	}
+	return nil, ErrNotSupported
+}
+
+func TestLocker_EvalError(t *testing.T) {
+	m := &failingEval{Manager: NewLocal()}
+	locker := NewLocker(m)
+	ctx := context.Background()
+
+	t.Run("when the script fails then the error is returned and the lock is kept", func(t *testing.T) {
+		lk, err := locker.Acquire(ctx, "job", time.Minute)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		m.failing = true
+		defer func() { m.failing = false }()
+		if err := lk.Release(ctx); err == nil || errors.Is(err, ErrLockLost) {
+			t.Errorf("Release() error = %v, want the error of the script", err)
+		}
+		if got, _ := m.Get(ctx, "job"); got != lk.Token() {
+			t.Errorf("job = %v, want the token kept", got)
+		}
+	})
+
+	t.Run("when the script fails then Acquire does not fall back", func(t *testing.T) {
+		m.failing = true
+		defer func() { m.failing = false }()
+		if _, err := locker.Acquire(ctx, "other", time.Minute); err == nil || errors.Is(err, ErrNotAcquired) {
+			t.Errorf("Acquire() error = %v, want the error of the script", err)
+		}
+		if _, err := m.Get(ctx, "other"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("other error = %v, want not set", err)
+		}
+	})
+}
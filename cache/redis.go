@@ -1,23 +1,33 @@
 package cache
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"errors"
 	"fmt"
-	"github.com/go-redis/redis/v8"
-	"github.com/tenz-io/trackingo/common"
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/dependencies"
 	"github.com/tenz-io/trackingo/logger"
-	"github.com/tenz-io/trackingo/monitor"
+	"golang.org/x/sync/singleflight"
+	"strings"
 	"time"
 )
 
+// incrByScript increments the counter and sets the ttl if it has none, i.e. on the first set
+var incrByScript = redis.NewScript(`
+local val = redis.call("INCRBY", KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return val
+`)
+
 type Opt func(m *manager)
 type Options []Opt
 
+// NewManager returns the Manager of client, e.g. *redis.Client, *redis.ClusterClient
+// or the failover client of sentinel
 func NewManager(
-	client *redis.Client,
+	client redis.UniversalClient,
 	opts Options,
 ) Manager {
 	m := &manager{
@@ -32,9 +42,17 @@ func NewManager(
 }
 
 type manager struct {
-	client        *redis.Client
+	client        redis.UniversalClient
+	cluster       bool // the keys of the multi-key commands must be in the same slot
 	enableMetrics bool
 	enableTraffic bool
+	dependency    string
+	loads         singleflight.Group // the loads of GetOrLoad by key
+	codec         Codec              // the codec of the blobs, nil for gob without prefix
+
+	compression       Compression // the algorithm of the values longer than compressThreshold, 0 for none
+	compressThreshold int
+	ttlJitter         float64 // the fraction randomizing the expire of Set, SetBlob and MSet
 }
 
 func WithMetrics(enable bool) Opt {
@@ -49,6 +67,23 @@ func WithTraffic(enable bool) Opt {
 	}
 }
 
+// WithDependency tags the metrics and traffic logs with the declared redis dependency of name
+func WithDependency(name string) Opt {
+	return func(m *manager) {
+		m.dependency = dependencies.Resolve(name, dependencies.TypeRedis).Label()
+	}
+}
+
+// startCall starts the metrics and the traffic log of cmd, bound with the dependency name
+func (m *manager) startCall(ctx context.Context, cmd string, req any, fields logger.Fields) *logger.CallRec {
+	return logger.StartCall(ctx, cmd, req,
+		logger.CallMetrics(m.enableMetrics),
+		logger.CallTraffic(m.enableTraffic),
+		logger.CallFields(fields),
+		logger.CallDependency(m.dependency),
+	)
+}
+
 func (m *manager) active() bool {
 	if m == nil || m.client == nil {
 		return false
@@ -57,26 +92,10 @@ func (m *manager) active() bool {
 }
 
 func (m *manager) Get(ctx context.Context, key string) (raw string, err error) {
-	if m.enableMetrics {
-		rec := monitor.BeginRecord(ctx, "cache_get")
-		defer func() {
-			rec.EndWithError(err)
-		}()
-	}
-
-	if m.enableTraffic {
-		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: "cache_get",
-			Req: key,
-		}, logger.Fields{})
-		defer func() {
-			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(err),
-				Msg:  common.ErrorMsg(err),
-				Resp: raw,
-			}, logger.Fields{})
-		}()
-	}
+	rec := m.startCall(ctx, "cache_get", key, nil)
+	defer func() {
+		rec.End(err, raw)
+	}()
 
 	if !m.active() {
 		return "", ErrInActive
@@ -89,98 +108,50 @@ func (m *manager) Get(ctx context.Context, key string) (raw string, err error) {
 		return "", err
 	}
 
-	return raw, nil
+	return decompressString(raw)
 }
 
 func (m *manager) Set(ctx context.Context, key string, raw string, expire time.Duration) (err error) {
 
-	if m.enableMetrics {
-		rec := monitor.BeginRecord(ctx, "cache_set")
-		defer func() {
-			rec.EndWithError(err)
-		}()
-	}
-
-	if m.enableTraffic {
-		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: "cache_set",
-			Req: key,
-		}, logger.Fields{
-			"expire": fmt.Errorf("%v", expire),
-		})
-		defer func() {
-			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(err),
-				Msg:  common.ErrorMsg(err),
-				Resp: raw,
-			}, logger.Fields{})
-		}()
-	}
+	rec := m.startCall(ctx, "cache_set", key, logger.Fields{
+		"expire": fmt.Errorf("%v", expire),
+	})
+	defer func() {
+		rec.End(err, raw)
+	}()
 
 	if !m.active() {
 		return ErrInActive
 	}
 
-	err = m.client.Set(ctx, key, raw, expire).Err()
+	err = m.client.Set(ctx, key, m.compressString(ctx, raw), m.jitter(expire)).Err()
 	return
 }
 
 func (m *manager) SetNx(ctx context.Context, key string, raw string, expire time.Duration) (existing bool, err error) {
 
-	if m.enableMetrics {
-		rec := monitor.BeginRecord(ctx, "cache_setnx")
-		defer func() {
-			rec.EndWithError(err)
-		}()
-	}
-
-	if m.enableTraffic {
-		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: "cache_setnx",
-			Req: key,
-		}, logger.Fields{
-			"expire": fmt.Errorf("%v", expire),
+	rec := m.startCall(ctx, "cache_setnx", key, logger.Fields{
+		"expire": fmt.Errorf("%v", expire),
+	})
+	defer func() {
+		rec.EndWithFields(err, raw, logger.Fields{
+			"existing": existing,
 		})
-		defer func() {
-			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(err),
-				Msg:  common.ErrorMsg(err),
-				Resp: raw,
-			}, logger.Fields{
-				"existing": existing,
-			})
-		}()
-	}
+	}()
 
 	if !m.active() {
 		return false, ErrInActive
 	}
 
-	existing, err = m.client.SetNX(ctx, key, raw, expire).Result()
+	existing, err = m.client.SetNX(ctx, key, m.compressString(ctx, raw), expire).Result()
 	return
 }
 
 func (m *manager) GetBlob(ctx context.Context, key string, output any) (err error) {
-	if m.enableMetrics {
-		rec := monitor.BeginRecord(ctx, "cache_get_blob")
-		defer func() {
-			rec.EndWithError(err)
-		}()
-	}
-
-	if m.enableTraffic {
-		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: "cache_get_blob",
-			Req: key,
-		}, logger.Fields{})
-		defer func() {
-			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(err),
-				Msg:  common.ErrorMsg(err),
-				Resp: output,
-			}, logger.Fields{})
-		}()
-	}
+	rec := m.startCall(ctx, "cache_get_blob", key, nil)
+	defer func() {
+		rec.End(err, output)
+	}()
 
 	if !m.active() {
 		return ErrInActive
@@ -194,51 +165,35 @@ func (m *manager) GetBlob(ctx context.Context, key string, output any) (err erro
 		return err
 	}
 
-	r := bytes.NewReader(bs)
-	decoder := gob.NewDecoder(r)
-	if err = decoder.Decode(output); err != nil {
+	if bs, err = decompress(bs); err != nil {
+		return err
+	}
+	if err = decodeBlob(bs, output); err != nil {
 		return fmt.Errorf("decode error: %w", err)
 	}
 	return nil
 }
 
 func (m *manager) SetBlob(ctx context.Context, key string, val any, expire time.Duration) (err error) {
-	if m.enableMetrics {
-		rec := monitor.BeginRecord(ctx, "cache_set_blob")
-		defer func() {
-			rec.EndWithError(err)
-		}()
-	}
-
-	if m.enableTraffic {
-		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: "cache_set_blob",
-			Req: key,
-		}, logger.Fields{
-			"expire": fmt.Errorf("%v", expire),
-		})
-		defer func() {
-			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(err),
-				Msg:  common.ErrorMsg(err),
-				Resp: val,
-			}, logger.Fields{})
-		}()
-	}
+	rec := m.startCall(ctx, "cache_set_blob", key, logger.Fields{
+		"expire": fmt.Errorf("%v", expire),
+	})
+	defer func() {
+		rec.End(err, val)
+	}()
 
 	if !m.active() {
 		return ErrInActive
 	}
 
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-	if err = encoder.Encode(val); err != nil {
+	bs, err := encodeBlob(m.codec, val)
+	if err != nil {
 		return fmt.Errorf("encode error: %w", err)
 	}
 
 	// expire is 0, then set no expire
 	// expire is -1, then set default expire
-	if err = m.client.Set(ctx, key, buf.Bytes(), expire).Err(); err != nil {
+	if err = m.client.Set(ctx, key, m.compress(ctx, bs), m.jitter(expire)).Err(); err != nil {
 		return fmt.Errorf("set error: %w", err)
 	}
 	return nil
@@ -246,25 +201,10 @@ func (m *manager) SetBlob(ctx context.Context, key string, val any, expire time.
 }
 
 func (m *manager) Del(ctx context.Context, key string) (err error) {
-	if m.enableMetrics {
-		rec := monitor.BeginRecord(ctx, "cache_del")
-		defer func() {
-			rec.EndWithError(err)
-		}()
-	}
-
-	if m.enableTraffic {
-		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: "cache_del",
-			Req: key,
-		}, logger.Fields{})
-		defer func() {
-			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(err),
-				Msg:  common.ErrorMsg(err),
-			}, logger.Fields{})
-		}()
-	}
+	rec := m.startCall(ctx, "cache_del", key, nil)
+	defer func() {
+		rec.End(err, nil)
+	}()
 
 	if !m.active() {
 		return ErrInActive
@@ -275,27 +215,12 @@ func (m *manager) Del(ctx context.Context, key string) (err error) {
 }
 
 func (m *manager) Expire(ctx context.Context, key string, expire time.Duration) (err error) {
-	if m.enableMetrics {
-		rec := monitor.BeginRecord(ctx, "cache_expire")
-		defer func() {
-			rec.EndWithError(err)
-		}()
-	}
-
-	if m.enableTraffic {
-		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: "cache_expire",
-			Req: key,
-		}, logger.Fields{
-			"expire": fmt.Errorf("%v", expire),
-		})
-		defer func() {
-			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(err),
-				Msg:  common.ErrorMsg(err),
-			}, logger.Fields{})
-		}()
-	}
+	rec := m.startCall(ctx, "cache_expire", key, logger.Fields{
+		"expire": fmt.Errorf("%v", expire),
+	})
+	defer func() {
+		rec.End(err, nil)
+	}()
 
 	if !m.active() {
 		return ErrInActive
@@ -306,34 +231,240 @@ func (m *manager) Expire(ctx context.Context, key string, expire time.Duration)
 }
 
 func (m *manager) Eval(ctx context.Context, script string, keys []string, args ...any) (val any, err error) {
-	if m.enableMetrics {
-		rec := monitor.BeginRecord(ctx, "cache_eval")
-		defer func() {
-			rec.EndWithError(err)
-		}()
-	}
-
-	if m.enableTraffic {
-		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: "cache_eval",
-			Req: script,
-		}, logger.Fields{
-			"keys": keys,
-			"args": args,
-		})
-		defer func() {
-			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(err),
-				Msg:  common.ErrorMsg(err),
-				Resp: val,
-			}, logger.Fields{})
-		}()
-	}
+	rec := m.startCall(ctx, "cache_eval", script, logger.Fields{
+		"keys": keys,
+		"args": args,
+	})
+	defer func() {
+		rec.End(err, val)
+	}()
 
 	if !m.active() {
 		return nil, ErrInActive
 	}
+	if m.cluster && !SameSlot(keys...) {
+		return nil, ErrCrossSlot
+	}
 
 	val, err = m.client.Eval(ctx, script, keys, args...).Result()
 	return
 }
+
+func (m *manager) MGet(ctx context.Context, keys ...string) (vals map[string]string, err error) {
+	rec := m.startCall(ctx, "cache_mget", keys, nil)
+	defer func() {
+		rec.EndWithFields(err, vals, logger.Fields{
+			"found": len(vals),
+		})
+	}()
+
+	if !m.active() {
+		return nil, ErrInActive
+	}
+
+	// the GETs are pipelined instead of MGET, so that the keys can be in different slots of the cluster
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err = m.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = p.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	vals = make(map[string]string, len(keys))
+	for i, cmd := range cmds {
+		raw, cmdErr := cmd.Result()
+		if errors.Is(cmdErr, redis.Nil) {
+			continue
+		}
+		if cmdErr != nil {
+			return nil, cmdErr
+		}
+		if raw, err = decompressString(raw); err != nil {
+			return nil, err
+		}
+		vals[keys[i]] = raw
+	}
+	return vals, nil
+}
+
+func (m *manager) MSet(ctx context.Context, kvs map[string]string, expire time.Duration) (err error) {
+	rec := m.startCall(ctx, "cache_mset", kvs, logger.Fields{
+		"expire": fmt.Errorf("%v", expire),
+	})
+	defer func() {
+		rec.End(err, nil)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	_, err = m.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for key, raw := range kvs {
+			p.Set(ctx, key, m.compressString(ctx, raw), m.jitter(expire))
+		}
+		return nil
+	})
+	return
+}
+
+func (m *manager) Exists(ctx context.Context, key string) (ok bool, err error) {
+	rec := m.startCall(ctx, "cache_exists", key, nil)
+	defer func() {
+		rec.End(err, ok)
+	}()
+
+	if !m.active() {
+		return false, ErrInActive
+	}
+
+	n, err := m.client.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+func (m *manager) TTL(ctx context.Context, key string) (ttl time.Duration, err error) {
+	rec := m.startCall(ctx, "cache_ttl", key, nil)
+	defer func() {
+		rec.End(err, fmt.Errorf("%v", ttl))
+	}()
+
+	if !m.active() {
+		return 0, ErrInActive
+	}
+
+	ttl, err = m.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// -2 if the key does not exist, -1 if it has no expire
+	switch {
+	case ttl == -2:
+		return 0, ErrNotFound
+	case ttl < 0:
+		return 0, nil
+	default:
+		return ttl, nil
+	}
+}
+
+func (m *manager) GetDel(ctx context.Context, key string) (raw string, err error) {
+	rec := m.startCall(ctx, "cache_getdel", key, nil)
+	defer func() {
+		rec.End(err, raw)
+	}()
+
+	if !m.active() {
+		return "", ErrInActive
+	}
+	raw, err = m.client.GetDel(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", ErrNotFound
+		}
+		return "", redisErr(err, key)
+	}
+
+	return decompressString(raw)
+}
+
+func (m *manager) GetSet(ctx context.Context, key string, raw string, expire time.Duration) (old string, err error) {
+	rec := m.startCall(ctx, "cache_getset", key, logger.Fields{
+		"expire": fmt.Errorf("%v", expire),
+	})
+	defer func() {
+		rec.EndWithFields(err, raw, logger.Fields{
+			"old": old,
+		})
+	}()
+
+	if !m.active() {
+		return "", ErrInActive
+	}
+	// SET GET instead of GETSET, which can not set the expire in the same command
+	old, err = m.client.SetArgs(ctx, key, m.compressString(ctx, raw), redis.SetArgs{
+		TTL: m.jitter(expire),
+		Get: true,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", ErrNotFound
+		}
+		return "", redisErr(err, key)
+	}
+
+	return decompressString(old)
+}
+
+func (m *manager) GetEx(ctx context.Context, key string, expire time.Duration) (raw string, err error) {
+	rec := m.startCall(ctx, "cache_getex", key, logger.Fields{
+		"expire": fmt.Errorf("%v", expire),
+	})
+	defer func() {
+		rec.End(err, raw)
+	}()
+
+	if !m.active() {
+		return "", ErrInActive
+	}
+	raw, err = m.client.GetEx(ctx, key, expire).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", ErrNotFound
+		}
+		return "", redisErr(err, key)
+	}
+
+	return decompressString(raw)
+}
+
+func (m *manager) Incr(ctx context.Context, key string, expire time.Duration) (val int64, err error) {
+	return m.incrBy(ctx, "cache_incr", key, 1, expire)
+}
+
+func (m *manager) IncrBy(ctx context.Context, key string, delta int64, expire time.Duration) (val int64, err error) {
+	return m.incrBy(ctx, "cache_incrby", key, delta, expire)
+}
+
+func (m *manager) Decr(ctx context.Context, key string, expire time.Duration) (val int64, err error) {
+	return m.incrBy(ctx, "cache_decr", key, -1, expire)
+}
+
+func (m *manager) DecrBy(ctx context.Context, key string, delta int64, expire time.Duration) (val int64, err error) {
+	return m.incrBy(ctx, "cache_decrby", key, -delta, expire)
+}
+
+// incrBy increments the counter of key by delta, the ttl is set on the first set
+func (m *manager) incrBy(ctx context.Context, cmd string, key string, delta int64, expire time.Duration) (val int64, err error) {
+	rec := m.startCall(ctx, cmd, key, logger.Fields{
+		"delta":  delta,
+		"expire": fmt.Errorf("%v", expire),
+	})
+	defer func() {
+		rec.End(err, val)
+	}()
+
+	if !m.active() {
+		return 0, ErrInActive
+	}
+
+	val, err = incrByScript.Run(ctx, m.client, []string{key}, delta, expire.Milliseconds()).Int64()
+	return val, redisErr(err, key)
+}
+
+// redisErr converts the errors of the redis replies of key to ErrNotInt and ErrWrongType
+func redisErr(err error, key string) error {
+	switch {
+	case err == nil:
+		return nil
+	case strings.HasPrefix(err.Error(), "WRONGTYPE"):
+		return fmt.Errorf("%w: %s", ErrWrongType, key)
+	case strings.Contains(err.Error(), "not an integer"):
+		return fmt.Errorf("%w: %s", ErrNotInt, key)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/logger"
+)
+
+func (m *manager) HSet(ctx context.Context, key string, fields map[string]string) (err error) {
+	rec := m.startCall(ctx, "cache_hset", key, logger.Fields{
+		"fields": len(fields),
+	})
+	defer func() {
+		rec.End(err, fields)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	values := make([]any, 0, 2*len(fields))
+	for field, raw := range fields {
+		values = append(values, field, raw)
+	}
+	err = redisErr(m.client.HSet(ctx, key, values...).Err(), key)
+	return
+}
+
+func (m *manager) HGet(ctx context.Context, key string, field string) (raw string, err error) {
+	rec := m.startCall(ctx, "cache_hget", key, logger.Fields{
+		"field": field,
+	})
+	defer func() {
+		rec.End(err, raw)
+	}()
+
+	if !m.active() {
+		return "", ErrInActive
+	}
+
+	raw, err = m.client.HGet(ctx, key, field).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", ErrNotFound
+		}
+		return "", redisErr(err, key)
+	}
+	return raw, nil
+}
+
+func (m *manager) HGetAll(ctx context.Context, key string) (fields map[string]string, err error) {
+	rec := m.startCall(ctx, "cache_hgetall", key, nil)
+	defer func() {
+		rec.End(err, fields)
+	}()
+
+	if !m.active() {
+		return nil, ErrInActive
+	}
+
+	fields, err = m.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, redisErr(err, key)
+	}
+	return fields, nil
+}
+
+func (m *manager) HDel(ctx context.Context, key string, fields ...string) (err error) {
+	rec := m.startCall(ctx, "cache_hdel", key, logger.Fields{
+		"fields": fields,
+	})
+	defer func() {
+		rec.End(err, nil)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	err = redisErr(m.client.HDel(ctx, key, fields...).Err(), key)
+	return
+}
+
+func (m *manager) HIncrBy(ctx context.Context, key string, field string, delta int64) (val int64, err error) {
+	rec := m.startCall(ctx, "cache_hincrby", key, logger.Fields{
+		"field": field,
+		"delta": delta,
+	})
+	defer func() {
+		rec.End(err, val)
+	}()
+
+	if !m.active() {
+		return 0, ErrInActive
+	}
+
+	val, err = m.client.HIncrBy(ctx, key, field, delta).Result()
+	return val, redisErr(err, key)
+}
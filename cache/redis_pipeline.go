@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/logger"
+)
+
+// Pipeliner queues the commands of Pipeline and TxPipeline
+type Pipeliner = redis.Pipeliner
+
+func (m *manager) Pipeline(ctx context.Context, fn func(Pipeliner) error) (err error) {
+	return m.pipeline(ctx, "cache_pipeline", fn, false)
+}
+
+func (m *manager) TxPipeline(ctx context.Context, fn func(Pipeliner) error) (err error) {
+	return m.pipeline(ctx, "cache_txpipeline", fn, true)
+}
+
+// pipeline runs the commands queued by fn as one call, traffic records the commands
+func (m *manager) pipeline(ctx context.Context, cmd string, fn func(Pipeliner) error, tx bool) (err error) {
+	var cmds []redis.Cmder
+	rec := m.startCall(ctx, cmd, nil, logger.Fields{
+		"tx": tx,
+	})
+	defer func() {
+		rec.EndWithFields(err, cmdStrings(cmds), logger.Fields{
+			"cmds": len(cmds),
+		})
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	var p redis.Pipeliner
+	if tx {
+		p = m.client.TxPipeline()
+	} else {
+		p = m.client.Pipeline()
+	}
+	if err = fn(p); err != nil {
+		p.Discard()
+		return err
+	}
+
+	cmds, err = p.Exec(ctx)
+	if err == nil {
+		return nil
+	}
+	for _, c := range cmds {
+		if cerr := c.Err(); cerr != nil && !errors.Is(cerr, redis.Nil) {
+			return cerr
+		}
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
+
+func cmdStrings(cmds []redis.Cmder) []string {
+	res := make([]string, len(cmds))
+	for i, c := range cmds {
+		res[i] = c.String()
+	}
+	return res
+}
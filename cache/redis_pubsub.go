@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/retry"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+const (
+	resubscribeBase = 100 * time.Millisecond
+	resubscribeMax  = 5 * time.Second
+)
+
+func (m *manager) Publish(ctx context.Context, channel string, payload string) (err error) {
+	rec := m.startCall(ctx, "cache_publish", payload, logger.Fields{
+		"channel": channel,
+	})
+	defer func() {
+		rec.End(err, nil)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	err = m.client.Publish(ctx, channel, payload).Err()
+	return
+}
+
+func (m *manager) Subscribe(ctx context.Context, channel string, handler MessageHandler) (err error) {
+	if !m.active() {
+		return ErrInActive
+	}
+
+	ps := m.client.Subscribe(ctx, channel)
+	if _, err = ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return fmt.Errorf("subscribe %s error: %w", channel, err)
+	}
+
+	go func() {
+		// closing ps breaks the blocking receive
+		<-ctx.Done()
+		_ = ps.Close()
+	}()
+	go m.receive(ctx, ps, handler)
+	return nil
+}
+
+// receive handles the messages of ps until ctx is done, the receive errors are retried with backoff
+func (m *manager) receive(ctx context.Context, ps *redis.PubSub, handler MessageHandler) {
+	failures := 0
+	for {
+		msg, err := ps.ReceiveMessage(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// ps reconnects and resubscribes on the next receive
+			failures++
+			delay := retry.Exponential(resubscribeBase, resubscribeMax, failures)
+			logger.FromContext(ctx).WithError(err).WithFields(logger.Fields{
+				"failures": failures,
+				"delay":    delay,
+			}).Warn("receive message error, retry later")
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			continue
+		}
+		failures = 0
+		_ = m.handle(ctx, msg, handler)
+	}
+}
+
+// handle calls handler with a ctx carrying a new request id, a panic fails the message
+func (m *manager) handle(ctx context.Context, msg *redis.Message, handler MessageHandler) (err error) {
+	requestId := tracking.NewRequestId()
+	ctx = tracking.WithRequestId(ctx, requestId)
+	ctx = logger.WithLogger(ctx, logger.FromContext(ctx).WithFields(logger.Fields{
+		"channel": msg.Channel,
+	}).WithTracing(requestId))
+
+	rec := m.startCall(ctx, "cache_message", msg.Payload, logger.Fields{
+		"channel": msg.Channel,
+	})
+	defer func() {
+		if r := recover(); r != nil {
+			logger.FromContext(ctx).ErrorWith("message handler panic recovery", logger.Fields{
+				"panic": fmt.Sprintf("%v", r),
+				"stack": string(debug.Stack()),
+			})
+			err = fmt.Errorf("message handler panic: %v", r)
+		}
+		rec.End(err, nil)
+	}()
+
+	return handler(ctx, msg.Channel, msg.Payload)
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	scanMetricCmd   = "cache_scan"
+	scanKeysMetric  = "scan_keys"
+	scanDelMetric   = "scan_deleted"
+	delPatternCount = 500
+)
+
+// scanner is the client of a node scanned by SCAN
+type scanner interface {
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
+}
+
+func (m *manager) Scan(ctx context.Context, pattern string, count int64) (iter KeyIterator) {
+	it := &scanIterator{
+		m:       m,
+		pattern: pattern,
+		count:   count,
+	}
+	if !m.active() {
+		it.err = ErrInActive
+	}
+	return it
+}
+
+func (m *manager) DelPattern(ctx context.Context, pattern string) (n int64, err error) {
+	rec := m.startCall(ctx, "cache_del_pattern", pattern, nil)
+	defer func() {
+		rec.EndWithFields(err, nil, logger.Fields{
+			"deleted": n,
+		})
+	}()
+
+	if !m.active() {
+		return 0, ErrInActive
+	}
+
+	it := m.Scan(ctx, pattern, delPatternCount)
+	batch := make([]string, 0, delPatternCount)
+	for it.Next(ctx) {
+		batch = append(batch, it.Key())
+		if len(batch) < delPatternCount {
+			continue
+		}
+		deleted, err := m.unlink(ctx, pattern, batch)
+		n += deleted
+		if err != nil {
+			return n, err
+		}
+		batch = batch[:0]
+	}
+	if err = it.Err(); err != nil {
+		return n, err
+	}
+
+	deleted, err := m.unlink(ctx, pattern, batch)
+	return n + deleted, err
+}
+
+// unlink deletes keys one by one in a pipeline, so that the keys can be in different slots of the cluster
+func (m *manager) unlink(ctx context.Context, pattern string, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, err := m.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = p.Unlink(ctx, key)
+		}
+		return nil
+	})
+
+	var n int64
+	for _, cmd := range cmds {
+		n += cmd.Val()
+	}
+	monitor.NewSingleFlight(scanMetricCmd).CountDelta(ctx, scanDelMetric, 0, int(n), pattern)
+	return n, err
+}
+
+// scanNodes returns the masters of the cluster, or the client itself
+func (m *manager) scanNodes(ctx context.Context) ([]scanner, error) {
+	cc, ok := m.client.(*redis.ClusterClient)
+	if !ok {
+		return []scanner{m.client}, nil
+	}
+
+	var (
+		lock  sync.Mutex
+		nodes []scanner
+	)
+	err := cc.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		lock.Lock()
+		defer lock.Unlock()
+		nodes = append(nodes, client)
+		return nil
+	})
+	return nodes, err
+}
+
+// scanIterator scans the nodes one by one, a page of SCAN per round trip
+type scanIterator struct {
+	m       *manager
+	pattern string
+	count   int64
+
+	nodes   []scanner
+	node    int
+	cursor  uint64
+	started bool // the first page of the node is scanned
+
+	keys []string
+	pos  int
+	err  error
+}
+
+func (it *scanIterator) Next(ctx context.Context) bool {
+	if it.nodes == nil && it.err == nil {
+		it.nodes, it.err = it.m.scanNodes(ctx)
+	}
+
+	for {
+		if it.err != nil {
+			return false
+		}
+		if it.pos < len(it.keys) {
+			it.pos++
+			return true
+		}
+		if it.node >= len(it.nodes) {
+			return false
+		}
+		if it.started && it.cursor == 0 {
+			it.node++
+			it.started = false
+			continue
+		}
+		it.page(ctx)
+	}
+}
+
+func (it *scanIterator) Key() string {
+	if it.pos == 0 || it.pos > len(it.keys) {
+		return ""
+	}
+	return it.keys[it.pos-1]
+}
+
+func (it *scanIterator) Err() error {
+	return it.err
+}
+
+// page scans the next page of the current node
+func (it *scanIterator) page(ctx context.Context) {
+	var (
+		keys []string
+		err  error
+	)
+	rec := it.m.startCall(ctx, "cache_scan", it.pattern, logger.Fields{
+		"node":   it.node,
+		"cursor": it.cursor,
+		"count":  it.count,
+	})
+	defer func() {
+		rec.EndWithFields(err, nil, logger.Fields{
+			"keys": len(keys),
+		})
+	}()
+
+	keys, it.cursor, err = it.nodes[it.node].Scan(ctx, it.cursor, it.pattern, it.count).Result()
+	it.started = true
+	it.keys, it.pos, it.err = keys, 0, err
+	monitor.NewSingleFlight(scanMetricCmd).CountDelta(ctx, scanKeysMetric, 0, len(keys), it.pattern)
+}
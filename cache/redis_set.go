@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/tenz-io/trackingo/logger"
+)
+
+func (m *manager) SAdd(ctx context.Context, key string, members ...string) (err error) {
+	rec := m.startCall(ctx, "cache_sadd", key, logger.Fields{
+		"members": len(members),
+	})
+	defer func() {
+		rec.End(err, members)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	err = redisErr(m.client.SAdd(ctx, key, toAnys(members)...).Err(), key)
+	return
+}
+
+func (m *manager) SRem(ctx context.Context, key string, members ...string) (err error) {
+	rec := m.startCall(ctx, "cache_srem", key, logger.Fields{
+		"members": len(members),
+	})
+	defer func() {
+		rec.End(err, members)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	err = redisErr(m.client.SRem(ctx, key, toAnys(members)...).Err(), key)
+	return
+}
+
+func (m *manager) SIsMember(ctx context.Context, key string, member string) (ok bool, err error) {
+	rec := m.startCall(ctx, "cache_sismember", key, logger.Fields{
+		"member": member,
+	})
+	defer func() {
+		rec.End(err, ok)
+	}()
+
+	if !m.active() {
+		return false, ErrInActive
+	}
+
+	ok, err = m.client.SIsMember(ctx, key, member).Result()
+	return ok, redisErr(err, key)
+}
+
+func (m *manager) SMembers(ctx context.Context, key string) (members []string, err error) {
+	rec := m.startCall(ctx, "cache_smembers", key, nil)
+	defer func() {
+		rec.End(err, members)
+	}()
+
+	if !m.active() {
+		return nil, ErrInActive
+	}
+
+	members, err = m.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, redisErr(err, key)
+	}
+	return members, nil
+}
+
+func (m *manager) SCard(ctx context.Context, key string) (n int64, err error) {
+	rec := m.startCall(ctx, "cache_scard", key, nil)
+	defer func() {
+		rec.End(err, n)
+	}()
+
+	if !m.active() {
+		return 0, ErrInActive
+	}
+
+	n, err = m.client.SCard(ctx, key).Result()
+	return n, redisErr(err, key)
+}
+
+func toAnys(ss []string) []any {
+	res := make([]any, len(ss))
+	for i, s := range ss {
+		res[i] = s
+	}
+	return res
+}
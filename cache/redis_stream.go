@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	streamLagMetric     = "redis_stream_lag"
+	streamPendingMetric = "redis_stream_pending"
+)
+
+func (m *manager) XAdd(ctx context.Context, stream string, values map[string]string) (id string, err error) {
+	rec := m.startCall(ctx, "cache_xadd", values, logger.Fields{
+		"stream": stream,
+	})
+	defer func() {
+		rec.End(err, id)
+	}()
+
+	if !m.active() {
+		return "", ErrInActive
+	}
+
+	id, err = m.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		Values: values,
+	}).Result()
+	return id, redisErr(err, stream)
+}
+
+func (m *manager) XGroupCreate(ctx context.Context, stream string, group string, start string) (err error) {
+	rec := m.startCall(ctx, "cache_xgroupcreate", stream, logger.Fields{
+		"group": group,
+		"start": start,
+	})
+	defer func() {
+		rec.End(err, nil)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	err = m.client.XGroupCreateMkStream(ctx, stream, group, start).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return redisErr(err, stream)
+}
+
+func (m *manager) XReadGroup(ctx context.Context, stream string, group string, consumer string, count int64, block time.Duration) (msgs []XMessage, err error) {
+	rec := m.startCall(ctx, "cache_xreadgroup", stream, logger.Fields{
+		"group":    group,
+		"consumer": consumer,
+		"count":    count,
+		"block":    fmt.Errorf("%v", block),
+	})
+	defer func() {
+		rec.EndWithFields(err, msgs, logger.Fields{
+			"msgs": len(msgs),
+		})
+	}()
+
+	if !m.active() {
+		return nil, ErrInActive
+	}
+
+	if block <= 0 {
+		// go-redis blocks forever with 0
+		block = -1
+	}
+	streams, err := m.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return []XMessage{}, nil
+		}
+		return nil, redisErr(err, stream)
+	}
+
+	msgs = []XMessage{}
+	for _, s := range streams {
+		for _, msg := range s.Messages {
+			msgs = append(msgs, fromRedisXMessage(msg))
+		}
+	}
+	m.observeLag(ctx, stream, group)
+	return msgs, nil
+}
+
+func (m *manager) XAck(ctx context.Context, stream string, group string, ids ...string) (err error) {
+	rec := m.startCall(ctx, "cache_xack", ids, logger.Fields{
+		"stream": stream,
+		"group":  group,
+	})
+	defer func() {
+		rec.End(err, nil)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	err = redisErr(m.client.XAck(ctx, stream, group, ids...).Err(), stream)
+	return
+}
+
+// observeLag sets the gauges of the messages not read yet and the ones read but not acked by group
+func (m *manager) observeLag(ctx context.Context, stream string, group string) {
+	if !m.enableMetrics {
+		return
+	}
+
+	groups, err := m.client.XInfoGroups(ctx, stream).Result()
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("stream", stream).Debug("get stream groups error")
+		return
+	}
+	opt := stream + ":" + group
+	for _, g := range groups {
+		if g.Name != group {
+			continue
+		}
+		sf := monitor.NewSingleFlight(redisMetricCmd)
+		sf.Set(ctx, streamLagMetric, 0, float64(g.Lag), opt)
+		sf.Set(ctx, streamPendingMetric, 0, float64(g.Pending), opt)
+		return
+	}
+}
+
+func fromRedisXMessage(msg redis.XMessage) XMessage {
+	values := make(map[string]string, len(msg.Values))
+	for k, v := range msg.Values {
+		values[k] = fmt.Sprint(v)
+	}
+	return XMessage{ID: msg.ID, Values: values}
+}
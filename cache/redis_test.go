@@ -0,0 +1,525 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestManager returns the Manager of a miniredis closed with t
+func newTestManager(t *testing.T) (Manager, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewManager(client, nil), mr
+}
+
+func Test_manager_MGet(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+	_ = mr.Set("a", "1")
+	_ = mr.Set("b", "2")
+
+	t.Run("when some keys are missing then only the found ones are returned", func(t *testing.T) {
+		got, err := m.MGet(ctx, "a", "missing", "b")
+		if err != nil {
+			t.Fatalf("MGet() error = %v", err)
+		}
+		if want := map[string]string{"a": "1", "b": "2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("MGet() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("when no keys then empty", func(t *testing.T) {
+		got, err := m.MGet(ctx)
+		if err != nil || len(got) != 0 {
+			t.Errorf("MGet() = %v, %v, want empty", got, err)
+		}
+	})
+}
+
+func Test_manager_MSet(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+
+	if err := m.MSet(ctx, map[string]string{"a": "1", "b": "2"}, time.Minute); err != nil {
+		t.Fatalf("MSet() error = %v", err)
+	}
+
+	t.Run("when set then the values expire with ttl", func(t *testing.T) {
+		for key, want := range map[string]string{"a": "1", "b": "2"} {
+			if got, _ := mr.Get(key); got != want {
+				t.Errorf("Get(%s) = %v, want %v", key, got, want)
+			}
+			if ttl := mr.TTL(key); ttl != time.Minute {
+				t.Errorf("TTL(%s) = %v, want 1m", key, ttl)
+			}
+		}
+	})
+}
+
+func Test_manager_IncrBy(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+
+	t.Run("when the counter is new then the ttl is set", func(t *testing.T) {
+		val, err := m.Incr(ctx, "c", time.Minute)
+		if err != nil || val != 1 {
+			t.Fatalf("Incr() = %v, %v, want 1", val, err)
+		}
+		if ttl := mr.TTL("c"); ttl != time.Minute {
+			t.Errorf("TTL() = %v, want 1m", ttl)
+		}
+	})
+
+	t.Run("when the counter exists then the ttl is kept", func(t *testing.T) {
+		mr.FastForward(10 * time.Second)
+		val, err := m.IncrBy(ctx, "c", 5, time.Hour)
+		if err != nil || val != 6 {
+			t.Fatalf("IncrBy() = %v, %v, want 6", val, err)
+		}
+		if ttl := mr.TTL("c"); ttl != 50*time.Second {
+			t.Errorf("TTL() = %v, want 50s", ttl)
+		}
+	})
+
+	t.Run("when decremented then the new value", func(t *testing.T) {
+		_, _ = m.Decr(ctx, "c", 0)
+		val, err := m.DecrBy(ctx, "c", 3, 0)
+		if err != nil || val != 2 {
+			t.Errorf("DecrBy() = %v, %v, want 2", val, err)
+		}
+	})
+
+	t.Run("when the value is not an integer then ErrNotInt", func(t *testing.T) {
+		_ = mr.Set("s", "abc")
+		if _, err := m.Incr(ctx, "s", 0); !errors.Is(err, ErrNotInt) {
+			t.Errorf("Incr() error = %v, want ErrNotInt", err)
+		}
+	})
+}
+
+func Test_manager_Hash(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+
+	if err := m.HSet(ctx, "h", map[string]string{"a": "1", "b": "2"}); err != nil {
+		t.Fatalf("HSet() error = %v", err)
+	}
+
+	t.Run("when the field exists then HGet returns it", func(t *testing.T) {
+		if got, err := m.HGet(ctx, "h", "a"); err != nil || got != "1" {
+			t.Errorf("HGet() = %v, %v, want 1", got, err)
+		}
+	})
+
+	t.Run("when the field is missing then ErrNotFound", func(t *testing.T) {
+		if _, err := m.HGet(ctx, "h", "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("HGet() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("when HIncrBy then the field is counted", func(t *testing.T) {
+		if got, err := m.HIncrBy(ctx, "h", "b", 3); err != nil || got != 5 {
+			t.Errorf("HIncrBy() = %v, %v, want 5", got, err)
+		}
+	})
+
+	t.Run("when HDel then HGetAll returns the rest", func(t *testing.T) {
+		if err := m.HDel(ctx, "h", "a"); err != nil {
+			t.Fatalf("HDel() error = %v", err)
+		}
+		got, err := m.HGetAll(ctx, "h")
+		if want := map[string]string{"b": "5"}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("HGetAll() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("when the key is a string then ErrWrongType", func(t *testing.T) {
+		_ = mr.Set("s", "abc")
+		if _, err := m.HGet(ctx, "s", "a"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("HGet() error = %v, want ErrWrongType", err)
+		}
+	})
+}
+
+func Test_manager_Set(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+
+	if err := m.SAdd(ctx, "s", "a", "b", "c"); err != nil {
+		t.Fatalf("SAdd() error = %v", err)
+	}
+
+	t.Run("when the member is added then SIsMember is true", func(t *testing.T) {
+		if ok, err := m.SIsMember(ctx, "s", "a"); err != nil || !ok {
+			t.Errorf("SIsMember() = %v, %v, want true", ok, err)
+		}
+	})
+
+	t.Run("when SRem then the rest are the members", func(t *testing.T) {
+		if err := m.SRem(ctx, "s", "b"); err != nil {
+			t.Fatalf("SRem() error = %v", err)
+		}
+		got, err := m.SMembers(ctx, "s")
+		sort.Strings(got)
+		if want := []string{"a", "c"}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("SMembers() = %v, %v, want %v", got, err, want)
+		}
+		if n, err := m.SCard(ctx, "s"); err != nil || n != 2 {
+			t.Errorf("SCard() = %v, %v, want 2", n, err)
+		}
+	})
+
+	t.Run("when the key is a string then ErrWrongType", func(t *testing.T) {
+		_ = mr.Set("str", "abc")
+		if _, err := m.SIsMember(ctx, "str", "a"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("SIsMember() error = %v, want ErrWrongType", err)
+		}
+	})
+}
+
+func Test_manager_SortedSet(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+
+	if err := m.ZAdd(ctx, "z", Z{Member: "a", Score: 1}, Z{Member: "b", Score: 2}, Z{Member: "c", Score: 3}); err != nil {
+		t.Fatalf("ZAdd() error = %v", err)
+	}
+
+	t.Run("when ZRangeByScore then the members in range are ascending", func(t *testing.T) {
+		got, err := m.ZRangeByScore(ctx, "z", 2, math.Inf(1))
+		if want := []Z{{Member: "b", Score: 2}, {Member: "c", Score: 3}}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ZRangeByScore() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("when ZIncrBy then the score is updated", func(t *testing.T) {
+		if got, err := m.ZIncrBy(ctx, "z", "a", 5); err != nil || got != 6 {
+			t.Errorf("ZIncrBy() = %v, %v, want 6", got, err)
+		}
+	})
+
+	t.Run("when ZRem then ZRevRange returns the rest descending", func(t *testing.T) {
+		if err := m.ZRem(ctx, "z", "b"); err != nil {
+			t.Fatalf("ZRem() error = %v", err)
+		}
+		got, err := m.ZRevRange(ctx, "z", 0, -1)
+		if want := []Z{{Member: "a", Score: 6}, {Member: "c", Score: 3}}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ZRevRange() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("when the key is a string then ErrWrongType", func(t *testing.T) {
+		_ = mr.Set("str", "abc")
+		if _, err := m.ZIncrBy(ctx, "str", "a", 1); !errors.Is(err, ErrWrongType) {
+			t.Errorf("ZIncrBy() error = %v, want ErrWrongType", err)
+		}
+	})
+}
+
+func Test_manager_Pipeline(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+	_ = mr.Set("a", "1")
+
+	t.Run("when the commands are queued then the results are read from them", func(t *testing.T) {
+		var a, missing *redis.StringCmd
+		err := m.Pipeline(ctx, func(p Pipeliner) error {
+			p.Set(ctx, "b", "2", 0)
+			a = p.Get(ctx, "a")
+			missing = p.Get(ctx, "missing")
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Pipeline() error = %v", err)
+		}
+		if a.Val() != "1" || !errors.Is(missing.Err(), redis.Nil) {
+			t.Errorf("Get() = %v, %v, want 1 and redis.Nil", a.Val(), missing.Err())
+		}
+		if got, _ := mr.Get("b"); got != "2" {
+			t.Errorf("b = %v, want 2", got)
+		}
+	})
+
+	t.Run("when fn fails then nothing is run", func(t *testing.T) {
+		wantErr := errors.New("abort")
+		err := m.Pipeline(ctx, func(p Pipeliner) error {
+			p.Set(ctx, "c", "3", 0)
+			return wantErr
+		})
+		if !errors.Is(err, wantErr) || mr.Exists("c") {
+			t.Errorf("Pipeline() error = %v, c exists = %v, want abort and no c", err, mr.Exists("c"))
+		}
+	})
+
+	t.Run("when TxPipeline then the commands run in a transaction", func(t *testing.T) {
+		var incr *redis.IntCmd
+		err := m.TxPipeline(ctx, func(p Pipeliner) error {
+			p.IncrBy(ctx, "n", 2)
+			incr = p.Incr(ctx, "n")
+			return nil
+		})
+		if err != nil || incr.Val() != 3 {
+			t.Errorf("TxPipeline() = %v, %v, want 3", incr.Val(), err)
+		}
+	})
+
+	t.Run("when a command fails then its error is returned", func(t *testing.T) {
+		err := m.Pipeline(ctx, func(p Pipeliner) error {
+			p.Get(ctx, "missing")
+			p.HGet(ctx, "a", "f")
+			return nil
+		})
+		if err == nil || errors.Is(err, redis.Nil) {
+			t.Errorf("Pipeline() error = %v, want WRONGTYPE", err)
+		}
+	})
+}
+
+func Test_manager_PubSub(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	got := make(chan string, 10)
+	err := m.Subscribe(ctx, "ch", func(ctx context.Context, channel string, payload string) error {
+		if payload == "panic" {
+			panic("boom")
+		}
+		got <- payload
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	receive := func(t *testing.T, want string) {
+		t.Helper()
+		select {
+		case payload := <-got:
+			if payload != want {
+				t.Errorf("payload = %v, want %v", payload, want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("no message, want %v", want)
+		}
+	}
+
+	t.Run("when published then the handler receives it", func(t *testing.T) {
+		if err := m.Publish(ctx, "ch", "hello"); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		receive(t, "hello")
+	})
+
+	t.Run("when the handler panics then the next messages are handled", func(t *testing.T) {
+		_ = m.Publish(ctx, "ch", "panic")
+		_ = m.Publish(ctx, "ch", "after")
+		receive(t, "after")
+	})
+
+	t.Run("when the connection is lost then it is resubscribed", func(t *testing.T) {
+		mr.Restart()
+		deadline := time.Now().Add(3 * time.Second)
+		for mr.PubSubNumSub("ch")["ch"] == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		_ = m.Publish(ctx, "ch", "again")
+		receive(t, "again")
+	})
+
+	t.Run("when ctx is done then the subscription stops", func(t *testing.T) {
+		cancel()
+		deadline := time.Now().Add(3 * time.Second)
+		for mr.PubSubNumSub("ch")["ch"] != 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := mr.PubSubNumSub("ch")["ch"]; n != 0 {
+			t.Errorf("subscribers = %v, want 0", n)
+		}
+	})
+}
+
+func Test_manager_Stream(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	m := NewManager(client, Options{WithMetrics(true)})
+	ctx := context.Background()
+
+	if err := m.XGroupCreate(ctx, "events", "workers", "$"); err != nil {
+		t.Fatalf("XGroupCreate() error = %v", err)
+	}
+
+	t.Run("when the group exists then XGroupCreate is not an error", func(t *testing.T) {
+		if err := m.XGroupCreate(ctx, "events", "workers", "$"); err != nil {
+			t.Errorf("XGroupCreate() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("when no messages then XReadGroup is empty", func(t *testing.T) {
+		msgs, err := m.XReadGroup(ctx, "events", "workers", "c1", 10, 0)
+		if err != nil || len(msgs) != 0 {
+			t.Errorf("XReadGroup() = %v, %v, want empty", msgs, err)
+		}
+	})
+
+	var ids []string
+	for _, v := range []string{"1", "2", "3"} {
+		id, err := m.XAdd(ctx, "events", map[string]string{"v": v})
+		if err != nil {
+			t.Fatalf("XAdd() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	t.Run("when the messages are added then they are read with the lag observed", func(t *testing.T) {
+		msgs, err := m.XReadGroup(ctx, "events", "workers", "c1", 2, 0)
+		if err != nil {
+			t.Fatalf("XReadGroup() error = %v", err)
+		}
+		want := []XMessage{{ID: ids[0], Values: map[string]string{"v": "1"}}, {ID: ids[1], Values: map[string]string{"v": "2"}}}
+		if !reflect.DeepEqual(msgs, want) {
+			t.Errorf("XReadGroup() = %v, want %v", msgs, want)
+		}
+		// miniredis reports the length of the stream as the lag
+		if got := gaugeValue(t, redisMetricCmd, streamLagMetric, "0", "events:workers"); got <= 0 {
+			t.Errorf("lag = %v, want > 0", got)
+		}
+		if got := gaugeValue(t, redisMetricCmd, streamPendingMetric, "0", "events:workers"); got != 2 {
+			t.Errorf("pending = %v, want 2", got)
+		}
+	})
+
+	t.Run("when XAck then the messages are not pending", func(t *testing.T) {
+		if err := m.XAck(ctx, "events", "workers", ids[0], ids[1]); err != nil {
+			t.Fatalf("XAck() error = %v", err)
+		}
+		msgs, err := m.XReadGroup(ctx, "events", "workers", "c1", 10, 10*time.Millisecond)
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("XReadGroup() = %v, %v, want 1 message", msgs, err)
+		}
+		if got := gaugeValue(t, redisMetricCmd, streamPendingMetric, "0", "events:workers"); got != 1 {
+			t.Errorf("pending = %v, want 1", got)
+		}
+	})
+}
+
+func Test_manager_Exists_TTL(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+	_ = mr.Set("forever", "1")
+	_ = mr.Set("expiring", "1")
+	mr.SetTTL("expiring", time.Minute)
+
+	t.Run("when the key exists then true", func(t *testing.T) {
+		if ok, err := m.Exists(ctx, "forever"); err != nil || !ok {
+			t.Errorf("Exists() = %v, %v, want true", ok, err)
+		}
+		if ok, err := m.Exists(ctx, "missing"); err != nil || ok {
+			t.Errorf("Exists() = %v, %v, want false", ok, err)
+		}
+	})
+
+	t.Run("when TTL then the remaining time, 0 for no expire and ErrNotFound for missing", func(t *testing.T) {
+		if ttl, err := m.TTL(ctx, "expiring"); err != nil || ttl != time.Minute {
+			t.Errorf("TTL() = %v, %v, want 1m", ttl, err)
+		}
+		if ttl, err := m.TTL(ctx, "forever"); err != nil || ttl != 0 {
+			t.Errorf("TTL() = %v, %v, want 0", ttl, err)
+		}
+		if _, err := m.TTL(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("TTL() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func Test_manager_GetDel_GetSet_GetEx(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+	_ = mr.Set("token", "t1")
+	_ = mr.Set("counter", "1")
+	_ = mr.Set("session", "s1")
+	mr.HSet("hash", "f", "v")
+
+	t.Run("when GetDel then the value is returned once", func(t *testing.T) {
+		if raw, err := m.GetDel(ctx, "token"); err != nil || raw != "t1" {
+			t.Errorf("GetDel() = %v, %v, want t1", raw, err)
+		}
+		if _, err := m.GetDel(ctx, "token"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetDel() error = %v, want ErrNotFound", err)
+		}
+		if _, err := m.GetDel(ctx, "hash"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("GetDel() error = %v, want ErrWrongType", err)
+		}
+	})
+
+	t.Run("when GetSet then the old value is returned and the new one stored with expire", func(t *testing.T) {
+		if old, err := m.GetSet(ctx, "counter", "2", time.Minute); err != nil || old != "1" {
+			t.Errorf("GetSet() = %v, %v, want 1", old, err)
+		}
+		if raw, _ := mr.Get("counter"); raw != "2" || mr.TTL("counter") != time.Minute {
+			t.Errorf("stored = %v, ttl = %v, want 2 and 1m", raw, mr.TTL("counter"))
+		}
+		if _, err := m.GetSet(ctx, "fresh", "1", 0); !errors.Is(err, ErrNotFound) || !mr.Exists("fresh") {
+			t.Errorf("GetSet() error = %v, stored = %v, want ErrNotFound and stored", err, mr.Exists("fresh"))
+		}
+	})
+
+	t.Run("when GetEx then the value is returned and the expire reset", func(t *testing.T) {
+		if raw, err := m.GetEx(ctx, "session", time.Hour); err != nil || raw != "s1" || mr.TTL("session") != time.Hour {
+			t.Errorf("GetEx() = %v, %v, ttl = %v, want s1 and 1h", raw, err, mr.TTL("session"))
+		}
+		if _, err := m.GetEx(ctx, "session", 0); err != nil || mr.TTL("session") != 0 {
+			t.Errorf("GetEx() error = %v, ttl = %v, want no expire", err, mr.TTL("session"))
+		}
+		if _, err := m.GetEx(ctx, "missing", time.Hour); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetEx() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func Test_manager_Scan_DelPattern(t *testing.T) {
+	m, mr := newTestManager(t)
+	ctx := context.Background()
+	for i := 0; i < 25; i++ {
+		_ = mr.Set(fmt.Sprintf("user:%d", i), "1")
+	}
+	_ = mr.Set("order:1", "1")
+
+	t.Run("when Scan then all the matching keys are iterated by pages", func(t *testing.T) {
+		before := counterValue(t, scanMetricCmd, scanKeysMetric, "0", "user:*")
+		seen := map[string]bool{}
+		it := m.Scan(ctx, "user:*", 10)
+		for it.Next(ctx) {
+			seen[it.Key()] = true
+		}
+		if err := it.Err(); err != nil || len(seen) != 25 {
+			t.Errorf("Scan() = %d keys, %v, want 25", len(seen), err)
+		}
+		if got := counterValue(t, scanMetricCmd, scanKeysMetric, "0", "user:*"); got < before+25 {
+			t.Errorf("scanned = %v, want at least %v", got, before+25)
+		}
+	})
+
+	t.Run("when DelPattern then only the matching keys are deleted", func(t *testing.T) {
+		n, err := m.DelPattern(ctx, "user:*")
+		if err != nil || n != 25 {
+			t.Errorf("DelPattern() = %v, %v, want 25", n, err)
+		}
+		if keys := mr.Keys(); len(keys) != 1 || keys[0] != "order:1" {
+			t.Errorf("keys = %v, want order:1", keys)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tenz-io/trackingo/logger"
+)
+
+func (m *manager) ZAdd(ctx context.Context, key string, members ...Z) (err error) {
+	rec := m.startCall(ctx, "cache_zadd", key, logger.Fields{
+		"members": len(members),
+	})
+	defer func() {
+		rec.End(err, members)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	zs := make([]redis.Z, len(members))
+	for i, z := range members {
+		zs[i] = redis.Z{Score: z.Score, Member: z.Member}
+	}
+	err = redisErr(m.client.ZAdd(ctx, key, zs...).Err(), key)
+	return
+}
+
+func (m *manager) ZRangeByScore(ctx context.Context, key string, min, max float64) (members []Z, err error) {
+	rec := m.startCall(ctx, "cache_zrangebyscore", key, logger.Fields{
+		"min": min,
+		"max": max,
+	})
+	defer func() {
+		rec.End(err, members)
+	}()
+
+	if !m.active() {
+		return nil, ErrInActive
+	}
+
+	zs, err := m.client.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min: formatScore(min),
+		Max: formatScore(max),
+	}).Result()
+	if err != nil {
+		return nil, redisErr(err, key)
+	}
+	return fromRedisZ(zs), nil
+}
+
+func (m *manager) ZRevRange(ctx context.Context, key string, start, stop int64) (members []Z, err error) {
+	rec := m.startCall(ctx, "cache_zrevrange", key, logger.Fields{
+		"start": start,
+		"stop":  stop,
+	})
+	defer func() {
+		rec.End(err, members)
+	}()
+
+	if !m.active() {
+		return nil, ErrInActive
+	}
+
+	zs, err := m.client.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, redisErr(err, key)
+	}
+	return fromRedisZ(zs), nil
+}
+
+func (m *manager) ZRem(ctx context.Context, key string, members ...string) (err error) {
+	rec := m.startCall(ctx, "cache_zrem", key, logger.Fields{
+		"members": len(members),
+	})
+	defer func() {
+		rec.End(err, members)
+	}()
+
+	if !m.active() {
+		return ErrInActive
+	}
+
+	err = redisErr(m.client.ZRem(ctx, key, toAnys(members)...).Err(), key)
+	return
+}
+
+func (m *manager) ZIncrBy(ctx context.Context, key string, member string, delta float64) (score float64, err error) {
+	rec := m.startCall(ctx, "cache_zincrby", key, logger.Fields{
+		"member": member,
+		"delta":  delta,
+	})
+	defer func() {
+		rec.End(err, score)
+	}()
+
+	if !m.active() {
+		return 0, ErrInActive
+	}
+
+	score, err = m.client.ZIncrBy(ctx, key, delta, member).Result()
+	return score, redisErr(err, key)
+}
+
+// formatScore formats the score of a range, the infinities as -inf and +inf
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, -1):
+		return "-inf"
+	case math.IsInf(score, 1):
+		return "+inf"
+	default:
+		return strconv.FormatFloat(score, 'f', -1, 64)
+	}
+}
+
+func fromRedisZ(zs []redis.Z) []Z {
+	res := make([]Z, len(zs))
+	for i, z := range zs {
+		res[i] = Z{Member: fmt.Sprint(z.Member), Score: z.Score}
+	}
+	return res
+}
@@ -0,0 +1,323 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/tracking"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultL1TTL               = time.Minute
+	defaultInvalidationChannel = "cache_invalidation"
+
+	tieredMetricCmd = "cache_tiered"
+	l1HitMetric     = "l1_hit"
+	l1MissMetric    = "l1_miss"
+)
+
+type TieredOpt func(t *tiered)
+
+// WithL1TTL sets the ttl of the values in l1, 1 minute by default
+func WithL1TTL(ttl time.Duration) TieredOpt {
+	return func(t *tiered) {
+		t.l1TTL = ttl
+	}
+}
+
+// WithL1TTLFor sets the ttl in l1 of the keys with prefix, the longest prefix wins,
+// ttl <= 0 keeps the keys out of l1
+func WithL1TTLFor(prefix string, ttl time.Duration) TieredOpt {
+	return func(t *tiered) {
+		t.l1TTLs[prefix] = ttl
+	}
+}
+
+// WithInvalidationChannel sets the pub/sub channel of the invalidations, "cache_invalidation" by default
+func WithInvalidationChannel(channel string) TieredOpt {
+	return func(t *tiered) {
+		t.channel = channel
+	}
+}
+
+// tiered keeps the strings and the blobs of l2 in l1, the writes to l2 evict the keys from l1 of
+// the other instances by pub/sub, the other operations, e.g. the hashes and the pipelines, go to l2 only
+type tiered struct {
+	Manager // l2
+	l1      Manager
+	l1TTL   time.Duration
+	l1TTLs  map[string]time.Duration
+	channel string
+	id      string // the instance id, to skip the own invalidations
+	loads   singleflight.Group
+}
+
+// invalidation is the message of the keys evicted from l1
+type invalidation struct {
+	From     string   `json:"from"`
+	Keys     []string `json:"keys,omitempty"`
+	Patterns []string `json:"patterns,omitempty"` // the glob patterns of DelPattern
+}
+
+// NewTiered returns the Manager reading through l1 to l2, e.g. NewLocal and the redis Manager,
+// the invalidations of the other instances are subscribed until ctx is done
+func NewTiered(ctx context.Context, l1, l2 Manager, opts ...TieredOpt) (Manager, error) {
+	t := &tiered{
+		Manager: l2,
+		l1:      l1,
+		l1TTL:   defaultL1TTL,
+		l1TTLs:  map[string]time.Duration{},
+		channel: defaultInvalidationChannel,
+		id:      tracking.NewRequestId(),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if err := l2.Subscribe(ctx, t.channel, t.onInvalidation); err != nil {
+		return nil, fmt.Errorf("subscribe invalidation error: %w", err)
+	}
+	return t, nil
+}
+
+func (t *tiered) Get(ctx context.Context, key string) (raw string, err error) {
+	sf := monitor.NewSingleFlight(tieredMetricCmd)
+	if raw, err = t.l1.Get(ctx, key); err == nil {
+		sf.Count(ctx, l1HitMetric, 0, "")
+		return raw, nil
+	}
+	sf.Count(ctx, l1MissMetric, 0, "")
+
+	if raw, err = t.Manager.Get(ctx, key); err != nil {
+		return "", err
+	}
+	t.setL1(ctx, key, raw, 0)
+	return raw, nil
+}
+
+func (t *tiered) Set(ctx context.Context, key string, raw string, expire time.Duration) (err error) {
+	if err = t.Manager.Set(ctx, key, raw, expire); err != nil {
+		return err
+	}
+	t.setL1(ctx, key, raw, expire)
+	t.publish(ctx, key)
+	return nil
+}
+
+// SetNx evicts key from l1 instead of setting it, l1 is filled with the value stored in l2 by the next Get
+func (t *tiered) SetNx(ctx context.Context, key string, raw string, expire time.Duration) (existing bool, err error) {
+	if existing, err = t.Manager.SetNx(ctx, key, raw, expire); err != nil {
+		return existing, err
+	}
+	t.invalidate(ctx, key)
+	return existing, nil
+}
+
+func (t *tiered) GetBlob(ctx context.Context, key string, output any) (err error) {
+	sf := monitor.NewSingleFlight(tieredMetricCmd)
+	if err = t.l1.GetBlob(ctx, key, output); err == nil {
+		sf.Count(ctx, l1HitMetric, 0, "")
+		return nil
+	}
+	sf.Count(ctx, l1MissMetric, 0, "")
+
+	if err = t.Manager.GetBlob(ctx, key, output); err != nil {
+		return err
+	}
+	if ttl := t.ttlOf(key, 0); ttl > 0 {
+		_ = t.l1.SetBlob(ctx, key, output, ttl)
+	}
+	return nil
+}
+
+func (t *tiered) SetBlob(ctx context.Context, key string, val any, expire time.Duration) (err error) {
+	if err = t.Manager.SetBlob(ctx, key, val, expire); err != nil {
+		return err
+	}
+	if ttl := t.ttlOf(key, expire); ttl > 0 {
+		_ = t.l1.SetBlob(ctx, key, val, ttl)
+	}
+	t.publish(ctx, key)
+	return nil
+}
+
+func (t *tiered) Del(ctx context.Context, key string) (err error) {
+	defer t.invalidate(ctx, key)
+	return t.Manager.Del(ctx, key)
+}
+
+func (t *tiered) Expire(ctx context.Context, key string, expire time.Duration) (err error) {
+	defer t.invalidate(ctx, key)
+	return t.Manager.Expire(ctx, key, expire)
+}
+
+func (t *tiered) Eval(ctx context.Context, script string, keys []string, args ...any) (val any, err error) {
+	defer t.invalidate(ctx, keys...)
+	return t.Manager.Eval(ctx, script, keys, args...)
+}
+
+func (t *tiered) MGet(ctx context.Context, keys ...string) (vals map[string]string, err error) {
+	if vals, err = t.l1.MGet(ctx, keys...); err != nil {
+		vals = map[string]string{}
+	}
+	var missing []string
+	for _, key := range keys {
+		if _, ok := vals[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return vals, nil
+	}
+
+	found, err := t.Manager.MGet(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+	for key, raw := range found {
+		vals[key] = raw
+		t.setL1(ctx, key, raw, 0)
+	}
+	return vals, nil
+}
+
+func (t *tiered) MSet(ctx context.Context, kvs map[string]string, expire time.Duration) (err error) {
+	if err = t.Manager.MSet(ctx, kvs, expire); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(kvs))
+	for key, raw := range kvs {
+		t.setL1(ctx, key, raw, expire)
+		keys = append(keys, key)
+	}
+	t.publish(ctx, keys...)
+	return nil
+}
+
+func (t *tiered) Incr(ctx context.Context, key string, expire time.Duration) (val int64, err error) {
+	defer t.invalidate(ctx, key)
+	return t.Manager.Incr(ctx, key, expire)
+}
+
+func (t *tiered) IncrBy(ctx context.Context, key string, delta int64, expire time.Duration) (val int64, err error) {
+	defer t.invalidate(ctx, key)
+	return t.Manager.IncrBy(ctx, key, delta, expire)
+}
+
+func (t *tiered) Decr(ctx context.Context, key string, expire time.Duration) (val int64, err error) {
+	defer t.invalidate(ctx, key)
+	return t.Manager.Decr(ctx, key, expire)
+}
+
+func (t *tiered) DecrBy(ctx context.Context, key string, delta int64, expire time.Duration) (val int64, err error) {
+	defer t.invalidate(ctx, key)
+	return t.Manager.DecrBy(ctx, key, delta, expire)
+}
+
+func (t *tiered) GetDel(ctx context.Context, key string) (raw string, err error) {
+	defer t.invalidate(ctx, key)
+	return t.Manager.GetDel(ctx, key)
+}
+
+func (t *tiered) GetSet(ctx context.Context, key string, raw string, expire time.Duration) (old string, err error) {
+	if old, err = t.Manager.GetSet(ctx, key, raw, expire); err != nil && !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+	t.setL1(ctx, key, raw, expire)
+	t.publish(ctx, key)
+	return old, err
+}
+
+func (t *tiered) GetEx(ctx context.Context, key string, expire time.Duration) (raw string, err error) {
+	defer t.invalidate(ctx, key)
+	return t.Manager.GetEx(ctx, key, expire)
+}
+
+func (t *tiered) DelPattern(ctx context.Context, pattern string) (n int64, err error) {
+	defer func() {
+		_, _ = t.l1.DelPattern(ctx, pattern)
+		t.send(ctx, &invalidation{From: t.id, Patterns: []string{pattern}})
+	}()
+	return t.Manager.DelPattern(ctx, pattern)
+}
+
+func (t *tiered) GetOrLoad(ctx context.Context, key string, expire time.Duration, loader Loader) (raw string, err error) {
+	return getOrLoad(ctx, &t.loads, key, func() (string, error) {
+		return t.Get(ctx, key)
+	}, loader, func(raw string) error {
+		return t.Set(ctx, key, raw, expire)
+	})
+}
+
+// ttlOf returns the ttl in l1 of key, at most expire if expire > 0
+func (t *tiered) ttlOf(key string, expire time.Duration) time.Duration {
+	ttl, matched := t.l1TTL, ""
+	for prefix, d := range t.l1TTLs {
+		if strings.HasPrefix(key, prefix) && len(prefix) >= len(matched) {
+			ttl, matched = d, prefix
+		}
+	}
+	if expire > 0 && expire < ttl {
+		return expire
+	}
+	return ttl
+}
+
+func (t *tiered) setL1(ctx context.Context, key string, raw string, expire time.Duration) {
+	if ttl := t.ttlOf(key, expire); ttl > 0 {
+		_ = t.l1.Set(ctx, key, raw, ttl)
+	}
+}
+
+// invalidate evicts keys from l1 of this and the other instances
+func (t *tiered) invalidate(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		_ = t.l1.Del(ctx, key)
+	}
+	t.publish(ctx, keys...)
+}
+
+// publish evicts keys from l1 of the other instances
+func (t *tiered) publish(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	t.send(ctx, &invalidation{From: t.id, Keys: keys})
+}
+
+func (t *tiered) send(ctx context.Context, inv *invalidation) {
+	payload, _ := json.Marshal(inv)
+	if err := t.Manager.Publish(ctx, t.channel, string(payload)); err != nil {
+		logger.FromContext(ctx).WithError(err).WithField("invalidation", inv).Warn("publish invalidation error")
+	}
+}
+
+func (t *tiered) onInvalidation(ctx context.Context, channel string, payload string) error {
+	var inv invalidation
+	if err := json.Unmarshal([]byte(payload), &inv); err != nil {
+		return fmt.Errorf("decode invalidation error: %w", err)
+	}
+	if inv.From == t.id {
+		return nil
+	}
+
+	for _, key := range inv.Keys {
+		if err := t.l1.Del(ctx, key); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	for _, pattern := range inv.Patterns {
+		if _, err := t.l1.DelPattern(ctx, pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
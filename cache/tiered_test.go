@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestTiered(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newTiered := func(t *testing.T) Manager {
+		t.Helper()
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		tm, err := NewTiered(ctx, NewLocal(), NewManager(client, nil), WithL1TTLFor("nol1:", 0))
+		if err != nil {
+			t.Fatalf("NewTiered() error = %v", err)
+		}
+		return tm
+	}
+	a, b := newTiered(t), newTiered(t)
+
+	// eventually waits for the invalidations delivered by pub/sub
+	eventually := func(t *testing.T, get func() string, want string) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for get() != want && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := get(); got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+
+	t.Run("when read then the value is kept in l1", func(t *testing.T) {
+		_ = a.Set(ctx, "k", "v1", time.Minute)
+		if got, err := b.Get(ctx, "k"); err != nil || got != "v1" {
+			t.Fatalf("Get() = %v, %v, want v1", got, err)
+		}
+		_ = mr.Set("k", "changed behind")
+		if got, _ := b.Get(ctx, "k"); got != "v1" {
+			t.Errorf("Get() = %v, want v1 of l1", got)
+		}
+	})
+
+	t.Run("when written by another instance then l1 is invalidated", func(t *testing.T) {
+		_ = a.Set(ctx, "k", "v2", time.Minute)
+		eventually(t, func() string {
+			got, _ := b.Get(ctx, "k")
+			return got
+		}, "v2")
+	})
+
+	t.Run("when deleted by another instance then l1 is invalidated", func(t *testing.T) {
+		_ = a.Del(ctx, "k")
+		eventually(t, func() string {
+			_, err := b.Get(ctx, "k")
+			return errString(err)
+		}, ErrNotFound.Error())
+	})
+
+	t.Run("when DelPattern by another instance then l1 is invalidated", func(t *testing.T) {
+		_ = a.Set(ctx, "p:1", "v", time.Minute)
+		_, _ = b.Get(ctx, "p:1")
+		if n, err := a.DelPattern(ctx, "p:*"); err != nil || n != 1 {
+			t.Fatalf("DelPattern() = %v, %v, want 1", n, err)
+		}
+		eventually(t, func() string {
+			_, err := b.Get(ctx, "p:1")
+			return errString(err)
+		}, ErrNotFound.Error())
+	})
+
+	t.Run("when the prefix ttl is 0 then the keys skip l1", func(t *testing.T) {
+		_ = a.Set(ctx, "nol1:k", "v1", time.Minute)
+		_ = mr.Set("nol1:k", "v2")
+		if got, _ := a.Get(ctx, "nol1:k"); got != "v2" {
+			t.Errorf("Get() = %v, want v2 of l2", got)
+		}
+	})
+
+	t.Run("when MGet then the missing keys are read from l2", func(t *testing.T) {
+		_ = a.MSet(ctx, map[string]string{"m1": "1", "m2": "2"}, time.Minute)
+		got, err := b.MGet(ctx, "m1", "m2", "missing")
+		if err != nil || len(got) != 2 || got["m1"] != "1" || got["m2"] != "2" {
+			t.Errorf("MGet() = %v, %v, want m1 and m2", got, err)
+		}
+	})
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
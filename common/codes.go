@@ -0,0 +1,298 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// the error codes are http alike, so they read the same in metrics, traffic logs and responses.
+// the codes of the applications must be registered within the ranges, see RegisterCode
+const (
+	CodeOK      = 0
+	CodeUnknown = 1
+
+	// client errors, [400, 499]
+	CodeInvalidArgument  = 400
+	CodeUnauthenticated  = 401
+	CodePermissionDenied = 403
+	CodeNotFound         = 404
+	CodeRequestTimeout   = 408
+	CodeConflict         = 409
+	CodeTooManyRequests  = 429
+	CodeCanceled         = 499
+
+	// server errors, [500, 599]
+	CodeInternal       = 500
+	CodeUnimplemented  = 501
+	CodeUnavailable    = 503
+	CodeTimeout        = 504
+	CodeDataLoss       = 507
+	CodeServerOverload = 529
+
+	// dependency errors, e.g. database, cache and downstream services, [600, 699]
+	CodeDependency        = 600
+	CodeDependencyTimeout = 604
+)
+
+// the gRPC status codes, same values as google.golang.org/grpc/codes
+const (
+	GRPCOK                 uint32 = 0
+	GRPCCanceled           uint32 = 1
+	GRPCUnknown            uint32 = 2
+	GRPCInvalidArgument    uint32 = 3
+	GRPCDeadlineExceeded   uint32 = 4
+	GRPCNotFound           uint32 = 5
+	GRPCAlreadyExists      uint32 = 6
+	GRPCPermissionDenied   uint32 = 7
+	GRPCResourceExhausted  uint32 = 8
+	GRPCFailedPrecondition uint32 = 9
+	GRPCAborted            uint32 = 10
+	GRPCOutOfRange         uint32 = 11
+	GRPCUnimplemented      uint32 = 12
+	GRPCInternal           uint32 = 13
+	GRPCUnavailable        uint32 = 14
+	GRPCDataLoss           uint32 = 15
+	GRPCUnauthenticated    uint32 = 16
+)
+
+// CodeRange is a range of codes, both ends included
+type CodeRange struct {
+	Min int
+	Max int
+}
+
+var (
+	ClientCodeRange     = CodeRange{Min: 400, Max: 499}
+	ServerCodeRange     = CodeRange{Min: 500, Max: 599}
+	DependencyCodeRange = CodeRange{Min: 600, Max: 699}
+)
+
+// Contains reports whether code is in r
+func (r CodeRange) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+var (
+	ErrCodeRegistered = errors.New("error code already registered")
+	ErrCodeOutOfRange = errors.New("error code out of the client/server/dependency ranges")
+)
+
+// CodeInfo describes a registered error code
+type CodeInfo struct {
+	Code       int
+	Name       string
+	HTTPStatus int
+	GRPCCode   uint32
+}
+
+var (
+	codesLock sync.RWMutex
+	codes     = map[int]CodeInfo{}
+)
+
+func init() {
+	for _, info := range []CodeInfo{
+		{CodeOK, "ok", http.StatusOK, GRPCOK},
+		{CodeUnknown, "unknown", http.StatusInternalServerError, GRPCUnknown},
+		{CodeInvalidArgument, "invalid_argument", http.StatusBadRequest, GRPCInvalidArgument},
+		{CodeUnauthenticated, "unauthenticated", http.StatusUnauthorized, GRPCUnauthenticated},
+		{CodePermissionDenied, "permission_denied", http.StatusForbidden, GRPCPermissionDenied},
+		{CodeNotFound, "not_found", http.StatusNotFound, GRPCNotFound},
+		{CodeRequestTimeout, "request_timeout", http.StatusRequestTimeout, GRPCDeadlineExceeded},
+		{CodeConflict, "conflict", http.StatusConflict, GRPCAlreadyExists},
+		{CodeTooManyRequests, "too_many_requests", http.StatusTooManyRequests, GRPCResourceExhausted},
+		{CodeCanceled, "canceled", 499, GRPCCanceled},
+		{CodeInternal, "internal", http.StatusInternalServerError, GRPCInternal},
+		{CodeUnimplemented, "unimplemented", http.StatusNotImplemented, GRPCUnimplemented},
+		{CodeUnavailable, "unavailable", http.StatusServiceUnavailable, GRPCUnavailable},
+		{CodeTimeout, "timeout", http.StatusGatewayTimeout, GRPCDeadlineExceeded},
+		{CodeDataLoss, "data_loss", http.StatusInternalServerError, GRPCDataLoss},
+		{CodeServerOverload, "server_overload", http.StatusServiceUnavailable, GRPCResourceExhausted},
+		{CodeDependency, "dependency", http.StatusBadGateway, GRPCUnavailable},
+		{CodeDependencyTimeout, "dependency_timeout", http.StatusGatewayTimeout, GRPCDeadlineExceeded},
+	} {
+		codes[info.Code] = info
+	}
+}
+
+// RegisterCode registers an error code of the application, e.g. 460 for insufficient balance.
+// the code must be in one of the client/server/dependency ranges and not registered yet,
+// the zero HTTPStatus and GRPCCode are derived from the range
+func RegisterCode(info CodeInfo) error {
+	if !ClientCodeRange.Contains(info.Code) &&
+		!ServerCodeRange.Contains(info.Code) &&
+		!DependencyCodeRange.Contains(info.Code) {
+		return fmt.Errorf("%w: %d", ErrCodeOutOfRange, info.Code)
+	}
+
+	fallback := rangeInfo(info.Code)
+	if info.HTTPStatus == 0 {
+		info.HTTPStatus = fallback.HTTPStatus
+	}
+	if info.GRPCCode == 0 {
+		info.GRPCCode = fallback.GRPCCode
+	}
+
+	codesLock.Lock()
+	defer codesLock.Unlock()
+
+	if _, ok := codes[info.Code]; ok {
+		return fmt.Errorf("%w: %d", ErrCodeRegistered, info.Code)
+	}
+	codes[info.Code] = info
+	return nil
+}
+
+// LookupCode returns the registered info of code
+func LookupCode(code int) (CodeInfo, bool) {
+	codesLock.RLock()
+	defer codesLock.RUnlock()
+
+	info, ok := codes[code]
+	return info, ok
+}
+
+// CodeInfoOf returns the info of code, derived from its range if not registered
+func CodeInfoOf(code int) CodeInfo {
+	if info, ok := LookupCode(code); ok {
+		return info
+	}
+	return rangeInfo(code)
+}
+
+// rangeInfo returns the info of an unregistered code from its range
+func rangeInfo(code int) CodeInfo {
+	switch {
+	case ClientCodeRange.Contains(code):
+		return CodeInfo{Code: code, Name: "client_error", HTTPStatus: http.StatusBadRequest, GRPCCode: GRPCFailedPrecondition}
+	case ServerCodeRange.Contains(code):
+		return CodeInfo{Code: code, Name: "server_error", HTTPStatus: http.StatusInternalServerError, GRPCCode: GRPCInternal}
+	case DependencyCodeRange.Contains(code):
+		return CodeInfo{Code: code, Name: "dependency_error", HTTPStatus: http.StatusBadGateway, GRPCCode: GRPCUnavailable}
+	default:
+		return CodeInfo{Code: code, Name: "unknown", HTTPStatus: http.StatusInternalServerError, GRPCCode: GRPCUnknown}
+	}
+}
+
+// IsClientError reports whether the code of err is a client error
+func IsClientError(err error) bool {
+	return ClientCodeRange.Contains(ErrorCode(err))
+}
+
+// IsServerError reports whether the code of err is a server error
+func IsServerError(err error) bool {
+	return ServerCodeRange.Contains(ErrorCode(err))
+}
+
+// IsDependencyError reports whether the code of err is a dependency error
+func IsDependencyError(err error) bool {
+	return DependencyCodeRange.Contains(ErrorCode(err))
+}
+
+// HTTPStatus returns the http status responding err, 200 if nil
+func HTTPStatus(err error) int {
+	return CodeInfoOf(ErrorCode(err)).HTTPStatus
+}
+
+// GRPCCode returns the grpc status code responding err, OK if nil
+func GRPCCode(err error) uint32 {
+	return CodeInfoOf(ErrorCode(err)).GRPCCode
+}
+
+// CodeName returns the name of the code of err, e.g. not_found
+func CodeName(err error) string {
+	return CodeInfoOf(ErrorCode(err)).Name
+}
+
+// FromHTTPStatus returns the code of a http response status, e.g. of a downstream
+func FromHTTPStatus(status int) int {
+	switch {
+	case status >= 200 && status < 300:
+		return CodeOK
+	case status == http.StatusGatewayTimeout:
+		return CodeDependencyTimeout
+	case status >= 500:
+		return CodeDependency
+	case ClientCodeRange.Contains(status):
+		return status
+	default:
+		return CodeUnknown
+	}
+}
+
+// FromGRPCCode returns the code of a grpc status code, e.g. of a downstream
+func FromGRPCCode(code uint32) int {
+	switch code {
+	case GRPCOK:
+		return CodeOK
+	case GRPCCanceled:
+		return CodeCanceled
+	case GRPCInvalidArgument, GRPCOutOfRange:
+		return CodeInvalidArgument
+	case GRPCDeadlineExceeded:
+		return CodeDependencyTimeout
+	case GRPCNotFound:
+		return CodeNotFound
+	case GRPCAlreadyExists, GRPCAborted:
+		return CodeConflict
+	case GRPCPermissionDenied:
+		return CodePermissionDenied
+	case GRPCResourceExhausted:
+		return CodeTooManyRequests
+	case GRPCFailedPrecondition:
+		return CodeInvalidArgument
+	case GRPCUnauthenticated:
+		return CodeUnauthenticated
+	case GRPCUnimplemented:
+		return CodeUnimplemented
+	default:
+		return CodeDependency
+	}
+}
+
+// NotFound wraps err with CodeNotFound
+func NotFound(err error) *ValError {
+	return NewValError(CodeNotFound, err)
+}
+
+// InvalidArgument wraps err with CodeInvalidArgument
+func InvalidArgument(err error) *ValError {
+	return NewValError(CodeInvalidArgument, err)
+}
+
+// Unauthenticated wraps err with CodeUnauthenticated
+func Unauthenticated(err error) *ValError {
+	return NewValError(CodeUnauthenticated, err)
+}
+
+// PermissionDenied wraps err with CodePermissionDenied
+func PermissionDenied(err error) *ValError {
+	return NewValError(CodePermissionDenied, err)
+}
+
+// Conflict wraps err with CodeConflict
+func Conflict(err error) *ValError {
+	return NewValError(CodeConflict, err)
+}
+
+// TooManyRequests wraps err with CodeTooManyRequests
+func TooManyRequests(err error) *ValError {
+	return NewValError(CodeTooManyRequests, err)
+}
+
+// Internal wraps err with CodeInternal
+func Internal(err error) *ValError {
+	return NewValError(CodeInternal, err)
+}
+
+// Unavailable wraps err with CodeUnavailable
+func Unavailable(err error) *ValError {
+	return NewValError(CodeUnavailable, err)
+}
+
+// Dependency wraps err of a dependency with CodeDependency
+func Dependency(err error) *ValError {
+	return NewValError(CodeDependency, err)
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "when err is nil then 200",
+			err:  nil,
+			want: http.StatusOK,
+		},
+		{
+			name: "when err is not ValError then 500",
+			err:  errors.New("boom"),
+			want: http.StatusInternalServerError,
+		},
+		{
+			name: "when err is wrapped NotFound then 404",
+			err:  fmt.Errorf("get user: %w", NotFound(errors.New("no such user"))),
+			want: http.StatusNotFound,
+		},
+		{
+			name: "when err is dependency error then 502",
+			err:  Dependency(errors.New("redis down")),
+			want: http.StatusBadGateway,
+		},
+		{
+			name: "when code is unregistered client code then 400",
+			err:  NewValError(488, errors.New("custom")),
+			want: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTTPStatus(tt.err); got != tt.want {
+				t.Errorf("HTTPStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCCode(t *testing.T) {
+	if got := GRPCCode(NotFound(errors.New("x"))); got != GRPCNotFound {
+		t.Errorf("GRPCCode() = %v, want %v", got, GRPCNotFound)
+	}
+	if got := GRPCCode(nil); got != GRPCOK {
+		t.Errorf("GRPCCode() = %v, want %v", got, GRPCOK)
+	}
+}
+
+func TestRegisterCode(t *testing.T) {
+	t.Run("when code is in client range then registered with derived mapping", func(t *testing.T) {
+		if err := RegisterCode(CodeInfo{Code: 460, Name: "insufficient_balance"}); err != nil {
+			t.Fatalf("RegisterCode() error = %v", err)
+		}
+		info, ok := LookupCode(460)
+		if !ok || info.HTTPStatus != http.StatusBadRequest || info.GRPCCode != GRPCFailedPrecondition {
+			t.Errorf("LookupCode() = %+v, %v", info, ok)
+		}
+		if got := CodeName(NewValError(460, errors.New("x"))); got != "insufficient_balance" {
+			t.Errorf("CodeName() = %v, want insufficient_balance", got)
+		}
+	})
+
+	t.Run("when code is registered then error", func(t *testing.T) {
+		if err := RegisterCode(CodeInfo{Code: CodeNotFound}); !errors.Is(err, ErrCodeRegistered) {
+			t.Errorf("RegisterCode() error = %v, want ErrCodeRegistered", err)
+		}
+	})
+
+	t.Run("when code is out of ranges then error", func(t *testing.T) {
+		if err := RegisterCode(CodeInfo{Code: 1000}); !errors.Is(err, ErrCodeOutOfRange) {
+			t.Errorf("RegisterCode() error = %v, want ErrCodeOutOfRange", err)
+		}
+	})
+}
+
+func TestFromHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   int
+	}{
+		{status: http.StatusOK, want: CodeOK},
+		{status: http.StatusNotFound, want: CodeNotFound},
+		{status: http.StatusBadGateway, want: CodeDependency},
+		{status: http.StatusGatewayTimeout, want: CodeDependencyTimeout},
+	}
+	for _, tt := range tests {
+		if got := FromHTTPStatus(tt.status); got != tt.want {
+			t.Errorf("FromHTTPStatus(%v) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MultiError aggregates the errors of a batch or shutdown, instead of reporting only the first one.
+// its code is the worst code of the errors, see ErrorCode
+type MultiError struct {
+	Errs []error
+}
+
+// Append appends the non nil errs to err, which is a MultiError or nil.
+// a MultiError in errs is flattened
+func Append(err error, errs ...error) *MultiError {
+	me, ok := err.(*MultiError)
+	if !ok || me == nil {
+		me = &MultiError{}
+		if err != nil {
+			me.Errs = append(me.Errs, err)
+		}
+	}
+
+	for _, e := range errs {
+		switch v := e.(type) {
+		case nil:
+		case *MultiError:
+			if v != nil {
+				me.Errs = append(me.Errs, v.Errs...)
+			}
+		default:
+			me.Errs = append(me.Errs, e)
+		}
+	}
+	return me
+}
+
+// ErrorOrNil returns nil if there is no error, so that the result can be returned as error
+func (me *MultiError) ErrorOrNil() error {
+	if me == nil || len(me.Errs) == 0 {
+		return nil
+	}
+	return me
+}
+
+func (me *MultiError) Error() string {
+	if me == nil || len(me.Errs) == 0 {
+		return ""
+	}
+	if len(me.Errs) == 1 {
+		return me.Errs[0].Error()
+	}
+
+	msgs := make([]string, len(me.Errs))
+	for i, err := range me.Errs {
+		msgs[i] = err.Error()
+	}
+	return strconv.Itoa(len(me.Errs)) + " errors occurred: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors, so errors.Is and errors.As match any of them
+func (me *MultiError) Unwrap() []error {
+	if me == nil {
+		return nil
+	}
+	return me.Errs
+}
+
+// Code returns the worst code of the errors, server and dependency errors are worse than
+// the unknown ones, which are worse than the client ones. the first is taken among equals
+func (me *MultiError) Code() int {
+	if me == nil || len(me.Errs) == 0 {
+		return CodeOK
+	}
+
+	code := CodeOK
+	for _, err := range me.Errs {
+		if c := ErrorCode(err); severity(c) > severity(code) {
+			code = c
+		}
+	}
+	return code
+}
+
+func severity(code int) int {
+	switch {
+	case code == CodeOK:
+		return 0
+	case ClientCodeRange.Contains(code):
+		return 1
+	case ServerCodeRange.Contains(code), DependencyCodeRange.Contains(code):
+		return 3
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	t.Run("when no error then ErrorOrNil returns nil", func(t *testing.T) {
+		var err error
+		err = Append(err, nil, nil).ErrorOrNil()
+		if err != nil {
+			t.Errorf("ErrorOrNil() = %v, want nil", err)
+		}
+	})
+
+	t.Run("when multi errors appended then flatten", func(t *testing.T) {
+		errA, errB, errC := errors.New("a"), errors.New("b"), errors.New("c")
+		err := Append(errA, Append(nil, errB, errC))
+		if len(err.Errs) != 3 {
+			t.Fatalf("Append() errs = %v, want 3", err.Errs)
+		}
+		if !errors.Is(err, errC) {
+			t.Errorf("errors.Is() = false, want true")
+		}
+		if got := ErrorMsg(err); got != "3 errors occurred: a; b; c" {
+			t.Errorf("ErrorMsg() = %v", got)
+		}
+	})
+}
+
+func TestMultiError_Code(t *testing.T) {
+	tests := []struct {
+		name string
+		errs []error
+		want int
+	}{
+		{
+			name: "when only client errors then first client code",
+			errs: []error{NotFound(errors.New("a")), Conflict(errors.New("b"))},
+			want: CodeNotFound,
+		},
+		{
+			name: "when client and unknown errors then unknown code",
+			errs: []error{NotFound(errors.New("a")), errors.New("b")},
+			want: CodeUnknown,
+		},
+		{
+			name: "when server error among others then server code",
+			errs: []error{NotFound(errors.New("a")), errors.New("b"), fmt.Errorf("c: %w", Dependency(errors.New("d")))},
+			want: CodeDependency,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("batch: %w", Append(nil, tt.errs...))
+			if got := ErrorCode(err); got != tt.want {
+				t.Errorf("ErrorCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorCode_nested(t *testing.T) {
+	batch := Append(nil, NotFound(errors.New("a")), Dependency(errors.New("b")))
+
+	t.Run("when a ValError wraps a MultiError then the code of the ValError", func(t *testing.T) {
+		err := fmt.Errorf("import: %w", NewValError(CodeConflict, batch))
+		if got := ErrorCode(err); got != CodeConflict {
+			t.Errorf("ErrorCode() = %v, want %v", got, CodeConflict)
+		}
+	})
+
+	t.Run("when a ValError without code wraps a MultiError then the code of the MultiError", func(t *testing.T) {
+		err := NewValError(CodeOK, batch)
+		if got := ErrorCode(err); got != CodeDependency {
+			t.Errorf("ErrorCode() = %v, want %v", got, CodeDependency)
+		}
+	})
+
+	t.Run("when a MultiError holds ValErrors then the worst code of them", func(t *testing.T) {
+		if got := ErrorCode(batch); got != CodeDependency {
+			t.Errorf("ErrorCode() = %v, want %v", got, CodeDependency)
+		}
+	})
+}
@@ -21,14 +21,32 @@ func (ve *ValError) Error() string {
 	return ve.Err.Error()
 }
 
+// Unwrap returns the wrapped error, so errors.Is and errors.As see through ValError
+func (ve *ValError) Unwrap() error {
+	return ve.Err
+}
+
 // ErrorCode returns the error code of the given error.
 // If the given error is nil, it returns 0.
+// The outermost ValError with a non zero code or MultiError in the chain wins, so that the explicit code of
+// a wrapper is not overridden by its causes, a MultiError returns the worst code of its errors.
 // If the given error is not a ValError, it returns 1.
 func ErrorCode(err error) int {
 	if err == nil {
 		return 0
 	}
 
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		switch v := e.(type) {
+		case *ValError:
+			if v.Code != CodeOK {
+				return v.Code
+			}
+		case *MultiError:
+			return v.Code()
+		}
+	}
+
 	var valErr *ValError
 	if match := errors.As(err, &valErr); match {
 		return valErr.Code
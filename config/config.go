@@ -0,0 +1,236 @@
+// Package config loads the Config structs of the modules, e.g. dborm.Config and httpgin.Config,
+// from yaml or json files with env overrides, applying their default tags and validating their required tags
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+type Opt func(l *loader)
+
+type loader struct {
+	envPrefix string
+	env       bool
+}
+
+// WithEnv overrides the fields with the env vars named after their path, e.g.
+// APP_DB_MAX_OPEN_CONN for the field of yaml path db.max_open_conn with prefix APP
+func WithEnv(prefix string) Opt {
+	return func(l *loader) {
+		l.env = true
+		l.envPrefix = prefix
+	}
+}
+
+// Load reads the yaml or json file of path into a new T, the format is decided by the file extension,
+// e.g. cfg, err := config.Load[dborm.Config]("db.yaml", config.WithEnv("DB"))
+func Load[T any](path string, opts ...Opt) (*T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file error: %w", err)
+	}
+
+	format := FormatYAML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = FormatJSON
+	}
+
+	out := new(T)
+	if err = LoadBytes(data, format, out, opts...); err != nil {
+		return nil, fmt.Errorf("load config file %s error: %w", path, err)
+	}
+	return out, nil
+}
+
+// LoadBytes decodes data of format into out, a pointer to struct, then applies the env overrides,
+// the defaults and validates the required fields.
+// defaults are applied to the fields missing from data, so the explicit zero values are kept,
+// including in the structs behind pointers, slices and maps
+func LoadBytes(data []byte, format string, out any, opts ...Opt) (err error) {
+	l := &loader{}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	v, err := structOf(out)
+	if err != nil {
+		return err
+	}
+
+	var node map[string]any
+	switch format {
+	case FormatYAML:
+		if err = yaml.Unmarshal(data, &node); err == nil {
+			err = yaml.Unmarshal(data, out)
+		}
+	case FormatJSON:
+		node, err = decodeJSON(data, v.Type(), out)
+	default:
+		return fmt.Errorf("unsupported config format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("decode %s error: %w", format, err)
+	}
+
+	if err = applyDefaults(v, node, format); err != nil {
+		return err
+	}
+
+	if l.env {
+		if err = ApplyEnv(out, l.envPrefix); err != nil {
+			return err
+		}
+	}
+
+	return Validate(out)
+}
+
+// decodeJSON decodes data into out of type t and returns the decoded node of data,
+// the duration fields may be strings, e.g. "30s", as in yaml
+func decodeJSON(data []byte, t reflect.Type, out any) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var node map[string]any
+	if err := dec.Decode(&node); err != nil {
+		return nil, err
+	}
+
+	normalized, err := parseDurations(node, t)
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	return node, json.Unmarshal(data, out)
+}
+
+// parseDurations returns a copy of node, decoded from json for type t, with the duration strings parsed
+func parseDurations(node any, t reflect.Type) (any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch n := node.(type) {
+	case string:
+		if t != durationType {
+			return n, nil
+		}
+		d, err := time.ParseDuration(n)
+		if err != nil {
+			return nil, err
+		}
+		return int64(d), nil
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return n, nil
+		}
+		list := make([]any, len(n))
+		for i, elem := range n {
+			var err error
+			if list[i], err = parseDurations(elem, t.Elem()); err != nil {
+				return nil, fmt.Errorf("%d: %w", i, err)
+			}
+		}
+		return list, nil
+	case map[string]any:
+		m := make(map[string]any, len(n))
+		for key, elem := range n {
+			m[key] = elem
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			for key, elem := range n {
+				var err error
+				if m[key], err = parseDurations(elem, t.Elem()); err != nil {
+					return nil, fmt.Errorf("%s: %w", key, err)
+				}
+			}
+		case reflect.Struct:
+			if err := parseFieldDurations(n, m, t); err != nil {
+				return nil, err
+			}
+		default:
+		}
+		return m, nil
+	default:
+		return n, nil
+	}
+}
+
+// parseFieldDurations parses the durations of the fields of struct t from node into m
+func parseFieldDurations(node, m map[string]any, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name := inputName(field, FormatJSON)
+		if name == "" {
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if field.Anonymous && ft.Kind() == reflect.Struct {
+				if err := parseFieldDurations(node, m, ft); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for key, elem := range node {
+			if !strings.EqualFold(key, name) {
+				continue
+			}
+			parsed, err := parseDurations(elem, field.Type)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			m[key] = parsed
+		}
+	}
+	return nil
+}
+
+// structOf returns the struct pointed by out
+func structOf(out any) (reflect.Value, error) {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("config must be a non-nil pointer to struct, got %T", out)
+	}
+	return v.Elem(), nil
+}
+
+// fieldName returns the name of field in config files, the yaml tag, then json tag, then lower case field name.
+// empty if the field is ignored by "-"
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"yaml", "json"} {
+		if tag, ok := field.Tag.Lookup(key); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+	}
+	return strings.ToLower(field.Name)
+}
@@ -0,0 +1,147 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tenz-io/trackingo/config"
+	"github.com/tenz-io/trackingo/dborm"
+	"github.com/tenz-io/trackingo/httpgin"
+)
+
+type appConfig struct {
+	Name string         `yaml:"name" json:"name" required:"true"`
+	Http httpgin.Config `yaml:"http" json:"http"`
+	DB   *dborm.Config  `yaml:"db" json:"db"`
+}
+
+func TestLoadBytes(t *testing.T) {
+	t.Run("when yaml loaded then apply defaults to missing fields only", func(t *testing.T) {
+		data := []byte(`
+name: app
+http:
+  enable_pprof: false
+  timeout: 5s
+db:
+  host: localhost
+  dbname: test
+  max_open_conn: 20
+`)
+		cfg := &appConfig{}
+		if err := config.LoadBytes(data, config.FormatYAML, cfg); err != nil {
+			t.Fatalf("LoadBytes() error = %v", err)
+		}
+		if cfg.Http.EnablePprof || !cfg.Http.EnableMetrics || cfg.Http.Timeout != 5*time.Second || cfg.Http.CheckEndpoint != "/health" {
+			t.Errorf("LoadBytes() http = %+v", cfg.Http)
+		}
+		if cfg.DB.MaxOpenConn != 20 || cfg.DB.MaxIdleConn != 5 || cfg.DB.QueryTimeout != 10*time.Second || !cfg.DB.EnableTracking {
+			t.Errorf("LoadBytes() db = %+v", cfg.DB)
+		}
+	})
+
+	t.Run("when nested structs set zero values then keep them", func(t *testing.T) {
+		data := []byte(`
+databases:
+  main:
+    host: localhost
+    dbname: main
+    enable_tracking: false
+    max_idle_conn: 0
+  report:
+    host: localhost
+    dbname: report
+`)
+		cfg := &dborm.RegistryConfig{}
+		if err := config.LoadBytes(data, config.FormatYAML, cfg); err != nil {
+			t.Fatalf("LoadBytes() error = %v", err)
+		}
+		if main := cfg.Databases["main"]; main.EnableTracking || main.MaxIdleConn != 0 || main.QueryTimeout != 10*time.Second {
+			t.Errorf("LoadBytes() main = %+v, want tracking disabled and no idle conn", main)
+		}
+		if report := cfg.Databases["report"]; !report.EnableTracking || report.MaxIdleConn != 5 {
+			t.Errorf("LoadBytes() report = %+v, want the defaults", report)
+		}
+	})
+
+	t.Run("when json of slice elements set zero values then keep them", func(t *testing.T) {
+		data := []byte(`{"shards": [{"host": "localhost", "dbname": "s0", "enable_tracking": false}, {"host": "localhost", "dbname": "s1"}]}`)
+		cfg := &dborm.ShardConfig{}
+		if err := config.LoadBytes(data, config.FormatJSON, cfg); err != nil {
+			t.Fatalf("LoadBytes() error = %v", err)
+		}
+		if cfg.Shards[0].EnableTracking || !cfg.Shards[1].EnableTracking {
+			t.Errorf("LoadBytes() tracking = %v, %v, want false, true", cfg.Shards[0].EnableTracking, cfg.Shards[1].EnableTracking)
+		}
+	})
+
+	t.Run("when json has duration strings then parse them", func(t *testing.T) {
+		data := []byte(`{"name": "app", "http": {"timeout": "30s"}, "db": {"host": "localhost", "dbname": "test", "query_timeout": "1m", "slow_threshold": 2000000000}}`)
+		cfg := &appConfig{}
+		if err := config.LoadBytes(data, config.FormatJSON, cfg); err != nil {
+			t.Fatalf("LoadBytes() error = %v", err)
+		}
+		if cfg.Http.Timeout != 30*time.Second || cfg.DB.QueryTimeout != time.Minute || cfg.DB.SlowThreshold != 2*time.Second {
+			t.Errorf("LoadBytes() timeouts = %v, %v, %v", cfg.Http.Timeout, cfg.DB.QueryTimeout, cfg.DB.SlowThreshold)
+		}
+	})
+
+	t.Run("when json has a bad duration string then return error", func(t *testing.T) {
+		cfg := &appConfig{}
+		if err := config.LoadBytes([]byte(`{"name": "app", "http": {"timeout": "soon"}}`), config.FormatJSON, cfg); err == nil {
+			t.Errorf("LoadBytes() want error")
+		}
+	})
+
+	t.Run("when env set then override file", func(t *testing.T) {
+		t.Setenv("APP_DB_MAX_IDLE_CONN", "7")
+		t.Setenv("APP_DB_MASK_COLUMNS", "email, phone")
+		t.Setenv("APP_HTTP_TIMEOUT", "1m")
+
+		cfg := &appConfig{}
+		data := []byte(`{"name": "app", "db": {"host": "localhost", "dbname": "test"}}`)
+		if err := config.LoadBytes(data, config.FormatJSON, cfg, config.WithEnv("app")); err != nil {
+			t.Fatalf("LoadBytes() error = %v", err)
+		}
+		if cfg.DB.MaxIdleConn != 7 || len(cfg.DB.MaskColumns) != 2 || cfg.DB.MaskColumns[1] != "phone" {
+			t.Errorf("LoadBytes() db = %+v", cfg.DB)
+		}
+		if cfg.Http.Timeout != time.Minute {
+			t.Errorf("LoadBytes() http timeout = %v, want %v", cfg.Http.Timeout, time.Minute)
+		}
+	})
+
+	t.Run("when required fields missing then return all of them", func(t *testing.T) {
+		cfg := &appConfig{}
+		err := config.LoadBytes([]byte(`db: {host: localhost}`), config.FormatYAML, cfg)
+		if !errors.Is(err, config.ErrRequired) {
+			t.Fatalf("LoadBytes() error = %v, want %v", err, config.ErrRequired)
+		}
+		if want := "required field is missing: [name db.dbname]"; err.Error() != want {
+			t.Errorf("LoadBytes() error = %v, want %v", err, want)
+		}
+	})
+
+	t.Run("when out is not pointer to struct then return error", func(t *testing.T) {
+		if err := config.LoadBytes([]byte(`{}`), config.FormatJSON, appConfig{}); err == nil {
+			t.Errorf("LoadBytes() want error")
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.yaml")
+	if err := os.WriteFile(path, []byte("host: localhost\ndbname: test\nmax_lifetime: 1m\n"), 0o644); err != nil {
+		t.Fatalf("write file error: %v", err)
+	}
+
+	cfg, err := config.Load[dborm.Config](path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.GetMaxLifetime() != time.Minute || cfg.SlowThreshold != time.Second {
+		t.Errorf("Load() = %+v", cfg)
+	}
+}
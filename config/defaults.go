@@ -0,0 +1,209 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ApplyDefaults sets the zero fields of out, a pointer to struct, to their default tag,
+// including the fields of nested structs, e.g. `default:"10s"`
+func ApplyDefaults(out any) error {
+	v, err := structOf(out)
+	if err != nil {
+		return err
+	}
+	return applyDefaults(v, nil, "")
+}
+
+// applyDefaults sets the zero fields of struct v to their default tag, including the structs nested in it,
+// behind pointers, slices and maps. if format is set, node is the struct decoded from the input of format,
+// and only the fields missing from it are set, so that the explicit zero values are kept
+func applyDefaults(v reflect.Value, node map[string]any, format string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		sub, present := fieldNode(node, field, format)
+		if err := applyNestedDefaults(fv, sub, format); err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+
+		def, ok := field.Tag.Lookup("default")
+		if !ok || present || !fv.IsZero() {
+			continue
+		}
+		if err := setValue(fv, def); err != nil {
+			return fmt.Errorf("set default of %s error: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+func applyNestedDefaults(fv reflect.Value, node any, format string) error {
+	switch fv.Kind() {
+	case reflect.Struct:
+		if fv.Type() == reflect.TypeOf(time.Time{}) {
+			return nil
+		}
+		m, _ := node.(map[string]any)
+		return applyDefaults(fv, m, format)
+	case reflect.Pointer:
+		if !fv.IsNil() && fv.Elem().Kind() == reflect.Struct {
+			m, _ := node.(map[string]any)
+			return applyDefaults(fv.Elem(), m, format)
+		}
+	case reflect.Slice, reflect.Array:
+		list, _ := node.([]any)
+		for i := 0; i < fv.Len(); i++ {
+			var elem any
+			if i < len(list) {
+				elem = list[i]
+			}
+			if err := applyElemDefaults(fv.Index(i), elem, format); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, _ := node.(map[string]any)
+		iter := fv.MapRange()
+		for iter.Next() {
+			if err := applyElemDefaults(iter.Value(), m[fmt.Sprint(iter.Key().Interface())], format); err != nil {
+				return err
+			}
+		}
+	default:
+	}
+	return nil
+}
+
+// applyElemDefaults applies the defaults to the struct of a slice or map element,
+// map values are not addressable, so only pointers are supported for maps
+func applyElemDefaults(elem reflect.Value, node any, format string) error {
+	m, _ := node.(map[string]any)
+	switch {
+	case elem.Kind() == reflect.Pointer && !elem.IsNil() && elem.Elem().Kind() == reflect.Struct:
+		return applyDefaults(elem.Elem(), m, format)
+	case elem.Kind() == reflect.Struct && elem.CanSet():
+		return applyDefaults(elem, m, format)
+	default:
+		return nil
+	}
+}
+
+// fieldNode returns the node of field in node, the struct decoded from the input of format,
+// and whether the field is present in the input. the embedded structs share the node of their parent
+func fieldNode(node map[string]any, field reflect.StructField, format string) (any, bool) {
+	if format == "" {
+		return nil, false
+	}
+
+	name := inputName(field, format)
+	if name == "" {
+		if field.Anonymous {
+			return node, false
+		}
+		return nil, false
+	}
+	if sub, ok := node[name]; ok {
+		return sub, true
+	}
+	if format == FormatJSON {
+		// json matches the keys case-insensitively
+		for key, sub := range node {
+			if strings.EqualFold(key, name) {
+				return sub, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// inputName returns the key of field in the input of format, empty if the field is inlined or ignored
+func inputName(field reflect.StructField, format string) string {
+	tag, ok := field.Tag.Lookup(format)
+	name, opts, _ := strings.Cut(tag, ",")
+	switch {
+	case name == "-":
+		return ""
+	case format == FormatYAML && strings.Contains(opts, "inline"):
+		return ""
+	case name != "":
+		return name
+	case format == FormatJSON && field.Anonymous && !ok:
+		return ""
+	case format == FormatJSON:
+		return field.Name
+	default:
+		return strings.ToLower(field.Name)
+	}
+}
+
+// setValue parses raw to the type of v, slices are comma separated
+func setValue(v reflect.Value, raw string) error {
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(raw))
+		}
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := strings.Split(raw, ",")
+		slice := reflect.MakeSlice(v.Type(), 0, len(parts))
+		for _, part := range parts {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(elem, strings.TrimSpace(part)); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ApplyEnv overrides the fields of out, a pointer to struct, with the env vars named after
+// their path in config files, upper cased and joined by "_", e.g. with prefix APP
+// APP_DB_MAX_OPEN_CONN overrides the field of yaml path db.max_open_conn.
+// nested structs behind nil pointers are left untouched
+func ApplyEnv(out any, prefix string) error {
+	v, err := structOf(out)
+	if err != nil {
+		return err
+	}
+	return applyEnv(v, strings.ToUpper(prefix))
+}
+
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		name := fieldName(field)
+		if !field.IsExported() || name == "" {
+			continue
+		}
+
+		envName := strings.ToUpper(name)
+		if prefix != "" {
+			envName = prefix + "_" + envName
+		}
+
+		switch {
+		case fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}):
+			if err := applyEnv(fv, envName); err != nil {
+				return err
+			}
+			continue
+		case fv.Kind() == reflect.Pointer && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct:
+			if err := applyEnv(fv.Elem(), envName); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(envName)
+		if !ok {
+			continue
+		}
+		if err := setValue(fv, raw); err != nil {
+			return fmt.Errorf("set %s from env %s error: %w", field.Name, envName, err)
+		}
+	}
+	return nil
+}
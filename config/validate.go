@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	ErrRequired = errors.New("required field is missing")
+)
+
+// Validate checks the fields with `required:"true"` of out, a pointer to struct, are not zero,
+// including the fields of nested structs, slice elements and map values
+func Validate(out any) error {
+	v, err := structOf(out)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	validate(v, "", &missing)
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %v", ErrRequired, missing)
+	}
+	return nil
+}
+
+func validate(v reflect.Value, path string, missing *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		name := fieldName(field)
+		if !field.IsExported() || name == "" {
+			continue
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+
+		if field.Tag.Get("required") == "true" && fv.IsZero() {
+			*missing = append(*missing, name)
+			continue
+		}
+
+		switch fv.Kind() {
+		case reflect.Struct:
+			if fv.Type() != reflect.TypeOf(time.Time{}) {
+				validate(fv, name, missing)
+			}
+		case reflect.Pointer:
+			if !fv.IsNil() && fv.Elem().Kind() == reflect.Struct {
+				validate(fv.Elem(), name, missing)
+			}
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < fv.Len(); j++ {
+				validateElem(fv.Index(j), fmt.Sprintf("%s[%d]", name, j), missing)
+			}
+		case reflect.Map:
+			iter := fv.MapRange()
+			for iter.Next() {
+				validateElem(iter.Value(), fmt.Sprintf("%s[%v]", name, iter.Key()), missing)
+			}
+		default:
+		}
+	}
+}
+
+func validateElem(elem reflect.Value, path string, missing *[]string) {
+	if elem.Kind() == reflect.Pointer {
+		if elem.IsNil() {
+			return
+		}
+		elem = elem.Elem()
+	}
+	if elem.Kind() == reflect.Struct {
+		validate(elem, path, missing)
+	}
+}
@@ -0,0 +1,260 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	syslog "log"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tenz-io/trackingo/cache"
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+const (
+	// CodeJobPanic is the metrics code of a job run which panicked
+	CodeJobPanic = common.CodeInternal
+	// CodeJobSkipped is the metrics code of a job run skipped by overlap or lock
+	CodeJobSkipped = common.CodeConflict
+
+	lockKeyPrefix = "cron_lock:"
+)
+
+var (
+	ErrJobExists = fmt.Errorf("job already registered")
+	ErrStarted   = fmt.Errorf("runner already started")
+)
+
+// Job is the function of a scheduled job, ctx carries the requestId, logger and monitor of the run
+type Job func(ctx context.Context) error
+
+type Runner interface {
+	// Register adds a job with a unique name and a schedule spec, see ParseSchedule
+	Register(name string, spec string, job Job, opts ...JobOpt) error
+	// Start starts scheduling the registered jobs
+	Start()
+	// Stop stops scheduling and waits for the running jobs until ctx is done
+	Stop(ctx context.Context) error
+}
+
+type JobOpt func(e *entry)
+
+// WithTimeout bounds every run of the job with timeout
+func WithTimeout(timeout time.Duration) JobOpt {
+	return func(e *entry) {
+		e.timeout = timeout
+	}
+}
+
+// WithLock runs the job on only one instance at a time, by holding a lock in locker for at most ttl,
+// ttl should be longer than the longest run of the job
+func WithLock(locker cache.Manager, ttl time.Duration) JobOpt {
+	return func(e *entry) {
+		e.locker = cache.NewLocker(locker, cache.WithLockPrefix(lockKeyPrefix))
+		e.lockTTL = ttl
+	}
+}
+
+type entry struct {
+	name     string
+	schedule Schedule
+	job      Job
+	timeout  time.Duration
+	locker   *cache.Locker
+	lockTTL  time.Duration
+	running  atomic.Bool
+}
+
+type runner struct {
+	lock    sync.Mutex
+	entries map[string]*entry
+	started bool
+	stopC   chan struct{}
+	loops   sync.WaitGroup
+	runs    sync.WaitGroup
+}
+
+func NewRunner() Runner {
+	return &runner{
+		entries: make(map[string]*entry),
+		stopC:   make(chan struct{}),
+	}
+}
+
+func (r *runner) Register(name string, spec string, job Job, opts ...JobOpt) error {
+	if job == nil {
+		return fmt.Errorf("job %s is nil", name)
+	}
+
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("parse schedule of job %s error: %w", name, err)
+	}
+
+	e := &entry{
+		name:     name,
+		schedule: schedule,
+		job:      job,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.started {
+		return ErrStarted
+	}
+	if _, ok := r.entries[name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobExists, name)
+	}
+	r.entries[name] = e
+	return nil
+}
+
+func (r *runner) Start() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.started {
+		return
+	}
+	r.started = true
+
+	syslog.Printf("[cron] start %d jobs\n", len(r.entries))
+	for _, e := range r.entries {
+		r.loops.Add(1)
+		go r.loop(e)
+	}
+}
+
+func (r *runner) Stop(ctx context.Context) error {
+	r.lock.Lock()
+	select {
+	case <-r.stopC:
+	default:
+		close(r.stopC)
+	}
+	r.lock.Unlock()
+
+	r.loops.Wait()
+
+	doneC := make(chan struct{})
+	go func() {
+		r.runs.Wait()
+		close(doneC)
+	}()
+
+	select {
+	case <-doneC:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait running jobs error: %w", ctx.Err())
+	}
+}
+
+// loop triggers the runs of e on its schedule until stopped
+func (r *runner) loop(e *entry) {
+	defer r.loops.Done()
+
+	for {
+		now := time.Now()
+		next := e.schedule.Next(now)
+		if next.IsZero() {
+			syslog.Printf("[cron] job %s will never run\n", e.name)
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-r.stopC:
+			timer.Stop()
+			return
+		case <-timer.C:
+			r.runs.Add(1)
+			go func() {
+				defer r.runs.Done()
+				r.run(e)
+			}()
+		}
+	}
+}
+
+// run runs the job once with a fresh requestId, skipped if the previous run is not finished yet
+func (r *runner) run(e *entry) {
+	cmd := "cron_" + e.name
+	ctx := monitor.InitSingleFlight(context.Background(), cmd)
+	ctx, requestId := tracking.EnsureRequestId(ctx)
+	ctx = logger.WithLogger(ctx, logger.WithFields(logger.Fields{
+		"job": e.name,
+	}).WithTracing(requestId))
+	ctx = logger.WithTrafficEntry(ctx, logger.WithTrafficTracing(ctx, requestId))
+
+	le := logger.FromContext(ctx)
+
+	if !e.running.CompareAndSwap(false, true) {
+		monitor.FromContext(ctx).Count(ctx, cmd, CodeJobSkipped, "overlap")
+		le.Warn("skip job run, previous run is not finished")
+		return
+	}
+	defer e.running.Store(false)
+
+	if e.locker != nil {
+		lk, err := e.locker.Acquire(ctx, e.name, e.lockTTL)
+		if err != nil {
+			monitor.FromContext(ctx).Count(ctx, cmd, CodeJobSkipped, "lock")
+			le.WithError(err).Info("skip job run, lock is not acquired")
+			return
+		}
+		defer func() {
+			// the lock may have expired and been taken by another instance
+			if err := lk.Release(ctx); err != nil {
+				le.WithError(err).Warn("release lock error")
+			}
+		}()
+	}
+
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	rec := monitor.BeginRecord(ctx, cmd)
+	start := time.Now()
+	le.Info("job run start")
+
+	err := e.safeRun(ctx)
+
+	rec.EndWithError(err)
+	fields := logger.Fields{
+		"cost_ms": time.Since(start).Milliseconds(),
+		"code":    common.ErrorCode(err),
+	}
+	if err != nil {
+		le.WithError(err).WarnWith("job run failed", fields)
+		return
+	}
+	le.InfoWith("job run done", fields)
+}
+
+// safeRun runs the job and recovers its panic as error with CodeJobPanic
+func (e *entry) safeRun(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.FromContext(ctx).ErrorWith("job panic recovery", logger.Fields{
+				"panic": fmt.Sprintf("%v", r),
+				"stack": string(debug.Stack()),
+			})
+			err = common.NewValError(CodeJobPanic, fmt.Errorf("job panic: %v", r))
+		}
+	}()
+
+	return e.job(ctx)
+}
@@ -0,0 +1,127 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tenz-io/trackingo/cache"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+func TestRunner_run(t *testing.T) {
+	t.Run("when job panics then recover it and log the stack", func(t *testing.T) {
+		out, err := os.Create(filepath.Join(t.TempDir(), "info.log"))
+		if err != nil {
+			t.Fatalf("create log file error = %v", err)
+		}
+		defer out.Close()
+		logger.Configure(logger.Config{
+			LoggingLevel:          logger.InfoLevel,
+			ConsoleLoggingEnabled: true,
+			ConsoleInfoStream:     out,
+			ConsoleErrorStream:    out,
+			ConsoleDebugStream:    out,
+		})
+		defer logger.Configure(logger.Config{LoggingLevel: logger.InfoLevel})
+
+		e := &entry{
+			name: "panic",
+			job: func(ctx context.Context) error {
+				panic("boom")
+			},
+		}
+		(&runner{}).run(e)
+		if e.running.Load() {
+			t.Errorf("run() want running reset after panic")
+		}
+
+		logger.Sync()
+		bs, _ := os.ReadFile(out.Name())
+		if log := string(bs); !strings.Contains(log, "job panic recovery") || !strings.Contains(log, "cron.(*entry).safeRun") {
+			t.Errorf("log = %s, want the panic with the stack", log)
+		}
+	})
+
+	t.Run("when previous run not finished then skip", func(t *testing.T) {
+		var calls atomic.Int32
+		e := &entry{
+			name: "overlap",
+			job: func(ctx context.Context) error {
+				calls.Add(1)
+				return nil
+			},
+		}
+		e.running.Store(true)
+		(&runner{}).run(e)
+		if calls.Load() != 0 {
+			t.Errorf("run() calls = %v, want 0", calls.Load())
+		}
+	})
+
+	t.Run("when lock is held then skip", func(t *testing.T) {
+		var calls atomic.Int32
+		locker := cache.NewLocal()
+		_ = locker.Set(context.Background(), lockKeyPrefix+"locked", "other", time.Minute)
+		e := &entry{
+			name:    "locked",
+			locker:  cache.NewLocker(locker, cache.WithLockPrefix(lockKeyPrefix)),
+			lockTTL: time.Minute,
+			job: func(ctx context.Context) error {
+				calls.Add(1)
+				return nil
+			},
+		}
+		(&runner{}).run(e)
+		if calls.Load() != 0 {
+			t.Errorf("run() calls = %v, want 0", calls.Load())
+		}
+	})
+
+	t.Run("when lock acquired then run and release it", func(t *testing.T) {
+		var requestId string
+		locker := cache.NewLocal()
+		e := &entry{
+			name:    "unlocked",
+			locker:  cache.NewLocker(locker, cache.WithLockPrefix(lockKeyPrefix)),
+			lockTTL: time.Minute,
+			job: func(ctx context.Context) error {
+				requestId = tracking.RequestId(ctx)
+				return nil
+			},
+		}
+		(&runner{}).run(e)
+		if requestId == "" {
+			t.Errorf("run() want requestId in ctx")
+		}
+		if _, err := locker.Get(context.Background(), lockKeyPrefix+"unlocked"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("run() want lock released, got err %v", err)
+		}
+	})
+}
+
+func TestRunner_Register(t *testing.T) {
+	r := NewRunner()
+	job := func(ctx context.Context) error { return nil }
+
+	if err := r.Register("job", "@every 1h", job); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register("job", "@every 1h", job); !errors.Is(err, ErrJobExists) {
+		t.Errorf("Register() error = %v, want %v", err, ErrJobExists)
+	}
+	if err := r.Register("bad", "bad spec", job); err == nil {
+		t.Errorf("Register() want error of bad spec")
+	}
+
+	r.Start()
+	if err := r.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+}
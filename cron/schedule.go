@@ -0,0 +1,194 @@
+package cron
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule tells the next run time of a job
+type Schedule interface {
+	// Next returns the first run time after t, zero time if never
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule parses the standard 5 fields cron spec "minute hour day-of-month month day-of-week",
+// fields support *, lists, ranges and steps, e.g. "*/5 9-18 * * 1-5".
+// descriptors @hourly, @daily (@midnight), @weekly, @monthly, @yearly (@annually)
+// and @every <duration>, e.g. "@every 30s", are supported as well
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("parse interval of %q error: %w", spec, err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("interval of %q must be positive", spec)
+		}
+		return everySchedule(interval), nil
+	}
+
+	switch spec {
+	case "@yearly", "@annually":
+		spec = "0 0 1 1 *"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@hourly":
+		spec = "0 * * * *"
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("spec %q must have 5 fields", spec)
+	}
+
+	var (
+		s   = &specSchedule{}
+		err error
+	)
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// both 0 and 7 are sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return s, nil
+}
+
+// everySchedule runs every fixed interval
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s)).Truncate(time.Second)
+}
+
+// specSchedule is the parsed cron spec, every field is a bitset of the allowed values
+type specSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// when both day-of-month and day-of-week are restricted, a day matching either one is allowed
+	domStar, dowStar bool
+}
+
+// maxSearchYears stops searching for specs never matching, e.g. "0 0 30 2 *"
+const maxSearchYears = 5
+
+func (s *specSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *specSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseField parses a comma separated list of *, n, n-m with optional /step to a bitset
+func parseField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		bitsOfPart, err := parseRange(part, min, max)
+		if err != nil {
+			return 0, fmt.Errorf("parse field %q error: %w", field, err)
+		}
+		set |= bitsOfPart
+	}
+	if bits.OnesCount64(set) == 0 {
+		return 0, fmt.Errorf("field %q allows no value", field)
+	}
+	return set, nil
+}
+
+func parseRange(part string, min, max int) (uint64, error) {
+	var (
+		start, end = min, max
+		step       = 1
+		err        error
+	)
+
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+	if hasStep {
+		if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", stepPart)
+		}
+	}
+
+	switch {
+	case rangePart == "*":
+	case strings.Contains(rangePart, "-"):
+		lo, hi, _ := strings.Cut(rangePart, "-")
+		if start, err = strconv.Atoi(lo); err != nil {
+			return 0, fmt.Errorf("invalid value %q", lo)
+		}
+		if end, err = strconv.Atoi(hi); err != nil {
+			return 0, fmt.Errorf("invalid value %q", hi)
+		}
+	default:
+		if start, err = strconv.Atoi(rangePart); err != nil {
+			return 0, fmt.Errorf("invalid value %q", rangePart)
+		}
+		end = start
+		if hasStep {
+			end = max
+		}
+	}
+
+	if start < min || end > max || start > end {
+		return 0, fmt.Errorf("range %q out of [%d, %d]", rangePart, min, max)
+	}
+
+	var set uint64
+	for i := start; i <= end; i += step {
+		set |= 1 << uint(i)
+	}
+	return set, nil
+}
@@ -0,0 +1,91 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 7, 30, 0, time.UTC) // wednesday
+	tests := []struct {
+		name    string
+		spec    string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "when every minute then return next minute",
+			spec: "* * * * *",
+			want: time.Date(2024, 1, 31, 10, 8, 0, 0, time.UTC),
+		},
+		{
+			name: "when step minutes then return next multiple",
+			spec: "*/15 * * * *",
+			want: time.Date(2024, 1, 31, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name: "when daily then return next midnight",
+			spec: "@daily",
+			want: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "when weekdays range then skip weekend",
+			spec: "0 9 * * 1-5",
+			want: time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "when sunday is 7 then return sunday",
+			spec: "30 8 * * 7",
+			want: time.Date(2024, 2, 4, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "when day of month not in this month then return next month",
+			spec: "0 0 29 2 *",
+			want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "when every interval then add it",
+			spec: "@every 90s",
+			want: time.Date(2024, 1, 31, 10, 9, 0, 0, time.UTC),
+		},
+		{
+			name:    "when fields missing then return error",
+			spec:    "* * *",
+			wantErr: true,
+		},
+		{
+			name:    "when value out of range then return error",
+			spec:    "60 * * * *",
+			wantErr: true,
+		},
+		{
+			name:    "when interval is invalid then return error",
+			spec:    "@every soon",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := s.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecSchedule_Next_never(t *testing.T) {
+	s, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseSchedule() error = %v", err)
+	}
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() = %v, want zero", got)
+	}
+}
@@ -0,0 +1,341 @@
+package dborm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/tenz-io/trackingo/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	auditPluginName    = "trackingo:audit"
+	auditBeforeKey     = "trackingo:audit_before"
+	auditConditionKey  = "trackingo:audit_condition"
+	defaultAuditMaxRow = 100
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditRecord is the data change of one statement
+type AuditRecord struct {
+	Table  string           `json:"table"`
+	Action string           `json:"action"`
+	Rows   int64            `json:"rows"`
+	Before []map[string]any `json:"before,omitempty"`
+	After  []map[string]any `json:"after,omitempty"`
+}
+
+// AuditSink receives the audit records
+type AuditSink interface {
+	Audit(ctx context.Context, rec *AuditRecord)
+}
+
+// LogAuditSink writes audit records to the audit log of logger with the requestId of ctx,
+// see logger.ConfigureAuditLog
+type LogAuditSink struct{}
+
+func (s LogAuditSink) Audit(ctx context.Context, rec *AuditRecord) {
+	logger.Audit(ctx, "data change audit", logger.Fields{
+		"audit": rec,
+	})
+}
+
+type auditPlugin struct {
+	cfg    *AuditConfig
+	tables map[string]bool
+	sink   AuditSink
+}
+
+// NewAuditPlugin creates a gorm plugin recording the before and after values
+// of create/update/delete on the audited tables to sink, LogAuditSink if nil
+func NewAuditPlugin(cfg *AuditConfig, sink AuditSink) gorm.Plugin {
+	if sink == nil {
+		sink = LogAuditSink{}
+	}
+
+	tables := make(map[string]bool, len(cfg.Tables))
+	for _, table := range cfg.Tables {
+		tables[table] = true
+	}
+
+	return &auditPlugin{
+		cfg:    cfg,
+		tables: tables,
+		sink:   sink,
+	}
+}
+
+func (p *auditPlugin) Name() string {
+	return auditPluginName
+}
+
+func (p *auditPlugin) Initialize(db *gorm.DB) (err error) {
+	err = db.Callback().Create().After("gorm:create").Register("audit_after_create", p.afterCreate)
+	if err != nil {
+		return fmt.Errorf("register audit_after_create error: %w", err)
+	}
+
+	err = db.Callback().Update().Before("gorm:update").Register("audit_before_update", p.before)
+	if err != nil {
+		return fmt.Errorf("register audit_before_update error: %w", err)
+	}
+
+	err = db.Callback().Update().After("gorm:update").Register("audit_after_update", p.afterUpdate)
+	if err != nil {
+		return fmt.Errorf("register audit_after_update error: %w", err)
+	}
+
+	err = db.Callback().Delete().Before("gorm:delete").Register("audit_before_delete", p.before)
+	if err != nil {
+		return fmt.Errorf("register audit_before_delete error: %w", err)
+	}
+
+	err = db.Callback().Delete().After("gorm:delete").Register("audit_after_delete", p.afterDelete)
+	if err != nil {
+		return fmt.Errorf("register audit_after_delete error: %w", err)
+	}
+
+	return nil
+}
+
+func (p *auditPlugin) audited(db *gorm.DB) bool {
+	if db.Error != nil || db.DryRun || db.Statement.Table == "" {
+		return false
+	}
+	return len(p.tables) == 0 || p.tables[db.Statement.Table]
+}
+
+// before snapshots the rows about to change
+func (p *auditPlugin) before(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+
+	exprs := conditionsOf(db.Statement)
+	if len(exprs) == 0 {
+		// global update/delete will be rejected by gorm
+		return
+	}
+
+	rows, err := p.find(db, exprs)
+	if err != nil {
+		logger.FromContext(db.Statement.Context).WithError(err).Warn("audit snapshot error")
+		return
+	}
+
+	db.InstanceSet(auditConditionKey, exprs)
+	db.InstanceSet(auditBeforeKey, rows)
+}
+
+func (p *auditPlugin) afterCreate(db *gorm.DB) {
+	if !p.audited(db) || db.Statement.Schema == nil {
+		return
+	}
+
+	var (
+		after []map[string]any
+		ctx   = db.Statement.Context
+		rv    = reflect.Indirect(db.Statement.ReflectValue)
+	)
+
+	appendRow := func(v reflect.Value) {
+		row := make(map[string]any, len(db.Statement.Schema.DBNames))
+		for _, name := range db.Statement.Schema.DBNames {
+			if field := db.Statement.Schema.LookUpField(name); field != nil {
+				row[name], _ = field.ValueOf(ctx, v)
+			}
+		}
+		after = append(after, row)
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		appendRow(rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len() && i < p.maxRows(); i++ {
+			appendRow(reflect.Indirect(rv.Index(i)))
+		}
+	default:
+		// map values are not supported
+	}
+
+	p.emit(db, AuditActionCreate, nil, after)
+}
+
+func (p *auditPlugin) afterUpdate(db *gorm.DB) {
+	before, exprs, ok := p.snapshot(db)
+	if !ok {
+		return
+	}
+
+	// find the changed rows again by primary key,
+	// the original conditions may not match after update
+	if pk := primaryKeyConditions(db.Statement.Schema, before); pk != nil {
+		exprs = []clause.Expression{pk}
+	}
+
+	after, err := p.find(db, exprs)
+	if err != nil {
+		logger.FromContext(db.Statement.Context).WithError(err).Warn("audit snapshot error")
+	}
+
+	p.emit(db, AuditActionUpdate, before, after)
+}
+
+func (p *auditPlugin) afterDelete(db *gorm.DB) {
+	before, _, ok := p.snapshot(db)
+	if !ok {
+		return
+	}
+
+	p.emit(db, AuditActionDelete, before, nil)
+}
+
+// snapshot returns the rows and conditions saved by before
+func (p *auditPlugin) snapshot(db *gorm.DB) (before []map[string]any, exprs []clause.Expression, ok bool) {
+	if !p.audited(db) {
+		return nil, nil, false
+	}
+
+	val, ok := db.InstanceGet(auditBeforeKey)
+	if !ok {
+		return nil, nil, false
+	}
+	before, _ = val.([]map[string]any)
+
+	val, ok = db.InstanceGet(auditConditionKey)
+	if !ok {
+		return nil, nil, false
+	}
+	exprs, _ = val.([]clause.Expression)
+
+	return before, exprs, true
+}
+
+// find selects the rows matching exprs with the same connection,
+// so that it sees the same transaction as the statement
+func (p *auditPlugin) find(db *gorm.DB, exprs []clause.Expression) (rows []map[string]any, err error) {
+	err = db.Session(&gorm.Session{NewDB: true}).
+		Table(db.Statement.Table).
+		Clauses(clause.Where{Exprs: exprs}).
+		Limit(p.maxRows()).
+		Find(&rows).Error
+	return rows, err
+}
+
+func (p *auditPlugin) emit(db *gorm.DB, action string, before, after []map[string]any) {
+	table := db.Statement.Table
+	p.sink.Audit(db.Statement.Context, &AuditRecord{
+		Table:  table,
+		Action: action,
+		Rows:   db.RowsAffected,
+		Before: p.filter(table, before),
+		After:  p.filter(table, after),
+	})
+}
+
+// filter keeps the configured columns of table and masks the sensitive ones
+func (p *auditPlugin) filter(table string, rows []map[string]any) []map[string]any {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns, limited := p.cfg.Columns[table]
+	maskColumns := p.cfg.MaskColumns
+	if maskColumns == nil {
+		maskColumns = defaultMaskColumns
+	}
+
+	filtered := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		newRow := make(map[string]any, len(row))
+		for column, val := range row {
+			if limited && !contains(columns, column) {
+				continue
+			}
+			if sensitiveColumn(column, maskColumns) {
+				val = maskedVal
+			}
+			if bs, ok := val.([]byte); ok {
+				val = string(bs)
+			}
+			newRow[column] = val
+		}
+		filtered = append(filtered, newRow)
+	}
+	return filtered
+}
+
+func (p *auditPlugin) maxRows() int {
+	if p.cfg.MaxRows <= 0 {
+		return defaultAuditMaxRow
+	}
+	return p.cfg.MaxRows
+}
+
+// conditionsOf returns the where conditions of stmt,
+// including the primary key of the model which gorm adds while building
+func conditionsOf(stmt *gorm.Statement) []clause.Expression {
+	var exprs []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			exprs = append(exprs, where.Exprs...)
+		}
+	}
+
+	if stmt.Schema != nil && stmt.ReflectValue.IsValid() {
+		if rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() == reflect.Struct {
+			for _, field := range stmt.Schema.PrimaryFields {
+				if val, zero := field.ValueOf(stmt.Context, rv); !zero {
+					exprs = append(exprs, clause.Eq{
+						Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+						Value:  val,
+					})
+				}
+			}
+		}
+	}
+
+	return exprs
+}
+
+// primaryKeyConditions returns the IN condition of the primary key of rows,
+// nil if the primary key is not a single column
+func primaryKeyConditions(sch *schema.Schema, rows []map[string]any) clause.Expression {
+	if sch == nil || len(sch.PrimaryFieldDBNames) != 1 || len(rows) == 0 {
+		return nil
+	}
+
+	column := sch.PrimaryFieldDBNames[0]
+	values := make([]any, 0, len(rows))
+	for _, row := range rows {
+		if val, ok := row[column]; ok {
+			values = append(values, val)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	return clause.IN{
+		Column: clause.Column{Table: clause.CurrentTable, Name: column},
+		Values: values,
+	}
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
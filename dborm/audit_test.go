@@ -0,0 +1,154 @@
+package dborm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tenz-io/trackingo/logger"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type auditUser struct {
+	ID       int
+	Name     string
+	Age      int
+	Password string
+}
+
+type auditOrder struct {
+	ID int
+}
+
+// memAuditSink keeps the audit records
+type memAuditSink struct {
+	records []*AuditRecord
+}
+
+func (s *memAuditSink) Audit(_ context.Context, rec *AuditRecord) {
+	s.records = append(s.records, rec)
+}
+
+func TestAuditPlugin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: gormlogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite error = %v", err)
+	}
+	if err = db.AutoMigrate(&auditUser{}, &auditOrder{}); err != nil {
+		t.Fatalf("migrate error = %v", err)
+	}
+
+	sink := &memAuditSink{}
+	err = db.Use(NewAuditPlugin(&AuditConfig{
+		Tables:  []string{"audit_users"},
+		Columns: map[string][]string{"audit_users": {"id", "age", "password"}},
+	}, sink))
+	if err != nil {
+		t.Fatalf("use audit plugin error = %v", err)
+	}
+
+	// last returns the only record audited since the last call
+	last := func(t *testing.T) *AuditRecord {
+		t.Helper()
+		defer func() { sink.records = nil }()
+		if len(sink.records) != 1 {
+			t.Fatalf("audit records = %+v, want 1", sink.records)
+		}
+		return sink.records[0]
+	}
+
+	t.Run("when create then the after is the configured columns with the sensitive masked", func(t *testing.T) {
+		if err := db.Create(&auditUser{ID: 1, Name: "alice", Age: 20, Password: "pwd"}).Error; err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		rec := last(t)
+		if rec.Table != "audit_users" || rec.Action != AuditActionCreate || rec.Rows != 1 || rec.Before != nil {
+			t.Fatalf("audit record = %+v", rec)
+		}
+		want := map[string]any{"id": 1, "age": 20, "password": maskedVal}
+		if len(rec.After) != 1 || !sameRow(rec.After[0], want) {
+			t.Errorf("after = %v, want [%v]", rec.After, want)
+		}
+	})
+
+	t.Run("when update then the before and after are captured", func(t *testing.T) {
+		if err := db.Model(&auditUser{}).Where("name = ?", "alice").Update("age", 21).Error; err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		rec := last(t)
+		if rec.Action != AuditActionUpdate || rec.Rows != 1 {
+			t.Fatalf("audit record = %+v", rec)
+		}
+		before := map[string]any{"id": 1, "age": 20, "password": maskedVal}
+		after := map[string]any{"id": 1, "age": 21, "password": maskedVal}
+		if len(rec.Before) != 1 || !sameRow(rec.Before[0], before) {
+			t.Errorf("before = %v, want [%v]", rec.Before, before)
+		}
+		if len(rec.After) != 1 || !sameRow(rec.After[0], after) {
+			t.Errorf("after = %v, want [%v]", rec.After, after)
+		}
+	})
+
+	t.Run("when delete then the before is captured without after", func(t *testing.T) {
+		if err := db.Delete(&auditUser{ID: 1}).Error; err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		rec := last(t)
+		before := map[string]any{"id": 1, "age": 21, "password": maskedVal}
+		if rec.Action != AuditActionDelete || len(rec.Before) != 1 || !sameRow(rec.Before[0], before) || rec.After != nil {
+			t.Errorf("audit record = %+v, want before [%v]", rec, before)
+		}
+	})
+
+	t.Run("when the table is not audited then no record", func(t *testing.T) {
+		if err := db.Create(&auditOrder{ID: 1}).Error; err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if len(sink.records) != 0 {
+			t.Errorf("audit records = %+v, want none", sink.records)
+		}
+	})
+}
+
+// sameRow compares the row with want by their json, which ignores the int types of the drivers
+func sameRow(row, want map[string]any) bool {
+	got, _ := json.Marshal(row)
+	exp, _ := json.Marshal(want)
+	return string(got) == string(exp)
+}
+
+func TestLogAuditSink(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	logger.ConfigureAuditLog(logger.AuditLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+	})
+	defer logger.ConfigureAuditLog(logger.AuditLogConfig{})
+
+	LogAuditSink{}.Audit(context.Background(), &AuditRecord{Table: "users", Action: AuditActionDelete, Rows: 1})
+	logger.Sync()
+
+	bs, _ := os.ReadFile(out.Name())
+	var got struct {
+		Msg   string      `json:"msg"`
+		Audit AuditRecord `json:"audit"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(bs))), &got); err != nil {
+		t.Fatalf("unmarshal %s error = %v", bs, err)
+	}
+	if got.Msg != "data change audit" || got.Audit.Table != "users" || got.Audit.Action != AuditActionDelete {
+		t.Errorf("audit log = %s", bs)
+	}
+}
@@ -0,0 +1,31 @@
+package dborm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tenz-io/trackingo/breaker"
+)
+
+// BreakerHook guards the statements with b under key, e.g. the database name,
+// statements fail fast with breaker.ErrOpen while the database keeps failing.
+// only the transient errors and timeouts count as failures, see IsTransient
+func BreakerHook(b breaker.Breaker, key string) Hook {
+	return Hook{
+		Name: "breaker",
+		Before: func(ctx context.Context, ev *HookEvent) error {
+			return b.Allow(key)
+		},
+		After: func(ctx context.Context, ev *HookEvent) {
+			// the statements rejected by Allow or aborted before it are not the results of the database
+			if !ev.Passed || errors.Is(ev.Err, breaker.ErrOpen) {
+				return
+			}
+			err := ev.Err
+			if !IsTransient(err) && !errors.Is(err, context.DeadlineExceeded) {
+				err = nil
+			}
+			b.Record(key, err)
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package dborm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tenz-io/trackingo/breaker"
+	"gorm.io/gorm"
+)
+
+// fakeBreaker allows the calls unless open, and keeps the recorded results
+type fakeBreaker struct {
+	breaker.Breaker
+	open    bool
+	allows  int
+	records []error
+}
+
+func (b *fakeBreaker) Allow(string) error {
+	b.allows++
+	if b.open {
+		return breaker.ErrOpen
+	}
+	return nil
+}
+
+func (b *fakeBreaker) Record(_ string, err error) {
+	b.records = append(b.records, err)
+}
+
+func TestBreakerHook(t *testing.T) {
+	m := &manager{cfg: &Config{}, db: newDryRunDB(t), active: true}
+	if err := m.registerHooks(); err != nil {
+		t.Fatalf("registerHooks() error = %v", err)
+	}
+
+	errDeny := errors.New("deny")
+	_ = m.AddHook(Hook{
+		Name: "deny_delete",
+		Ops:  []HookOp{HookDelete},
+		Before: func(ctx context.Context, ev *HookEvent) error {
+			return errDeny
+		},
+	})
+	b := &fakeBreaker{}
+	_ = m.AddHook(BreakerHook(b, "db"))
+
+	t.Run("when allowed then the result is recorded", func(t *testing.T) {
+		b.open, b.allows, b.records = false, 0, nil
+		var items []pageItem
+		if err := m.db.Find(&items).Error; err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		if b.allows != 1 || len(b.records) != 1 || b.records[0] != nil {
+			t.Errorf("allows = %v, records = %v, want 1 and [nil]", b.allows, b.records)
+		}
+	})
+
+	t.Run("when rejected by the breaker then nothing is recorded", func(t *testing.T) {
+		b.open, b.allows, b.records = true, 0, nil
+		var items []pageItem
+		if err := m.db.Find(&items).Error; !errors.Is(err, breaker.ErrOpen) {
+			t.Fatalf("Find() error = %v, want ErrOpen", err)
+		}
+		if b.allows != 1 || len(b.records) != 0 {
+			t.Errorf("allows = %v, records = %v, want 1 and none", b.allows, b.records)
+		}
+	})
+
+	t.Run("when aborted by a hook before the breaker then nothing is recorded", func(t *testing.T) {
+		b.open, b.allows, b.records = false, 0, nil
+		err := m.db.Session(&gorm.Session{SkipDefaultTransaction: true}).Where("id = ?", 1).Delete(&pageItem{}).Error
+		if !errors.Is(err, errDeny) {
+			t.Fatalf("Delete() error = %v, want %v", err, errDeny)
+		}
+		if b.allows != 0 || len(b.records) != 0 {
+			t.Errorf("allows = %v, records = %v, want none", b.allows, b.records)
+		}
+	})
+}
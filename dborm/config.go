@@ -8,13 +8,24 @@ import (
 type Config struct {
 	Username       string        `yaml:"username" json:"username"`
 	Password       string        `yaml:"password" json:"password"`
-	Dbname         string        `yaml:"dbname" json:"dbname"`
-	Host           string        `yaml:"host" json:"host"`
+	Dbname         string        `yaml:"dbname" json:"dbname" required:"true"`
+	Host           string        `yaml:"host" json:"host" required:"true"`
 	Port           int           `yaml:"port" json:"port"`
 	MaxOpenConn    int           `yaml:"max_open_conn" json:"max_open_conn" default:"10"`
 	MaxIdleConn    int           `yaml:"max_idle_conn" json:"max_idle_conn" default:"5"`
 	MaxLifetime    time.Duration `yaml:"max_lifetime" json:"max_lifetime" default:"300s"`
 	EnableTracking bool          `yaml:"enable_tracking" json:"enable_tracking" default:"true"`
+	QueryTimeout   time.Duration `yaml:"query_timeout" json:"query_timeout" default:"10s"`
+	MaskColumns    []string      `yaml:"mask_columns" json:"mask_columns"`
+	MaskPositions  []int         `yaml:"mask_positions" json:"mask_positions"`
+	MaxSQLLength   int           `yaml:"max_sql_length" json:"max_sql_length" default:"1024"`
+	SlowThreshold  time.Duration `yaml:"slow_threshold" json:"slow_threshold" default:"1s"`
+	ExplainSlow    bool          `yaml:"explain_slow" json:"explain_slow" default:"false"`
+	ExplainRatio   float64       `yaml:"explain_ratio" json:"explain_ratio" default:"0.1"`
+	Audit          *AuditConfig  `yaml:"audit" json:"audit"`
+	LogLevel       string        `yaml:"log_level" json:"log_level" default:"warn"` // gorm log level: silent, error, warn or info
+	ReadOnlyMode   string        `yaml:"read_only_mode" json:"read_only_mode"`      // reject or skip the write statements, disabled if empty
+	Dependency     string        `yaml:"dependency" json:"dependency"`              // declared dependency name, tags the metrics and traffic logs
 }
 
 func (dc *Config) GetDSN() string {
@@ -27,3 +38,28 @@ func (dc *Config) GetDSN() string {
 		dc.Dbname,
 	)
 }
+
+// GetMaxLifetime returns the max lifetime of connections,
+// values less than a second are plain numbers of seconds, e.g. 300 from yaml
+func (dc *Config) GetMaxLifetime() time.Duration {
+	if dc.MaxLifetime < time.Second {
+		return dc.MaxLifetime * time.Second
+	}
+	return dc.MaxLifetime
+}
+
+type AuditConfig struct {
+	Tables      []string            `yaml:"tables" json:"tables"`
+	Columns     map[string][]string `yaml:"columns" json:"columns"`
+	MaskColumns []string            `yaml:"mask_columns" json:"mask_columns"`
+	MaxRows     int                 `yaml:"max_rows" json:"max_rows" default:"100"`
+}
+
+type ShardConfig struct {
+	Shards         []*Config     `yaml:"shards" json:"shards"`
+	HealthInterval time.Duration `yaml:"health_interval" json:"health_interval" default:"10s"`
+}
+
+type RegistryConfig struct {
+	Databases map[string]*Config `yaml:"databases" json:"databases"`
+}
@@ -2,12 +2,12 @@ package dborm
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"gorm.io/gorm/logger"
 	syslog "log"
 	"sync"
-	"time"
 
+	"github.com/tenz-io/trackingo/dependencies"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -18,6 +18,11 @@ var (
 
 type Manager interface {
 	GetDB(ctx context.Context) (*gorm.DB, error)
+	// GetSQLDB returns a *sql.DB whose queries and execs are tracked like the gorm statements,
+	// for sqlx or hand-written SQL. it has its own connection pool with the same settings
+	GetSQLDB(ctx context.Context) (*sql.DB, error)
+	// AddHook adds a hook called around the query/create/update/delete statements
+	AddHook(hook Hook) error
 	Active() bool
 }
 
@@ -26,27 +31,50 @@ type manager struct {
 	db     *gorm.DB
 	active bool
 	lock   sync.RWMutex
+	name   string // database name in registry, empty if not registered
+	shard  string // shard name, empty if not sharded
+	// dependency is the label of the declared dependency of Config.Dependency
+	dependency string
+
+	sqlDB     *sql.DB
+	sqlDBErr  error
+	sqlDBOnce sync.Once
+
+	hooks     []Hook
+	hooksLock sync.RWMutex
 }
 
 func NewManager(
 	cfg *Config,
 ) (Manager, error) {
+	return newManager(cfg, "", ""), nil
+}
+
+func newManager(cfg *Config, name, shard string) *manager {
+	dependency := dependencies.Resolve(cfg.Dependency, dependencies.TypeMySQL).Label()
+	if name == "" {
+		// the declared dependency tells the databases apart when not registered
+		name = dependency
+	}
 	m := &manager{
-		cfg: cfg,
+		cfg:        cfg,
+		name:       name,
+		shard:      shard,
+		dependency: dependency,
 	}
 
 	if err := m.connect(); err != nil {
 		syslog.Println("[DB] connect database error: ", err)
-		return m, nil
+		return m
 	}
 
 	if err := m.applyPlugins(); err != nil {
 		syslog.Println("[DB] apply plugins error: ", err)
-		return m, nil
+		return m
 	}
 
 	m.active = true
-	return m, nil
+	return m
 }
 
 func (m *manager) connect() (err error) {
@@ -58,10 +86,7 @@ func (m *manager) connect() (err error) {
 	dsn := m.cfg.GetDSN()
 
 	m.db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.New(
-			emptyLog{},
-			logger.Config{},
-		),
+		Logger: newGormLog(m),
 	})
 
 	if err != nil {
@@ -76,7 +101,7 @@ func (m *manager) connect() (err error) {
 
 	sqlDB.SetMaxIdleConns(m.cfg.MaxIdleConn)
 	sqlDB.SetMaxOpenConns(m.cfg.MaxOpenConn)
-	sqlDB.SetConnMaxLifetime(time.Duration(m.cfg.MaxLifetime) * time.Second)
+	sqlDB.SetConnMaxLifetime(m.cfg.GetMaxLifetime())
 
 	return nil
 }
@@ -103,9 +128,19 @@ func (m *manager) Active() bool {
 	return m.active
 }
 
-type emptyLog struct {
-}
+// ping verifies the connection to the database is still alive
+func (m *manager) ping(ctx context.Context) error {
+	if !m.Active() {
+		return ErrNotActive
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
 
-func (_ emptyLog) Printf(format string, args ...interface{}) {
-	// ignore
+	return sqlDB.PingContext(ctx)
 }
@@ -0,0 +1,190 @@
+package dborm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/tenz-io/trackingo/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	explainPluginName     = "trackingo:explain"
+	explainStartKey       = "trackingo:explain_start"
+	defaultSlowThreshold  = time.Second
+	defaultExplainTimeout = 5 * time.Second
+	defaultExplainRatio   = 0.1
+	maxExplainConcurrency = 2
+)
+
+// explainPlugin runs EXPLAIN for the sampled slow SELECT statements in background
+// and logs the plan, it's read only and never blocks the original query
+type explainPlugin struct {
+	threshold time.Duration
+	ratio     float64
+	sem       chan struct{}
+}
+
+func newExplainPlugin(cfg *Config) *explainPlugin {
+	threshold := cfg.SlowThreshold
+	if threshold <= 0 {
+		threshold = defaultSlowThreshold
+	}
+	ratio := cfg.ExplainRatio
+	if ratio <= 0 {
+		// the zero ratio of an unset Config would never explain
+		ratio = defaultExplainRatio
+	}
+
+	return &explainPlugin{
+		threshold: threshold,
+		ratio:     ratio,
+		sem:       make(chan struct{}, maxExplainConcurrency),
+	}
+}
+
+func (p *explainPlugin) Name() string {
+	return explainPluginName
+}
+
+func (p *explainPlugin) Initialize(db *gorm.DB) (err error) {
+	err = db.Callback().Query().Before("*").Register("start_query_explain", p.start)
+	if err != nil {
+		return fmt.Errorf("register start_explain error: %w", err)
+	}
+
+	err = db.Callback().Row().Before("*").Register("start_row_explain", p.start)
+	if err != nil {
+		return fmt.Errorf("register start_explain error: %w", err)
+	}
+
+	err = db.Callback().Query().After("*").Register("end_query_explain", p.end)
+	if err != nil {
+		return fmt.Errorf("register end_explain error: %w", err)
+	}
+
+	err = db.Callback().Row().After("*").Register("end_row_explain", p.end)
+	if err != nil {
+		return fmt.Errorf("register end_explain error: %w", err)
+	}
+
+	return nil
+}
+
+func (p *explainPlugin) start(db *gorm.DB) {
+	db.InstanceSet(explainStartKey, time.Now())
+}
+
+func (p *explainPlugin) end(db *gorm.DB) {
+	val, ok := db.InstanceGet(explainStartKey)
+	if !ok {
+		return
+	}
+	startTime, ok := val.(time.Time)
+	if !ok {
+		return
+	}
+
+	cost := time.Since(startTime)
+	if cost < p.threshold || rand.Float64() >= p.ratio {
+		return
+	}
+
+	query := db.Statement.SQL.String()
+	if !isSelect(query) {
+		return
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+
+	// skip if too many explains are running
+	select {
+	case p.sem <- struct{}{}:
+	default:
+		return
+	}
+
+	var (
+		le   = logger.FromContext(db.Statement.Context)
+		vars = append([]any{}, db.Statement.Vars...)
+	)
+
+	go func() {
+		defer func() {
+			<-p.sem
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultExplainTimeout)
+		defer cancel()
+
+		le = le.WithFields(logger.Fields{
+			"sql":  query,
+			"cost": cost.String(),
+		})
+
+		plan, err := explain(ctx, sqlDB, query, vars)
+		if err != nil {
+			le.WithError(err).Warn("explain slow query error")
+			return
+		}
+
+		le.WithField("plan", plan).Warn("slow query explain")
+	}()
+}
+
+// explain runs EXPLAIN of query in a read only transaction
+func explain(ctx context.Context, sqlDB *sql.DB, query string, vars []any) (plan []map[string]string, err error) {
+	tx, err := sqlDB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin read only tx error: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	rows, err := tx.QueryContext(ctx, "EXPLAIN "+query, vars...)
+	if err != nil {
+		return nil, fmt.Errorf("query explain error: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("get explain columns error: %w", err)
+	}
+
+	for rows.Next() {
+		vals := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan explain error: %w", err)
+		}
+
+		row := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if vals[i].Valid {
+				row[column] = vals[i].String
+			}
+		}
+		plan = append(plan, row)
+	}
+
+	return plan, rows.Err()
+}
+
+func isSelect(query string) bool {
+	query = strings.TrimSpace(query)
+	return len(query) >= 6 && strings.EqualFold(query[:6], "SELECT")
+}
@@ -0,0 +1,99 @@
+package dborm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tenz-io/trackingo/logger"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func Test_newExplainPlugin(t *testing.T) {
+	t.Run("when zero config then the defaults", func(t *testing.T) {
+		p := newExplainPlugin(&Config{})
+		if p.threshold != defaultSlowThreshold || p.ratio != defaultExplainRatio {
+			t.Errorf("threshold = %v, ratio = %v, want the defaults", p.threshold, p.ratio)
+		}
+	})
+
+	t.Run("when configured then kept", func(t *testing.T) {
+		p := newExplainPlugin(&Config{SlowThreshold: time.Millisecond, ExplainRatio: 1})
+		if p.threshold != time.Millisecond || p.ratio != 1 {
+			t.Errorf("threshold = %v, ratio = %v, want 1ms and 1", p.threshold, p.ratio)
+		}
+	})
+}
+
+func TestExplainPlugin(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "info.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	logger.Configure(logger.Config{
+		LoggingLevel:          logger.InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     out,
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    out,
+	})
+	defer logger.Configure(logger.Config{LoggingLevel: logger.InfoLevel})
+
+	type user struct {
+		ID  int
+		Age int
+	}
+
+	// query runs a select on a new sqlite with the explain plugin of cfg, returns the logs in 1s
+	query := func(t *testing.T, cfg *Config) string {
+		db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+			Logger: gormlogger.Discard,
+		})
+		if err != nil {
+			t.Fatalf("open sqlite error = %v", err)
+		}
+		if err = db.AutoMigrate(&user{}); err != nil {
+			t.Fatalf("migrate error = %v", err)
+		}
+		if err = db.Use(newExplainPlugin(cfg)); err != nil {
+			t.Fatalf("use explain plugin error = %v", err)
+		}
+		_ = out.Truncate(0)
+
+		var users []user
+		if err = db.WithContext(context.Background()).Where("age > ?", 1).Find(&users).Error; err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+
+		// the explain runs in background
+		var log string
+		for i := 0; i < 100 && !strings.Contains(log, "explain"); i++ {
+			time.Sleep(10 * time.Millisecond)
+			logger.Sync()
+			bs, _ := os.ReadFile(out.Name())
+			log = string(bs)
+		}
+		return log
+	}
+
+	t.Run("when slower than the threshold then the plan is logged", func(t *testing.T) {
+		log := query(t, &Config{SlowThreshold: time.Nanosecond, ExplainRatio: 1})
+		if !strings.Contains(log, "slow query explain") {
+			t.Errorf("log = %s, want the plan", log)
+		}
+	})
+
+	t.Run("when faster than the threshold then no explain", func(t *testing.T) {
+		log := query(t, &Config{SlowThreshold: time.Hour, ExplainRatio: 1})
+		if strings.Contains(log, "explain") {
+			t.Errorf("log = %s, want no explain", log)
+		}
+	})
+}
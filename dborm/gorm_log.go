@@ -0,0 +1,100 @@
+package dborm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/tenz-io/trackingo/logger"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// gormLog routes the logs of gorm to the logger of ctx, which carries the requestId
+type gormLog struct {
+	m     *manager
+	level gormlogger.LogLevel
+}
+
+func newGormLog(m *manager) *gormLog {
+	return &gormLog{
+		m:     m,
+		level: parseLogLevel(m.cfg.LogLevel),
+	}
+}
+
+// parseLogLevel parses silent/error/warn/info, default warn
+func parseLogLevel(level string) gormlogger.LogLevel {
+	switch strings.ToLower(level) {
+	case "silent":
+		return gormlogger.Silent
+	case "error":
+		return gormlogger.Error
+	case "info":
+		return gormlogger.Info
+	default:
+		return gormlogger.Warn
+	}
+}
+
+func (l *gormLog) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	newLog := *l
+	newLog.level = level
+	return &newLog
+}
+
+func (l *gormLog) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Info {
+		entry(ctx).Infof("[DB] "+msg, data...)
+	}
+}
+
+func (l *gormLog) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Warn {
+		entry(ctx).Warnf("[DB] "+msg, data...)
+	}
+}
+
+func (l *gormLog) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Error {
+		entry(ctx).Errorf("[DB] "+msg, data...)
+	}
+}
+
+func (l *gormLog) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	fields := func() logger.Fields {
+		sql, rows := fc()
+		return logger.Fields{
+			"sql":     logger.StringLimit(sql, l.m.cfg.MaxSQLLength),
+			"rows":    rows,
+			"elapsed": elapsed.Milliseconds(),
+		}
+	}
+
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		entry(ctx).WithError(err).ErrorWith("[DB] sql error", fields())
+	case l.m.cfg.SlowThreshold > 0 && elapsed > l.m.cfg.SlowThreshold && l.level >= gormlogger.Warn:
+		entry(ctx).WarnWith("[DB] slow sql", fields())
+	case l.level >= gormlogger.Info:
+		entry(ctx).InfoWith("[DB] sql", fields())
+	}
+}
+
+// ParamsFilter masks the sensitive values before gorm inlines them into the logged sql
+func (l *gormLog) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, maskVars(sql, params, l.m.cfg.MaskColumns, l.m.cfg.MaskPositions)
+}
+
+func entry(ctx context.Context) logger.Entry {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return logger.FromContext(ctx)
+}
@@ -0,0 +1,70 @@
+package dborm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func Test_parseLogLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		want  gormlogger.LogLevel
+	}{
+		{
+			name:  "when level is empty then return warn",
+			level: "",
+			want:  gormlogger.Warn,
+		},
+		{
+			name:  "when level is upper case then parse it",
+			level: "INFO",
+			want:  gormlogger.Info,
+		},
+		{
+			name:  "when level is silent then return silent",
+			level: "silent",
+			want:  gormlogger.Silent,
+		},
+		{
+			name:  "when level is unknown then return warn",
+			level: "verbose",
+			want:  gormlogger.Warn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLogLevel(tt.level); got != tt.want {
+				t.Errorf("parseLogLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_gormLog_ParamsFilter(t *testing.T) {
+	l := newGormLog(&manager{cfg: &Config{}})
+	sql := "SELECT * FROM `users` WHERE `password` = ? AND `age` > ?"
+
+	gotSQL, gotVars := l.ParamsFilter(context.Background(), sql, "secret", 18)
+	if gotSQL != sql {
+		t.Errorf("ParamsFilter() sql = %v, want %v", gotSQL, sql)
+	}
+	if want := []any{maskedVal, 18}; !reflect.DeepEqual(gotVars, want) {
+		t.Errorf("ParamsFilter() vars = %v, want %v", gotVars, want)
+	}
+}
+
+func Test_gormLog_LogMode(t *testing.T) {
+	l := newGormLog(&manager{cfg: &Config{}})
+
+	got := l.LogMode(gormlogger.Info).(*gormLog)
+	if got.level != gormlogger.Info {
+		t.Errorf("LogMode() level = %v, want %v", got.level, gormlogger.Info)
+	}
+	if l.level != gormlogger.Warn {
+		t.Errorf("LogMode() changed the original level to %v", l.level)
+	}
+}
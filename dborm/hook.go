@@ -0,0 +1,175 @@
+package dborm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	hookStartKey  = "trackingo:hook_start"
+	hookPassedKey = "trackingo:hook_passed"
+)
+
+type HookOp string
+
+const (
+	HookQuery  HookOp = "query"
+	HookCreate HookOp = "create"
+	HookUpdate HookOp = "update"
+	HookDelete HookOp = "delete"
+)
+
+// HookEvent describes the statement a hook is called on
+type HookEvent struct {
+	Op    HookOp
+	Table string
+	// SQL and Vars are built by gorm after the before hooks, so they are only set for the after hooks
+	SQL  string
+	Vars []any
+	// Duration, RowsAffected and Err are only set for the after hooks
+	Duration     time.Duration
+	RowsAffected int64
+	Err          error
+	// Passed is whether Before of the hook was called and returned nil, only set for the after hooks
+	Passed bool
+}
+
+// Hook is called around the statements of Ops, all ops if empty.
+// an error returned by Before aborts the statement with it
+type Hook struct {
+	Name   string
+	Ops    []HookOp
+	Before func(ctx context.Context, ev *HookEvent) error
+	After  func(ctx context.Context, ev *HookEvent)
+}
+
+func (h *Hook) match(op HookOp) bool {
+	if len(h.Ops) == 0 {
+		return true
+	}
+	for _, o := range h.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// AddHook adds hook to the statements of the manager, hooks are called in the order they are added
+func (m *manager) AddHook(hook Hook) error {
+	if m == nil {
+		return fmt.Errorf("db manager is nil")
+	}
+
+	if hook.Before == nil && hook.After == nil {
+		return fmt.Errorf("hook %s has neither before nor after", hook.Name)
+	}
+
+	m.hooksLock.Lock()
+	defer m.hooksLock.Unlock()
+
+	hooks := make([]Hook, len(m.hooks), len(m.hooks)+1)
+	copy(hooks, m.hooks)
+	m.hooks = append(hooks, hook)
+	return nil
+}
+
+func (m *manager) getHooks() []Hook {
+	m.hooksLock.RLock()
+	defer m.hooksLock.RUnlock()
+	return m.hooks
+}
+
+// registerHooks registers the callbacks which call the hooks around the gorm statements
+func (m *manager) registerHooks() (err error) {
+	callbacks := []struct {
+		op     HookOp
+		before func(name string, fn func(*gorm.DB)) error
+		after  func(name string, fn func(*gorm.DB)) error
+	}{
+		{HookQuery, m.db.Callback().Query().Before("gorm:query").Register, m.db.Callback().Query().After("gorm:query").Register},
+		{HookCreate, m.db.Callback().Create().Before("gorm:create").Register, m.db.Callback().Create().After("gorm:create").Register},
+		{HookUpdate, m.db.Callback().Update().Before("gorm:update").Register, m.db.Callback().Update().After("gorm:update").Register},
+		{HookDelete, m.db.Callback().Delete().Before("gorm:delete").Register, m.db.Callback().Delete().After("gorm:delete").Register},
+	}
+
+	for _, cb := range callbacks {
+		err = cb.before(fmt.Sprintf("trackingo:before_%s_hooks", cb.op), m.beforeHooks(cb.op))
+		if err != nil {
+			return fmt.Errorf("register before_%s_hooks error: %w", cb.op, err)
+		}
+
+		err = cb.after(fmt.Sprintf("trackingo:after_%s_hooks", cb.op), m.afterHooks(cb.op))
+		if err != nil {
+			return fmt.Errorf("register after_%s_hooks error: %w", cb.op, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *manager) beforeHooks(op HookOp) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		hooks := m.getHooks()
+		if len(hooks) == 0 {
+			return
+		}
+
+		db.InstanceSet(hookStartKey, time.Now())
+
+		passed := make([]bool, len(hooks))
+		db.InstanceSet(hookPassedKey, passed)
+		for i, hook := range hooks {
+			if hook.Before == nil || !hook.match(op) || db.Error != nil {
+				continue
+			}
+			ev := &HookEvent{
+				Op:    op,
+				Table: db.Statement.Table,
+			}
+			if err := hook.Before(db.Statement.Context, ev); err != nil {
+				_ = db.AddError(err)
+				continue
+			}
+			passed[i] = true
+		}
+	}
+}
+
+func (m *manager) afterHooks(op HookOp) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		hooks := m.getHooks()
+		if len(hooks) == 0 {
+			return
+		}
+
+		var duration time.Duration
+		if start, ok := db.InstanceGet(hookStartKey); ok {
+			duration = time.Since(start.(time.Time))
+		}
+
+		var passed []bool
+		if v, ok := db.InstanceGet(hookPassedKey); ok {
+			passed = v.([]bool)
+		}
+
+		for i, hook := range hooks {
+			if hook.After == nil || !hook.match(op) {
+				continue
+			}
+			hook.After(db.Statement.Context, &HookEvent{
+				Op:           op,
+				Table:        db.Statement.Table,
+				SQL:          db.Statement.SQL.String(),
+				Vars:         db.Statement.Vars,
+				Duration:     duration,
+				RowsAffected: db.RowsAffected,
+				Err:          db.Error,
+				Passed:       i < len(passed) && passed[i],
+			})
+		}
+	}
+}
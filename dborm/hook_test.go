@@ -0,0 +1,71 @@
+package dborm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestManager_AddHook(t *testing.T) {
+	m := &manager{cfg: &Config{}, db: newDryRunDB(t), active: true}
+	if err := m.registerHooks(); err != nil {
+		t.Fatalf("registerHooks() error = %v", err)
+	}
+
+	var (
+		befores []HookOp
+		afters  []*HookEvent
+		errDeny = errors.New("deny")
+	)
+	_ = m.AddHook(Hook{
+		Name: "recorder",
+		Before: func(ctx context.Context, ev *HookEvent) error {
+			befores = append(befores, ev.Op)
+			return nil
+		},
+		After: func(ctx context.Context, ev *HookEvent) {
+			afters = append(afters, ev)
+		},
+	})
+	_ = m.AddHook(Hook{
+		Name: "deny_delete",
+		Ops:  []HookOp{HookDelete},
+		Before: func(ctx context.Context, ev *HookEvent) error {
+			return errDeny
+		},
+	})
+
+	t.Run("when query then call before and after hooks", func(t *testing.T) {
+		befores, afters = nil, nil
+		var items []pageItem
+		if err := m.db.Where("name = ?", "foo").Find(&items).Error; err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		if len(befores) != 1 || befores[0] != HookQuery {
+			t.Errorf("before hooks = %v, want [%v]", befores, HookQuery)
+		}
+		if len(afters) != 1 || afters[0].Table != "page_items" || !strings.Contains(afters[0].SQL, "SELECT") {
+			t.Errorf("after hooks = %+v, want one query event on page_items", afters)
+		}
+	})
+
+	t.Run("when before hook returns error then abort the statement", func(t *testing.T) {
+		befores, afters = nil, nil
+		err := m.db.Session(&gorm.Session{SkipDefaultTransaction: true}).Where("id = ?", 1).Delete(&pageItem{}).Error
+		if !errors.Is(err, errDeny) {
+			t.Errorf("Delete() error = %v, want %v", err, errDeny)
+		}
+		if len(afters) != 1 || afters[0].SQL != "" || !errors.Is(afters[0].Err, errDeny) {
+			t.Errorf("after hooks = %+v, want one denied event without sql", afters)
+		}
+	})
+
+	t.Run("when hook has neither before nor after then return error", func(t *testing.T) {
+		if err := m.AddHook(Hook{Name: "empty"}); err == nil {
+			t.Errorf("AddHook() want error")
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package dborm
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	maskedVal = "***"
+)
+
+var (
+	// defaultMaskColumns is used when Config.MaskColumns is nil,
+	// a var is masked when its column name contains any of them
+	defaultMaskColumns = []string{
+		"password",
+		"passwd",
+		"secret",
+		"token",
+		"email",
+		"phone",
+		"mobile",
+		"card",
+	}
+
+	// sqlKeywords are the words which are not column names
+	sqlKeywords = map[string]bool{
+		"SELECT": true, "INSERT": true, "REPLACE": true, "UPDATE": true, "DELETE": true,
+		"INTO": true, "FROM": true, "WHERE": true, "SET": true, "VALUES": true,
+		"AND": true, "OR": true, "NOT": true, "IN": true, "LIKE": true, "REGEXP": true,
+		"BETWEEN": true, "IS": true, "NULL": true, "AS": true, "ON": true,
+		"DUPLICATE": true, "KEY": true, "JOIN": true, "LEFT": true, "RIGHT": true,
+		"INNER": true, "ORDER": true, "GROUP": true, "BY": true, "HAVING": true,
+		"ASC": true, "DESC": true, "DISTINCT": true, "CASE": true, "WHEN": true,
+		"THEN": true, "ELSE": true, "END": true, "LIMIT": true, "OFFSET": true,
+	}
+)
+
+// maskVars returns a copy of vars with sensitive values replaced by "***",
+// vars are masked by their positions or by the column names they are compared with
+func maskVars(sql string, vars []any, columns []string, positions []int) []any {
+	if len(vars) == 0 {
+		return vars
+	}
+
+	if columns == nil {
+		columns = defaultMaskColumns
+	}
+
+	masked := make([]any, len(vars))
+	copy(masked, vars)
+
+	for _, pos := range positions {
+		if pos >= 0 && pos < len(masked) {
+			masked[pos] = maskedVal
+		}
+	}
+
+	if len(columns) == 0 {
+		return masked
+	}
+
+	for i, column := range placeholderColumns(sql) {
+		if i >= len(masked) {
+			break
+		}
+		if sensitiveColumn(column, columns) {
+			masked[i] = maskedVal
+		}
+	}
+
+	return masked
+}
+
+// sensitiveColumn returns true if the column name contains one of the columns
+func sensitiveColumn(column string, columns []string) bool {
+	if column == "" {
+		return false
+	}
+
+	column = strings.ToLower(column)
+	for _, c := range columns {
+		if c != "" && strings.Contains(column, strings.ToLower(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholderColumns guesses the column name of every '?' placeholder in sql,
+// it's the column list for INSERT values, otherwise the nearest column before the placeholder.
+// the name is empty if no column is found
+func placeholderColumns(sql string) []string {
+	var (
+		columns     []string
+		lastIdent   string
+		insert      bool
+		insertCols  []string
+		inColList   bool
+		inValues    bool
+		valuesCount int
+		depth       int
+		firstWord   = true
+	)
+
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case ch == '\'' || ch == '"':
+			// skip string literal
+			for i++; i < len(sql) && sql[i] != ch; i++ {
+				if sql[i] == '\\' {
+					i++
+				}
+			}
+		case ch == '`':
+			end := strings.IndexByte(sql[i+1:], '`')
+			if end < 0 {
+				return columns
+			}
+			ident := sql[i+1 : i+1+end]
+			i += end + 1
+			if inColList {
+				insertCols = append(insertCols, ident)
+			}
+			lastIdent = ident
+		case ch == '?':
+			if inValues && len(insertCols) > 0 {
+				columns = append(columns, insertCols[valuesCount%len(insertCols)])
+				valuesCount++
+			} else {
+				columns = append(columns, lastIdent)
+			}
+		case ch == '(':
+			depth++
+			if insert && !inValues && depth == 1 && len(insertCols) == 0 {
+				inColList = true
+			}
+		case ch == ')':
+			depth--
+			inColList = false
+		case isIdentChar(ch):
+			start := i
+			for i+1 < len(sql) && isIdentChar(sql[i+1]) {
+				i++
+			}
+			word := sql[start : i+1]
+			upper := strings.ToUpper(word)
+
+			if firstWord {
+				firstWord = false
+				insert = upper == "INSERT" || upper == "REPLACE"
+			}
+
+			switch {
+			case upper == "VALUES" && insert:
+				inValues = true
+			case upper == "ON" && inValues:
+				// ON DUPLICATE KEY UPDATE
+				inValues = false
+			case upper == "LIMIT" || upper == "OFFSET":
+				lastIdent = ""
+			case sqlKeywords[upper] || unicode.IsDigit(rune(word[0])):
+				// not a column
+			default:
+				if inColList {
+					insertCols = append(insertCols, word)
+				}
+				lastIdent = word
+			}
+		default:
+			// operators, spaces and punctuations
+		}
+	}
+
+	return columns
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch == '$' ||
+		('a' <= ch && ch <= 'z') ||
+		('A' <= ch && ch <= 'Z') ||
+		('0' <= ch && ch <= '9')
+}
@@ -0,0 +1,80 @@
+package dborm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_maskVars(t *testing.T) {
+	type args struct {
+		sql       string
+		vars      []any
+		columns   []string
+		positions []int
+	}
+	tests := []struct {
+		name string
+		args args
+		want []any
+	}{
+		{
+			name: "when vars is empty then return empty",
+			args: args{
+				sql:  "SELECT * FROM `users`",
+				vars: []any{},
+			},
+			want: []any{},
+		},
+		{
+			name: "when where column is sensitive then mask it",
+			args: args{
+				sql:  "SELECT * FROM `users` WHERE `users`.`email` = ? AND `age` > ? LIMIT ?",
+				vars: []any{"foo@bar.com", 18, 1},
+			},
+			want: []any{maskedVal, 18, 1},
+		},
+		{
+			name: "when insert column is sensitive then mask it in every row",
+			args: args{
+				sql:  "INSERT INTO `users` (`name`,`password`) VALUES (?,?),(?,?)",
+				vars: []any{"a", "hash_a", "b", "hash_b"},
+			},
+			want: []any{"a", maskedVal, "b", maskedVal},
+		},
+		{
+			name: "when update column is sensitive then mask it",
+			args: args{
+				sql:  "UPDATE `users` SET `phone`=?,`updated_at`=? WHERE `id` IN (?,?)",
+				vars: []any{"123456", "now", 1, 2},
+			},
+			want: []any{maskedVal, "now", 1, 2},
+		},
+		{
+			name: "when position is given then mask it",
+			args: args{
+				sql:       "SELECT * FROM `users` WHERE `name` = ? AND `id` = ?",
+				vars:      []any{"foo", 1},
+				columns:   []string{},
+				positions: []int{1, 5},
+			},
+			want: []any{"foo", maskedVal},
+		},
+		{
+			name: "when question mark inside literal then ignore it",
+			args: args{
+				sql:     "SELECT * FROM `users` WHERE `note` = '?' AND `secret_key` = ?",
+				vars:    []any{"abc"},
+				columns: []string{"secret"},
+			},
+			want: []any{maskedVal},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := maskVars(tt.args.sql, tt.args.vars, tt.args.columns, tt.args.positions)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("maskVars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package dborm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	dsCmdPaginate   = "db_paginate"
+	defaultPageSize = 20
+	maxPageSize     = 1000
+)
+
+type PageMode int
+
+const (
+	// OffsetMode pages by Page number with LIMIT/OFFSET
+	OffsetMode PageMode = iota
+	// KeysetMode pages by Cursor with WHERE key > cursor ORDER BY key
+	KeysetMode
+)
+
+// PageRequest describes the page to fetch
+type PageRequest struct {
+	Mode PageMode
+	// Size is the number of items per page, default 20, at most 1000
+	Size int
+	// Page is the page number starting from 1, only for OffsetMode
+	Page int
+	// Cursor is the NextCursor of previous page, empty for the first page, only for KeysetMode
+	Cursor string
+	// KeyColumn is the unique ordered column, default "id", only for KeysetMode
+	KeyColumn string
+	// Desc orders by KeyColumn descending, only for KeysetMode
+	Desc bool
+	// WithTotal counts the total number of items
+	WithTotal bool
+}
+
+// Page is the result of Paginate
+type Page[T any] struct {
+	Items []T
+	// Total is the number of all items, only when PageRequest.WithTotal
+	Total int64
+	Page  int
+	Size  int
+	// NextCursor is the cursor of the next page, empty if no more items, only for KeysetMode
+	NextCursor string
+	HasMore    bool
+}
+
+// Paginate fetches one page of T with the conditions of db,
+// e.g. Paginate[User](ctx, db.Where("status = ?", 1), PageRequest{Size: 50})
+func Paginate[T any](ctx context.Context, db *gorm.DB, req PageRequest) (page Page[T], err error) {
+	rec := monitor.BeginRecord(ctx, dsCmdPaginate)
+	trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
+		Cmd: dsCmdPaginate,
+		Req: req,
+	}, logger.Fields{})
+	defer func() {
+		rec.EndWithError(err)
+		trafficRec.End(&logger.TrafficResp{
+			Code: common.ErrorCode(err),
+			Msg:  common.ErrorMsg(err),
+		}, logger.Fields{
+			"items":    len(page.Items),
+			"total":    page.Total,
+			"has_more": page.HasMore,
+		})
+	}()
+
+	req = normalizePageRequest(req)
+	page.Size = req.Size
+	page.Page = req.Page
+
+	tx := db.WithContext(ctx).Model(new(T)).Session(&gorm.Session{})
+
+	if req.WithTotal {
+		if err = tx.Count(&page.Total).Error; err != nil {
+			return page, fmt.Errorf("count error: %w", err)
+		}
+	}
+
+	switch req.Mode {
+	case KeysetMode:
+		tx, err = keysetQuery(tx, req)
+		if err != nil {
+			return page, err
+		}
+	default:
+		tx = tx.Offset((req.Page - 1) * req.Size)
+	}
+
+	var items []T
+	if err = tx.Limit(req.Size + 1).Find(&items).Error; err != nil {
+		return page, fmt.Errorf("find error: %w", err)
+	}
+
+	if len(items) > req.Size {
+		items = items[:req.Size]
+		page.HasMore = true
+	}
+	page.Items = items
+
+	if req.Mode == KeysetMode && page.HasMore {
+		page.NextCursor, err = nextCursor(tx, items[len(items)-1], req.KeyColumn)
+		if err != nil {
+			return page, err
+		}
+	}
+
+	return page, nil
+}
+
+func normalizePageRequest(req PageRequest) PageRequest {
+	if req.Size <= 0 {
+		req.Size = defaultPageSize
+	}
+	if req.Size > maxPageSize {
+		req.Size = maxPageSize
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.KeyColumn == "" {
+		req.KeyColumn = "id"
+	}
+	return req
+}
+
+// keysetQuery adds the cursor condition and the key order to tx
+func keysetQuery(tx *gorm.DB, req PageRequest) (*gorm.DB, error) {
+	column := clause.Column{Name: req.KeyColumn}
+
+	if req.Cursor != "" {
+		key, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return tx, err
+		}
+
+		if req.Desc {
+			tx = tx.Where(clause.Lt{Column: column, Value: key})
+		} else {
+			tx = tx.Where(clause.Gt{Column: column, Value: key})
+		}
+	}
+
+	return tx.Order(clause.OrderByColumn{Column: column, Desc: req.Desc}), nil
+}
+
+// nextCursor returns the cursor pointing after the given item
+func nextCursor[T any](tx *gorm.DB, item T, keyColumn string) (string, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(&item); err != nil {
+		return "", fmt.Errorf("parse schema error: %w", err)
+	}
+
+	field := stmt.Schema.LookUpField(keyColumn)
+	if field == nil {
+		return "", fmt.Errorf("key column %s not found", keyColumn)
+	}
+
+	key, _ := field.ValueOf(tx.Statement.Context, reflect.Indirect(reflect.ValueOf(&item)))
+	return encodeCursor(key)
+}
+
+func encodeCursor(key any) (string, error) {
+	bs, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor error: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bs), nil
+}
+
+func decodeCursor(cursor string) (key any, err error) {
+	bs, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor error: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(bs))
+	decoder.UseNumber()
+	if err = decoder.Decode(&key); err != nil {
+		return nil, fmt.Errorf("decode cursor error: %w", err)
+	}
+
+	if num, ok := key.(json.Number); ok {
+		return num.String(), nil
+	}
+	return key, nil
+}
@@ -0,0 +1,83 @@
+package dborm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type pageItem struct {
+	ID   int64
+	Name string
+}
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry run db error: %v", err)
+	}
+	return db
+}
+
+func Test_cursor(t *testing.T) {
+	t.Run("when encode key then decode the same key", func(t *testing.T) {
+		cursor, err := encodeCursor(int64(12345678901234))
+		if err != nil {
+			t.Fatalf("encodeCursor() error = %v", err)
+		}
+		key, err := decodeCursor(cursor)
+		if err != nil {
+			t.Fatalf("decodeCursor() error = %v", err)
+		}
+		if key != "12345678901234" {
+			t.Errorf("decodeCursor() = %v, want %v", key, "12345678901234")
+		}
+	})
+
+	t.Run("when cursor is malformed then return error", func(t *testing.T) {
+		if _, err := decodeCursor("%%%"); err == nil {
+			t.Errorf("decodeCursor() want error")
+		}
+	})
+}
+
+func Test_keysetQuery(t *testing.T) {
+	db := newDryRunDB(t)
+	cursor, _ := encodeCursor(100)
+
+	tx, err := keysetQuery(db.Model(&pageItem{}), normalizePageRequest(PageRequest{
+		Mode:   KeysetMode,
+		Cursor: cursor,
+		Desc:   true,
+	}))
+	if err != nil {
+		t.Fatalf("keysetQuery() error = %v", err)
+	}
+
+	stmt := tx.Limit(11).Find(&[]pageItem{}).Statement
+	sql := stmt.SQL.String()
+	if !strings.Contains(sql, "WHERE `id` < ?") || !strings.Contains(sql, "ORDER BY `id` DESC") {
+		t.Errorf("keysetQuery() sql = %v", sql)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	db := newDryRunDB(t)
+
+	page, err := Paginate[pageItem](context.Background(), db, PageRequest{
+		Page: 3,
+		Size: 10,
+	})
+	if err != nil {
+		t.Fatalf("Paginate() error = %v", err)
+	}
+	if page.Page != 3 || page.Size != 10 || page.HasMore {
+		t.Errorf("Paginate() = %+v", page)
+	}
+}
@@ -0,0 +1,112 @@
+package dborm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"gorm.io/gorm"
+)
+
+const (
+	// ReadOnlyReject fails the write statements with ErrReadOnly
+	ReadOnlyReject = "reject"
+	// ReadOnlySkip logs and skips the write statements, which return no error and no rows affected
+	ReadOnlySkip = "skip"
+)
+
+var (
+	ErrReadOnly = fmt.Errorf("db manager is read only")
+)
+
+// errWriteSkipped aborts a skipped write and is cleared once gorm has skipped it
+var errWriteSkipped = fmt.Errorf("write statement skipped")
+
+// readOnlyStatements are the leading keywords of raw statements allowed in read only mode
+var readOnlyStatements = []string{"SELECT", "SHOW", "EXPLAIN", "DESC", "DESCRIBE"}
+
+// registerReadOnlyGuard registers the callbacks guarding the write statements
+func (m *manager) registerReadOnlyGuard() (err error) {
+	callbacks := []struct {
+		op    string
+		guard func(name string, fn func(*gorm.DB)) error
+		clear func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", m.db.Callback().Create().Before("gorm:create").Register, m.db.Callback().Create().After("gorm:create").Register},
+		{"update", m.db.Callback().Update().Before("gorm:update").Register, m.db.Callback().Update().After("gorm:update").Register},
+		{"delete", m.db.Callback().Delete().Before("gorm:delete").Register, m.db.Callback().Delete().After("gorm:delete").Register},
+		{"raw", m.db.Callback().Raw().Before("gorm:raw").Register, m.db.Callback().Raw().After("gorm:raw").Register},
+	}
+
+	for _, cb := range callbacks {
+		err = cb.guard(fmt.Sprintf("trackingo:read_only_%s", cb.op), m.guardWrite(cb.op))
+		if err != nil {
+			return fmt.Errorf("register read_only_%s error: %w", cb.op, err)
+		}
+
+		err = cb.clear(fmt.Sprintf("trackingo:read_only_%s_skipped", cb.op), clearSkipped)
+		if err != nil {
+			return fmt.Errorf("register read_only_%s_skipped error: %w", cb.op, err)
+		}
+	}
+
+	return nil
+}
+
+// guardWrite rejects or skips the write statement according to Config.ReadOnlyMode
+func (m *manager) guardWrite(op string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil {
+			return
+		}
+
+		// raw statements are only known to be writes by their sql
+		if op == "raw" && isReadOnlyStatement(db.Statement.SQL.String()) {
+			return
+		}
+
+		ctx := db.Statement.Context
+		fields := logger.Fields{
+			"op":    op,
+			"table": db.Statement.Table,
+			"mode":  m.cfg.ReadOnlyMode,
+		}
+		if op == "raw" {
+			fields["sql"] = logger.StringLimit(db.Statement.SQL.String(), m.cfg.MaxSQLLength)
+		}
+
+		if m.cfg.ReadOnlyMode == ReadOnlySkip {
+			monitor.FromContext(ctx).Count(ctx, "db_read_only_"+op, common.ErrorCode(nil), m.cfg.ReadOnlyMode)
+			logger.FromContext(ctx).WithFields(fields).Warn("[DB] skip write statement in read only mode")
+			_ = db.AddError(errWriteSkipped)
+			return
+		}
+
+		monitor.FromContext(ctx).Count(ctx, "db_read_only_"+op, common.ErrorCode(ErrReadOnly), m.cfg.ReadOnlyMode)
+		logger.FromContext(ctx).WithFields(fields).Warn("[DB] reject write statement in read only mode")
+		_ = db.AddError(ErrReadOnly)
+	}
+}
+
+// clearSkipped clears the error of a statement skipped by the guard
+func clearSkipped(db *gorm.DB) {
+	if db.Error == errWriteSkipped {
+		db.Error = nil
+		db.RowsAffected = 0
+	}
+}
+
+func isReadOnlyStatement(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, keyword := range readOnlyStatements {
+		if strings.EqualFold(fields[0], keyword) {
+			return true
+		}
+	}
+	return false
+}
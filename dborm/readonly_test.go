@@ -0,0 +1,47 @@
+package dborm
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestManager_registerReadOnlyGuard(t *testing.T) {
+	newDB := func(t *testing.T, mode string) *gorm.DB {
+		m := &manager{cfg: &Config{ReadOnlyMode: mode}, db: newDryRunDB(t), active: true}
+		if err := m.registerReadOnlyGuard(); err != nil {
+			t.Fatalf("registerReadOnlyGuard() error = %v", err)
+		}
+		return m.db.Session(&gorm.Session{SkipDefaultTransaction: true})
+	}
+
+	t.Run("when reject mode then write returns ErrReadOnly", func(t *testing.T) {
+		db := newDB(t, ReadOnlyReject)
+		if err := db.Create(&pageItem{Name: "foo"}).Error; !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Create() error = %v, want %v", err, ErrReadOnly)
+		}
+		if err := db.Exec("UPDATE page_items SET name = ?", "bar").Error; !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Exec() error = %v, want %v", err, ErrReadOnly)
+		}
+	})
+
+	t.Run("when skip mode then write returns no error", func(t *testing.T) {
+		db := newDB(t, ReadOnlySkip)
+		tx := db.Where("id = ?", 1).Delete(&pageItem{})
+		if tx.Error != nil || tx.RowsAffected != 0 {
+			t.Errorf("Delete() error = %v, rows = %v, want nil and 0", tx.Error, tx.RowsAffected)
+		}
+	})
+
+	t.Run("when raw statement reads then allow it", func(t *testing.T) {
+		db := newDB(t, ReadOnlyReject)
+		if err := db.Exec("  show tables").Error; err != nil {
+			t.Errorf("Exec() error = %v, want nil", err)
+		}
+		var items []pageItem
+		if err := db.Find(&items).Error; err != nil {
+			t.Errorf("Find() error = %v, want nil", err)
+		}
+	})
+}
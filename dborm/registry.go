@@ -0,0 +1,110 @@
+package dborm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDatabaseNotFound = fmt.Errorf("database not found in registry")
+)
+
+type registryCtxKeyType string
+
+const (
+	registryCtxKey = registryCtxKeyType("_registry_ctx_key")
+)
+
+// Registry manages the named database managers, e.g. orders-db and users-db
+type Registry interface {
+	// Get returns the manager of the named database.
+	Get(name string) (Manager, error)
+	// GetDB returns the db of the named database.
+	GetDB(ctx context.Context, name string) (*gorm.DB, error)
+	// Names returns the sorted names of the databases.
+	Names() []string
+}
+
+type registry struct {
+	managers map[string]*manager
+}
+
+// NewRegistry connects to every database of cfg,
+// the database name is added to metrics and traffic logs of its statements
+func NewRegistry(cfg *RegistryConfig) (Registry, error) {
+	if cfg == nil || len(cfg.Databases) == 0 {
+		return nil, fmt.Errorf("no database configured")
+	}
+
+	r := &registry{
+		managers: make(map[string]*manager, len(cfg.Databases)),
+	}
+
+	for name, dbCfg := range cfg.Databases {
+		if dbCfg == nil {
+			return nil, fmt.Errorf("database %s config is nil", name)
+		}
+		r.managers[name] = newManager(dbCfg, name, "")
+	}
+
+	return r, nil
+}
+
+func (r *registry) Get(name string) (Manager, error) {
+	if r == nil {
+		return nil, fmt.Errorf("registry is nil")
+	}
+
+	m, ok := r.managers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDatabaseNotFound, name)
+	}
+	return m, nil
+}
+
+func (r *registry) GetDB(ctx context.Context, name string) (*gorm.DB, error) {
+	m, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return m.GetDB(ctx)
+}
+
+func (r *registry) Names() []string {
+	names := make([]string, 0, len(r.managers))
+	for name := range r.managers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithRegistry injects the registry to ctx
+func WithRegistry(ctx context.Context, r Registry) context.Context {
+	if ctx == nil || r == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, registryCtxKey, r)
+}
+
+// FromContext returns the manager of the named database of the registry in ctx,
+// an inactive manager is returned if not found, so it's never nil
+func FromContext(ctx context.Context, name string) Manager {
+	if ctx == nil {
+		return &manager{}
+	}
+
+	r, ok := ctx.Value(registryCtxKey).(Registry)
+	if !ok {
+		return &manager{}
+	}
+
+	m, err := r.Get(name)
+	if err != nil {
+		return &manager{}
+	}
+	return m
+}
@@ -0,0 +1,46 @@
+package dborm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	r := &registry{
+		managers: map[string]*manager{
+			"users-db":  {name: "users-db", active: true},
+			"orders-db": {name: "orders-db"},
+		},
+	}
+	ctx := WithRegistry(context.Background(), r)
+
+	t.Run("when database registered then return its manager", func(t *testing.T) {
+		if got := FromContext(ctx, "users-db"); !got.Active() {
+			t.Errorf("FromContext() active = %v, want true", got.Active())
+		}
+	})
+
+	t.Run("when database not registered then return inactive manager", func(t *testing.T) {
+		got := FromContext(ctx, "unknown-db")
+		if got == nil || got.Active() {
+			t.Errorf("FromContext() want non-nil inactive manager")
+		}
+		if _, err := got.GetDB(ctx); !errors.Is(err, ErrNotActive) {
+			t.Errorf("GetDB() error = %v, want %v", err, ErrNotActive)
+		}
+	})
+
+	t.Run("when ctx has no registry then return inactive manager", func(t *testing.T) {
+		if got := FromContext(context.Background(), "users-db"); got.Active() {
+			t.Errorf("FromContext() want inactive manager")
+		}
+	})
+
+	t.Run("names are sorted", func(t *testing.T) {
+		if got := r.Names(); !reflect.DeepEqual(got, []string{"orders-db", "users-db"}) {
+			t.Errorf("Names() = %v", got)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package dborm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/tenz-io/trackingo/retry"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryBackoff    = 50 * time.Millisecond
+	defaultRetryMaxBackoff = time.Second
+)
+
+// mysql server error numbers which are worth retrying
+const (
+	errLockWaitTimeout    = 1205
+	errLockDeadlock       = 1213
+	errTooManyConnections = 1040
+	errServerShutdown     = 1053
+	errOptionPrevents     = 1290 // e.g. replica still in --super-read-only while starting
+)
+
+type retrier struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	retryable  func(err error) bool
+}
+
+type RetryOpt func(r *retrier)
+
+// WithRetryAttempts sets the max attempts including the first one
+func WithRetryAttempts(attempts int) RetryOpt {
+	return func(r *retrier) {
+		r.attempts = attempts
+	}
+}
+
+// WithRetryBackoff sets the initial and the max backoff between attempts,
+// backoff doubles on every retry with jitter
+func WithRetryBackoff(backoff, maxBackoff time.Duration) RetryOpt {
+	return func(r *retrier) {
+		r.backoff = backoff
+		r.maxBackoff = maxBackoff
+	}
+}
+
+// WithRetryable replaces IsTransient to decide which errors are retried
+func WithRetryable(retryable func(err error) bool) RetryOpt {
+	return func(r *retrier) {
+		r.retryable = retryable
+	}
+}
+
+// ReadWithRetry runs the read function fn with the db of ctx,
+// fn is called again while it fails with a transient error.
+// fn must be idempotent, so only use it for read queries.
+// the retries are recorded as retry_db_read metrics, see retry.Do
+func ReadWithRetry(ctx context.Context, m Manager, fn func(db *gorm.DB) error, opts ...RetryOpt) (err error) {
+	r := &retrier{
+		attempts:   defaultRetryAttempts,
+		backoff:    defaultRetryBackoff,
+		maxBackoff: defaultRetryMaxBackoff,
+		retryable:  IsTransient,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	read := func(ctx context.Context) error {
+		db, err := m.GetDB(ctx)
+		if err != nil {
+			return retry.Permanent(err)
+		}
+		return fn(db)
+	}
+
+	return retry.Do(ctx, "db_read", read,
+		retry.WithMaxAttempts(r.attempts),
+		retry.WithBackoff(r.backoff, r.maxBackoff),
+		retry.WithRetryable(r.retryable),
+	)
+}
+
+// IsTransient reports whether err is a temporary database error,
+// such as deadlock, lost or reset connection and replica not ready
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		switch myErr.Number {
+		case errLockWaitTimeout,
+			errLockDeadlock,
+			errTooManyConnections,
+			errServerShutdown,
+			errOptionPrevents:
+			return true
+		default:
+			return false
+		}
+	}
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,123 @@
+package dborm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type stubManager struct {
+}
+
+func (s *stubManager) GetDB(ctx context.Context) (*gorm.DB, error) {
+	return &gorm.DB{}, nil
+}
+
+func (s *stubManager) GetSQLDB(ctx context.Context) (*sql.DB, error) {
+	return nil, ErrNotActive
+}
+
+func (s *stubManager) AddHook(hook Hook) error {
+	return nil
+}
+
+func (s *stubManager) Active() bool {
+	return true
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "when err is nil then return false",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "when err is deadlock then return true",
+			err:  fmt.Errorf("query: %w", &mysql.MySQLError{Number: 1213}),
+			want: true,
+		},
+		{
+			name: "when err is duplicate entry then return false",
+			err:  &mysql.MySQLError{Number: 1062},
+			want: false,
+		},
+		{
+			name: "when err is bad conn then return true",
+			err:  driver.ErrBadConn,
+			want: true,
+		},
+		{
+			name: "when err is record not found then return false",
+			err:  gorm.ErrRecordNotFound,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "when first attempt succeeds then call once",
+			errs:      []error{nil},
+			wantCalls: 1,
+			wantErr:   false,
+		},
+		{
+			name:      "when transient error then retry until success",
+			errs:      []error{driver.ErrBadConn, &mysql.MySQLError{Number: 1213}, nil},
+			wantCalls: 3,
+			wantErr:   false,
+		},
+		{
+			name:      "when non transient error then return without retry",
+			errs:      []error{gorm.ErrRecordNotFound},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "when transient error persists then stop at max attempts",
+			errs:      []error{driver.ErrBadConn, driver.ErrBadConn, driver.ErrBadConn, nil},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := ReadWithRetry(context.Background(), &stubManager{}, func(db *gorm.DB) error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			}, WithRetryBackoff(time.Millisecond, time.Millisecond))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("ReadWithRetry() calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
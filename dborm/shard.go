@@ -0,0 +1,278 @@
+package dborm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoShardKey   = fmt.Errorf("shard key not found in ctx")
+	ErrInvalidShard = fmt.Errorf("invalid shard id")
+)
+
+type shardKeyCtxKeyType string
+
+const (
+	shardKeyCtxKey = shardKeyCtxKeyType("_shard_key_ctx_key")
+)
+
+const (
+	defaultHealthInterval = 10 * time.Second
+)
+
+// ShardFunc maps the shard key to a shard id in [0, shards)
+type ShardFunc func(key any, shards int) (int, error)
+
+// ShardedManager routes to one of the shard databases by the shard key of ctx
+type ShardedManager interface {
+	Manager
+	// GetDBByKey returns the db of the shard which the key belongs to.
+	GetDBByKey(ctx context.Context, key any) (*gorm.DB, error)
+	// GetShardDB returns the db of the given shard id.
+	GetShardDB(ctx context.Context, shardId int) (*gorm.DB, error)
+	// Shards returns the number of shards.
+	Shards() int
+	// Healthy reports whether the shard passed its last health check.
+	Healthy(shardId int) bool
+	// Close stops the health checking.
+	Close()
+}
+
+type shardedManager struct {
+	cfg      *ShardConfig
+	shardFn  ShardFunc
+	shards   []*manager
+	healthy  []atomic.Bool
+	stopC    chan struct{}
+	stopOnce sync.Once
+}
+
+// NewShardedManager connects to every shard of cfg and starts the health checking,
+// shardFn decides which shard a key belongs to, HashShard is used if nil
+func NewShardedManager(
+	cfg *ShardConfig,
+	shardFn ShardFunc,
+) (ShardedManager, error) {
+	if cfg == nil || len(cfg.Shards) == 0 {
+		return nil, fmt.Errorf("no shard configured")
+	}
+
+	if shardFn == nil {
+		shardFn = HashShard
+	}
+
+	sm := &shardedManager{
+		cfg:     cfg,
+		shardFn: shardFn,
+		shards:  make([]*manager, len(cfg.Shards)),
+		healthy: make([]atomic.Bool, len(cfg.Shards)),
+		stopC:   make(chan struct{}),
+	}
+
+	for i, shardCfg := range cfg.Shards {
+		sm.shards[i] = newManager(shardCfg, "", shardName(i))
+		sm.healthy[i].Store(sm.shards[i].Active())
+	}
+
+	interval := cfg.HealthInterval
+	if interval <= 0 {
+		interval = defaultHealthInterval
+	}
+	sm.startHealthCheck(interval)
+
+	return sm, nil
+}
+
+// WithShardKey returns a copy of ctx carrying the shard key used by GetDB
+func WithShardKey(ctx context.Context, key any) context.Context {
+	return context.WithValue(ctx, shardKeyCtxKey, key)
+}
+
+// ShardKey returns the shard key of ctx
+func ShardKey(ctx context.Context) (key any, ok bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	key = ctx.Value(shardKeyCtxKey)
+	return key, key != nil
+}
+
+// HashShard maps integer keys by modulo and the other keys by fnv hash of their string form
+func HashShard(key any, shards int) (int, error) {
+	if shards <= 0 {
+		return 0, ErrInvalidShard
+	}
+
+	var sum uint64
+	switch k := key.(type) {
+	case int:
+		sum = uint64(k)
+	case int32:
+		sum = uint64(k)
+	case int64:
+		sum = uint64(k)
+	case uint:
+		sum = uint64(k)
+	case uint32:
+		sum = uint64(k)
+	case uint64:
+		sum = k
+	default:
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(fmt.Sprint(key)))
+		sum = h.Sum64()
+	}
+
+	return int(sum % uint64(shards)), nil
+}
+
+func (sm *shardedManager) GetDB(ctx context.Context) (*gorm.DB, error) {
+	key, ok := ShardKey(ctx)
+	if !ok {
+		return nil, ErrNoShardKey
+	}
+
+	return sm.GetDBByKey(ctx, key)
+}
+
+func (sm *shardedManager) GetSQLDB(ctx context.Context) (*sql.DB, error) {
+	key, ok := ShardKey(ctx)
+	if !ok {
+		return nil, ErrNoShardKey
+	}
+
+	shard, err := sm.shardOf(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return shard.GetSQLDB(ctx)
+}
+
+func (sm *shardedManager) GetDBByKey(ctx context.Context, key any) (*gorm.DB, error) {
+	shard, err := sm.shardOf(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return shard.GetDB(ctx)
+}
+
+func (sm *shardedManager) GetShardDB(ctx context.Context, shardId int) (*gorm.DB, error) {
+	if shardId < 0 || shardId >= len(sm.shards) {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidShard, shardId)
+	}
+
+	return sm.shards[shardId].GetDB(ctx)
+}
+
+// shardOf returns the manager of the shard which key belongs to
+func (sm *shardedManager) shardOf(key any) (*manager, error) {
+	shardId, err := sm.shardFn(key, len(sm.shards))
+	if err != nil {
+		return nil, fmt.Errorf("get shard of key %v error: %w", key, err)
+	}
+	if shardId < 0 || shardId >= len(sm.shards) {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidShard, shardId)
+	}
+
+	return sm.shards[shardId], nil
+}
+
+// AddHook adds hook to every shard
+func (sm *shardedManager) AddHook(hook Hook) error {
+	for i, shard := range sm.shards {
+		if err := shard.AddHook(hook); err != nil {
+			return fmt.Errorf("add hook to shard %d error: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (sm *shardedManager) Active() bool {
+	if sm == nil {
+		return false
+	}
+
+	for _, shard := range sm.shards {
+		if !shard.Active() {
+			return false
+		}
+	}
+	return true
+}
+
+func (sm *shardedManager) Shards() int {
+	return len(sm.shards)
+}
+
+func (sm *shardedManager) Healthy(shardId int) bool {
+	if shardId < 0 || shardId >= len(sm.shards) {
+		return false
+	}
+	return sm.healthy[shardId].Load()
+}
+
+func (sm *shardedManager) Close() {
+	sm.stopOnce.Do(func() {
+		close(sm.stopC)
+	})
+}
+
+// startHealthCheck pings every shard with interval until closed
+func (sm *shardedManager) startHealthCheck(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sm.checkHealth(interval)
+
+			select {
+			case <-sm.stopC:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// checkHealth pings the shards and exports the result as db_shard_health gauge
+func (sm *shardedManager) checkHealth(timeout time.Duration) {
+	ctx := monitor.InitSingleFlight(context.Background(), "dborm")
+	mon := monitor.FromContext(ctx)
+
+	for i, shard := range sm.shards {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := shard.ping(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if sm.healthy[i].Swap(healthy) != healthy {
+			logger.WithError(err).WithFields(logger.Fields{
+				"shard":   shard.shard,
+				"healthy": healthy,
+			}).Warn("db shard health changed")
+		}
+
+		var val float64
+		if healthy {
+			val = 1
+		}
+		mon.Set(ctx, "db_shard_health", 0, val, shard.shard)
+	}
+}
+
+func shardName(shardId int) string {
+	return "shard_" + strconv.Itoa(shardId)
+}
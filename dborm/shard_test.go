@@ -0,0 +1,98 @@
+package dborm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHashShard(t *testing.T) {
+	type args struct {
+		key    any
+		shards int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "when key is int then return modulo",
+			args: args{
+				key:    int64(10),
+				shards: 4,
+			},
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name: "when key is string then return the fnv-64a modulo",
+			args: args{
+				key:    "user_1",
+				shards: 4,
+			},
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name: "when another string key then return its fnv-64a modulo",
+			args: args{
+				key:    "user_2",
+				shards: 16,
+			},
+			want:    15,
+			wantErr: false,
+		},
+		{
+			name: "when shards is 0 then return error",
+			args: args{
+				key:    1,
+				shards: 0,
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HashShard(tt.args.key, tt.args.shards)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HashShard() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("HashShard() = %v, want %v", got, tt.want)
+			}
+			if got < 0 || (tt.args.shards > 0 && got >= tt.args.shards) {
+				t.Errorf("HashShard() = %v, out of range", got)
+			}
+		})
+	}
+}
+
+func Test_shardedManager_GetDB(t *testing.T) {
+	sm := &shardedManager{
+		shardFn: HashShard,
+		shards:  []*manager{{shard: shardName(0)}, {shard: shardName(1)}},
+	}
+
+	t.Run("when ctx has no shard key then return ErrNoShardKey", func(t *testing.T) {
+		if _, err := sm.GetDB(context.Background()); !errors.Is(err, ErrNoShardKey) {
+			t.Errorf("GetDB() error = %v, want %v", err, ErrNoShardKey)
+		}
+	})
+
+	t.Run("when shard is not active then return ErrNotActive", func(t *testing.T) {
+		ctx := WithShardKey(context.Background(), 1)
+		if _, err := sm.GetDB(ctx); !errors.Is(err, ErrNotActive) {
+			t.Errorf("GetDB() error = %v, want %v", err, ErrNotActive)
+		}
+	})
+
+	t.Run("when shard id out of range then return ErrInvalidShard", func(t *testing.T) {
+		if _, err := sm.GetShardDB(context.Background(), 2); !errors.Is(err, ErrInvalidShard) {
+			t.Errorf("GetShardDB() error = %v, want %v", err, ErrInvalidShard)
+		}
+	})
+}
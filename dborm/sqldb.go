@@ -0,0 +1,244 @@
+package dborm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	dsCmdSQLQuery = "db_sql_query"
+	dsCmdSQLExec  = "db_sql_exec"
+)
+
+func (m *manager) GetSQLDB(ctx context.Context) (*sql.DB, error) {
+	if m == nil {
+		return nil, fmt.Errorf("db manager is nil")
+	}
+
+	if !m.Active() {
+		return nil, ErrNotActive
+	}
+
+	m.sqlDBOnce.Do(func() {
+		m.sqlDB, m.sqlDBErr = m.openSQLDB()
+	})
+
+	return m.sqlDB, m.sqlDBErr
+}
+
+// openSQLDB opens a tracked connection pool
+func (m *manager) openSQLDB() (*sql.DB, error) {
+	mysqlCfg, err := mysql.ParseDSN(m.cfg.GetDSN())
+	if err != nil {
+		return nil, fmt.Errorf("parse dsn error: %w", err)
+	}
+
+	connector, err := mysql.NewConnector(mysqlCfg)
+	if err != nil {
+		return nil, fmt.Errorf("new connector error: %w", err)
+	}
+
+	sqlDB := sql.OpenDB(&trackedConnector{
+		Connector: connector,
+		m:         m,
+	})
+	sqlDB.SetMaxIdleConns(m.cfg.MaxIdleConn)
+	sqlDB.SetMaxOpenConns(m.cfg.MaxOpenConn)
+	sqlDB.SetConnMaxLifetime(m.cfg.GetMaxLifetime())
+
+	return sqlDB, nil
+}
+
+// track starts the metrics record of a sql, the returned func ends it and writes the traffic records,
+// nothing is recorded for driver.ErrSkip since the sql is then run as a prepared statement
+func (m *manager) track(ctx context.Context, dsCmd, query string, args []driver.NamedValue) func(err error, rows int64) {
+	if !m.cfg.EnableTracking {
+		return func(err error, rows int64) {}
+	}
+
+	vars := make([]any, len(args))
+	for i, arg := range args {
+		vars[i] = arg.Value
+	}
+
+	startTime := time.Now()
+	rec := monitor.BeginRecord(ctx, dsCmd)
+
+	return func(err error, rows int64) {
+		if errors.Is(err, driver.ErrSkip) {
+			rec.Discard()
+			return
+		}
+		rec.EndWithErrorOpt(err, m.metricsOpt())
+
+		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
+			Cmd:       dsCmd,
+			StartTime: startTime,
+		}, m.statementFields(query, vars))
+		fields := m.statementFields(query, vars)
+		if rows >= 0 {
+			fields["rows"] = rows
+			monitor.FromContext(ctx).Sample(ctx, dsCmd+"_rows", common.ErrorCode(err), float64(rows), m.metricsOpt())
+		}
+		trafficRec.End(&logger.TrafficResp{
+			Code: common.ErrorCode(err),
+			Msg:  common.ErrorMsg(err),
+		}, fields)
+	}
+}
+
+// trackedConnector creates tracked connections
+type trackedConnector struct {
+	driver.Connector
+	m *manager
+}
+
+func (c *trackedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &trackedConn{Conn: conn, m: c.m}, nil
+}
+
+// trackedConn tracks the queries and execs of the driver connection,
+// the optional interfaces of the connection are forwarded
+type trackedConn struct {
+	driver.Conn
+	m *manager
+}
+
+func (c *trackedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	end := c.m.track(ctx, dsCmdSQLQuery, query, args)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	end(err, -1)
+	return rows, err
+}
+
+func (c *trackedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	end := c.m.track(ctx, dsCmdSQLExec, query, args)
+	result, err := execer.ExecContext(ctx, query, args)
+	end(err, rowsAffected(result, err))
+	return result, err
+}
+
+func (c *trackedConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &trackedStmt{Stmt: stmt, query: query, m: c.m}, nil
+}
+
+func (c *trackedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *trackedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *trackedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *trackedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *trackedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// trackedStmt tracks the executions of prepared statement
+type trackedStmt struct {
+	driver.Stmt
+	query string
+	m     *manager
+}
+
+func (s *trackedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
+	end := s.m.track(ctx, dsCmdSQLQuery, s.query, args)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValues(args))
+	}
+	end(err, -1)
+	return rows, err
+}
+
+func (s *trackedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (result driver.Result, err error) {
+	end := s.m.track(ctx, dsCmdSQLExec, s.query, args)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedValues(args))
+	}
+	end(err, rowsAffected(result, err))
+	return result, err
+}
+
+func (s *trackedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func rowsAffected(result driver.Result, err error) int64 {
+	if err != nil || result == nil {
+		return 0
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return rows
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
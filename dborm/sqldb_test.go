@@ -0,0 +1,164 @@
+package dborm
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+// skipConnector connects to skipConn, which skips the parametrized sql as the mysql driver
+// without InterpolateParams, so that they are run as prepared statements
+type skipConnector struct{}
+
+func (skipConnector) Connect(context.Context) (driver.Conn, error) { return skipConn{}, nil }
+func (skipConnector) Driver() driver.Driver                        { return nil }
+
+type skipConn struct{}
+
+func (skipConn) Prepare(string) (driver.Stmt, error) { return skipStmt{}, nil }
+func (skipConn) Close() error                        { return nil }
+func (skipConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (skipConn) QueryContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+	return &skipRows{}, nil
+}
+
+func (skipConn) ExecContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type skipStmt struct{}
+
+func (skipStmt) Close() error                               { return nil }
+func (skipStmt) NumInput() int                              { return -1 }
+func (skipStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (skipStmt) Query([]driver.Value) (driver.Rows, error)  { return &skipRows{}, nil }
+
+type skipRows struct{}
+
+func (*skipRows) Columns() []string         { return []string{"id"} }
+func (*skipRows) Close() error              { return nil }
+func (*skipRows) Next([]driver.Value) error { return io.EOF }
+
+// countValue returns the sum of the counters of the single flight monitor of cmd and dsCmd of all codes
+func countValue(t *testing.T, cmd, dsCmd string) float64 {
+	t.Helper()
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather metrics error = %v", err)
+	}
+
+	var sum float64
+	for _, mf := range mfs {
+		if mf.GetName() != "trackingo_flight_singleFlightC" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["cmd"] == cmd && labels["dsCmd"] == dsCmd {
+				sum += m.GetCounter().GetValue()
+			}
+		}
+	}
+	return sum
+}
+
+func TestManager_sqlDB(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	logger.ConfigureTrafficLog(logger.TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+		JSONFormat:            true,
+	})
+	defer logger.ConfigureTrafficLog(logger.TrafficLogConfig{})
+
+	m := &manager{cfg: &Config{EnableTracking: true, MaxSQLLength: 1024}, active: true}
+	sqlDB := sql.OpenDB(&trackedConnector{Connector: skipConnector{}, m: m})
+	defer sqlDB.Close()
+
+	// records returns the traffic records of dsCmd
+	records := func(t *testing.T, dsCmd string) []map[string]any {
+		logger.Sync()
+		f, _ := os.Open(out.Name())
+		defer f.Close()
+		var got []map[string]any
+		for scanner := bufio.NewScanner(f); scanner.Scan(); {
+			var record map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("unmarshal %s error = %v", scanner.Text(), err)
+			}
+			if record["cmd"] == dsCmd {
+				got = append(got, record)
+			}
+		}
+		return got
+	}
+
+	// eventually waits the counters, which are written asynchronously
+	eventually := func(t *testing.T, cond func() bool) {
+		t.Helper()
+		for i := 0; i < 100 && !cond(); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if !cond() {
+			t.Errorf("condition not met in 1s")
+		}
+	}
+
+	t.Run("when the driver skips a parametrized exec then it is tracked once as the prepared statement", func(t *testing.T) {
+		ctx := monitor.InitSingleFlight(context.Background(), "dborm_sqldb_exec")
+		if _, err := sqlDB.ExecContext(ctx, "update users set age = ? where id = ?", 1, 2); err != nil {
+			t.Fatalf("ExecContext() error = %v", err)
+		}
+
+		eventually(t, func() bool {
+			return countValue(t, "dborm_sqldb_exec", dsCmdSQLExec) == 1
+		})
+		got := records(t, dsCmdSQLExec)
+		if len(got) != 2 {
+			t.Fatalf("traffic records = %v, want the req and the resp", got)
+		}
+		if got[1]["code"] != float64(0) || got[1]["rows"] != float64(1) {
+			t.Errorf("traffic resp = %v, want code 0 and 1 row", got[1])
+		}
+	})
+
+	t.Run("when the driver runs a query then it is tracked once", func(t *testing.T) {
+		ctx := monitor.InitSingleFlight(context.Background(), "dborm_sqldb_query")
+		rows, err := sqlDB.QueryContext(ctx, "select id from users")
+		if err != nil {
+			t.Fatalf("QueryContext() error = %v", err)
+		}
+		_ = rows.Close()
+
+		eventually(t, func() bool {
+			return countValue(t, "dborm_sqldb_query", dsCmdSQLQuery) == 1
+		})
+	})
+}
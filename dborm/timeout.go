@@ -0,0 +1,98 @@
+package dborm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tenz-io/trackingo/common"
+	"gorm.io/gorm"
+)
+
+const (
+	// CodeQueryTimeout is the metrics and traffic code of a statement aborted by its timeout
+	CodeQueryTimeout = common.CodeRequestTimeout
+)
+
+type timeoutCtxKeyType string
+
+const (
+	queryTimeoutCtxKey  timeoutCtxKeyType = "_query_timeout_ctx_key"
+	timeoutRecordCtxKey timeoutCtxKeyType = "_timeout_record_ctx_key"
+)
+
+// timeoutRecord keeps what is needed to undo a statement timeout
+type timeoutRecord struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// WithQueryTimeout returns a copy of ctx whose statements use the given timeout
+// instead of Config.QueryTimeout, non-positive timeout disables it for this ctx
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutCtxKey, timeout)
+}
+
+// queryTimeout returns the timeout of ctx if overridden, otherwise the default one
+func queryTimeout(ctx context.Context, defaultTimeout time.Duration) time.Duration {
+	if ctx == nil {
+		return defaultTimeout
+	}
+
+	if timeout, ok := ctx.Value(queryTimeoutCtxKey).(time.Duration); ok {
+		return timeout
+	}
+
+	return defaultTimeout
+}
+
+// beginTimeout bounds the statement context with the query timeout
+func beginTimeout(db *gorm.DB, defaultTimeout time.Duration) {
+	parent := db.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	timeout := queryTimeout(parent, defaultTimeout)
+	if timeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	ctx = context.WithValue(ctx, timeoutRecordCtxKey, &timeoutRecord{
+		parent: parent,
+		cancel: cancel,
+	})
+	db.Statement.Context = ctx
+}
+
+// endTimeout releases the statement timeout and restores the caller's context,
+// so that a reused statement doesn't inherit an expired context
+func endTimeout(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		return
+	}
+
+	rec, ok := ctx.Value(timeoutRecordCtxKey).(*timeoutRecord)
+	if !ok {
+		return
+	}
+
+	rec.cancel()
+	db.Statement.Context = rec.parent
+}
+
+// isQueryTimeout reports whether the statement failed because of its deadline
+func isQueryTimeout(db *gorm.DB) bool {
+	if db.Error == nil {
+		return false
+	}
+
+	if errors.Is(db.Error, context.DeadlineExceeded) {
+		return true
+	}
+
+	ctx := db.Statement.Context
+	return ctx != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
@@ -0,0 +1,84 @@
+package dborm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func Test_queryTimeout(t *testing.T) {
+	type args struct {
+		ctx            context.Context
+		defaultTimeout time.Duration
+	}
+	tests := []struct {
+		name string
+		args args
+		want time.Duration
+	}{
+		{
+			name: "when ctx has no timeout then return default timeout",
+			args: args{
+				ctx:            context.Background(),
+				defaultTimeout: time.Second,
+			},
+			want: time.Second,
+		},
+		{
+			name: "when ctx has timeout then return ctx timeout",
+			args: args{
+				ctx:            WithQueryTimeout(context.Background(), time.Minute),
+				defaultTimeout: time.Second,
+			},
+			want: time.Minute,
+		},
+		{
+			name: "when ctx disables timeout then return zero",
+			args: args{
+				ctx:            WithQueryTimeout(context.Background(), 0),
+				defaultTimeout: time.Second,
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryTimeout(tt.args.ctx, tt.args.defaultTimeout); got != tt.want {
+				t.Errorf("queryTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_beginTimeout(t *testing.T) {
+	t.Run("when timeout is set then bound and restore statement context", func(t *testing.T) {
+		parent := context.Background()
+		db := &gorm.DB{Statement: &gorm.Statement{Context: parent}}
+
+		beginTimeout(db, time.Second)
+		if _, ok := db.Statement.Context.Deadline(); !ok {
+			t.Errorf("beginTimeout() want deadline on statement context")
+		}
+
+		ctx := db.Statement.Context
+		endTimeout(db)
+		if db.Statement.Context != parent {
+			t.Errorf("endTimeout() want parent context restored")
+		}
+		if ctx.Err() == nil {
+			t.Errorf("endTimeout() want timeout context canceled")
+		}
+	})
+
+	t.Run("when timeout is disabled then keep statement context", func(t *testing.T) {
+		parent := WithQueryTimeout(context.Background(), 0)
+		db := &gorm.DB{Statement: &gorm.Statement{Context: parent}}
+
+		beginTimeout(db, time.Second)
+		if db.Statement.Context != parent {
+			t.Errorf("beginTimeout() want statement context untouched")
+		}
+	})
+}
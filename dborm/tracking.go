@@ -16,108 +16,191 @@ const (
 	trafficRecordCtxKey recordCtxKeyType = "_traffic_record_ctx_key"
 )
 
+const (
+	dsCmdQuery  = "db_query"
+	dsCmdCreate = "db_create"
+	dsCmdUpdate = "db_update"
+	dsCmdDelete = "db_delete"
+	dsCmdRow    = "db_row"
+	dsCmdRaw    = "db_raw"
+)
+
 func (m *manager) applyPlugins() (err error) {
-	err = m.db.Callback().Query().Before("*").Register("start_query_metrics", m.enter("db_query"))
+	err = m.db.Callback().Query().Before("*").Register("start_query_metrics", m.enter(dsCmdQuery))
 	if err != nil {
 		return fmt.Errorf("register start_metrics error: %w", err)
 	}
 
-	err = m.db.Callback().Create().Before("*").Register("start_create_metrics", m.enter("db_create"))
+	err = m.db.Callback().Create().Before("*").Register("start_create_metrics", m.enter(dsCmdCreate))
 	if err != nil {
 		return fmt.Errorf("register start_metrics error: %w", err)
 	}
 
-	err = m.db.Callback().Update().Before("*").Register("start_update_metrics", m.enter("db_update"))
+	err = m.db.Callback().Update().Before("*").Register("start_update_metrics", m.enter(dsCmdUpdate))
 	if err != nil {
 		return fmt.Errorf("register start_metrics error: %w", err)
 	}
 
-	err = m.db.Callback().Delete().Before("*").Register("start_delete_metrics", m.enter("db_delete"))
+	err = m.db.Callback().Delete().Before("*").Register("start_delete_metrics", m.enter(dsCmdDelete))
 	if err != nil {
 		return fmt.Errorf("register start_metrics error: %w", err)
 	}
 
-	err = m.db.Callback().Row().Before("*").Register("start_row_metrics", m.enter("db_row"))
+	err = m.db.Callback().Row().Before("*").Register("start_row_metrics", m.enter(dsCmdRow))
 	if err != nil {
 		return fmt.Errorf("register start_metrics error: %w", err)
 	}
 
-	err = m.db.Callback().Raw().Before("*").Register("start_raw_metrics", m.enter("db_raw"))
+	err = m.db.Callback().Raw().Before("*").Register("start_raw_metrics", m.enter(dsCmdRaw))
 	if err != nil {
 		return fmt.Errorf("register start_metrics error: %w", err)
 	}
 
-	err = m.db.Callback().Query().After("*").Register("end_query_metrics", m.exit())
+	err = m.db.Callback().Query().After("*").Register("end_query_metrics", m.exit(dsCmdQuery))
 	if err != nil {
 		return fmt.Errorf("register end_metrics error: %w", err)
 	}
 
-	err = m.db.Callback().Create().After("*").Register("end_create_metrics", m.exit())
+	err = m.db.Callback().Create().After("*").Register("end_create_metrics", m.exit(dsCmdCreate))
 	if err != nil {
 		return fmt.Errorf("register end_metrics error: %w", err)
 	}
 
-	err = m.db.Callback().Update().After("*").Register("end_update_metrics", m.exit())
+	err = m.db.Callback().Update().After("*").Register("end_update_metrics", m.exit(dsCmdUpdate))
 	if err != nil {
 		return fmt.Errorf("register end_metrics error: %w", err)
 	}
 
-	err = m.db.Callback().Delete().After("*").Register("end_delete_metrics", m.exit())
+	err = m.db.Callback().Delete().After("*").Register("end_delete_metrics", m.exit(dsCmdDelete))
 	if err != nil {
 		return fmt.Errorf("register end_metrics error: %w", err)
 	}
 
+	err = m.db.Callback().Raw().After("*").Register("end_raw_metrics", m.exit(dsCmdRaw))
+	if err != nil {
+		return fmt.Errorf("register end_metrics error: %w", err)
+	}
+
+	if err = m.registerHooks(); err != nil {
+		return err
+	}
+
+	if m.cfg.ReadOnlyMode != "" {
+		if err = m.registerReadOnlyGuard(); err != nil {
+			return err
+		}
+	}
+
+	if m.cfg.ExplainSlow {
+		if err = m.db.Use(newExplainPlugin(m.cfg)); err != nil {
+			return fmt.Errorf("use explain plugin error: %w", err)
+		}
+	}
+
+	if m.cfg.Audit != nil {
+		if err = m.db.Use(NewAuditPlugin(m.cfg.Audit, nil)); err != nil {
+			return fmt.Errorf("use audit plugin error: %w", err)
+		}
+	}
+
 	return nil
 }
 
 // enter is a callback function that will be called when the gorm
+// statement starts, it bounds the statement with the query timeout.
+// rows returned by db_row are scanned after the callbacks, so no timeout for it
 func (m *manager) enter(dsCmd string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if dsCmd != dsCmdRow {
+			beginTimeout(db, m.cfg.QueryTimeout)
+		}
 
-	if !m.cfg.EnableTracking {
-		return func(db *gorm.DB) {}
-	}
+		if !m.cfg.EnableTracking {
+			return
+		}
 
-	return func(db *gorm.DB) {
 		ctx := db.Statement.Context
 		rec := monitor.BeginRecord(ctx, dsCmd)
 		ctx = context.WithValue(ctx, metricsRecordCtxKey, rec)
 		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
 			Cmd: dsCmd,
-		}, logger.Fields{
-			"sql": db.Statement.SQL.String(),
-			"val": db.Statement.Vars,
-		})
+		}, m.trafficFields(db))
 		ctx = context.WithValue(ctx, trafficRecordCtxKey, trafficRec)
 		db.Statement.Context = ctx
-
 	}
 }
 
 // exit is a callback function that will be called when the gorm
-func (m *manager) exit() func(db *gorm.DB) {
-	if !m.cfg.EnableTracking {
-		return func(db *gorm.DB) {}
-	}
-
+// statement ends, timeout is reported with CodeQueryTimeout.
+// rows affected or returned are sampled as <dsCmd>_rows
+func (m *manager) exit(dsCmd string) func(db *gorm.DB) {
 	return func(db *gorm.DB) {
+		defer endTimeout(db)
+
+		if !m.cfg.EnableTracking {
+			return
+		}
+
+		err := db.Error
+		if isQueryTimeout(db) {
+			err = common.NewValError(CodeQueryTimeout, db.Error)
+		}
+
 		ctx := db.Statement.Context
 		rec, ok := ctx.Value(metricsRecordCtxKey).(*monitor.Recorder)
 		if ok {
-			rec.EndWithError(db.Error)
+			rec.EndWithErrorOpt(err, m.metricsOpt())
 		}
 
+		monitor.FromContext(ctx).Sample(ctx, dsCmd+"_rows", common.ErrorCode(err), float64(db.RowsAffected), m.metricsOpt())
+
 		trafficRec, ok := ctx.Value(trafficRecordCtxKey).(*logger.TrafficRec)
 		if ok {
+			fields := m.trafficFields(db)
+			fields["rows"] = db.RowsAffected
 			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(db.Error),
-				Msg:  common.ErrorMsg(db.Error),
-			}, logger.Fields{
-				"sql": db.Statement.SQL.String(),
-				"val": db.Statement.Vars,
-			})
+				Code: common.ErrorCode(err),
+				Msg:  common.ErrorMsg(err),
+			}, fields)
 
 		}
 
 	}
 
 }
+
+// trafficFields returns the statement fields of traffic log
+func (m *manager) trafficFields(db *gorm.DB) logger.Fields {
+	return m.statementFields(db.Statement.SQL.String(), db.Statement.Vars)
+}
+
+// statementFields returns the fields of sql and vars for traffic log,
+// sql is cut to Config.MaxSQLLength and sensitive vars are masked
+func (m *manager) statementFields(sql string, vars []any) logger.Fields {
+	fields := logger.Fields{
+		"sql": logger.StringLimit(sql, m.cfg.MaxSQLLength),
+		"val": maskVars(sql, vars, m.cfg.MaskColumns, m.cfg.MaskPositions),
+	}
+	if m.name != "" {
+		fields["db"] = m.name
+	}
+	if m.shard != "" {
+		fields["shard"] = m.shard
+	}
+	if m.dependency != "" {
+		fields["dependency"] = m.dependency
+	}
+	return fields
+}
+
+// metricsOpt returns the opt label of metrics, which tells databases apart
+func (m *manager) metricsOpt() string {
+	switch {
+	case m.name != "" && m.shard != "":
+		return m.name + ":" + m.shard
+	case m.name != "":
+		return m.name
+	default:
+		return m.shard
+	}
+}
@@ -0,0 +1,119 @@
+package dborm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// summaryValue returns the count and the sum of the summary of the single flight monitor with the labels
+func summaryValue(t *testing.T, cmd, dsCmd, code, opt string) (count uint64, sum float64) {
+	t.Helper()
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather metrics error = %v", err)
+	}
+
+	want := map[string]string{"cmd": cmd, "dsCmd": dsCmd, "code": code, "opt": opt}
+	for _, mf := range mfs {
+		if mf.GetName() != "trackingo_flight_singleFlightS" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			matched := 0
+			for _, l := range m.GetLabel() {
+				if v, ok := want[l.GetName()]; ok && v == l.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(want) {
+				return m.GetSummary().GetSampleCount(), m.GetSummary().GetSampleSum()
+			}
+		}
+	}
+	return 0, 0
+}
+
+func TestManager_exit_rows(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	logger.ConfigureTrafficLog(logger.TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+		JSONFormat:            true,
+	})
+	defer logger.ConfigureTrafficLog(logger.TrafficLogConfig{})
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: gormlogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite error = %v", err)
+	}
+	type user struct {
+		ID  int
+		Age int
+	}
+	if err = db.AutoMigrate(&user{}); err != nil {
+		t.Fatalf("migrate error = %v", err)
+	}
+	for i := 1; i <= 5; i++ {
+		if err = db.Create(&user{ID: i, Age: i}).Error; err != nil {
+			t.Fatalf("create error = %v", err)
+		}
+	}
+
+	m := &manager{cfg: &Config{EnableTracking: true, MaxSQLLength: 1024}, db: db, active: true}
+	if err = m.applyPlugins(); err != nil {
+		t.Fatalf("applyPlugins() error = %v", err)
+	}
+
+	t.Run("when an update touches N rows then N is sampled and logged as rows", func(t *testing.T) {
+		ctx := monitor.InitSingleFlight(context.Background(), "dborm_rows")
+		beforeCount, beforeSum := summaryValue(t, "dborm_rows", dsCmdUpdate+"_rows", "0", "NA")
+
+		res := db.WithContext(ctx).Model(&user{}).Where("age > ?", 2).Update("age", 0)
+		if res.Error != nil || res.RowsAffected != 3 {
+			t.Fatalf("update = %v, %v, want 3 rows", res.RowsAffected, res.Error)
+		}
+
+		count, sum := summaryValue(t, "dborm_rows", dsCmdUpdate+"_rows", "0", "NA")
+		if count != beforeCount+1 || sum != beforeSum+3 {
+			t.Errorf("sampled = %v times %v, want 1 time 3", count-beforeCount, sum-beforeSum)
+		}
+
+		logger.Sync()
+		f, _ := os.Open(out.Name())
+		defer f.Close()
+		var rows any
+		for scanner := bufio.NewScanner(f); scanner.Scan(); {
+			var record map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("unmarshal %s error = %v", scanner.Text(), err)
+			}
+			if record["cmd"] == dsCmdUpdate {
+				if v, ok := record["rows"]; ok {
+					rows = v
+				}
+			}
+		}
+		if rows != float64(3) {
+			t.Errorf("traffic rows = %v, want 3", rows)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+// Package dependencies declares the external dependencies of a service once, with a name and a type,
+// so that the clients tag their metrics and traffic logs with the same dependency name.
+// the clients resolve their dependency names with Resolve, the calls to a dependency are labeled
+// with <type>:<name> as the opt of the metrics and the "dependency" field of the traffic logs,
+// which makes one dashboard fit all the services
+package dependencies
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+type Type string
+
+const (
+	TypeMySQL Type = "mysql"
+	TypeRedis Type = "redis"
+	TypeHTTP  Type = "http"
+	TypeGRPC  Type = "grpc"
+	TypeKafka Type = "kafka"
+	TypeMongo Type = "mongodb"
+	TypeNATS  Type = "nats"
+)
+
+var (
+	ErrDeclared = fmt.Errorf("dependency already declared")
+)
+
+// Dependency is an external system the service calls
+type Dependency struct {
+	// Name is the metrics and traffic logs tag, e.g. orders-db or payment-api
+	Name string `yaml:"name" json:"name" required:"true"`
+	Type Type   `yaml:"type" json:"type" required:"true"`
+	// Target is the address, e.g. the host of an http upstream, for the dashboards only
+	Target string `yaml:"target" json:"target"`
+	// Critical tells the service can't serve without the dependency
+	Critical bool `yaml:"critical" json:"critical"`
+}
+
+var (
+	lock     sync.RWMutex
+	declared = map[string]Dependency{}
+	mon      = monitor.NewSingleFlight("dependencies")
+)
+
+// Declare adds dep to the registry and exports it as the dependency_<type> gauge labeled with its name
+func Declare(dep Dependency) error {
+	if dep.Name == "" || dep.Type == "" {
+		return fmt.Errorf("dependency name and type are required")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, ok := declared[dep.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDeclared, dep.Name)
+	}
+	declared[dep.Name] = dep
+
+	var critical float64
+	if dep.Critical {
+		critical = 1
+	}
+	mon.Set(context.Background(), "dependency_"+string(dep.Type), 0, critical, dep.Name)
+	return nil
+}
+
+// Lookup returns the declared dependency of name
+func Lookup(name string) (Dependency, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	dep, ok := declared[name]
+	return dep, ok
+}
+
+// Resolve returns the declared dependency of name, a client of typ calls it.
+// an undeclared name or a name declared with another type is warned and resolved to a dependency of typ,
+// so that a typo doesn't break the client but shows up in the logs
+func Resolve(name string, typ Type) Dependency {
+	if name == "" {
+		return Dependency{}
+	}
+
+	dep, ok := Lookup(name)
+	switch {
+	case !ok:
+		logger.WithFields(logger.Fields{
+			"dependency": name,
+			"type":       typ,
+		}).Warn("dependency not declared")
+	case dep.Type != typ:
+		logger.WithFields(logger.Fields{
+			"dependency": name,
+			"type":       typ,
+			"declared":   dep.Type,
+		}).Warn("dependency declared with another type")
+	default:
+		return dep
+	}
+	return Dependency{Name: name, Type: typ}
+}
+
+// Label returns the metrics and traffic logs tag of the dependency, <type>:<name>, empty if no name
+func (d Dependency) Label() string {
+	if d.Name == "" {
+		return ""
+	}
+	return string(d.Type) + ":" + d.Name
+}
+
+// All returns the declared dependencies sorted by name
+func All() []Dependency {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	deps := make([]Dependency, 0, len(declared))
+	for _, dep := range declared {
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		return deps[i].Name < deps[j].Name
+	})
+	return deps
+}
+
+// OfType returns the declared dependencies of typ sorted by name
+func OfType(typ Type) []Dependency {
+	var deps []Dependency
+	for _, dep := range All() {
+		if dep.Type == typ {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
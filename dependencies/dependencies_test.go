@@ -0,0 +1,120 @@
+package dependencies
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tenz-io/trackingo/logger"
+)
+
+func TestDeclare(t *testing.T) {
+	if err := Declare(Dependency{Name: "orders-db", Type: TypeMySQL, Critical: true}); err != nil {
+		t.Fatalf("Declare() error = %v", err)
+	}
+	if err := Declare(Dependency{Name: "payment-api", Type: TypeHTTP, Target: "pay.example.com"}); err != nil {
+		t.Fatalf("Declare() error = %v", err)
+	}
+
+	t.Run("when name declared then ErrDeclared", func(t *testing.T) {
+		if err := Declare(Dependency{Name: "orders-db", Type: TypeMySQL}); !errors.Is(err, ErrDeclared) {
+			t.Errorf("Declare() error = %v, want ErrDeclared", err)
+		}
+	})
+
+	t.Run("when type missing then error", func(t *testing.T) {
+		if err := Declare(Dependency{Name: "cache"}); err == nil {
+			t.Errorf("Declare() error = nil, want error")
+		}
+	})
+
+	t.Run("when lookup then return declared", func(t *testing.T) {
+		dep, ok := Lookup("payment-api")
+		if !ok || dep.Target != "pay.example.com" {
+			t.Errorf("Lookup() = %+v, %v", dep, ok)
+		}
+	})
+
+	t.Run("when list then sorted by name", func(t *testing.T) {
+		all := All()
+		if len(all) != 2 || all[0].Name != "orders-db" || all[1].Name != "payment-api" {
+			t.Errorf("All() = %+v", all)
+		}
+		if mysql := OfType(TypeMySQL); len(mysql) != 1 || mysql[0].Name != "orders-db" {
+			t.Errorf("OfType() = %+v", mysql)
+		}
+	})
+}
+
+func TestResolve(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "info.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	logger.Configure(logger.Config{
+		LoggingLevel:          logger.InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     out,
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    out,
+	})
+	defer logger.Configure(logger.Config{LoggingLevel: logger.InfoLevel})
+
+	// logged returns the logs written since the last call
+	var read int
+	logged := func() string {
+		logger.Sync()
+		bs, _ := os.ReadFile(out.Name())
+		log := string(bs[read:])
+		read = len(bs)
+		return log
+	}
+	logged() // skip the logs of Configure
+
+	if err := Declare(Dependency{Name: "orders-cache", Type: TypeRedis, Target: "redis:6379"}); err != nil {
+		t.Fatalf("Declare() error = %v", err)
+	}
+
+	t.Run("when declared then return it labeled with its type", func(t *testing.T) {
+		dep := Resolve("orders-cache", TypeRedis)
+		if dep.Target != "redis:6379" || dep.Label() != "redis:orders-cache" {
+			t.Errorf("Resolve() = %+v, label %v", dep, dep.Label())
+		}
+		if log := logged(); log != "" {
+			t.Errorf("log = %v, want none", log)
+		}
+	})
+
+	t.Run("when undeclared then warn and resolve to the client type", func(t *testing.T) {
+		dep := Resolve("orders-cahce", TypeRedis)
+		if dep.Label() != "redis:orders-cahce" {
+			t.Errorf("Label() = %v, want redis:orders-cahce", dep.Label())
+		}
+		if log := logged(); !strings.Contains(log, "dependency not declared") {
+			t.Errorf("log = %v, want the undeclared warning", log)
+		}
+	})
+
+	t.Run("when declared with another type then warn and resolve to the client type", func(t *testing.T) {
+		dep := Resolve("orders-cache", TypeHTTP)
+		if dep.Label() != "http:orders-cache" {
+			t.Errorf("Label() = %v, want http:orders-cache", dep.Label())
+		}
+		if log := logged(); !strings.Contains(log, "dependency declared with another type") {
+			t.Errorf("log = %v, want the type warning", log)
+		}
+	})
+
+	t.Run("when no name then no label", func(t *testing.T) {
+		if label := Resolve("", TypeRedis).Label(); label != "" {
+			t.Errorf("Label() = %v, want none", label)
+		}
+		if log := logged(); log != "" {
+			t.Errorf("log = %v, want none", log)
+		}
+	})
+}
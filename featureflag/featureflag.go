@@ -0,0 +1,65 @@
+// Package featureflag evaluates feature flags of bool, percentage rollout and attribute rules
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+type Client interface {
+	// Bool returns the value of the flag for subject, def if the flag is not found or failed to load
+	Bool(ctx context.Context, key string, subject Subject, def bool) bool
+	// Evaluate returns the decision of the flag for subject with its reason
+	Evaluate(ctx context.Context, key string, subject Subject, def bool) Decision
+}
+
+type client struct {
+	provider Provider
+}
+
+func NewClient(provider Provider) Client {
+	return &client{
+		provider: provider,
+	}
+}
+
+func (c *client) Bool(ctx context.Context, key string, subject Subject, def bool) bool {
+	return c.Evaluate(ctx, key, subject, def).Value
+}
+
+// Evaluate counts the decisions as flag_<key> labeled with the value and reason
+func (c *client) Evaluate(ctx context.Context, key string, subject Subject, def bool) (d Decision) {
+	defer func() {
+		monitor.FromContext(ctx).Count(ctx, "flag_"+key, 0, strconv.FormatBool(d.Value)+":"+d.Reason)
+	}()
+
+	flag, err := c.provider.GetFlag(ctx, key)
+	if err != nil {
+		d = Decision{Key: key, Value: def, Reason: ReasonNotFound}
+		if !errors.Is(err, ErrFlagNotFound) {
+			d.Reason = ReasonError
+			logger.FromContext(ctx).WithError(err).WithField("flag", key).Warn("get flag error, use default")
+		}
+		return d
+	}
+
+	return flag.evaluate(subject)
+}
+
+// WithDecisions returns a copy of ctx whose logger has the decisions as fields, e.g. "flag.new_checkout": "true:rule",
+// so the logs tell which flags the request was served with
+func WithDecisions(ctx context.Context, decisions ...Decision) context.Context {
+	if len(decisions) == 0 {
+		return ctx
+	}
+
+	fields := make(logger.Fields, len(decisions))
+	for _, d := range decisions {
+		fields["flag."+d.Key] = strconv.FormatBool(d.Value) + ":" + d.Reason
+	}
+	return logger.WithLogger(ctx, logger.FromContext(ctx).WithFields(fields))
+}
@@ -0,0 +1,118 @@
+package featureflag
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tenz-io/trackingo/cache"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestFlag_evaluate(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    *Flag
+		subject Subject
+		want    Decision
+	}{
+		{
+			name: "when flag disabled then off",
+			flag: &Flag{Key: "f", Enabled: false},
+			want: Decision{Key: "f", Value: false, Reason: ReasonDisabled},
+		},
+		{
+			name: "when flag enabled without rules then on",
+			flag: &Flag{Key: "f", Enabled: true},
+			want: Decision{Key: "f", Value: true, Reason: ReasonOn},
+		},
+		{
+			name: "when rule matches then serve its value",
+			flag: &Flag{Key: "f", Enabled: true, Percentage: intPtr(0), Rules: []Rule{
+				{Attribute: "country", Values: []string{"SG", "MY"}, Value: true},
+			}},
+			subject: Subject{Key: "u1", Attrs: map[string]string{"country": "MY"}},
+			want:    Decision{Key: "f", Value: true, Reason: ReasonRule},
+		},
+		{
+			name: "when no rule matches then use percentage",
+			flag: &Flag{Key: "f", Enabled: true, Percentage: intPtr(0), Rules: []Rule{
+				{Attribute: "country", Values: []string{"SG"}, Value: true},
+			}},
+			subject: Subject{Key: "u1", Attrs: map[string]string{"country": "VN"}},
+			want:    Decision{Key: "f", Value: false, Reason: ReasonPercentage},
+		},
+		{
+			name:    "when percentage is 100 then on",
+			flag:    &Flag{Key: "f", Enabled: true, Percentage: intPtr(100)},
+			subject: Subject{Key: "u1"},
+			want:    Decision{Key: "f", Value: true, Reason: ReasonPercentage},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.flag.evaluate(tt.subject); got != tt.want {
+				t.Errorf("evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_bucket(t *testing.T) {
+	on := 0
+	for i := 0; i < 1000; i++ {
+		if bucket("f", "user"+strconv.Itoa(i)) < 30 {
+			on++
+		}
+	}
+	if on < 200 || on > 400 {
+		t.Errorf("bucket() 30%% rollout got %d of 1000", on)
+	}
+	if bucket("f", "u1") != bucket("f", "u1") {
+		t.Errorf("bucket() want stable result")
+	}
+}
+
+func TestClient_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	provider := NewCacheProvider(cache.NewLocal(), time.Minute)
+	c := NewClient(provider)
+
+	if got := c.Evaluate(ctx, "new_checkout", Subject{}, true); got.Value != true || got.Reason != ReasonNotFound {
+		t.Errorf("Evaluate() = %v, want default with not_found", got)
+	}
+
+	if err := provider.SetFlag(ctx, &Flag{Key: "new_checkout", Enabled: false}); err != nil {
+		t.Fatalf("SetFlag() error = %v", err)
+	}
+	if got := c.Bool(ctx, "new_checkout", Subject{}, true); got {
+		t.Errorf("Bool() = %v, want false after flag saved", got)
+	}
+}
+
+func TestCacheProvider_GetFlag(t *testing.T) {
+	ctx := context.Background()
+	store := cache.NewLocal()
+	provider := NewCacheProvider(store, time.Minute)
+	now := time.Now()
+	provider.nowFunc = func() time.Time { return now }
+
+	_ = store.Set(ctx, flagKeyPrefix+"f", `{"key":"f","enabled":true}`, 0)
+	if flag, err := provider.GetFlag(ctx, "f"); err != nil || !flag.Enabled {
+		t.Fatalf("GetFlag() = %v, %v", flag, err)
+	}
+
+	_ = store.Set(ctx, flagKeyPrefix+"f", `{"key":"f","enabled":false}`, 0)
+	if flag, _ := provider.GetFlag(ctx, "f"); !flag.Enabled {
+		t.Errorf("GetFlag() want locally cached flag before ttl")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if flag, _ := provider.GetFlag(ctx, "f"); flag.Enabled {
+		t.Errorf("GetFlag() want reloaded flag after ttl")
+	}
+}
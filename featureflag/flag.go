@@ -0,0 +1,90 @@
+package featureflag
+
+import (
+	"hash/fnv"
+)
+
+// Flag is the definition of a feature flag, evaluated in order:
+// disabled flags are off, then the first matching rule decides,
+// then the subject is in the rollout percentage or not, on if Percentage is nil
+type Flag struct {
+	Key     string `json:"key"`
+	Enabled bool   `json:"enabled"`
+	Rules   []Rule `json:"rules,omitempty"`
+	// Percentage in [0, 100] rolls out the flag to a stable part of the subjects by their key
+	Percentage *int `json:"percentage,omitempty"`
+}
+
+// Rule matches the subjects whose Attribute is one of Values, and serves Value to them
+type Rule struct {
+	Attribute string   `json:"attribute"`
+	Values    []string `json:"values"`
+	Value     bool     `json:"value"`
+}
+
+// Subject is who the flag is evaluated for, e.g. a user with its country and app version
+type Subject struct {
+	Key   string
+	Attrs map[string]string
+}
+
+const (
+	ReasonNotFound   = "not_found"
+	ReasonError      = "error"
+	ReasonDisabled   = "disabled"
+	ReasonRule       = "rule"
+	ReasonPercentage = "percentage"
+	ReasonOn         = "on"
+)
+
+// Decision is the result of a flag evaluation
+type Decision struct {
+	Key    string `json:"key"`
+	Value  bool   `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// evaluate decides the value of f for subject
+func (f *Flag) evaluate(subject Subject) Decision {
+	d := Decision{Key: f.Key}
+
+	if !f.Enabled {
+		d.Reason = ReasonDisabled
+		return d
+	}
+
+	for _, rule := range f.Rules {
+		if rule.match(subject) {
+			d.Value, d.Reason = rule.Value, ReasonRule
+			return d
+		}
+	}
+
+	if f.Percentage != nil {
+		d.Value, d.Reason = bucket(f.Key, subject.Key) < *f.Percentage, ReasonPercentage
+		return d
+	}
+
+	d.Value, d.Reason = true, ReasonOn
+	return d
+}
+
+func (r *Rule) match(subject Subject) bool {
+	val, ok := subject.Attrs[r.Attribute]
+	if !ok {
+		return false
+	}
+	for _, v := range r.Values {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+// bucket maps the subject to [0, 100) stably, salted by the flag so rollouts of flags are independent
+func bucket(flagKey, subjectKey string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(flagKey + ":" + subjectKey))
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,119 @@
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tenz-io/trackingo/cache"
+)
+
+const (
+	flagKeyPrefix   = "featureflag:"
+	defaultLocalTTL = 30 * time.Second
+)
+
+var (
+	ErrFlagNotFound = fmt.Errorf("flag not found")
+)
+
+// Provider provides the flag definitions
+type Provider interface {
+	// GetFlag returns the flag of key, ErrFlagNotFound if not defined
+	GetFlag(ctx context.Context, key string) (*Flag, error)
+}
+
+// CacheProvider stores the flags as json in a cache.Manager, e.g. redis shared by all instances,
+// and caches them locally for a ttl to keep evaluations off the network
+type CacheProvider struct {
+	store    cache.Manager
+	localTTL time.Duration
+	nowFunc  func() time.Time
+
+	lock  sync.RWMutex
+	local map[string]localFlag
+}
+
+type localFlag struct {
+	flag     *Flag // nil if not found
+	expireAt time.Time
+}
+
+// NewCacheProvider creates the provider over store, localTTL is 30s if not positive
+func NewCacheProvider(store cache.Manager, localTTL time.Duration) *CacheProvider {
+	if localTTL <= 0 {
+		localTTL = defaultLocalTTL
+	}
+	return &CacheProvider{
+		store:    store,
+		localTTL: localTTL,
+		nowFunc:  time.Now,
+		local:    make(map[string]localFlag),
+	}
+}
+
+func (p *CacheProvider) GetFlag(ctx context.Context, key string) (*Flag, error) {
+	p.lock.RLock()
+	lf, ok := p.local[key]
+	p.lock.RUnlock()
+	if ok && p.nowFunc().Before(lf.expireAt) {
+		if lf.flag == nil {
+			return nil, ErrFlagNotFound
+		}
+		return lf.flag, nil
+	}
+
+	flag, err := p.load(ctx, key)
+	if err != nil && !errors.Is(err, ErrFlagNotFound) {
+		// keep serving the stale flag if any
+		if ok && lf.flag != nil {
+			return lf.flag, nil
+		}
+		return nil, err
+	}
+
+	p.lock.Lock()
+	p.local[key] = localFlag{
+		flag:     flag,
+		expireAt: p.nowFunc().Add(p.localTTL),
+	}
+	p.lock.Unlock()
+
+	return flag, err
+}
+
+// SetFlag saves flag to the store, instances see it once their local cache expires
+func (p *CacheProvider) SetFlag(ctx context.Context, flag *Flag) error {
+	bs, err := json.Marshal(flag)
+	if err != nil {
+		return fmt.Errorf("marshal flag error: %w", err)
+	}
+
+	if err = p.store.Set(ctx, flagKeyPrefix+flag.Key, string(bs), 0); err != nil {
+		return fmt.Errorf("save flag error: %w", err)
+	}
+
+	p.lock.Lock()
+	delete(p.local, flag.Key)
+	p.lock.Unlock()
+	return nil
+}
+
+func (p *CacheProvider) load(ctx context.Context, key string) (*Flag, error) {
+	raw, err := p.store.Get(ctx, flagKeyPrefix+key)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return nil, ErrFlagNotFound
+		}
+		return nil, fmt.Errorf("load flag error: %w", err)
+	}
+
+	flag := &Flag{}
+	if err = json.Unmarshal([]byte(raw), flag); err != nil {
+		return nil, fmt.Errorf("unmarshal flag error: %w", err)
+	}
+	return flag, nil
+}
@@ -0,0 +1,253 @@
+// Package grpccli provides the gRPC client interceptors, the counterpart of httpcli for gRPC dependencies.
+package grpccli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/dependencies"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/tracking"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type Opt func(i *interceptor)
+
+type Opts []Opt
+
+type interceptor struct {
+	enableMetrics bool
+	enableTraffic bool
+	dependency    string
+}
+
+func WithMetrics() Opt {
+	return func(i *interceptor) {
+		i.enableMetrics = true
+	}
+}
+
+func WithTraffic() Opt {
+	return func(i *interceptor) {
+		i.enableTraffic = true
+	}
+}
+
+// WithDependency tags the metrics and traffic logs with the declared grpc dependency of name
+func WithDependency(name string) Opt {
+	return func(i *interceptor) {
+		i.dependency = dependencies.Resolve(name, dependencies.TypeGRPC).Label()
+	}
+}
+
+func newInterceptor(opts Opts) *interceptor {
+	i := &interceptor{}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// UnaryClientInterceptor propagates the tracking info of ctx and records the metrics and traffic of unary calls,
+// the method name e.g. /helloworld.Greeter/SayHello is the cmd of them
+func UnaryClientInterceptor(opts Opts) grpc.UnaryClientInterceptor {
+	i := newInterceptor(opts)
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		callOpts ...grpc.CallOption,
+	) (err error) {
+		ctx = outgoingContext(ctx)
+
+		if i.enableMetrics {
+			rec := monitor.BeginRecord(ctx, method)
+			defer func() {
+				rec.EndWithErrorOpt(toValError(err), i.dependency)
+			}()
+		}
+
+		if i.enableTraffic {
+			trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
+				Cmd: method,
+				Req: req,
+			}, i.fields(logger.Fields{
+				"target": cc.Target(),
+			}))
+			defer func() {
+				var resp any
+				if err == nil {
+					resp = reply
+				}
+				trafficRec.End(&logger.TrafficResp{
+					Code: common.ErrorCode(toValError(err)),
+					Msg:  common.ErrorMsg(err),
+					Resp: resp,
+				}, logger.Fields{
+					"status": status.Code(err).String(),
+				})
+			}()
+		}
+
+		return invoker(ctx, method, req, reply, cc, callOpts...)
+	}
+}
+
+// StreamClientInterceptor propagates the tracking info of ctx and records the metrics and traffic of streams,
+// the stream is recorded from its creation to its end, sent and received messages are counted
+func StreamClientInterceptor(opts Opts) grpc.StreamClientInterceptor {
+	i := newInterceptor(opts)
+
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		callOpts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		ctx = outgoingContext(ctx)
+
+		ts := &trackedStream{
+			ctx:           ctx,
+			method:        method,
+			serverStreams: desc.ServerStreams,
+			dependency:    i.dependency,
+			done:          make(chan struct{}),
+		}
+
+		if i.enableMetrics {
+			ts.rec = monitor.BeginRecord(ctx, method)
+		}
+
+		if i.enableTraffic {
+			ts.trafficRec = logger.StartTrafficRec(ctx, &logger.TrafficReq{
+				Cmd: method,
+			}, i.fields(logger.Fields{
+				"target":        cc.Target(),
+				"client_stream": desc.ClientStreams,
+				"server_stream": desc.ServerStreams,
+			}))
+		}
+
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			ts.end(err)
+			return nil, err
+		}
+
+		ts.ClientStream = cs
+		go ts.watch()
+		return ts, nil
+	}
+}
+
+// trackedStream ends the records when the stream finishes, that is RecvMsg returns an error (io.EOF for success),
+// RecvMsg receives the only response of a non server streaming call, SendMsg fails or ctx is done
+type trackedStream struct {
+	grpc.ClientStream
+	ctx           context.Context
+	method        string
+	serverStreams bool
+	dependency    string
+	rec           *monitor.Recorder
+	trafficRec    *logger.TrafficRec
+	sent          atomic.Int64
+	received      atomic.Int64
+	once          sync.Once
+	done          chan struct{} // closed by end
+}
+
+func (s *trackedStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil {
+		s.end(err)
+		return err
+	}
+	s.sent.Add(1)
+	return nil
+}
+
+func (s *trackedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			s.end(nil)
+		} else {
+			s.end(err)
+		}
+		return err
+	}
+	s.received.Add(1)
+	if !s.serverStreams {
+		// e.g. CloseAndRecv of the client streams, no more message follows
+		s.end(nil)
+	}
+	return nil
+}
+
+// watch ends the records when ctx is done before the stream finishes
+func (s *trackedStream) watch() {
+	select {
+	case <-s.ctx.Done():
+		s.end(status.FromContextError(s.ctx.Err()).Err())
+	case <-s.done:
+	}
+}
+
+func (s *trackedStream) end(err error) {
+	s.once.Do(func() {
+		close(s.done)
+
+		if s.rec != nil {
+			s.rec.EndWithErrorOpt(toValError(err), s.dependency)
+		}
+
+		if s.trafficRec != nil {
+			s.trafficRec.End(&logger.TrafficResp{
+				Code: common.ErrorCode(toValError(err)),
+				Msg:  common.ErrorMsg(err),
+			}, logger.Fields{
+				"status":   status.Code(err).String(),
+				"sent":     s.sent.Load(),
+				"received": s.received.Load(),
+			})
+		}
+	})
+}
+
+// fields adds the dependency to the traffic log fields
+func (i *interceptor) fields(fields logger.Fields) logger.Fields {
+	if i.dependency != "" {
+		fields["dependency"] = i.dependency
+	}
+	return fields
+}
+
+// outgoingContext adds the tracking info of ctx to the outgoing metadata, a requestId is generated if none
+func outgoingContext(ctx context.Context) context.Context {
+	ctx, _ = tracking.EnsureRequestId(ctx)
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	tracking.Inject(ctx, tracking.MetadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// toValError converts err to ValError with the code of its grpc status, so that the metrics are labeled with it
+func toValError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return common.NewValError(common.FromGRPCCode(uint32(status.Code(err))), err)
+}
@@ -0,0 +1,317 @@
+package grpccli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/tracking"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	echoMethod    = "/test.Echo/Echo"
+	collectMethod = "/test.Echo/Collect"
+	watchMethod   = "/test.Echo/Watch"
+	chatMethod    = "/test.Echo/Chat"
+)
+
+var (
+	collectDesc = &grpc.StreamDesc{StreamName: "Collect", ClientStreams: true}
+	watchDesc   = &grpc.StreamDesc{StreamName: "Watch", ServerStreams: true}
+	chatDesc    = &grpc.StreamDesc{StreamName: "Chat", ClientStreams: true, ServerStreams: true}
+)
+
+// echoService replies the request id of the incoming metadata to Echo, NotFound to the empty requests,
+// the count of the messages to Collect, blocks Watch until the client is gone and echoes Chat
+var echoService = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+			req := &wrapperspb.StringValue{}
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if req.GetValue() == "" {
+				return nil, status.Error(codes.NotFound, "not found")
+			}
+			md, _ := metadata.FromIncomingContext(ctx)
+			return wrapperspb.String(tracking.MetadataCarrier(md).Get(tracking.HeaderRequestId)), nil
+		},
+	}},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Collect",
+			ClientStreams: true,
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				n := 0
+				for {
+					err := stream.RecvMsg(&wrapperspb.StringValue{})
+					if errors.Is(err, io.EOF) {
+						return stream.SendMsg(wrapperspb.String(strconv.Itoa(n)))
+					}
+					if err != nil {
+						return err
+					}
+					n++
+				}
+			},
+		},
+		{
+			StreamName:    "Watch",
+			ServerStreams: true,
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				<-stream.Context().Done()
+				return stream.Context().Err()
+			},
+		},
+		{
+			StreamName:    "Chat",
+			ClientStreams: true,
+			ServerStreams: true,
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				for {
+					msg := &wrapperspb.StringValue{}
+					if err := stream.RecvMsg(msg); err != nil {
+						if errors.Is(err, io.EOF) {
+							return nil
+						}
+						return err
+					}
+					if err := stream.SendMsg(msg); err != nil {
+						return err
+					}
+				}
+			},
+		},
+	},
+}
+
+// newTestConn returns the client conn of the echo service served by bufconn with the interceptors of opts
+func newTestConn(t *testing.T, opts Opts) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	srv.RegisterService(&echoService, nil)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(opts)),
+	)
+	if err != nil {
+		t.Fatalf("dial error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cc.Close()
+	})
+	return cc
+}
+
+// metricValue returns the value of the counter or the gauge of name with the labels
+func metricValue(t *testing.T, name, cmd, dsCmd, code, opt string) float64 {
+	t.Helper()
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather metrics error = %v", err)
+	}
+
+	want := map[string]string{"cmd": cmd, "dsCmd": dsCmd, "code": code, "opt": opt}
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			matched := 0
+			for _, l := range m.GetLabel() {
+				if v, ok := want[l.GetName()]; ok && v == l.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(want) {
+				return m.GetCounter().GetValue() + m.GetGauge().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+// eventually waits the metric of the recorders, which are written asynchronously
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 100 && !cond(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !cond() {
+		t.Errorf("condition not met in 1s")
+	}
+}
+
+func actives(t *testing.T, cmd, method string) float64 {
+	return metricValue(t, "trackingo_flight_singleFlightG", cmd, method, "0", "actives")
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	cc := newTestConn(t, Opts{WithMetrics()})
+
+	t.Run("when call then the request id is propagated", func(t *testing.T) {
+		ctx := tracking.WithRequestId(context.Background(), "req-grpc")
+		reply := &wrapperspb.StringValue{}
+		if err := cc.Invoke(ctx, echoMethod, wrapperspb.String("hi"), reply); err != nil || reply.GetValue() != "req-grpc" {
+			t.Errorf("Invoke() = %v, %v, want req-grpc", reply.GetValue(), err)
+		}
+	})
+
+	t.Run("when call fails then counted with the code of the grpc status", func(t *testing.T) {
+		ctx := monitor.InitSingleFlight(context.Background(), "grpccli_unary")
+		code := strconv.Itoa(common.FromGRPCCode(uint32(codes.NotFound)))
+		before := metricValue(t, "trackingo_flight_singleFlightC", "grpccli_unary", echoMethod, code, "NA")
+		err := cc.Invoke(ctx, echoMethod, wrapperspb.String(""), &wrapperspb.StringValue{})
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("Invoke() error = %v, want NotFound", err)
+		}
+		eventually(t, func() bool {
+			return metricValue(t, "trackingo_flight_singleFlightC", "grpccli_unary", echoMethod, code, "NA") == before+1
+		})
+	})
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	logger.ConfigureTrafficLog(logger.TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+		JSONFormat:            true,
+	})
+	defer logger.ConfigureTrafficLog(logger.TrafficLogConfig{})
+
+	// lastRecord returns the last traffic record of cmd
+	lastRecord := func(t *testing.T, cmd string) map[string]any {
+		logger.Sync()
+		f, _ := os.Open(out.Name())
+		defer f.Close()
+		var last map[string]any
+		for scanner := bufio.NewScanner(f); scanner.Scan(); {
+			var record map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("unmarshal %s error = %v", scanner.Text(), err)
+			}
+			if record["cmd"] == cmd {
+				last = record
+			}
+		}
+		return last
+	}
+
+	cc := newTestConn(t, Opts{WithMetrics(), WithTraffic()})
+
+	t.Run("when CloseAndRecv of a client stream then the records end", func(t *testing.T) {
+		ctx := monitor.InitSingleFlight(context.Background(), "grpccli_collect")
+		before := metricValue(t, "trackingo_flight_singleFlightC", "grpccli_collect", collectMethod, "0", "NA")
+		cs, err := cc.NewStream(ctx, collectDesc, collectMethod)
+		if err != nil {
+			t.Fatalf("NewStream() error = %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			if err := cs.SendMsg(wrapperspb.String("msg")); err != nil {
+				t.Fatalf("SendMsg() error = %v", err)
+			}
+		}
+		_ = cs.CloseSend()
+		reply := &wrapperspb.StringValue{}
+		if err := cs.RecvMsg(reply); err != nil || reply.GetValue() != "3" {
+			t.Fatalf("RecvMsg() = %v, %v, want 3", reply.GetValue(), err)
+		}
+
+		eventually(t, func() bool {
+			return actives(t, "grpccli_collect", collectMethod) == 0 &&
+				metricValue(t, "trackingo_flight_singleFlightC", "grpccli_collect", collectMethod, "0", "NA") == before+1
+		})
+		if record := lastRecord(t, collectMethod); record["sent"] != float64(3) || record["received"] != float64(1) {
+			t.Errorf("traffic = %v, want sent 3 and received 1", record)
+		}
+	})
+
+	t.Run("when ctx is done before a server stream ends then the records end", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(monitor.InitSingleFlight(context.Background(), "grpccli_watch"))
+		cs, err := cc.NewStream(ctx, watchDesc, watchMethod)
+		if err != nil {
+			t.Fatalf("NewStream() error = %v", err)
+		}
+		_ = cs.SendMsg(wrapperspb.String("watch"))
+		_ = cs.CloseSend()
+		eventually(t, func() bool {
+			return actives(t, "grpccli_watch", watchMethod) == 1
+		})
+
+		cancel()
+		eventually(t, func() bool {
+			return actives(t, "grpccli_watch", watchMethod) == 0
+		})
+		if record := lastRecord(t, watchMethod); record["status"] != codes.Canceled.String() {
+			t.Errorf("traffic = %v, want Canceled", record)
+		}
+	})
+
+	t.Run("when send and recv on different goroutines then no race", func(t *testing.T) {
+		cs, err := cc.NewStream(context.Background(), chatDesc, chatMethod)
+		if err != nil {
+			t.Fatalf("NewStream() error = %v", err)
+		}
+
+		const n = 20
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				_ = cs.SendMsg(wrapperspb.String("ping"))
+			}
+			_ = cs.CloseSend()
+		}()
+		received := 0
+		for cs.RecvMsg(&wrapperspb.StringValue{}) == nil {
+			received++
+		}
+		wg.Wait()
+
+		if record := lastRecord(t, chatMethod); received != n || record["sent"] != float64(n) || record["received"] != float64(n) {
+			t.Errorf("received = %v, traffic = %v, want %v", received, record, n)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tenz-io/trackingo/cache"
+	"github.com/tenz-io/trackingo/dborm"
+)
+
+const (
+	cacheProbeKey = "_health_probe"
+)
+
+// DBChecker pings the database of m
+func DBChecker(m dborm.Manager) Checker {
+	return func(ctx context.Context) error {
+		db, err := m.GetDB(ctx)
+		if err != nil {
+			return err
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("get sql db error: %w", err)
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// CacheChecker reads a probe key of m, a missing key is healthy
+func CacheChecker(m cache.Manager) Checker {
+	return func(ctx context.Context) error {
+		_, err := m.Get(ctx, cacheProbeKey)
+		if err != nil && !errors.Is(err, cache.ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+}
+
+// PingChecker adapts a component with Ping(ctx), e.g. a kafka producer or mongo client wrapper
+func PingChecker(pinger interface {
+	Ping(ctx context.Context) error
+}) Checker {
+	return func(ctx context.Context) error {
+		return pinger.Ping(ctx)
+	}
+}
@@ -0,0 +1,10 @@
+package health
+
+import "time"
+
+type Config struct {
+	// Timeout bounds every check, default 3s
+	Timeout time.Duration `yaml:"timeout" json:"timeout" default:"3s"`
+	// CacheTTL is how long the result of a check is reused, default 1s
+	CacheTTL time.Duration `yaml:"cache_ttl" json:"cache_ttl" default:"1s"`
+}
@@ -0,0 +1,258 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	defaultTimeout  = 3 * time.Second
+	defaultCacheTTL = time.Second
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+var (
+	ErrDuplicated = fmt.Errorf("health check already registered")
+)
+
+// Checker returns nil if the component is healthy
+type Checker func(ctx context.Context) error
+
+// Result is the last result of one check
+type Result struct {
+	Status    string        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// Report is the aggregated result of the checks, Status is down if any check is down
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+// Up reports whether all checks are up
+func (r *Report) Up() bool {
+	return r != nil && r.Status == StatusUp
+}
+
+type Manager interface {
+	// Register adds the named check, it's a readiness check unless WithLiveness
+	Register(name string, check Checker, opts ...Opt) error
+	// Liveness runs the liveness checks, a failure means the process should be restarted
+	Liveness(ctx context.Context) *Report
+	// Readiness runs all the checks, a failure means the process should not receive traffic
+	Readiness(ctx context.Context) *Report
+	// Names returns the sorted names of the checks.
+	Names() []string
+}
+
+type Opt func(*check)
+
+// WithLiveness makes the check a liveness check as well,
+// only for the components the process can't recover without restarting
+func WithLiveness() Opt {
+	return func(c *check) {
+		c.liveness = true
+	}
+}
+
+// WithTimeout overrides the Timeout of Config for the check
+func WithTimeout(timeout time.Duration) Opt {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// WithCacheTTL overrides the CacheTTL of Config for the check
+func WithCacheTTL(ttl time.Duration) Opt {
+	return func(c *check) {
+		c.cacheTTL = ttl
+	}
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	liveness bool
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	lock   sync.Mutex
+	result *Result
+}
+
+type manager struct {
+	cfg    *Config
+	mon    monitor.SingleFlight
+	lock   sync.RWMutex
+	checks map[string]*check
+}
+
+// NewManager creates an empty health manager, the components register their checks to it
+func NewManager(cfg *Config) Manager {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	return &manager{
+		cfg:    cfg,
+		mon:    monitor.NewSingleFlight("health"),
+		checks: make(map[string]*check),
+	}
+}
+
+func (m *manager) Register(name string, checker Checker, opts ...Opt) error {
+	if name == "" || checker == nil {
+		return fmt.Errorf("health check name and checker are required")
+	}
+
+	c := &check{
+		name:     name,
+		checker:  checker,
+		timeout:  m.cfg.Timeout,
+		cacheTTL: m.cfg.CacheTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.timeout <= 0 {
+		c.timeout = defaultTimeout
+	}
+	if c.cacheTTL < 0 {
+		c.cacheTTL = defaultCacheTTL
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.checks[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicated, name)
+	}
+	m.checks[name] = c
+	return nil
+}
+
+func (m *manager) Liveness(ctx context.Context) *Report {
+	return m.run(ctx, true)
+}
+
+func (m *manager) Readiness(ctx context.Context) *Report {
+	return m.run(ctx, false)
+}
+
+func (m *manager) Names() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	names := make([]string, 0, len(m.checks))
+	for name := range m.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// run runs the checks concurrently, the cached results are reused
+func (m *manager) run(ctx context.Context, livenessOnly bool) *Report {
+	m.lock.RLock()
+	checks := make([]*check, 0, len(m.checks))
+	for _, c := range m.checks {
+		if !livenessOnly || c.liveness {
+			checks = append(checks, c)
+		}
+	}
+	m.lock.RUnlock()
+
+	report := &Report{
+		Status: StatusUp,
+		Checks: make(map[string]*Result, len(checks)),
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c *check) {
+			defer wg.Done()
+			result := m.runCheck(ctx, c)
+
+			lock.Lock()
+			defer lock.Unlock()
+			report.Checks[c.name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// runCheck returns the cached result of c if still valid, otherwise checks it again.
+// the check is serialized so that concurrent probes don't hit the component together
+func (m *manager) runCheck(ctx context.Context, c *check) *Result {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.result != nil && time.Since(c.result.CheckedAt) < c.cacheTTL {
+		return c.result
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	begin := time.Now()
+	err := safeCheck(checkCtx, c.checker)
+	result := &Result{
+		Status:    StatusUp,
+		Duration:  time.Since(begin),
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	if c.result == nil || c.result.Status != result.Status {
+		logger.WithError(err).WithFields(logger.Fields{
+			"check":  c.name,
+			"status": result.Status,
+		}).Warn("health check status changed")
+	}
+	c.result = result
+
+	var val float64
+	if err == nil {
+		val = 1
+	}
+	m.mon.Set(ctx, "health_"+c.name, common.ErrorCode(err), val, "")
+	m.mon.Sample(ctx, "health_"+c.name+"_latency", common.ErrorCode(err), float64(result.Duration.Milliseconds()), "")
+
+	return result
+}
+
+// safeCheck runs checker, a panic is reported as the failure of the check
+func safeCheck(ctx context.Context, checker Checker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("health check panic: %v", r)
+		}
+	}()
+	return checker(ctx)
+}
@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/tenz-io/trackingo/cache"
+)
+
+func TestManager_Readiness(t *testing.T) {
+	t.Run("when all checks are up then report is up", func(t *testing.T) {
+		m := NewManager(&Config{})
+		_ = m.Register("a", func(ctx context.Context) error { return nil })
+		_ = m.Register("b", func(ctx context.Context) error { return nil }, WithLiveness())
+
+		report := m.Readiness(context.Background())
+		if !report.Up() || len(report.Checks) != 2 {
+			t.Errorf("Readiness() = %+v, want up with 2 checks", report)
+		}
+	})
+
+	t.Run("when one check is down then report is down", func(t *testing.T) {
+		m := NewManager(&Config{})
+		_ = m.Register("a", func(ctx context.Context) error { return nil })
+		_ = m.Register("b", func(ctx context.Context) error { return errors.New("boom") })
+
+		report := m.Readiness(context.Background())
+		if report.Up() {
+			t.Errorf("Readiness() is up, want down")
+		}
+		if report.Checks["b"].Error != "boom" {
+			t.Errorf("Readiness() check b = %+v", report.Checks["b"])
+		}
+	})
+
+	t.Run("when check exceeds timeout then it's down", func(t *testing.T) {
+		m := NewManager(&Config{})
+		_ = m.Register("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, WithTimeout(10*time.Millisecond))
+
+		if report := m.Readiness(context.Background()); report.Up() {
+			t.Errorf("Readiness() is up, want down")
+		}
+	})
+
+	t.Run("when check panics then it's down", func(t *testing.T) {
+		m := NewManager(&Config{})
+		_ = m.Register("panic", func(ctx context.Context) error { panic("boom") })
+
+		if report := m.Readiness(context.Background()); report.Up() {
+			t.Errorf("Readiness() is up, want down")
+		}
+	})
+}
+
+func TestManager_Liveness(t *testing.T) {
+	m := NewManager(&Config{})
+	_ = m.Register("db", func(ctx context.Context) error { return errors.New("down") })
+	_ = m.Register("process", func(ctx context.Context) error { return nil }, WithLiveness())
+
+	report := m.Liveness(context.Background())
+	if !report.Up() || len(report.Checks) != 1 {
+		t.Errorf("Liveness() = %+v, want only the liveness check", report)
+	}
+}
+
+func TestManager_Cache(t *testing.T) {
+	var calls atomic.Int32
+	m := NewManager(&Config{CacheTTL: time.Minute})
+	_ = m.Register("a", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	m.Readiness(context.Background())
+	m.Readiness(context.Background())
+	if got := calls.Load(); got != 1 {
+		t.Errorf("checker calls = %v, want 1", got)
+	}
+}
+
+func TestManager_Register(t *testing.T) {
+	m := NewManager(&Config{})
+	if err := m.Register("a", func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := m.Register("a", func(ctx context.Context) error { return nil }); !errors.Is(err, ErrDuplicated) {
+		t.Errorf("Register() error = %v, want ErrDuplicated", err)
+	}
+}
+
+func TestCacheChecker(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name:    "when key not found then healthy",
+			err:     cache.ErrNotFound,
+			wantErr: false,
+		},
+		{
+			name:    "when cache fails then unhealthy",
+			err:     errors.New("conn refused"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := cache.NewMockManager(t)
+			cm.On("Get", mock.Anything, cacheProbeKey).Return("", tt.err)
+
+			if err := CacheChecker(cm)(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("CacheChecker() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/tenz-io/trackingo/breaker"
 	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/dependencies"
 	"github.com/tenz-io/trackingo/logger"
-	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/retry"
+	"github.com/tenz-io/trackingo/tracking"
 	"github.com/tenz-io/trackingo/util"
 	"io"
 	"net/http"
@@ -73,6 +77,9 @@ type client struct {
 	sender        sender
 	enableMetrics bool
 	enableTraffic bool
+	retryOpts     []retry.Opt
+	breaker       breaker.Breaker
+	dependency    string
 }
 
 func WithMetrics() Opt {
@@ -87,6 +94,29 @@ func WithTraffic() Opt {
 	}
 }
 
+// WithRetry retries the idempotent requests, e.g. GET and PUT, on send errors
+// and 429 or 5xx responses, see retry.Do for opts
+func WithRetry(opts ...retry.Opt) Opt {
+	return func(c *client) {
+		c.retryOpts = append([]retry.Opt{retry.WithRetryable(retryable)}, opts...)
+	}
+}
+
+// WithBreaker guards the requests with b, keyed by the host of the request url.
+// requests fail fast with breaker.ErrOpen while the host keeps failing
+func WithBreaker(b breaker.Breaker) Opt {
+	return func(c *client) {
+		c.breaker = b
+	}
+}
+
+// WithDependency tags the metrics and traffic logs with the declared http dependency of the upstream
+func WithDependency(name string) Opt {
+	return func(c *client) {
+		c.dependency = dependencies.Resolve(name, dependencies.TypeHTTP).Label()
+	}
+}
+
 func (c *client) Head(
 	ctx context.Context,
 	url string,
@@ -178,53 +208,84 @@ func (c *client) Request(ctx context.Context, req *http.Request) (resp *http.Res
 	var (
 		path       = req.URL.Path
 		cmd        = util.If(path == "", "/", path)
-		code       = 0
 		respHeader http.Header
 		respCode   int
 	)
 
-	if c.enableMetrics {
-		rec := monitor.BeginRecord(ctx, cmd)
-		defer func() {
-			rec.EndWithError(err)
-		}()
+	if req.Header == nil {
+		req.Header = http.Header{}
 	}
+	tracking.Inject(ctx, tracking.HeaderCarrier(req.Header))
 
+	var (
+		reqPayload any
+		reqFields  logger.Fields
+		pairId     string
+	)
 	if c.enableTraffic {
+		// the callee logs its traffic with the pair id of the call
+		pairId = logger.NewPairId()
+		req.Header.Set(logger.HeaderPairId, pairId)
+
 		reqBody := captureRequest(ctx, req)
-		trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: cmd,
-			Req: printPayload(req.Header, reqBody),
-		}, logger.Fields{
+		reqPayload = printPayload(req.Header, reqBody)
+		reqFields = logger.Fields{
 			"method":    req.Method,
 			"req_url":   req.URL.String(),
-			"header":    req.Header,
+			"header":    req.Header.Clone(),
 			"params":    req.URL.Query(),
 			"body_size": len(reqBody),
+		}
+	}
+	rec := logger.StartCall(ctx, cmd, reqPayload,
+		logger.CallMetrics(c.enableMetrics),
+		logger.CallTraffic(c.enableTraffic),
+		logger.CallFields(reqFields),
+		logger.CallDependency(c.dependency),
+		logger.CallPairId(pairId),
+	)
+	defer func() {
+		if !c.enableTraffic {
+			rec.End(err, nil)
+			return
+		}
+		respBody := captureResponse(ctx, resp)
+		rec.EndWithFields(err, printPayload(respHeader, respBody), logger.Fields{
+			"code":      respCode,
+			"header":    respHeader,
+			"body_size": len(respBody),
 		})
+	}()
+
+	if c.breaker != nil {
+		host := req.URL.Host
+		if err = c.breaker.Allow(host); err != nil {
+			return nil, common.Dependency(err)
+		}
 		defer func() {
-			var (
-				respBody = captureResponse(ctx, resp)
-			)
-			trafficRec.End(&logger.TrafficResp{
-				Code: common.ErrorCode(err),
-				Msg:  common.ErrorMsg(err),
-				Resp: printPayload(respHeader, respBody),
-			}, logger.Fields{
-				"code":      respCode,
-				"header":    respHeader,
-				"body_size": len(respBody),
-			})
+			c.breaker.Record(host, downstreamErr(err))
 		}()
 	}
 
-	resp, err = c.sender.Do(req)
-	if err != nil {
-		return resp, common.NewValError(1, fmt.Errorf("error sending request: %w", err))
+	if c.retryOpts != nil && canRetry(req) {
+		err = retry.Do(ctx, "http_"+cmd, func(ctx context.Context) error {
+			if resp != nil && resp.Body != nil {
+				// the response of the failed attempt is dropped
+				_ = resp.Body.Close()
+			}
+			if req.GetBody != nil {
+				if req.Body, err = req.GetBody(); err != nil {
+					return retry.Permanent(err)
+				}
+			}
+			resp, err = c.send(req)
+			return err
+		}, c.retryOpts...)
+	} else {
+		resp, err = c.send(req)
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return resp, common.NewValError(code, fmt.Errorf("response with status: %d", resp.StatusCode))
+	if err != nil {
+		return resp, err
 	}
 
 	respHeader = resp.Header
@@ -233,6 +294,56 @@ func (c *client) Request(ctx context.Context, req *http.Request) (resp *http.Res
 	return resp, nil
 }
 
+// send sends req once, a response other than 200 is an error with the code of its status
+func (c *client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.sender.Do(req)
+	if err != nil {
+		return resp, common.Dependency(fmt.Errorf("error sending request: %w", err))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp, common.NewValError(common.FromHTTPStatus(resp.StatusCode), &statusError{status: resp.StatusCode})
+	}
+
+	return resp, nil
+}
+
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("response with status: %d", e.status)
+}
+
+// canRetry reports whether req is idempotent and its body can be sent again
+func canRetry(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	default:
+		return false
+	}
+}
+
+// retryable retries the send errors, the throttled and the server side errors
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.status == http.StatusTooManyRequests || se.status >= http.StatusInternalServerError
+	}
+	return retry.Retryable(err)
+}
+
+// downstreamErr drops the errors which are not the fault of the server, e.g. 4xx responses
+func downstreamErr(err error) error {
+	var se *statusError
+	if errors.As(err, &se) && !retryable(err) {
+		return nil
+	}
+	return err
+}
+
 func (c *client) newRequest(ctx context.Context,
 	method string,
 	url string,
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/stretchr/testify/mock"
+	"github.com/tenz-io/trackingo/retry"
 	"net/http"
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_client_Request(t *testing.T) {
@@ -15,6 +17,7 @@ func Test_client_Request(t *testing.T) {
 		sender        sender
 		enableMetrics bool
 		enableTraffic bool
+		retryOpts     []retry.Opt
 	}
 	type behavior func(fields)
 	type args struct {
@@ -59,6 +62,65 @@ func Test_client_Request(t *testing.T) {
 			wantResp: nil,
 			wantErr:  true,
 		},
+		{
+			name: "when retry enabled and GET gets 503 then retry until 200",
+			fields: fields{
+				sender:    new(mockSender),
+				retryOpts: []retry.Opt{retry.WithBackoff(time.Millisecond, time.Millisecond)},
+			},
+			behavior: func(fields fields) {
+				var (
+					senderMock = fields.sender.(*mockSender)
+				)
+
+				senderMock.On("Do", mock.Anything).Return(
+					&http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody},
+					nil,
+				).Once()
+				senderMock.On("Do", mock.Anything).Return(
+					&http.Response{StatusCode: http.StatusOK, Body: http.NoBody},
+					nil,
+				).Once()
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &http.Request{
+					Method: http.MethodGet,
+					URL:    &url.URL{},
+					Header: http.Header{},
+				},
+			},
+			wantResp: &http.Response{StatusCode: http.StatusOK, Body: http.NoBody},
+			wantErr:  false,
+		},
+		{
+			name: "when retry enabled and POST fails then no retry",
+			fields: fields{
+				sender:    new(mockSender),
+				retryOpts: []retry.Opt{retry.WithBackoff(time.Millisecond, time.Millisecond)},
+			},
+			behavior: func(fields fields) {
+				var (
+					senderMock = fields.sender.(*mockSender)
+				)
+
+				senderMock.On("Do", mock.Anything).Return(
+					nil,
+					fmt.Errorf("some error"),
+				).Once()
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &http.Request{
+					Method: http.MethodPost,
+					URL:    &url.URL{},
+					Header: http.Header{},
+					Body:   http.NoBody,
+				},
+			},
+			wantResp: nil,
+			wantErr:  true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -67,6 +129,9 @@ func Test_client_Request(t *testing.T) {
 				enableMetrics: true,
 				enableTraffic: true,
 			}
+			if tt.fields.retryOpts != nil {
+				WithRetry(tt.fields.retryOpts...)(c)
+			}
 
 			tt.behavior(tt.fields)
 
@@ -77,9 +142,14 @@ func Test_client_Request(t *testing.T) {
 				t.Errorf("Request() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(gotResp, tt.wantResp) {
+			if gotResp != nil && tt.wantResp != nil {
+				if gotResp.StatusCode != tt.wantResp.StatusCode {
+					t.Errorf("Request() gotResp.StatusCode = %v, want %v", gotResp.StatusCode, tt.wantResp.StatusCode)
+				}
+			} else if !reflect.DeepEqual(gotResp, tt.wantResp) {
 				t.Errorf("Request() gotResp = %v, want %v", gotResp, tt.wantResp)
 			}
+			tt.fields.sender.(*mockSender).AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,233 @@
+package httpcli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tenz-io/trackingo/httpgin"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+// Test_propagation checks that a handler calling httpcli with the gin context forwards the
+// tracking info of the inbound request, and the access, traffic and client logs share its requestId
+func Test_propagation(t *testing.T) {
+	dir := t.TempDir()
+	logFile := createFile(t, dir, "info.log")
+	trafficFile := createFile(t, dir, "traffic.log")
+	logger.Configure(logger.Config{
+		LoggingLevel:          logger.InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     logFile,
+		ConsoleErrorStream:    logFile,
+		ConsoleDebugStream:    logFile,
+	})
+	logger.ConfigureTrafficLog(logger.TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         trafficFile,
+	})
+	defer func() {
+		logger.Configure(logger.Config{LoggingLevel: logger.InfoLevel})
+		logger.ConfigureTrafficLog(logger.TrafficLogConfig{})
+	}()
+
+	var downstreamHeader http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamHeader = r.Header.Clone()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer downstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	m := httpgin.NewManager(&httpgin.Config{
+		EnableAccess:  true,
+		AccessLogbase: dir,
+		EnableTraffic: true,
+		Timeout:       time.Minute,
+	})
+	cli := NewClient(http.DefaultClient, Opts{WithTraffic()})
+	m.GetEngine().GET("/call", func(c *gin.Context) {
+		if _, err := cli.Get(c, downstream.URL+"/down", nil, nil); err != nil {
+			c.String(http.StatusBadGateway, err.Error())
+			return
+		}
+		logger.FromContext(c).Info("downstream called")
+		c.String(http.StatusOK, "ok")
+	})
+
+	upstream := httptest.NewServer(m.Handler())
+	defer upstream.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, upstream.URL+"/call", nil)
+	req.Header.Set(tracking.HeaderRequestId, "req-e2e")
+	req.Header.Set(tracking.HeaderSpanId, "span-caller")
+	req.Header.Set(tracking.HeaderTenant, "tenant-1")
+	req.Header.Set(tracking.HeaderSampled, "1")
+	req.Header.Set(tracking.HeaderBudget, "5000")
+	req.Header.Set(logger.HeaderPairId, "pair-caller")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %v, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get(tracking.HeaderRequestId); got != "req-e2e" {
+		t.Errorf("response requestId = %v, want req-e2e", got)
+	}
+
+	t.Run("when handler calls downstream then tracking info is forwarded", func(t *testing.T) {
+		for header, want := range map[string]string{
+			tracking.HeaderRequestId: "req-e2e",
+			tracking.HeaderTraceId:   "req-e2e",
+			tracking.HeaderTenant:    "tenant-1",
+			tracking.HeaderSampled:   "1",
+		} {
+			if got := downstreamHeader.Get(header); got != want {
+				t.Errorf("downstream %s = %v, want %v", header, got, want)
+			}
+		}
+
+		if span := downstreamHeader.Get(tracking.HeaderSpanId); span == "" || span == "span-caller" {
+			t.Errorf("downstream span = %v, want the span of the handler", span)
+		}
+
+		budget, err := strconv.Atoi(downstreamHeader.Get(tracking.HeaderBudget))
+		if err != nil || budget <= 0 || budget > 5000 {
+			t.Errorf("downstream budget = %v, want in (0, 5000]", downstreamHeader.Get(tracking.HeaderBudget))
+		}
+	})
+
+	t.Run("when request is done then logs share the requestId", func(t *testing.T) {
+		access := readFile(t, filepath.Join(dir, "access.log"))
+		if !strings.Contains(access, "req-e2e") {
+			t.Errorf("access log has no requestId: %s", access)
+		}
+
+		traffic := readFile(t, trafficFile.Name())
+		for _, cmd := range []string{"/call", "/down"} {
+			if !containsLine(traffic, "req-e2e", cmd) {
+				t.Errorf("traffic log of %s has no requestId: %s", cmd, traffic)
+			}
+		}
+
+		info := readFile(t, logFile.Name())
+		if !containsLine(info, "req-e2e", "downstream called") {
+			t.Errorf("client log has no requestId: %s", info)
+		}
+	})
+
+	t.Run("when request is done then traffic shares the pair id with the caller and the callee", func(t *testing.T) {
+		traffic := readFile(t, trafficFile.Name())
+		if !containsLine(traffic, "pair-caller", "/call") {
+			t.Errorf("traffic log of /call has no pair id of the caller: %s", traffic)
+		}
+
+		pairId := downstreamHeader.Get(logger.HeaderPairId)
+		if pairId == "" || pairId == "pair-caller" {
+			t.Fatalf("downstream pair id = %v, want the pair id of the call", pairId)
+		}
+		if !containsLine(traffic, pairId, "/down") {
+			t.Errorf("traffic log of /down has no pair id %s: %s", pairId, traffic)
+		}
+	})
+}
+
+func Test_propagation_sampling(t *testing.T) {
+	var downstreamHeader http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamHeader = r.Header.Clone()
+	}))
+	defer downstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	m := httpgin.NewManager(&httpgin.Config{
+		TrafficSampleRatio: 0.000001,
+	})
+	cli := NewClient(http.DefaultClient, Opts{})
+	m.GetEngine().GET("/call", func(c *gin.Context) {
+		_, _ = cli.Get(c, downstream.URL, nil, nil)
+	})
+
+	upstream := httptest.NewServer(m.Handler())
+	defer upstream.Close()
+
+	resp, err := http.Get(upstream.URL + "/call")
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if got := downstreamHeader.Get(tracking.HeaderSampled); got != "0" {
+		t.Errorf("downstream %s = %v, want 0", tracking.HeaderSampled, got)
+	}
+}
+
+func Test_propagation_nilHeader(t *testing.T) {
+	var downstreamHeader http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamHeader = r.Header.Clone()
+	}))
+	defer downstream.Close()
+
+	t.Run("when request has no header then tracking info is injected", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, downstream.URL, nil)
+		req.Header = nil
+		ctx := tracking.WithRequestId(context.Background(), "req-nil")
+		resp, err := NewClient(http.DefaultClient, Opts{WithTraffic()}).Request(ctx, req)
+		if err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if got := downstreamHeader.Get(tracking.HeaderRequestId); got != "req-nil" {
+			t.Errorf("downstream %s = %v, want req-nil", tracking.HeaderRequestId, got)
+		}
+	})
+}
+
+func createFile(t *testing.T, dir, name string) *os.File {
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("create %s error: %v", name, err)
+	}
+	t.Cleanup(func() {
+		_ = f.Close()
+	})
+	return f
+}
+
+func readFile(t *testing.T, name string) string {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("read %s error: %v", name, err)
+	}
+	return string(bs)
+}
+
+// containsLine reports whether a line of s contains all subs
+func containsLine(s string, subs ...string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		matched := true
+		for _, sub := range subs {
+			if !strings.Contains(line, sub) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
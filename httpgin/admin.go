@@ -0,0 +1,46 @@
+package httpgin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tenz-io/trackingo/logger"
+)
+
+const (
+	adminPrefix = "/debug"
+)
+
+// registerAdmin registers the admin endpoints to the authenticated group
+func registerAdmin(g *gin.RouterGroup) {
+	g.GET("/recent-errors", recentErrorsHandler)
+
+	levelHandler := gin.WrapH(logger.LevelHandler())
+	g.GET("/log-level", levelHandler)
+	g.PUT("/log-level", levelHandler)
+}
+
+// adminAuth rejects the requests without the bearer token with 401
+func adminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// recentErrorsHandler responds the recent warn and error logs and failed traffic, the newest first.
+// the query limit caps the number of records
+func recentErrorsHandler(c *gin.Context) {
+	records := logger.RecentErrors()
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit >= 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	c.JSON(http.StatusOK, records)
+}
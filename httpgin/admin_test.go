@@ -0,0 +1,64 @@
+package httpgin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tenz-io/trackingo/logger"
+)
+
+func Test_recentErrors(t *testing.T) {
+	logger.SetRecentSize(10)
+	defer logger.SetRecentSize(0)
+	logger.WithTracing("req-1").Error("failed to query")
+
+	gin.SetMode(gin.TestMode)
+	m := NewManager(&Config{
+		Timeout:    time.Minute,
+		AdminToken: "secret",
+	})
+	handler := m.Handler()
+
+	t.Run("when token is wrong then 401", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/debug/recent-errors", nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("status = %v, want 401", w.Code)
+		}
+	})
+
+	t.Run("when token is right then respond records", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/debug/recent-errors?limit=1", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var records []logger.RecentRecord
+		if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+			t.Fatalf("unmarshal error = %v, body = %s", err, w.Body.String())
+		}
+		if w.Code != http.StatusOK || len(records) != 1 || records[0].RequestId != "req-1" {
+			t.Errorf("status = %v, records = %+v", w.Code, records)
+		}
+	})
+	t.Run("when put log level then changed", func(t *testing.T) {
+		defer logger.SetLevel(logger.InfoLevel)
+
+		req := httptest.NewRequest(http.MethodPut, "/debug/log-level", strings.NewReader(`{"level":"error"}`))
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || logger.GetLevel() != logger.ErrorLevel {
+			t.Errorf("status = %v, level = %v", w.Code, logger.GetLevel())
+		}
+	})
+}
@@ -11,5 +11,12 @@ type Config struct {
 	EnableTraffic   bool          `yaml:"enable_traffic" json:"enable_traffic" default:"true"`
 	EnableCheck     bool          `yaml:"enable_check" json:"enable_check" default:"true"`
 	CheckEndpoint   string        `yaml:"check_endpoint" json:"check_endpoint" default:"/health"`
+	ReadyEndpoint   string        `yaml:"ready_endpoint" json:"ready_endpoint" default:"/ready"`
 	Timeout         time.Duration `yaml:"timeout" json:"timeout" default:"60s"`
+	// TrafficSampleRatio is the ratio of the requests whose traffic is logged, in (0, 1], all if not set.
+	// the decision of the caller is followed if any, and is propagated to the downstream services
+	TrafficSampleRatio float64 `yaml:"traffic_sample_ratio" json:"traffic_sample_ratio" default:"1"`
+	// AdminToken guards the admin endpoints under /debug, e.g. /debug/recent-errors and /debug/log-level,
+	// which require the header 'Authorization: Bearer <AdminToken>'. the endpoints are disabled if empty
+	AdminToken string `yaml:"admin_token" json:"admin_token"`
 }
@@ -3,8 +3,7 @@ package httpgin
 import (
 	"context"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"strings"
+	"github.com/tenz-io/trackingo/tracking"
 )
 
 // RequestContext returns the value associated with this context for key, or nil
@@ -23,39 +22,20 @@ func WithContext(c *gin.Context, ctx context.Context) {
 	c.Request = c.Request.WithContext(ctx)
 }
 
-// newUUID generate a uuid string
-func newUUID() string {
-	return uuid.NewString()
-}
-
-// traceID generate a trace id
-//
-//	uuid string, remove the '-' in the uuid string
-func traceID() string {
-	return strings.ReplaceAll(newUUID(), "-", "")
-}
-
-type requestIdCtxKeyType string
-
-const (
-	requestIdCtxKey = requestIdCtxKeyType("requestId_ctx_key")
-)
-
-// RequestId returns the value associated with this context for key, or nil
+// RequestId returns the requestId of ctx, a new one is generated if ctx has none
 func RequestId(ctx context.Context) string {
 	if ctx == nil {
 		return ""
 	}
 
-	if requestId, ok := ctx.Value(requestIdCtxKey).(string); ok {
+	if requestId := tracking.RequestId(ctx); requestId != "" {
 		return requestId
 	}
 
-	return traceID()
+	return tracking.NewRequestId()
 }
 
-// WithRequestId returns a copy of parent in which the value associated with key is val.
+// WithRequestId returns a copy of ctx with the requestId, see tracking.WithRequestId
 func WithRequestId(ctx context.Context, requestId string) context.Context {
-	ctx = context.WithValue(ctx, requestIdCtxKey, requestId)
-	return ctx
+	return tracking.WithRequestId(ctx, requestId)
 }
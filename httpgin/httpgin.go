@@ -1,10 +1,14 @@
 package httpgin
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tenz-io/trackingo/health"
+	"net/http"
+	"sync"
 )
 
 type ginFunc func(*Config) gin.HandlerFunc
@@ -16,13 +20,31 @@ type Manager interface {
 	Use(gin.HandlerFunc)
 	// Run a http server.
 	Run(addr ...string) error
+	// Handler returns the engine with the endpoints registered, e.g. to serve with a http.Server
+	// which can be shut down gracefully.
+	Handler() http.Handler
 }
 
-func NewManager(cfg *Config) Manager {
+type Opt func(*manager)
+
+// WithHealth serves the liveness report of h at CheckEndpoint and the readiness report at ReadyEndpoint
+func WithHealth(h health.Manager) Opt {
+	return func(m *manager) {
+		m.health = h
+	}
+}
+
+func NewManager(cfg *Config, opts ...Opt) Manager {
 	m := &manager{
 		cfg:    cfg,
 		engine: gin.New(),
 	}
+	// the handlers can pass *gin.Context as ctx, e.g. to httpcli, with the values and deadline of the request
+	m.engine.ContextWithFallback = true
+
+	for _, opt := range opts {
+		opt(m)
+	}
 
 	for _, fn := range buildInMiddlewares {
 		m.Use(fn(cfg))
@@ -32,8 +54,10 @@ func NewManager(cfg *Config) Manager {
 }
 
 type manager struct {
-	cfg    *Config
-	engine *gin.Engine
+	cfg          *Config
+	engine       *gin.Engine
+	health       health.Manager
+	registerOnce sync.Once
 }
 
 func (m *manager) GetEngine() *gin.Engine {
@@ -45,7 +69,7 @@ func (m *manager) Use(fn gin.HandlerFunc) {
 }
 
 func (m *manager) Run(addr ...string) error {
-	m.register()
+	m.registerOnce.Do(m.register)
 
 	err := m.engine.Run(addr...)
 	if err != nil {
@@ -54,6 +78,11 @@ func (m *manager) Run(addr ...string) error {
 	return nil
 }
 
+func (m *manager) Handler() http.Handler {
+	m.registerOnce.Do(m.register)
+	return m.engine
+}
+
 // register registers the endpoints.
 func (m *manager) register() {
 
@@ -73,9 +102,33 @@ func (m *manager) register() {
 		if m.cfg.CheckEndpoint == "" {
 			m.cfg.CheckEndpoint = "/health"
 		}
-		m.engine.GET(m.cfg.CheckEndpoint, func(c *gin.Context) {
-			c.String(200, "ok")
-		})
+		if m.health == nil {
+			m.engine.GET(m.cfg.CheckEndpoint, func(c *gin.Context) {
+				c.String(200, "ok")
+			})
+		} else {
+			if m.cfg.ReadyEndpoint == "" {
+				m.cfg.ReadyEndpoint = "/ready"
+			}
+			m.engine.GET(m.cfg.CheckEndpoint, healthHandler(m.health.Liveness))
+			m.engine.GET(m.cfg.ReadyEndpoint, healthHandler(m.health.Readiness))
+		}
+	}
+
+	if m.cfg.AdminToken != "" {
+		registerAdmin(m.engine.Group(adminPrefix, adminAuth(m.cfg.AdminToken)))
 	}
 
 }
+
+// healthHandler responds the report of run, 503 if any check is down
+func healthHandler(run func(ctx context.Context) *health.Report) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := run(c.Request.Context())
+		if !report.Up() {
+			c.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
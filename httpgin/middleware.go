@@ -2,14 +2,23 @@ package httpgin
 
 import (
 	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/tenz-io/trackingo/logger"
 	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/tracking"
 	"gopkg.in/natefinch/lumberjack.v2"
 	syslog "log"
+	"math/rand"
 	"net/http"
 	"runtime/debug"
 	"strings"
+	"time"
+)
+
+const (
+	// requestIdKey is the gin context key of the requestId for the access log
+	requestIdKey = "_request_id"
 )
 
 var (
@@ -46,7 +55,33 @@ func applyAccessLog(cfg *Config) gin.HandlerFunc {
 		Compress:   true, // compress old log files with gzip
 	}
 
-	return gin.LoggerWithWriter(accessLogger)
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Output:    accessLogger,
+		Formatter: accessLogFormatter,
+	})
+}
+
+// accessLogFormatter is the default format of gin with the requestId set by applyTracking
+func accessLogFormatter(param gin.LogFormatterParams) string {
+	requestId, _ := param.Keys[requestIdKey].(string)
+	if requestId == "" {
+		requestId = "-"
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+
+	return fmt.Sprintf("[GIN] %v | %s | %3d | %13v | %15s | %-7s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		requestId,
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		param.Path,
+		param.ErrorMessage,
+	)
 }
 
 func applyMetrics(cfg *Config) gin.HandlerFunc {
@@ -81,6 +116,8 @@ func applyTimeout(cfg *Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(RequestContext(c), cfg.Timeout)
 		defer cancel()
+		// the deadline is propagated to the downstream calls of the handler as budget
+		WithContext(c, ctx)
 
 		doneC := make(chan struct{})
 		go func() {
@@ -90,6 +127,7 @@ func applyTimeout(cfg *Config) gin.HandlerFunc {
 
 		select {
 		case <-ctx.Done():
+			logger.FromContext(ctx).WithError(ctx.Err()).Warnf("request timeout after %s", cfg.Timeout)
 			c.AbortWithStatus(http.StatusRequestTimeout)
 			return
 		case <-doneC:
@@ -104,7 +142,11 @@ func applyPanicRecovery(cfg *Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if r := recover(); r != nil {
-				syslog.Printf("panic recovery: %s, stacktrace: %s\n", r, string(debug.Stack()))
+				// the logger of the request carries the requestId and the url set by applyTracking
+				logger.FromContext(RequestContext(c)).ErrorWith("panic recovery", logger.Fields{
+					"panic":      fmt.Sprint(r),
+					"stacktrace": string(debug.Stack()),
+				})
 				c.AbortWithStatus(http.StatusInternalServerError)
 			}
 		}()
@@ -125,8 +167,19 @@ func applyTracking(cfg *Config) gin.HandlerFunc {
 		// metrics
 		ctx = monitor.InitSingleFlight(ctx, url)
 
-		requestId := RequestId(ctx)
-		ctx = WithRequestId(ctx, requestId)
+		// tracking info of the caller, the deadline budget bounds the handling
+		ctx, cancel := tracking.Extract(ctx, tracking.HeaderCarrier(c.Request.Header))
+		defer cancel()
+		ctx = logger.ExtractPairId(ctx, tracking.HeaderCarrier(c.Request.Header))
+
+		info := tracking.FromContext(ctx)
+		if info.Sampling == tracking.SamplingUnset {
+			info.Sampling = sample(cfg.TrafficSampleRatio)
+			ctx = tracking.WithInfo(ctx, info)
+		}
+
+		requestId := info.RequestId
+		c.Set(requestIdKey, requestId)
 		le := logger.WithFields(logger.Fields{
 			"url": url,
 		}).WithTracing(requestId)
@@ -140,13 +193,23 @@ func applyTracking(cfg *Config) gin.HandlerFunc {
 				"password",
 				//"Authorization",
 			)
+		if info.Sampling == tracking.SamplingDrop {
+			te = te.WithPolicy(logger.NewRejectAllPolicy())
+		}
 		ctx = logger.WithTrafficEntry(ctx, te)
 		WithContext(c, ctx)
 
-		defer func() {
-			c.Writer.Header().Set("X-Request-Id", requestId)
-		}()
+		// headers can't be changed once the handler writes the body
+		c.Writer.Header().Set(tracking.HeaderRequestId, requestId)
 
 		c.Next()
 	}
 }
+
+// sample decides whether the traffic of a request is logged with ratio
+func sample(ratio float64) tracking.Sampling {
+	if ratio <= 0 || ratio >= 1 || rand.Float64() < ratio {
+		return tracking.SamplingKeep
+	}
+	return tracking.SamplingDrop
+}
@@ -2,6 +2,10 @@ package httpgin
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/tenz-io/trackingo/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -29,3 +33,35 @@ func Test_applyTimeout(t *testing.T) {
 
 	})
 }
+
+func Test_applyPanicRecovery(t *testing.T) {
+	t.Run("when handler panics then log the stacktrace to the logger of the request", func(t *testing.T) {
+		entry, logs := logger.NewTestEntry(t)
+
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			WithContext(c, logger.WithLogger(RequestContext(c), entry.WithTracing("req-1")))
+			c.Next()
+		}, applyPanicRecovery(&Config{}))
+		r.GET("/panic", func(c *gin.Context) {
+			panic("boom")
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("status = %v, want 500", w.Code)
+		}
+
+		records := logs.FilterLevel(logger.ErrorLevel)
+		if len(records) != 1 {
+			t.Fatalf("records = %+v, want 1", logs.Records())
+		}
+		rec := records[0]
+		stack, _ := rec.Fields["stacktrace"].(string)
+		if rec.RequestId != "req-1" || rec.Fields["panic"] != "boom" || !strings.Contains(stack, "applyPanicRecovery") {
+			t.Errorf("record = %+v", rec)
+		}
+	})
+}
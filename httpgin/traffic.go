@@ -25,9 +25,11 @@ func applyTraffic(cfg *Config) gin.HandlerFunc {
 			trafficRec *logger.TrafficRec
 		)
 
+		// the pair id of the caller joins its records and ours
 		trafficRec = logger.StartTrafficRec(ctx, &logger.TrafficReq{
-			Cmd: c.Request.URL.Path,
-			Req: reqCopy,
+			Cmd:    c.Request.URL.Path,
+			Req:    reqCopy,
+			PairId: logger.PairIdFromContext(ctx),
 		}, logger.Fields{
 			"method":    c.Request.Method,
 			"client":    c.ClientIP(),
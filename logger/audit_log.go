@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+	"os"
+
+	"github.com/tenz-io/trackingo/tracking"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// defaultAuditLogger is the logger of the audit records, separated from the default logger
+	// It's assigned a default value here for tests (which do not call log.ConfigureAuditLog())
+	defaultAuditLogger = newAuditLogger(os.Stdout)
+)
+
+// AuditLogConfig for audit logging, the audit records go to their own file, e.g. to be kept longer
+type AuditLogConfig struct {
+	// FileLoggingEnabled makes the framework log to a file
+	// the fields below can be skipped if this value is false!
+	FileLoggingEnabled bool `yaml:"file_logging_enabled" json:"file_logging_enabled"`
+	// ConsoleLoggingEnabled makes the framework log to console
+	ConsoleLoggingEnabled bool `yaml:"console_logging_enabled" json:"console_logging_enabled"`
+	// LoggingDirectory to log to to when filelogging is enabled
+	LoggingDirectory string `yaml:"logging_directory" json:"logging_directory" default:"log"`
+	// Filename is the name of the logfile which will be placed inside the directory
+	Filename string `yaml:"filename" json:"filename" default:"audit.log"`
+	// MaxSize the max size in MB of the logfile before it's rolled
+	MaxSize int `yaml:"max_size" json:"max_size" default:"100"`
+	// MaxBackups the max number of rolled files to keep
+	MaxBackups int `yaml:"max_backups" json:"max_backups" default:"10"`
+	// MaxAge the max age in days to keep a logfile
+	MaxAge int `yaml:"max_age" json:"max_age"`
+	// ConsoleStream
+	ConsoleStream *os.File `yaml:"-" json:"-"`
+}
+
+// Audit logs an audit record of msg and fields to the audit log as a json object with the requestId of ctx,
+// the fields are neither trimmed nor redacted, unlike the other logs
+func Audit(ctx context.Context, msg string, fields Fields) {
+	zapFields := make([]zapcore.Field, 0, len(fields)+1)
+	if requestId := tracking.RequestId(ctx); requestId != "" {
+		zapFields = append(zapFields, zap.String("request_id", requestId))
+	}
+	for k, v := range fields {
+		zapFields = append(zapFields, zap.Any(k, v))
+	}
+	defaultAuditLogger.Info(msg, zapFields...)
+}
+
+// ConfigureAuditLog sets up audit logging, the audit records are written unbuffered
+func ConfigureAuditLog(config AuditLogConfig) {
+	var writers []zapcore.WriteSyncer
+
+	if config.FileLoggingEnabled {
+		auditLog := newRollingFile(config.LoggingDirectory, config.Filename, config.MaxSize, config.MaxAge, config.MaxBackups)
+		writers = append(writers, countBytes(auditLog, config.Filename))
+	} else {
+		config.ConsoleLoggingEnabled = true
+	}
+
+	if config.ConsoleLoggingEnabled {
+		var console zapcore.WriteSyncer = os.Stdout
+		if config.ConsoleStream != nil {
+			console = config.ConsoleStream
+		}
+		writers = append(writers, countBytes(console, consoleOutputName))
+	}
+
+	old := defaultAuditLogger
+	defaultAuditLogger = newAuditLogger(zapcore.NewMultiWriteSyncer(writers...))
+	_ = old.Sync()
+}
+
+func newAuditLogger(logOutput zapcore.WriteSyncer) *zap.Logger {
+	encCfg := zapcore.EncoderConfig{
+		TimeKey:        defaultTimeKey,
+		MessageKey:     defaultMessageKey,
+		EncodeTime:     longTimeEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+	}
+	return zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(encCfg), logOutput, zapcore.Level(InfoLevel)))
+}
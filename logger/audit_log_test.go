@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+func TestAudit(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureAuditLog(AuditLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+	})
+	defer ConfigureAuditLog(AuditLogConfig{})
+
+	t.Run("when audit then a json record with the requestId and the untrimmed fields", func(t *testing.T) {
+		ctx := tracking.WithRequestId(context.Background(), "req-1")
+		long := strings.Repeat("a", 2048)
+		Audit(ctx, "data change audit", Fields{"before": long})
+		Sync()
+
+		bs, _ := os.ReadFile(out.Name())
+		var got map[string]any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(string(bs))), &got); err != nil {
+			t.Fatalf("unmarshal %s error = %v", bs, err)
+		}
+		if got["msg"] != "data change audit" || got["request_id"] != "req-1" || got["before"] != long {
+			t.Errorf("audit log = %s", bs)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"testing"
+)
+
+func configureBench(b *testing.B, level Level) {
+	f := devNull(b)
+	Configure(Config{
+		LoggingLevel:          level,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     f,
+		ConsoleErrorStream:    f,
+		ConsoleDebugStream:    f,
+	})
+	b.Cleanup(func() { Configure(Config{LoggingLevel: InfoLevel}) })
+}
+
+func BenchmarkEntry_Infof(b *testing.B) {
+	configureBench(b, InfoLevel)
+	entry := WithTracing("req-1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Infof("order %d created", i)
+	}
+}
+
+func BenchmarkEntry_Info(b *testing.B) {
+	configureBench(b, InfoLevel)
+	entry := WithTracing("req-1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Info("order created")
+	}
+}
+
+func BenchmarkEntry_WithFields(b *testing.B) {
+	configureBench(b, InfoLevel)
+	entry := WithTracing("req-1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.WithFields(Fields{"order_id": i, "user": "alice"}).Info("order created")
+	}
+}
+
+func BenchmarkEntry_WithFieldsDisabled(b *testing.B) {
+	configureBench(b, InfoLevel)
+	entry := WithTracing("req-1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.WithFields(Fields{"order_id": i, "user": "alice"}).Debug("order created")
+	}
+}
+
+func BenchmarkEntry_DebugfDisabled(b *testing.B) {
+	configureBench(b, InfoLevel)
+	entry := WithTracing("req-1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Debugf("order %d created", i)
+	}
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultFlushInterval = time.Second
+	pendingTrafficWait   = time.Second // max wait of the background traffic logs on Sync
+)
+
+var (
+	buffersLock    sync.Mutex
+	logBuffers     []*zapcore.BufferedWriteSyncer // buffers of the default logger
+	trafficBuffers []*zapcore.BufferedWriteSyncer // buffers of the default traffic logger
+
+	// pendingTraffic counts the traffic logs queued to be written in background
+	pendingTraffic atomic.Int64
+)
+
+// newBuffered returns ws buffered up to size bytes and flushed every interval in background,
+// ws itself if size <= 0. the buffer is appended to bufs to be stopped later
+func newBuffered(ws zapcore.WriteSyncer, size int, interval time.Duration, bufs *[]*zapcore.BufferedWriteSyncer) zapcore.WriteSyncer {
+	if size <= 0 {
+		return ws
+	}
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+
+	buffered := &zapcore.BufferedWriteSyncer{
+		WS:            ws,
+		Size:          size,
+		FlushInterval: interval,
+	}
+	*bufs = append(*bufs, buffered)
+	return buffered
+}
+
+// swapBuffers sets the buffers of a new configuration to dst and returns the replaced ones
+func swapBuffers(dst *[]*zapcore.BufferedWriteSyncer, bufs []*zapcore.BufferedWriteSyncer) []*zapcore.BufferedWriteSyncer {
+	buffersLock.Lock()
+	defer buffersLock.Unlock()
+
+	old := *dst
+	*dst = bufs
+	return old
+}
+
+func stopBuffers(bufs []*zapcore.BufferedWriteSyncer) {
+	for _, buf := range bufs {
+		_ = buf.Stop()
+	}
+}
+
+// Close flushes the logs like Sync, drains the traffic queue, stops the background flushers
+// of the buffered writes and closes the syslog, call it last before exit, e.g. on SIGTERM.
+// the traffic is written by the caller after Close
+func Close() {
+	Sync()
+	defaultTrafficLogger.queue.close()
+
+	buffersLock.Lock()
+	defer buffersLock.Unlock()
+
+	stopBuffers(logBuffers)
+	stopBuffers(trafficBuffers)
+	logBuffers, trafficBuffers = nil, nil
+
+	closeSyslog(swapSyslog(nil))
+}
+
+// waitTraffic waits for the background traffic logs for timeout at most
+func waitTraffic(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for pendingTraffic.Load() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuffered(t *testing.T) {
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "info.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+	trafficOut, err := os.Create(filepath.Join(dir, "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer trafficOut.Close()
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     out,
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    out,
+		BufferSize:            64 * 1024,
+		FlushInterval:         time.Hour,
+	})
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         trafficOut,
+		BufferSize:            64 * 1024,
+		FlushInterval:         time.Hour,
+	})
+	defer func() {
+		Configure(Config{LoggingLevel: InfoLevel})
+		ConfigureTrafficLog(TrafficLogConfig{})
+	}()
+
+	read := func(f *os.File) string {
+		bs, _ := os.ReadFile(f.Name())
+		return string(bs)
+	}
+
+	Info("buffered info")
+	Data(&Traffic{Typ: TrafficTypReq, Cmd: "buffered_cmd"})
+
+	t.Run("when not flushed then buffered", func(t *testing.T) {
+		if logs := read(out); strings.Contains(logs, "buffered info") {
+			t.Errorf("logs = %s, want buffered", logs)
+		}
+	})
+
+	t.Run("when close then flushed", func(t *testing.T) {
+		Close()
+		if logs := read(out); !strings.Contains(logs, "buffered info") {
+			t.Errorf("logs = %s, want flushed", logs)
+		}
+		if logs := read(trafficOut); !strings.Contains(logs, "buffered_cmd") {
+			t.Errorf("traffic logs = %s, want flushed", logs)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	dependencyFieldName = "dependency"
+)
+
+// CallRec records the metrics and the traffic log of a call to the dependency,
+// use StartCall to create a CallRec and End to end it
+type CallRec struct {
+	rec        *monitor.Recorder
+	trafficRec *TrafficRec
+	dependency string
+}
+
+type callOptions struct {
+	metrics    bool
+	traffic    bool
+	fields     Fields
+	dependency string
+	pairId     string
+}
+
+type CallOpt func(*callOptions)
+
+// CallMetrics enables the metrics of the call, enabled by default
+func CallMetrics(enabled bool) CallOpt {
+	return func(o *callOptions) {
+		o.metrics = enabled
+	}
+}
+
+// CallTraffic enables the traffic log of the call, enabled by default
+func CallTraffic(enabled bool) CallOpt {
+	return func(o *callOptions) {
+		o.traffic = enabled
+	}
+}
+
+// CallFields binds fields to the request traffic log of the call
+func CallFields(fields Fields) CallOpt {
+	return func(o *callOptions) {
+		o.fields = fields
+	}
+}
+
+// CallDependency sets the dependency name as the opt of the metrics and the field of the traffic log
+func CallDependency(name string) CallOpt {
+	return func(o *callOptions) {
+		o.dependency = name
+	}
+}
+
+// CallPairId sets the pair id of the traffic log of the call, generated if empty
+func CallPairId(pairId string) CallOpt {
+	return func(o *callOptions) {
+		o.pairId = pairId
+	}
+}
+
+// StartCall starts the metrics recorder and writes the request traffic log of cmd,
+// End emits the metrics and the response traffic log, e.g.
+//
+//	rec := logger.StartCall(ctx, "cache_get", key)
+//	defer func() { rec.End(err, raw) }()
+func StartCall(ctx context.Context, cmd string, req any, opts ...CallOpt) *CallRec {
+	o := &callOptions{
+		metrics: true,
+		traffic: true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cr := &CallRec{
+		dependency: o.dependency,
+	}
+	if o.metrics {
+		cr.rec = monitor.BeginRecord(ctx, cmd)
+	}
+	if o.traffic {
+		fields := Fields{}
+		for k, v := range o.fields {
+			fields[k] = v
+		}
+		if o.dependency != "" {
+			fields[dependencyFieldName] = o.dependency
+		}
+		cr.trafficRec = StartTrafficRec(ctx, &TrafficReq{
+			Cmd:    cmd,
+			Req:    req,
+			PairId: o.pairId,
+		}, fields)
+	}
+	return cr
+}
+
+// PairId returns the pair id of the traffic log of the call, empty if the traffic is disabled
+func (c *CallRec) PairId() string {
+	if c == nil {
+		return ""
+	}
+	return c.trafficRec.PairId()
+}
+
+// End ends the call with err and resp
+func (c *CallRec) End(err error, resp any) {
+	c.EndWithFields(err, resp, Fields{})
+}
+
+// EndWithFields ends the call with err and resp, and binds fields to the response traffic log
+func (c *CallRec) EndWithFields(err error, resp any, fields Fields) {
+	if c == nil {
+		return
+	}
+
+	if c.rec != nil {
+		c.rec.EndWithErrorOpt(err, c.dependency)
+	}
+	if c.trafficRec != nil {
+		c.trafficRec.End(&TrafficResp{
+			Code: common.ErrorCode(err),
+			Msg:  common.ErrorMsg(err),
+			Resp: resp,
+		}, fields)
+	}
+}
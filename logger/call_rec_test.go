@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tenz-io/trackingo/common"
+)
+
+func TestStartCall(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+		JSONFormat:            true,
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	readRecords := func(t *testing.T) []map[string]any {
+		Sync()
+		f, _ := os.Open(out.Name())
+		defer f.Close()
+		var records []map[string]any
+		for scanner := bufio.NewScanner(f); scanner.Scan(); {
+			var record map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("unmarshal %s error = %v", scanner.Text(), err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+
+	t.Run("when call ends then write req and resp traffic", func(t *testing.T) {
+		rec := StartCall(context.Background(), "cache_get", "user:1",
+			CallFields(Fields{"db": 0}),
+			CallDependency("redis-main"),
+		)
+		rec.EndWithFields(common.NewValError(404, errors.New("not found")), "-", Fields{"hit": false})
+
+		records := readRecords(t)
+		if len(records) != 2 {
+			t.Fatalf("records = %v, want req and resp", records)
+		}
+		req, resp := records[0], records[1]
+		if req["cmd"] != "cache_get" || req["req"] != "user:1" || req["dependency"] != "redis-main" || req["db"] != float64(0) {
+			t.Errorf("req = %v", req)
+		}
+		if resp["code"] != float64(404) || resp["msg"] != "not found" || resp["hit"] != false || resp["pair_id"] != req["pair_id"] {
+			t.Errorf("resp = %v", resp)
+		}
+	})
+
+	t.Run("when traffic is disabled then write nothing", func(t *testing.T) {
+		before := len(readRecords(t))
+		rec := StartCall(context.Background(), "cache_get", "user:1", CallTraffic(false), CallMetrics(false))
+		rec.End(nil, "v")
+
+		if after := len(readRecords(t)); after != before {
+			t.Errorf("records = %v, want %v", after, before)
+		}
+	})
+
+	t.Run("when rec is nil then ignore", func(t *testing.T) {
+		var rec *CallRec
+		rec.End(nil, nil)
+	})
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	maxCallerDepth = 32
+)
+
+// loggerPkgPrefix is the prefix of the function names of the logger package
+var loggerPkgPrefix = reflect.TypeOf(LogEntry{}).PkgPath() + "."
+
+// callerOutside returns the short file:line of the first caller outside of the logger package,
+// skipping skip callers more. the tests of the logger package are outside
+func callerOutside(skip int) string {
+	var pcs [maxCallerDepth]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		inside := strings.HasPrefix(frame.Function, loggerPkgPrefix) && !strings.HasSuffix(frame.File, "_test.go")
+		if !inside {
+			if skip <= 0 {
+				return zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true).TrimmedPath()
+			}
+			skip--
+		}
+		if !more {
+			return defaultFieldOccupied
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"github.com/tenz-io/trackingo/config"
+)
+
+const (
+	configEnvPrefix        = "LOG"
+	trafficConfigEnvPrefix = "LOG_TRAFFIC"
+)
+
+// ConfigFromEnv returns the Config of the default tags overridden by the env vars named after the yaml tags
+// with prefix LOG, e.g. LOG_LOGGING_LEVEL=debug, LOG_FILE_LOGGING_ENABLED=true or LOG_REDACT_PATTERNS=token,secret.
+// the fields with yaml tag "-", e.g. the streams and the policies, are left for the code
+func ConfigFromEnv() (Config, error) {
+	var cfg Config
+	if err := config.ApplyDefaults(&cfg); err != nil {
+		return cfg, err
+	}
+	if err := config.ApplyEnv(&cfg, configEnvPrefix); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// TrafficConfigFromEnv returns the TrafficLogConfig of the default tags overridden by the env vars
+// named after the yaml tags with prefix LOG_TRAFFIC, e.g. LOG_TRAFFIC_JSON_FORMAT=true
+func TrafficConfigFromEnv() (TrafficLogConfig, error) {
+	var cfg TrafficLogConfig
+	if err := config.ApplyDefaults(&cfg); err != nil {
+		return cfg, err
+	}
+	if err := config.ApplyEnv(&cfg, trafficConfigEnvPrefix); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tenz-io/trackingo/config"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("when env is empty then use defaults", func(t *testing.T) {
+		cfg, err := ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("ConfigFromEnv() error = %v", err)
+		}
+		if cfg.LoggingLevel != InfoLevel || cfg.CallerSkip != 1 || cfg.Directory != "log" || cfg.MaxSize != 100 {
+			t.Errorf("ConfigFromEnv() = %+v", cfg)
+		}
+	})
+
+	t.Run("when env is set then override", func(t *testing.T) {
+		t.Setenv("LOG_LOGGING_LEVEL", "debug")
+		t.Setenv("LOG_FILE_LOGGING_ENABLED", "true")
+		t.Setenv("LOG_FLUSH_INTERVAL", "2s")
+		t.Setenv("LOG_REDACT_PATTERNS", "token,secret")
+
+		cfg, err := ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("ConfigFromEnv() error = %v", err)
+		}
+		if cfg.LoggingLevel != DebugLevel || !cfg.FileLoggingEnabled || cfg.FlushInterval != 2*time.Second || len(cfg.RedactPatterns) != 2 {
+			t.Errorf("ConfigFromEnv() = %+v", cfg)
+		}
+	})
+
+	t.Run("when level is invalid then return error", func(t *testing.T) {
+		t.Setenv("LOG_LOGGING_LEVEL", "verbose")
+
+		if _, err := ConfigFromEnv(); err == nil {
+			t.Errorf("ConfigFromEnv() want error")
+		}
+	})
+
+	t.Run("when traffic env is set then override", func(t *testing.T) {
+		t.Setenv("LOG_TRAFFIC_JSON_FORMAT", "true")
+
+		cfg, err := TrafficConfigFromEnv()
+		if err != nil {
+			t.Fatalf("TrafficConfigFromEnv() error = %v", err)
+		}
+		if !cfg.JSONFormat || cfg.Filename != "data.log" || cfg.QueueSize != 4096 {
+			t.Errorf("TrafficConfigFromEnv() = %+v", cfg)
+		}
+	})
+}
+
+func TestConfig_yaml(t *testing.T) {
+	data := []byte(`
+logging_level: warn
+file_logging_enabled: true
+level_files:
+  warn: warn.log
+syslog:
+  network: udp
+  address: localhost:514
+`)
+	var cfg Config
+	if err := config.LoadBytes(data, config.FormatYAML, &cfg); err != nil {
+		t.Fatalf("LoadBytes() error = %v", err)
+	}
+	if cfg.LoggingLevel != WarnLevel || cfg.LevelFiles[WarnLevel] != "warn.log" || cfg.Syslog == nil || cfg.Syslog.Network != "udp" {
+		t.Errorf("LoadBytes() = %+v", cfg)
+	}
+	if cfg.MaxBackups != 10 || cfg.StacktraceLevel != ErrorLevel {
+		t.Errorf("LoadBytes() defaults = %+v", cfg)
+	}
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+const (
+	cmdFieldName = "cmd"
+)
+
+// ContextExtractor returns the fields of ctx bound by Entry.WithContext, e.g. the user id of the request
+type ContextExtractor func(ctx context.Context) Fields
+
+var (
+	extractorsLock sync.RWMutex
+	extractors     []ContextExtractor
+)
+
+// RegisterContextExtractor adds ex to the extractors of Entry.WithContext, register them on init
+func RegisterContextExtractor(ex ContextExtractor) {
+	if ex == nil {
+		return
+	}
+
+	extractorsLock.Lock()
+	defer extractorsLock.Unlock()
+
+	extractors = append(extractors, ex)
+}
+
+// withContext binds the requestId, the single flight cmd and the fields of the extractors of ctx to e
+func withContext(e Entry, ctx context.Context) Entry {
+	if ctx == nil {
+		return e
+	}
+
+	if requestId := tracking.RequestId(ctx); requestId != "" {
+		e = e.WithTracing(requestId)
+	}
+	if fields := contextFields(ctx); len(fields) > 0 {
+		e = e.WithFields(fields)
+	}
+	return e
+}
+
+// contextFields returns the single flight cmd and the fields of the extractors of ctx
+func contextFields(ctx context.Context) Fields {
+	fields := Fields{}
+	if cmd := monitor.Cmd(ctx); cmd != "" {
+		fields[cmdFieldName] = cmd
+	}
+
+	extractorsLock.RLock()
+	defer extractorsLock.RUnlock()
+
+	for _, ex := range extractors {
+		for k, v := range ex(ctx) {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// Detach returns a background context carrying the logger, the traffic entry, the tracking info and
+// the pair id of ctx but not its deadline nor its cancellation, for the goroutines outliving the request, e.g.
+//
+//	go notify(logger.Detach(ctx), order)
+func Detach(ctx context.Context) context.Context {
+	detached := context.Background()
+	if ctx == nil {
+		return detached
+	}
+
+	for _, key := range []loggerCtxKeyType{logCtxKey, trafficLogCtxKey, pairIdCtxKey} {
+		if val := ctx.Value(key); val != nil {
+			detached = context.WithValue(detached, key, val)
+		}
+	}
+	return tracking.CopyToContext(ctx, detached)
+}
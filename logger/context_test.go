@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+type userIdKey struct{}
+
+func TestEntry_WithContext(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "context.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     out,
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    out,
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	RegisterContextExtractor(func(ctx context.Context) Fields {
+		if uid, ok := ctx.Value(userIdKey{}).(string); ok {
+			return Fields{"user_id": uid}
+		}
+		return nil
+	})
+
+	ctx := tracking.WithRequestId(context.Background(), "req-ctx")
+	ctx = monitor.InitSingleFlight(ctx, "get_user")
+	ctx = context.WithValue(ctx, userIdKey{}, "u-1")
+
+	t.Run("when ctx has metadata then bind them", func(t *testing.T) {
+		WithField("k", "v").WithContext(ctx).Info("handled")
+		Named("dao").WithContext(ctx).Info("queried")
+
+		bs, _ := os.ReadFile(out.Name())
+		matched := 0
+		for _, line := range strings.Split(string(bs), "\n") {
+			if !strings.Contains(line, "handled") && !strings.Contains(line, "queried") {
+				continue
+			}
+			matched++
+			for _, want := range []string{"req-ctx", `"cmd": "get_user"`, `"user_id": "u-1"`} {
+				if !strings.Contains(line, want) {
+					t.Errorf("log = %s, want %s", line, want)
+				}
+			}
+		}
+		if matched != 2 {
+			t.Errorf("logs = %s, want handled and queried", bs)
+		}
+	})
+
+	t.Run("when ctx is empty then keep the entry", func(t *testing.T) {
+		le := WithField("k", "v")
+		if got := le.WithContext(context.Background()); got.(*LogEntry).requestId != "" {
+			t.Errorf("WithContext() requestId = %v, want empty", got.(*LogEntry).requestId)
+		}
+	})
+}
+
+func TestTracingFromTraceparent(t *testing.T) {
+	t.Run("when header is valid then requestId is traceid:parentid", func(t *testing.T) {
+		requestId, ok := TracingFromTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		if !ok || requestId != "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7" {
+			t.Errorf("TracingFromTraceparent() = %v, %v", requestId, ok)
+		}
+	})
+
+	t.Run("when header is invalid then not ok", func(t *testing.T) {
+		if requestId, ok := TracingFromTraceparent("invalid"); ok || requestId != "" {
+			t.Errorf("TracingFromTraceparent() = %v, %v", requestId, ok)
+		}
+	})
+}
+
+func TestDetach(t *testing.T) {
+	entry, _ := NewTestEntry(t)
+	te := WithTrafficTracing(context.Background(), "req-1")
+
+	parent, cancel := context.WithCancel(context.Background())
+	parent = tracking.WithRequestId(parent, "req-1")
+	parent = WithLogger(parent, entry)
+	parent = WithTrafficEntry(parent, te)
+	parent = WithPairId(parent, "pair-1")
+	cancel()
+
+	ctx := Detach(parent)
+
+	t.Run("when the parent is canceled then the detached is not", func(t *testing.T) {
+		if err := ctx.Err(); err != nil {
+			t.Errorf("Err() = %v, want nil", err)
+		}
+		if _, ok := ctx.Deadline(); ok {
+			t.Errorf("Deadline() ok, want no deadline")
+		}
+	})
+
+	t.Run("when detached then the correlation is kept", func(t *testing.T) {
+		if got := FromContext(ctx); got != entry {
+			t.Errorf("FromContext() = %v, want the logger of the parent", got)
+		}
+		if got := TrafficEntryFromContext(ctx); got != te {
+			t.Errorf("TrafficEntryFromContext() = %v, want the traffic entry of the parent", got)
+		}
+		if got := tracking.RequestId(ctx); got != "req-1" {
+			t.Errorf("RequestId() = %v, want req-1", got)
+		}
+		if got := PairIdFromContext(ctx); got != "pair-1" {
+			t.Errorf("PairIdFromContext() = %v, want pair-1", got)
+		}
+	})
+}
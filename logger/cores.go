@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// withCores returns a copy of le writing to the extra cores as well,
+// and wraps the cores by wrapper if not nil
+func withCores(le *LogEntry, extras []zapcore.Core, wrapper func(zapcore.Core) zapcore.Core) *LogEntry {
+	if len(extras) == 0 && wrapper == nil {
+		return le
+	}
+
+	wrap := zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		if len(extras) > 0 {
+			c = zapcore.NewTee(append([]zapcore.Core{c}, extras...)...)
+		}
+		if wrapper != nil {
+			c = wrapper(c)
+		}
+		return c
+	})
+
+	res := le.clone()
+	res.infoLogger = le.infoLogger.WithOptions(wrap)
+	res.errLogger = le.errLogger.WithOptions(wrap)
+	res.debugLogger = le.debugLogger.WithOptions(wrap)
+	return res
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestExtraCores(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	wrapped := 0
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     devNull(t),
+		ConsoleErrorStream:    devNull(t),
+		ConsoleDebugStream:    devNull(t),
+		ExtraCores:            []zapcore.Core{core},
+		CoreWrapper: func(c zapcore.Core) zapcore.Core {
+			wrapped++
+			return c
+		},
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	if wrapped == 0 {
+		t.Errorf("CoreWrapper is not called")
+	}
+
+	t.Run("when log then write to extra cores", func(t *testing.T) {
+		logs.TakeAll()
+		WithField("k", "v").Error("query failed")
+
+		entries := logs.TakeAll()
+		if len(entries) != 1 || entries[0].Level != zapcore.ErrorLevel {
+			t.Fatalf("extra core entries = %v", entries)
+		}
+		if entries[0].ContextMap()["k"] != "v" {
+			t.Errorf("extra core fields = %v", entries[0].ContextMap())
+		}
+	})
+
+	t.Run("when level disabled then skip extra cores", func(t *testing.T) {
+		logs.TakeAll()
+		Debug("not logged")
+
+		if n := logs.Len(); n != 0 {
+			t.Errorf("extra core has %d entries, want 0", n)
+		}
+	})
+}
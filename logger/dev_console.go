@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	devTimeLayout = "15:04:05.000"
+	devSeparator  = " "
+)
+
+// devEncoderConfig returns the encoder config of the colored and compact console logs for local development
+func devEncoderConfig() zapcore.EncoderConfig {
+	cfg := logEncoderConfig()
+	cfg.ConsoleSeparator = devSeparator
+	cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	cfg.EncodeTime = zapcore.TimeEncoderOfLayout(devTimeLayout)
+	cfg.EncodeDuration = zapcore.StringDurationEncoder
+	return cfg
+}
+
+// newDevConsoleCore returns the core writing the colored and compact logs to the console streams of config,
+// the warn and error logs to the error stream, the streams are wrapped by wrap if not nil, e.g. buffered
+func newDevConsoleCore(config Config, wrap func(zapcore.WriteSyncer) zapcore.WriteSyncer) zapcore.Core {
+	// the level enabler of the default logger, changed by SetLevel
+	lv := loglv
+	encoder := newLogEncoder(devEncoderConfig())
+
+	stream := func(f *os.File, def *os.File) zapcore.WriteSyncer {
+		var ws zapcore.WriteSyncer = def
+		if f != nil {
+			ws = f
+		}
+		if wrap != nil {
+			ws = wrap(ws)
+		}
+		return ws
+	}
+	enabler := func(min, max zapcore.Level) zapcore.LevelEnabler {
+		return zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return lv.Enabled(l) && l >= min && l <= max
+		})
+	}
+
+	return zapcore.NewTee(
+		zapcore.NewCore(encoder, stream(config.ConsoleDebugStream, os.Stdout), enabler(zapcore.DebugLevel, zapcore.DebugLevel)),
+		zapcore.NewCore(encoder, stream(config.ConsoleInfoStream, os.Stdout), enabler(zapcore.InfoLevel, zapcore.InfoLevel)),
+		zapcore.NewCore(encoder, stream(config.ConsoleErrorStream, os.Stderr), enabler(zapcore.WarnLevel, zapcore.FatalLevel)),
+	)
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultTimeKey    = "@t"
+	defaultLevelKey   = "lvl"
+	defaultMessageKey = "msg"
+)
+
+// EncoderConfig overrides the keys and the layout of the encoded logs, the defaults are used for the empty ones
+type EncoderConfig struct {
+	// TimeKey the key of the time, @t by default
+	TimeKey string `yaml:"time_key" json:"time_key"`
+	// LevelKey the key of the level, lvl by default
+	LevelKey string `yaml:"level_key" json:"level_key"`
+	// MessageKey the key of the message, msg by default
+	MessageKey string `yaml:"message_key" json:"message_key"`
+	// Separator the separator of the console layout and of the requestId in the message, | by default
+	Separator string `yaml:"separator" json:"separator"`
+	// TimeLayout the layout of the time, 2006-01-02T15:04:05.000Z0700 by default,
+	// or one of rfc3339nano, rfc3339, iso8601, millis and nanos
+	TimeLayout string `yaml:"time_layout" json:"time_layout"`
+	// DurationEncoder the encoding of the durations, nanos by default, or one of string, ms and s
+	DurationEncoder string `yaml:"duration_encoder" json:"duration_encoder"`
+	// Layout the layout of the logs, console by default, or logfmt of the key=value pairs
+	Layout string `yaml:"layout" json:"layout"`
+	// DisableSanitize writes the CR/LF and the control characters of the messages as is,
+	// they are escaped by default against the forged log lines
+	DisableSanitize bool `yaml:"disable_sanitize" json:"disable_sanitize"`
+}
+
+// logEncoding is the EncoderConfig of the default logger, set by Configure
+var logEncoding atomic.Pointer[EncoderConfig]
+
+func setLogEncoding(c EncoderConfig) {
+	logEncoding.Store(&c)
+}
+
+// logSeparator returns the separator of the default logger
+func logSeparator() string {
+	if c := logEncoding.Load(); c != nil {
+		return c.separator()
+	}
+	return defaultSeparator
+}
+
+func (c EncoderConfig) separator() string {
+	if c.Separator == "" {
+		return defaultSeparator
+	}
+	return c.Separator
+}
+
+// newEncoder returns the encoder of the layout of c with cfg
+func (c EncoderConfig) newEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	if c.Layout == layoutLogfmt {
+		return newLogfmtEncoder(cfg)
+	}
+	return zapcore.NewConsoleEncoder(cfg)
+}
+
+// apply overrides cfg by the non-empty fields of c
+func (c EncoderConfig) apply(cfg *zapcore.EncoderConfig) {
+	override := func(dst *string, val string) {
+		if val != "" && *dst != zapcore.OmitKey {
+			*dst = val
+		}
+	}
+	override(&cfg.TimeKey, c.TimeKey)
+	override(&cfg.LevelKey, c.LevelKey)
+	override(&cfg.MessageKey, c.MessageKey)
+	cfg.ConsoleSeparator = c.separator()
+
+	if c.TimeLayout != "" {
+		cfg.EncodeTime = timeEncoderOf(c.TimeLayout)
+	}
+	if c.DurationEncoder != "" {
+		// the unknown names are encoded in seconds
+		_ = cfg.EncodeDuration.UnmarshalText([]byte(c.DurationEncoder))
+	}
+}
+
+// timeEncoderOf returns the time encoder of the name known by zap, or of the layout
+func timeEncoderOf(layout string) zapcore.TimeEncoder {
+	switch layout {
+	case "rfc3339nano", "RFC3339Nano", "rfc3339", "RFC3339", "iso8601", "ISO8601", "millis", "nanos":
+		var enc zapcore.TimeEncoder
+		_ = enc.UnmarshalText([]byte(layout))
+		return enc
+	default:
+		return zapcore.TimeEncoderOfLayout(layout)
+	}
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"time"
@@ -52,22 +54,79 @@ type Entry interface {
 	Errorf(format string, args ...any)
 	// ErrorWith logs a message with fields at ErrorLevel.
 	ErrorWith(msg string, fields Fields)
+	// Log logs a message at level, the levels above ErrorLevel at ErrorLevel.
+	Log(level Level, msg string)
+	// Logf logs a message at level, the levels above ErrorLevel at ErrorLevel.
+	Logf(level Level, format string, args ...any)
+	// LogWith logs a message with fields at level, the levels above ErrorLevel at ErrorLevel.
+	LogWith(level Level, msg string, fields Fields)
+	// DebugKV logs a message with the key/value pairs at DebugLevel.
+	DebugKV(msg string, kvs ...any)
+	// InfoKV logs a message with the key/value pairs at InfoLevel.
+	InfoKV(msg string, kvs ...any)
+	// WarnKV logs a message with the key/value pairs at WarnLevel.
+	WarnKV(msg string, kvs ...any)
+	// ErrorKV logs a message with the key/value pairs at ErrorLevel.
+	ErrorKV(msg string, kvs ...any)
 
 	// WithFields returns a new entry with after adding fields
 	WithFields(fields Fields) Entry
 	// WithField returns a new entry with after adding field
 	WithField(k string, v any) Entry
+	// WithKV returns a new entry with after adding the key/value pairs, e.g. WithKV("k1", v1, "k2", v2)
+	WithKV(kvs ...any) Entry
 	// With returns a new entry with after adding data with default field name
 	With(data any) Entry
 	// WithError returns a new entry with after adding error
 	WithError(err error) Entry
+	// WithGroup returns a new entry nesting the fields added after in the group name
+	WithGroup(name string) Entry
+	// WithLevel returns a new entry logging at level instead of the global level
+	WithLevel(level Level) Entry
 	// WithTracing returns a new entry with after adding requestId
 	WithTracing(requestId string) Entry
+	// WithPolicy returns a new entry asking policy before each log
+	WithPolicy(policy Policy) Entry
+	// WithContext returns a new entry with the requestId, the single flight cmd
+	// and the fields of the registered extractors of ctx
+	WithContext(ctx context.Context) Entry
 
 	// Enabled is entry enabled at level
 	Enabled(level Level) bool
 }
 
+// String returns the lower-case name of the level, e.g. info
+func (l Level) String() string {
+	return zapcore.Level(l).String()
+}
+
+// ParseLevel parses the level name, e.g. debug, info, warn or error
+func ParseLevel(text string) (Level, error) {
+	var lv zapcore.Level
+	if err := lv.UnmarshalText([]byte(text)); err != nil {
+		return InfoLevel, err
+	}
+	if l := Level(lv); l.validate() {
+		return l, nil
+	}
+	return InfoLevel, fmt.Errorf("unsupported level: %s", text)
+}
+
+// MarshalText marshals the level to its name, e.g. info in yaml and json
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText unmarshals the level name, e.g. info in yaml, json and env
+func (l *Level) UnmarshalText(text []byte) error {
+	lv, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = lv
+	return nil
+}
+
 // validate checks if the given level is valid, only support DebugLevel, InfoLevel, WarnLevel, ErrorLevel
 func (l Level) validate() bool {
 	switch l {
@@ -78,19 +137,104 @@ func (l Level) validate() bool {
 	}
 }
 
+// clampLevel returns DebugLevel for the levels below it and ErrorLevel for the levels above it
+func clampLevel(level Level) Level {
+	switch {
+	case level < DebugLevel:
+		return DebugLevel
+	case level > ErrorLevel:
+		return ErrorLevel
+	default:
+		return level
+	}
+}
+
+// logAt logs msg to e at level, the levels below DebugLevel at DebugLevel and above ErrorLevel at ErrorLevel
+func logAt(e Entry, level Level, msg string) {
+	switch {
+	case level <= DebugLevel:
+		e.Debug(msg)
+	case level == InfoLevel:
+		e.Info(msg)
+	case level == WarnLevel:
+		e.Warn(msg)
+	default:
+		e.Error(msg)
+	}
+}
+
+// logfAt logs the formatted msg to e at level, see logAt
+func logfAt(e Entry, level Level, format string, args ...any) {
+	switch {
+	case level <= DebugLevel:
+		e.Debugf(format, args...)
+	case level == InfoLevel:
+		e.Infof(format, args...)
+	case level == WarnLevel:
+		e.Warnf(format, args...)
+	default:
+		e.Errorf(format, args...)
+	}
+}
+
+// logWithAt logs msg with fields to e at level, see logAt
+func logWithAt(e Entry, level Level, msg string, fields Fields) {
+	switch {
+	case level <= DebugLevel:
+		e.DebugWith(msg, fields)
+	case level == InfoLevel:
+		e.InfoWith(msg, fields)
+	case level == WarnLevel:
+		e.WarnWith(msg, fields)
+	default:
+		e.ErrorWith(msg, fields)
+	}
+}
+
+// kvFields returns the fields of the key/value pairs of kvs, the non-string keys
+// and the last key without value are kept in the kv_mispaired field
+func kvFields(kvs []any) Fields {
+	fields := make(Fields, len(kvs)/2)
+	var mispaired []any
+	for i := 0; i < len(kvs); {
+		k, ok := kvs[i].(string)
+		if !ok || i+1 == len(kvs) {
+			mispaired = append(mispaired, kvs[i])
+			i++
+			continue
+		}
+		fields[k] = kvs[i+1]
+		i += 2
+	}
+	if len(mispaired) > 0 {
+		fields[mispairedFieldName] = mispaired
+	}
+	return fields
+}
+
 // toZapFields converts the fields to zapcore.Field
 func toZapFields(fields Fields, ignores ...string) []zapcore.Field {
 	if fields == nil {
 		return []zapcore.Field{}
 	}
 	zapFields := make([]zapcore.Field, 0, len(fields))
+	state := trimState{redacts: RedactPatterns()}
 	for k, v := range fields {
+		if state.redacted(k) {
+			zapFields = append(zapFields, zap.String(k, redactedValue))
+			continue
+		}
+		if lv, ok := v.(*LazyValue); ok {
+			// encoded by MarshalJSON when written
+			zapFields = append(zapFields, zap.Reflect(k, lv.withIgnores(ignores)))
+			continue
+		}
 		f := zap.Any(k, v)
 		switch typ := f.Type; typ {
 		//case zapcore.StringType, zapcore.StringerType:
 		//	zapFields = append(zapFields, zap.String(k, utils.StringLimit(fmt.Sprintf("%s", v), maxStringFieldSize)))
 		case zapcore.StringType:
-			zapFields = append(zapFields, f)
+			zapFields = append(zapFields, zap.String(k, maskPII(f.String)))
 		case zapcore.StringerType,
 			zapcore.BinaryType,
 			zapcore.ArrayMarshalerType,
@@ -154,6 +298,31 @@ func (e *empty) Errorf(format string, args ...any) {
 func (e *empty) ErrorWith(msg string, fields Fields) {
 }
 
+func (e *empty) Log(level Level, msg string) {
+}
+
+func (e *empty) Logf(level Level, format string, args ...any) {
+}
+
+func (e *empty) LogWith(level Level, msg string, fields Fields) {
+}
+
+func (e *empty) DebugKV(msg string, kvs ...any) {
+}
+
+func (e *empty) InfoKV(msg string, kvs ...any) {
+}
+
+func (e *empty) WarnKV(msg string, kvs ...any) {
+}
+
+func (e *empty) ErrorKV(msg string, kvs ...any) {
+}
+
+func (e *empty) WithKV(kvs ...any) Entry {
+	return e
+}
+
 func (e *empty) WithFields(fields Fields) Entry {
 	return e
 }
@@ -170,10 +339,26 @@ func (e *empty) WithError(err error) Entry {
 	return e
 }
 
+func (e *empty) WithGroup(name string) Entry {
+	return e
+}
+
+func (e *empty) WithLevel(level Level) Entry {
+	return e
+}
+
 func (e *empty) WithTracing(requestId string) Entry {
 	return e
 }
 
+func (e *empty) WithPolicy(policy Policy) Entry {
+	return e
+}
+
+func (e *empty) WithContext(ctx context.Context) Entry {
+	return e
+}
+
 func (e *empty) Enabled(level Level) bool {
 	return false
 }
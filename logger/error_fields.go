@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/tenz-io/trackingo/common"
+)
+
+const (
+	errCauseFieldName = "err_cause"
+	errTypeFieldName  = "err_type"
+	errCodeFieldName  = "val_error_code"
+)
+
+// expandErrors is set by SetExpandErrors
+var expandErrors atomic.Bool
+
+// SetExpandErrors makes WithError bind the root cause of the errors.Unwrap chain as err_cause,
+// the type of the root cause as err_type, e.g. *net.OpError, and the code of the common.ValError
+// in the chain as val_error_code along with err, so that the logs are aggregated by the root cause
+func SetExpandErrors(enabled bool) {
+	expandErrors.Store(enabled)
+}
+
+// errorFields returns the fields of err bound by WithError
+func errorFields(err error) Fields {
+	fields := Fields{defaultErrFieldName: err}
+	if err == nil || !expandErrors.Load() {
+		return fields
+	}
+
+	cause := err
+	for next := errors.Unwrap(cause); next != nil; next = errors.Unwrap(cause) {
+		cause = next
+	}
+	if cause != err {
+		fields[errCauseFieldName] = cause.Error()
+	}
+	fields[errTypeFieldName] = fmt.Sprintf("%T", cause)
+
+	var valErr *common.ValError
+	if errors.As(err, &valErr) {
+		fields[errCodeFieldName] = valErr.Code
+	}
+	return fields
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/tenz-io/trackingo/common"
+)
+
+func TestWithError_Expand(t *testing.T) {
+	err := fmt.Errorf("query user: %w", common.NotFound(fmt.Errorf("read: %w", io.EOF)))
+
+	t.Run("when disabled then bind err only", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		entry.WithError(err).Error("failed")
+
+		fields := logs.Records()[0].Fields
+		if len(fields) != 1 || fields[defaultErrFieldName] != err.Error() {
+			t.Errorf("Fields = %v, want err only", fields)
+		}
+	})
+
+	t.Run("when enabled then bind cause, type and code", func(t *testing.T) {
+		SetExpandErrors(true)
+		defer SetExpandErrors(false)
+
+		entry, logs := NewTestEntry(t)
+		entry.WithError(err).Error("failed")
+
+		fields := logs.Records()[0].Fields
+		want := map[string]any{
+			defaultErrFieldName: err.Error(),
+			errCauseFieldName:   "EOF",
+			errTypeFieldName:    "*errors.errorString",
+			errCodeFieldName:    int64(common.CodeNotFound),
+		}
+		for k, v := range want {
+			if fields[k] != v {
+				t.Errorf("Fields[%s] = %v, want %v", k, fields[k], v)
+			}
+		}
+	})
+
+	t.Run("when error is not wrapped then no cause", func(t *testing.T) {
+		SetExpandErrors(true)
+		defer SetExpandErrors(false)
+
+		fields := errorFields(errors.New("boom"))
+		if _, ok := fields[errCauseFieldName]; ok || fields[errTypeFieldName] != "*errors.errorString" {
+			t.Errorf("errorFields() = %v", fields)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// lazyWithCore binds the fields to the core on the first log passed the level,
+// the fields of the entries never logged, e.g. at a disabled level, are not encoded
+type lazyWithCore struct {
+	core   zapcore.Core // without the fields, never changed
+	fields []zapcore.Field
+
+	once   sync.Once
+	withed zapcore.Core // with the fields
+}
+
+// withLazy returns a copy of l with the fields bound lazily
+func withLazy(l *zap.Logger, fields []zapcore.Field) *zap.Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	return l.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return &lazyWithCore{
+			core:   c,
+			fields: fields,
+		}
+	}))
+}
+
+func (c *lazyWithCore) load() zapcore.Core {
+	c.once.Do(func() {
+		c.withed = c.core.With(c.fields)
+	})
+	return c.withed
+}
+
+func (c *lazyWithCore) Enabled(level zapcore.Level) bool {
+	return c.core.Enabled(level)
+}
+
+func (c *lazyWithCore) With(fields []zapcore.Field) zapcore.Core {
+	return c.load().With(fields)
+}
+
+func (c *lazyWithCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.load().Check(e, ce)
+}
+
+func (c *lazyWithCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	return c.load().Write(e, fields)
+}
+
+func (c *lazyWithCore) Sync() error {
+	return c.core.Sync()
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	syslog "log"
+	"runtime/debug"
+	"sync"
+)
+
+// LazyValue is a field value computed only when the log is written, see Lazy
+type LazyValue struct {
+	f       func() any
+	ignores []string
+
+	once sync.Once
+	val  any
+}
+
+// Lazy defers f until the log with the field is written, f is never called if the level is disabled
+// or the policy drops the log, e.g.
+//
+//	logger.WithFields(logger.Fields{"dump": logger.Lazy(func() any { return db.Dump() })}).Debug("state")
+//
+// f is called at most once, the value is trimmed as the other fields
+func Lazy(f func() any) *LazyValue {
+	return &LazyValue{f: f}
+}
+
+// Value returns the value of f, trimmed if not a string
+func (v *LazyValue) Value() any {
+	v.once.Do(func() {
+		v.val = v.eval()
+	})
+	return v.val
+}
+
+func (v *LazyValue) eval() (ret any) {
+	defer func() {
+		if r := recover(); r != nil {
+			syslog.Printf("panic recovery: %s, stacktrace: %s\n", r, string(debug.Stack()))
+			ret = fmt.Sprintf("panic recovery: %s", r)
+		}
+	}()
+
+	if v.f == nil {
+		return nil
+	}
+	val := v.f()
+	if s, ok := val.(string); ok {
+		return s
+	}
+	return TrimObjectWithOpts(val, WithIgnores(v.ignores...))
+}
+
+// MarshalJSON encodes the value by the json and console encoders
+func (v *LazyValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Value())
+}
+
+func (v *LazyValue) String() string {
+	return fmt.Sprint(v.Value())
+}
+
+// withIgnores returns a copy of v ignoring the fields, v is never evaluated
+func (v *LazyValue) withIgnores(ignores []string) *LazyValue {
+	if len(ignores) == 0 {
+		return v
+	}
+	return &LazyValue{f: v.f, ignores: ignores}
+}
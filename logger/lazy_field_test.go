@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	t.Run("when level is disabled then lazy value is not computed", func(t *testing.T) {
+		old := GetLevel()
+		SetLevel(InfoLevel)
+		defer SetLevel(old)
+
+		entry, logs := NewTestEntry(t)
+		calls := 0
+		dump := Lazy(func() any {
+			calls++
+			return "state"
+		})
+
+		entry.WithFields(Fields{"dump": dump}).Debug("skipped")
+		entry.DebugWith("skipped", Fields{"dump": dump})
+		if calls != 0 {
+			t.Errorf("calls = %d, want 0", calls)
+		}
+
+		entry.WithFields(Fields{"dump": dump}).Info("written")
+		entry.InfoWith("written", Fields{"dump": dump})
+		if got := logs.FilterField("dump", "state"); len(got) != 2 {
+			t.Errorf("FilterField() = %+v, want 2", got)
+		}
+		if calls != 1 {
+			t.Errorf("calls = %d, want 1", calls)
+		}
+	})
+
+	t.Run("when value is struct then it is trimmed", func(t *testing.T) {
+		type user struct {
+			Name     string
+			Password string `log:"mask"`
+		}
+		b, err := json.Marshal(Lazy(func() any {
+			return user{Name: "tom", Password: "secret"}
+		}))
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if got, want := string(b), `{"Name":"tom","Password":"***"}`; got != want {
+			t.Errorf("Marshal() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("when f panics then value is the recovery", func(t *testing.T) {
+		v := Lazy(func() any { panic("boom") }).Value()
+		if v != "panic recovery: boom" {
+			t.Errorf("Value() = %v", v)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// routedLevels are the levels routed by Config.LevelFiles
+var routedLevels = []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel}
+
+// newLevelFilesCore returns the core writing the logs of each level to the file of the level in LevelFiles,
+// or to the default file of the level if not found. the levels of the same file share one rolling file,
+// the files are wrapped by wrap if not nil, e.g. buffered
+func newLevelFilesCore(config Config, wrap func(zapcore.WriteSyncer) zapcore.WriteSyncer) zapcore.Core {
+	// the level enabler of the default logger, changed by SetLevel
+	lv := loglv
+	encoder := newLogEncoder(logEncoderConfig())
+
+	var (
+		names  []string
+		levels = make(map[string]map[Level]bool)
+	)
+	for _, l := range routedLevels {
+		name := config.LevelFiles[l]
+		if name == "" {
+			name = getNameByLogLevel(config.Filename, l)
+		}
+		if _, ok := levels[name]; !ok {
+			names = append(names, name)
+			levels[name] = make(map[Level]bool)
+		}
+		levels[name][l] = true
+	}
+
+	cores := make([]zapcore.Core, 0, len(names))
+	for _, name := range names {
+		var out zapcore.WriteSyncer = newRollingFile(config.Directory, name, config.MaxSize, config.MaxAge, config.MaxBackups)
+		if wrap != nil {
+			out = wrap(out)
+		}
+
+		fileLevels := levels[name]
+		enabler := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			// the fatal and panic logs go with the error logs
+			if l > zapcore.ErrorLevel {
+				l = zapcore.ErrorLevel
+			}
+			return lv.Enabled(l) && fileLevels[Level(l)]
+		})
+		cores = append(cores, zapcore.NewCore(encoder, out, enabler))
+	}
+
+	return zapcore.NewTee(cores...)
+}
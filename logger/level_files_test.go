@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLevelFiles(t *testing.T) {
+	readFile := func(t *testing.T, dir, name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s error = %v", name, err)
+		}
+		return string(b)
+	}
+
+	t.Run("when warn is routed then separate warn from error", func(t *testing.T) {
+		dir := t.TempDir()
+		Configure(Config{
+			LoggingLevel:       InfoLevel,
+			FileLoggingEnabled: true,
+			Directory:          dir,
+			LevelFiles:         map[Level]string{WarnLevel: "warn.log"},
+		})
+		defer Configure(Config{LoggingLevel: InfoLevel})
+
+		Warn("slow query")
+		Error("query failed")
+		Sync()
+
+		if warn := readFile(t, dir, "warn.log"); !strings.Contains(warn, "slow query") || strings.Contains(warn, "query failed") {
+			t.Errorf("warn.log = %s", warn)
+		}
+		if errs := readFile(t, dir, "error.log"); strings.Contains(errs, "slow query") || !strings.Contains(errs, "query failed") {
+			t.Errorf("error.log = %s", errs)
+		}
+	})
+
+	t.Run("when all levels are routed to one file then merge them", func(t *testing.T) {
+		dir := t.TempDir()
+		Configure(Config{
+			LoggingLevel:       DebugLevel,
+			FileLoggingEnabled: true,
+			Directory:          dir,
+			LevelFiles: map[Level]string{
+				DebugLevel: "app.log",
+				InfoLevel:  "app.log",
+				WarnLevel:  "app.log",
+				ErrorLevel: "app.log",
+			},
+		})
+		defer Configure(Config{LoggingLevel: InfoLevel})
+
+		Debugf("cache %s", "miss")
+		Info("order created")
+		Error("query failed")
+		Sync()
+
+		app := readFile(t, dir, "app.log")
+		for _, msg := range []string{"cache miss", "order created", "query failed"} {
+			if strings.Count(app, msg) != 1 {
+				t.Errorf("app.log has %d %q, want 1: %s", strings.Count(app, msg), msg, app)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "info.log")); !os.IsNotExist(err) {
+			t.Errorf("info.log exists, stat error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// levelPayload is the body of LevelHandler
+type levelPayload struct {
+	Name  string            `json:"name,omitempty"`
+	Level string            `json:"level"`
+	Named map[string]string `json:"named,omitempty"`
+}
+
+// LevelHandler returns a http.Handler to get and change the levels at runtime:
+//
+//	GET  /                                  {"level":"info","named":{"dao":"debug"}}
+//	GET  /?name=dao                         {"name":"dao","level":"debug"}
+//	PUT  /  {"level":"debug"}               sets the global level
+//	PUT  /  {"name":"dao","level":"debug"}  sets the level of the module dao
+//	PUT  /  {"name":"dao","level":""}       the module dao inherits the level again
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(serveLevel)
+}
+
+func serveLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeLevel(w, http.StatusOK, r.URL.Query().Get("name"))
+	case http.MethodPut:
+		var req levelPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeLevelError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+			return
+		}
+		if err := changeLevel(req.Name, req.Level); err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeLevel(w, http.StatusOK, req.Name)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// changeLevel sets the level of the named module, or the global level if name is empty
+func changeLevel(name, level string) error {
+	if name != "" && level == "" {
+		Named(name).ResetLevel()
+		return nil
+	}
+
+	lv, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		SetLevel(lv)
+		return nil
+	}
+	Named(name).SetLevel(lv)
+	return nil
+}
+
+func writeLevel(w http.ResponseWriter, status int, name string) {
+	resp := levelPayload{
+		Name: name,
+	}
+	if name == "" {
+		resp.Level = GetLevel().String()
+		resp.Named = make(map[string]string)
+		for n, lv := range NamedLevels() {
+			resp.Named[n] = lv.String()
+		}
+	} else {
+		resp.Level = Named(name).GetLevel().String()
+	}
+	writeLevelJSON(w, status, resp)
+}
+
+func writeLevelError(w http.ResponseWriter, status int, err error) {
+	writeLevelJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeLevelJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
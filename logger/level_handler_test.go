@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	defer SetLevel(InfoLevel)
+	defer Named("svc").ResetLevel()
+
+	serve := func(method, target, body string) (int, levelPayload) {
+		w := httptest.NewRecorder()
+		LevelHandler().ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var resp levelPayload
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	t.Run("when put global level then changed", func(t *testing.T) {
+		code, resp := serve(http.MethodPut, "/", `{"level":"warn"}`)
+		if code != http.StatusOK || resp.Level != "warn" || GetLevel() != WarnLevel {
+			t.Errorf("code = %v, resp = %+v, level = %v", code, resp, GetLevel())
+		}
+	})
+
+	t.Run("when put named level then changed", func(t *testing.T) {
+		code, resp := serve(http.MethodPut, "/", `{"name":"svc","level":"debug"}`)
+		if code != http.StatusOK || resp.Level != "debug" {
+			t.Errorf("code = %v, resp = %+v", code, resp)
+		}
+
+		_, resp = serve(http.MethodGet, "/", "")
+		if resp.Level != "warn" || resp.Named["svc"] != "debug" {
+			t.Errorf("resp = %+v", resp)
+		}
+	})
+
+	t.Run("when put empty named level then inherit", func(t *testing.T) {
+		_, resp := serve(http.MethodPut, "/", `{"name":"svc","level":""}`)
+		if resp.Level != "warn" {
+			t.Errorf("resp = %+v, want warn", resp)
+		}
+	})
+
+	t.Run("when level invalid then 400", func(t *testing.T) {
+		if code, _ := serve(http.MethodPut, "/", `{"level":"fatal"}`); code != http.StatusBadRequest {
+			t.Errorf("code = %v, want 400", code)
+		}
+		if code, _ := serve(http.MethodPost, "/", ""); code != http.StatusMethodNotAllowed {
+			t.Errorf("code = %v, want 405", code)
+		}
+	})
+}
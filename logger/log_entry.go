@@ -1,15 +1,16 @@
 package logger
 
 import (
-	"fmt"
+	"context"
 	"go.uber.org/zap"
-	"strings"
+	"go.uber.org/zap/zapcore"
 )
 
 const (
-	defaultFieldName    = "-"   // defaultFieldName of fields of the log record
-	defaultErrFieldName = "err" // defaultErrFieldName of error field of the log record
-	defaultSeparator    = "|"   // defaultSeparator of fields of the log record
+	defaultFieldName    = "-"            // defaultFieldName of fields of the log record
+	defaultErrFieldName = "err"          // defaultErrFieldName of error field of the log record
+	mispairedFieldName  = "kv_mispaired" // mispairedFieldName of the arguments of WithKV not in pairs
+	defaultSeparator    = "|"            // defaultSeparator of fields of the log record
 	defaultTraceOccupy  = "-:-:-"
 )
 
@@ -19,6 +20,10 @@ type LogEntry struct {
 	debugLogger *zap.Logger
 
 	requestId string
+	named     *namedLogger // the level of the named logger, the global level if nil
+
+	policy        Policy           // asked before each log, allow all if nil
+	levelPolicies map[Level]Policy // asked before each log of the level
 }
 
 func newLogEntry(le *LogEntry, fields Fields) *LogEntry {
@@ -26,13 +31,21 @@ func newLogEntry(le *LogEntry, fields Fields) *LogEntry {
 		return le
 	}
 
-	args := toZapFields(fields)
+	// the fields are encoded by the first log of each logger
+	return le.with(toZapFields(fields))
+}
 
+// with returns a copy of le with args bound lazily
+func (le *LogEntry) with(args []zapcore.Field) *LogEntry {
 	return &LogEntry{
-		infoLogger:  le.infoLogger.With(args...),
-		errLogger:   le.errLogger.With(args...),
-		debugLogger: le.debugLogger.With(args...),
+		infoLogger:  withLazy(le.infoLogger, args),
+		errLogger:   withLazy(le.errLogger, args),
+		debugLogger: withLazy(le.debugLogger, args),
 		requestId:   le.requestId,
+		named:       le.named,
+
+		policy:        le.policy,
+		levelPolicies: le.levelPolicies,
 	}
 }
 
@@ -46,7 +59,7 @@ func getLogEntry(infoLogger, errLogger, debugLogger *zap.Logger) *LogEntry {
 
 // Debug logs a message at DebugLevel.
 func (le *LogEntry) Debug(msg string) {
-	if !le.Enabled(DebugLevel) {
+	if !le.Enabled(DebugLevel) || !le.allow(DebugLevel) {
 		return
 	}
 
@@ -55,16 +68,16 @@ func (le *LogEntry) Debug(msg string) {
 
 // Debugf logs a message at DebugLevel.
 func (le *LogEntry) Debugf(format string, args ...any) {
-	if !le.Enabled(DebugLevel) {
+	if !le.Enabled(DebugLevel) || !le.allow(DebugLevel) {
 		return
 	}
 
-	le.debugLogger.Debug(le.withTrace(fmt.Sprintf(format, args...)))
+	le.debugLogger.Debug(le.withTracef(format, args...))
 }
 
 // DebugWith logs a message with fields at DebugLevel.
 func (le *LogEntry) DebugWith(msg string, fields Fields) {
-	if !le.Enabled(DebugLevel) {
+	if !le.Enabled(DebugLevel) || !le.allow(DebugLevel) {
 		return
 	}
 	le.debugLogger.Debug(le.withTrace(msg), toZapFields(fields)...)
@@ -72,23 +85,23 @@ func (le *LogEntry) DebugWith(msg string, fields Fields) {
 
 // Info logs a message at InfoLevel.
 func (le *LogEntry) Info(msg string) {
-	if !le.Enabled(InfoLevel) {
+	if !le.Enabled(InfoLevel) || !le.allow(InfoLevel) {
 		return
 	}
 	le.infoLogger.Info(le.withTrace(msg))
 }
 
 func (le *LogEntry) Infof(format string, args ...any) {
-	if !le.Enabled(InfoLevel) {
+	if !le.Enabled(InfoLevel) || !le.allow(InfoLevel) {
 		return
 	}
 
-	le.infoLogger.Info(le.withTrace(fmt.Sprintf(format, args...)))
+	le.infoLogger.Info(le.withTracef(format, args...))
 }
 
 // InfoWith logs a message with fields at InfoLevel.
 func (le *LogEntry) InfoWith(msg string, fields Fields) {
-	if !le.Enabled(InfoLevel) {
+	if !le.Enabled(InfoLevel) || !le.allow(InfoLevel) {
 		return
 	}
 	le.infoLogger.Info(le.withTrace(msg), toZapFields(fields)...)
@@ -96,23 +109,23 @@ func (le *LogEntry) InfoWith(msg string, fields Fields) {
 
 // Warn logs a message at WarnLevel.
 func (le *LogEntry) Warn(msg string) {
-	if !le.Enabled(WarnLevel) {
+	if !le.Enabled(WarnLevel) || !le.allow(WarnLevel) {
 		return
 	}
 	le.errLogger.Warn(le.withTrace(msg))
 }
 
 func (le *LogEntry) Warnf(format string, args ...any) {
-	if !le.Enabled(WarnLevel) {
+	if !le.Enabled(WarnLevel) || !le.allow(WarnLevel) {
 		return
 	}
 
-	le.errLogger.Warn(le.withTrace(fmt.Sprintf(format, args...)))
+	le.errLogger.Warn(le.withTracef(format, args...))
 }
 
 // WarnWith logs a message with fields at WarnLevel.
 func (le *LogEntry) WarnWith(msg string, fields Fields) {
-	if !le.Enabled(WarnLevel) {
+	if !le.Enabled(WarnLevel) || !le.allow(WarnLevel) {
 		return
 	}
 	le.errLogger.Warn(le.withTrace(msg), toZapFields(fields)...)
@@ -120,23 +133,23 @@ func (le *LogEntry) WarnWith(msg string, fields Fields) {
 
 // Error logs a message at ErrorLevel.
 func (le *LogEntry) Error(msg string) {
-	if !le.Enabled(ErrorLevel) {
+	if !le.Enabled(ErrorLevel) || !le.allow(ErrorLevel) {
 		return
 	}
 	le.errLogger.Error(le.withTrace(msg))
 }
 
 func (le *LogEntry) Errorf(format string, args ...any) {
-	if !le.Enabled(ErrorLevel) {
+	if !le.Enabled(ErrorLevel) || !le.allow(ErrorLevel) {
 		return
 	}
 
-	le.errLogger.Error(le.withTrace(fmt.Sprintf(format, args...)))
+	le.errLogger.Error(le.withTracef(format, args...))
 }
 
 // ErrorWith logs a message with fields at ErrorLevel.
 func (le *LogEntry) ErrorWith(msg string, fields Fields) {
-	if !le.Enabled(ErrorLevel) {
+	if !le.Enabled(ErrorLevel) || !le.allow(ErrorLevel) {
 		return
 	}
 	le.errLogger.Error(le.withTrace(msg), toZapFields(fields)...)
@@ -147,9 +160,80 @@ func (le *LogEntry) With(data any) Entry {
 	return le.WithField(defaultFieldName, data)
 }
 
+// Log logs a message at level.
+func (le *LogEntry) Log(level Level, msg string) {
+	level = clampLevel(level)
+	if !le.Enabled(level) || !le.allow(level) {
+		return
+	}
+	le.loggerOf(level).Log(zapcore.Level(level), le.withTrace(msg))
+}
+
+// Logf logs a message at level.
+func (le *LogEntry) Logf(level Level, format string, args ...any) {
+	level = clampLevel(level)
+	if !le.Enabled(level) || !le.allow(level) {
+		return
+	}
+	le.loggerOf(level).Log(zapcore.Level(level), le.withTracef(format, args...))
+}
+
+// LogWith logs a message with fields at level.
+func (le *LogEntry) LogWith(level Level, msg string, fields Fields) {
+	level = clampLevel(level)
+	if !le.Enabled(level) || !le.allow(level) {
+		return
+	}
+	le.loggerOf(level).Log(zapcore.Level(level), le.withTrace(msg), toZapFields(fields)...)
+}
+
+// DebugKV logs a message with the key/value pairs at DebugLevel.
+func (le *LogEntry) DebugKV(msg string, kvs ...any) {
+	if !le.Enabled(DebugLevel) || !le.allow(DebugLevel) {
+		return
+	}
+	le.debugLogger.Debug(le.withTrace(msg), toZapFields(kvFields(kvs))...)
+}
+
+// InfoKV logs a message with the key/value pairs at InfoLevel.
+func (le *LogEntry) InfoKV(msg string, kvs ...any) {
+	if !le.Enabled(InfoLevel) || !le.allow(InfoLevel) {
+		return
+	}
+	le.infoLogger.Info(le.withTrace(msg), toZapFields(kvFields(kvs))...)
+}
+
+// WarnKV logs a message with the key/value pairs at WarnLevel.
+func (le *LogEntry) WarnKV(msg string, kvs ...any) {
+	if !le.Enabled(WarnLevel) || !le.allow(WarnLevel) {
+		return
+	}
+	le.errLogger.Warn(le.withTrace(msg), toZapFields(kvFields(kvs))...)
+}
+
+// ErrorKV logs a message with the key/value pairs at ErrorLevel.
+func (le *LogEntry) ErrorKV(msg string, kvs ...any) {
+	if !le.Enabled(ErrorLevel) || !le.allow(ErrorLevel) {
+		return
+	}
+	le.errLogger.Error(le.withTrace(msg), toZapFields(kvFields(kvs))...)
+}
+
+// loggerOf returns the logger of level, the warn and error logs go to the errLogger
+func (le *LogEntry) loggerOf(level Level) *zap.Logger {
+	switch level {
+	case DebugLevel:
+		return le.debugLogger
+	case InfoLevel:
+		return le.infoLogger
+	default:
+		return le.errLogger
+	}
+}
+
 // WithError binds a default error field to a log message
 func (le *LogEntry) WithError(err error) Entry {
-	return le.WithField(defaultErrFieldName, err)
+	return le.WithFields(errorFields(err))
 }
 
 // WithField binds a field to a log message
@@ -157,11 +241,44 @@ func (le *LogEntry) WithField(k string, v any) Entry {
 	return le.WithFields(Fields{k: v})
 }
 
+// WithKV binds the key/value pairs to a log message
+func (le *LogEntry) WithKV(kvs ...any) Entry {
+	return le.WithFields(kvFields(kvs))
+}
+
 // WithFields Add a map of fields to the Entry.
 func (le *LogEntry) WithFields(fields Fields) Entry {
 	return newLogEntry(le, fields)
 }
 
+// WithGroup create copy of LogEntry nesting the fields added after in the group name,
+// e.g. WithGroup("http").WithField("method", "GET") logs {"http": {"method": "GET"}}
+func (le *LogEntry) WithGroup(name string) Entry {
+	if !le.validate() || name == "" {
+		return le
+	}
+	return le.with([]zapcore.Field{zap.Namespace(name)})
+}
+
+// WithLevel create copy of LogEntry logging at level instead of the global level or the level of the named module,
+// e.g. WithLevel(WarnLevel) for a noisy background job
+func (le *LogEntry) WithLevel(level Level) Entry {
+	if !le.validate() || !level.validate() {
+		return le
+	}
+
+	node := levelNode(le.named, level)
+	wrap := zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &namedCore{Core: core, node: node}
+	})
+	res := le.clone()
+	res.infoLogger = le.infoLogger.WithOptions(wrap)
+	res.errLogger = le.errLogger.WithOptions(wrap)
+	res.debugLogger = le.debugLogger.WithOptions(wrap)
+	res.named = node
+	return res
+}
+
 // WithTracing create copy of LogEntry with tracing.Span
 func (le *LogEntry) WithTracing(requestId string) Entry {
 	if !le.validate() {
@@ -172,22 +289,63 @@ func (le *LogEntry) WithTracing(requestId string) Entry {
 		errLogger:   le.errLogger,
 		debugLogger: le.debugLogger,
 		requestId:   requestId,
+		named:       le.named,
+
+		policy:        le.policy,
+		levelPolicies: le.levelPolicies,
+	}
+}
+
+// WithPolicy create copy of LogEntry with policy, which is asked before each log,
+// e.g. NewRateLimitPolicy(10, 1) drops the logs over 10 per second
+func (le *LogEntry) WithPolicy(policy Policy) Entry {
+	if !le.validate() || policy == nil {
+		return le
 	}
+	res := le.clone()
+	res.policy = policy
+	return res
+}
+
+// WithContext create copy of LogEntry with the requestId, the single flight cmd
+// and the fields of the registered extractors of ctx
+func (le *LogEntry) WithContext(ctx context.Context) Entry {
+	if !le.validate() {
+		return le
+	}
+	return withContext(le, ctx)
+}
+
+// allow asks the policies if the log of level is printed
+func (le *LogEntry) allow(level Level) bool {
+	if le.policy != nil && !le.policy.Allow() {
+		countSuppressedLog(level, suppressedByPolicy)
+		return false
+	}
+	if p := le.levelPolicies[level]; p != nil && !p.Allow() {
+		countSuppressedLog(level, suppressedByLevel)
+		return false
+	}
+	return true
 }
 
 func (le *LogEntry) Enabled(level Level) bool {
 	if le == nil {
 		return false
 	}
+	lv := GetLevel()
+	if le.named != nil {
+		lv = le.named.level()
+	}
 	switch level {
 	case DebugLevel:
-		return GetLevel() <= DebugLevel && le.debugLogger != nil
+		return lv <= DebugLevel && le.debugLogger != nil
 	case InfoLevel:
-		return GetLevel() <= InfoLevel && le.infoLogger != nil
+		return lv <= InfoLevel && le.infoLogger != nil
 	case WarnLevel:
-		return GetLevel() <= WarnLevel && le.errLogger != nil
+		return lv <= WarnLevel && le.errLogger != nil
 	case ErrorLevel:
-		return GetLevel() <= ErrorLevel && le.errLogger != nil
+		return lv <= ErrorLevel && le.errLogger != nil
 	default:
 		return false
 	}
@@ -195,21 +353,16 @@ func (le *LogEntry) Enabled(level Level) bool {
 
 func (le *LogEntry) withTrace(msg string) string {
 	if le == nil {
-		return strings.Join(append([]string{
-			defaultTraceOccupy,
-			msg,
-		}), defaultSeparator)
+		return traceMsg("", msg)
 	}
-	if le.requestId == "" {
-		return strings.Join(append([]string{
-			defaultTraceOccupy,
-			msg,
-		}), defaultSeparator)
+	return traceMsg(le.requestId, msg)
+}
+
+func (le *LogEntry) withTracef(format string, args ...any) string {
+	if le == nil {
+		return traceMsgf("", format, args...)
 	}
-	return strings.Join(append([]string{
-		le.requestId,
-		msg,
-	}), defaultSeparator)
+	return traceMsgf(le.requestId, format, args...)
 }
 
 func (le *LogEntry) validate() bool {
@@ -230,5 +383,9 @@ func (le *LogEntry) clone() *LogEntry {
 		infoLogger:  le.infoLogger,
 		errLogger:   le.errLogger,
 		requestId:   le.requestId,
+		named:       le.named,
+
+		policy:        le.policy,
+		levelPolicies: le.levelPolicies,
 	}
 }
@@ -3,6 +3,9 @@ package logger
 import (
 	"github.com/smarty/assertions"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -39,3 +42,52 @@ func TestSamplingPolicy_Allow(t *testing.T) {
 		assertions.ShouldBeTrue(diff < 0.01)
 	})
 }
+
+func TestEntry_WithPolicy(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "policy.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     out,
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    out,
+		LevelPolicies: map[Level]Policy{
+			ErrorLevel: NewRateLimitPolicy(1, 2),
+		},
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	count := func(msg string) int {
+		bs, _ := os.ReadFile(out.Name())
+		return strings.Count(string(bs), msg)
+	}
+
+	t.Run("when entry policy rejects then skip", func(t *testing.T) {
+		WithPolicy(NewRejectAllPolicy()).Warn("rejected warn")
+		WithField("k", "v").Warn("allowed warn")
+
+		if got := count("rejected warn"); got != 0 {
+			t.Errorf("count(rejected warn) = %v, want 0", got)
+		}
+		if got := count("allowed warn"); got != 1 {
+			t.Errorf("count(allowed warn) = %v, want 1", got)
+		}
+	})
+
+	t.Run("when level policy limits then drop the storm", func(t *testing.T) {
+		// the burst is 2 and one token is taken by the error log of Configure
+		for i := 0; i < 10; i++ {
+			WithField("i", i).Error("error storm")
+		}
+		Named("dao").Error("error storm")
+
+		if got := count("error storm"); got != 1 {
+			t.Errorf("count(error storm) = %v, want 1", got)
+		}
+	})
+}
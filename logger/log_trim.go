@@ -1,11 +1,14 @@
 package logger
 
 import (
+	"encoding"
 	"encoding/json"
 	"fmt"
 	syslog "log"
 	"reflect"
+	"regexp"
 	"runtime/debug"
+	"sort"
 	"strings"
 	"time"
 )
@@ -15,6 +18,13 @@ const (
 	defaultStrLimit   = 128
 	defaultDeepLimit  = 10
 	defaultWholeLimit = 4096
+
+	truncatedFieldName = "_truncated__" // marks the map or struct truncated by WholeLimit
+	truncatedValue     = "..."          // marks the slice truncated by WholeLimit
+
+	logTag     = "log"  // e.g. `log:"mask"`
+	logTagMask = "mask" // the value of the field is always masked
+	logTagOmit = "omit" // the field is always skipped
 )
 
 type ObjectTrimmer struct {
@@ -23,6 +33,7 @@ type ObjectTrimmer struct {
 	DeepLimit  int
 	WholeLimit int
 	Ignores    []string
+	Redacts    []*regexp.Regexp
 }
 
 type TrimOption func(*ObjectTrimmer)
@@ -57,6 +68,14 @@ func WithIgnores(ignores ...string) TrimOption {
 	}
 }
 
+// WithRedactPatterns adds patterns to the redact patterns set by SetRedactPatterns,
+// the values of the fields whose names match any of them are replaced by "***"
+func WithRedactPatterns(patterns ...*regexp.Regexp) TrimOption {
+	return func(t *ObjectTrimmer) {
+		t.Redacts = append(t.Redacts[:len(t.Redacts):len(t.Redacts)], patterns...)
+	}
+}
+
 func JsonObjectWithOpts(obj any, opts ...TrimOption) string {
 	j, err := json.Marshal(TrimObjectWithOpts(obj, opts...))
 	if err != nil {
@@ -84,27 +103,80 @@ func TrimObjectWithOpts(obj any, opts ...TrimOption) (ret any) {
 		DeepLimit:  defaultDeepLimit,
 		WholeLimit: defaultWholeLimit,
 		Ignores:    []string{},
+		Redacts:    RedactPatterns(),
 	}
 
 	for _, opt := range opts {
 		opt(trimmer)
 	}
 
-	return trimObjectWithIgnores(obj, trimmer.ArrLimit, trimmer.StrLimit, trimmer.DeepLimit, trimmer.Ignores...)
+	return trimObjectWithState(obj, trimmer.ArrLimit, trimmer.StrLimit, trimmer.DeepLimit, trimmer.WholeLimit, trimmer.Ignores, trimmer.Redacts)
 }
 
-func trimObjectWithIgnores(obj any, arrLmt, strLmt, deepLmt int, ignores ...string) any {
-	ignoreMap := make(map[string]bool)
-	if len(ignores) > 0 {
-		for _, ignore := range ignores {
-			ignoreMap[ignore] = true
+func trimObjectWithState(obj any, arrLmt, strLmt, deepLmt, wholeLmt int, ignores []string, redacts []*regexp.Regexp) any {
+	state := &trimState{
+		ignores: make(map[string]bool),
+		redacts: redacts,
+		budget:  wholeLmt,
+	}
+	for _, ignore := range ignores {
+		state.ignores[ignore] = true
+	}
+
+	return trimObject(obj, arrLmt, strLmt, deepLmt, state)
+}
+
+// trimState tells the fields to drop and the fields to redact,
+// and counts down the budget of the whole output
+type trimState struct {
+	ignores   map[string]bool
+	redacts   []*regexp.Regexp
+	budget    int  // the bytes left of the whole output, unlimited if <= 0 at first
+	truncated bool // the budget is used up
+}
+
+// redacted returns true if the field name matches any of the redact patterns
+func (f *trimState) redacted(fieldName string) bool {
+	for _, re := range f.redacts {
+		if re.MatchString(fieldName) {
+			return true
 		}
 	}
+	return false
+}
 
-	return trimObject(obj, arrLmt, strLmt, deepLmt, ignoreMap)
+// spend takes the approximate serialized size of the field from the budget,
+// returns false if the budget is used up, the field is not output then
+func (f *trimState) spend(fieldName string, val any) bool {
+	if f.truncated {
+		return false
+	}
+	if f.budget <= 0 {
+		// unlimited
+		return true
+	}
+
+	size := len(fieldName) + 4 // quotes, colon and comma
+	switch v := val.(type) {
+	case nil:
+	case string:
+		size += len(v) + 2
+	case bool:
+		size += 5
+	default:
+		size += 8
+	}
+
+	if size >= f.budget {
+		f.budget = 0
+		f.truncated = true
+		return false
+	}
+	f.budget -= size
+	return true
 }
 
-func trimObject(obj any, arrLmt, strLmt, deepLmt int, ignores map[string]bool) any {
+func trimObject(obj any, arrLmt, strLmt, deepLmt int, state *trimState) any {
 	if obj == nil {
 		return nil
 	}
@@ -127,11 +199,11 @@ func trimObject(obj any, arrLmt, strLmt, deepLmt int, ignores map[string]bool) a
 	case reflect.Ptr:
 		// should not happen
 	case reflect.Struct:
-		return trimStruct(v, arrLmt, strLmt, deepLmt-1, ignores)
+		return trimStruct(v, arrLmt, strLmt, deepLmt-1, state)
 	case reflect.Map:
-		return trimMap(v, arrLmt, strLmt, deepLmt-1, ignores)
+		return trimMap(v, arrLmt, strLmt, deepLmt-1, state)
 	case reflect.Array, reflect.Slice:
-		return trimSlice(v, arrLmt, strLmt, deepLmt, ignores)
+		return trimSlice(v, arrLmt, strLmt, deepLmt, state)
 	default:
 		//ignore
 	}
@@ -139,7 +211,7 @@ func trimObject(obj any, arrLmt, strLmt, deepLmt int, ignores map[string]bool) a
 	return nil
 }
 
-func trimStruct(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string]bool) map[string]any {
+func trimStruct(v reflect.Value, arrLmt, strLmt, deepLmt int, state *trimState) map[string]any {
 	m := make(map[string]any)
 	if deepLmt <= 0 {
 		return m
@@ -148,8 +220,18 @@ func trimStruct(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string
 	t := v.Type()
 
 	for i := 0; i < t.NumField(); i++ {
+		if state.truncated {
+			break
+		}
+
 		fieldName := t.Field(i).Name
 
+		// get log tag, honored regardless of the ignores
+		logOpt := t.Field(i).Tag.Get(logTag)
+		if logOpt == logTagOmit {
+			continue
+		}
+
 		// get json tag
 		if tag := t.Field(i).Tag.Get("json"); tag != "" {
 			if tag == "-" {
@@ -163,7 +245,7 @@ func trimStruct(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string
 			}
 		}
 
-		if !visibleName(fieldName, ignores) {
+		if !visibleName(fieldName, state.ignores) {
 			continue
 		}
 
@@ -173,8 +255,17 @@ func trimStruct(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string
 			continue
 		}
 
+		if logOpt == logTagMask || state.redacted(fieldName) {
+			if state.spend(fieldName, redactedValue) {
+				m[fieldName] = redactedValue
+			}
+			continue
+		}
+
 		if val, ok := valOfSupportType(fv, arrLmt, strLmt); ok {
-			m[fieldName] = val
+			if state.spend(fieldName, val) {
+				m[fieldName] = val
+			}
 			continue
 		}
 
@@ -186,20 +277,32 @@ func trimStruct(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string
 		case reflect.Ptr:
 			// should never happen
 		case reflect.Struct:
-			if sv := trimStruct(fv, arrLmt, strLmt, deepLmt-1, ignores); len(sv) > 0 {
+			if !state.spend(fieldName, nil) {
+				continue
+			}
+			if sv := trimStruct(fv, arrLmt, strLmt, deepLmt-1, state); len(sv) > 0 {
 				m[fieldName] = sv
 			}
 		case reflect.Map:
-			if mv := trimMap(fv, arrLmt, strLmt, deepLmt-1, ignores); len(mv) > 0 {
-				m[fieldName] = trimMap(fv, arrLmt, strLmt, deepLmt-1, ignores)
+			if !state.spend(fieldName, nil) {
+				continue
+			}
+			if mv := trimMap(fv, arrLmt, strLmt, deepLmt-1, state); len(mv) > 0 {
+				m[fieldName] = mv
 			}
 		case reflect.Array, reflect.Slice:
-			if sv := trimSlice(fv, arrLmt, strLmt, deepLmt, ignores); len(sv) > 0 {
-				m[fieldName] = trimSlice(fv, arrLmt, strLmt, deepLmt, ignores)
+			if !state.spend(fieldName, nil) {
+				continue
+			}
+			if sv := trimSlice(fv, arrLmt, strLmt, deepLmt, state); len(sv) > 0 {
+				m[fieldName] = sv
 				m["_size__"+fieldName] = fv.Len()
 			}
 		case reflect.Interface:
-			if iv := trimObject(fv.Interface(), arrLmt, strLmt, deepLmt-1, ignores); iv != nil {
+			if !state.spend(fieldName, nil) {
+				continue
+			}
+			if iv := trimObject(fv.Interface(), arrLmt, strLmt, deepLmt-1, state); iv != nil {
 				m[fieldName] = iv
 			}
 		default:
@@ -207,10 +310,13 @@ func trimStruct(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string
 		}
 	}
 
+	if state.truncated {
+		m[truncatedFieldName] = true
+	}
 	return m
 }
 
-func trimMap(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string]bool) map[string]any {
+func trimMap(v reflect.Value, arrLmt, strLmt, deepLmt int, state *trimState) map[string]any {
 	m := make(map[string]any)
 	if deepLmt <= 0 {
 		return m
@@ -219,8 +325,25 @@ func trimMap(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string]bo
 	if v.Kind() != reflect.Map {
 		return m
 	}
-	for _, k := range v.MapKeys() {
-		if !visibleName(k.String(), ignores) {
+
+	keys := v.MapKeys()
+	names := make(map[reflect.Value]string, len(keys))
+	for _, k := range keys {
+		names[k] = mapKeyName(k, deepLmt, strLmt, state)
+	}
+
+	// sorted to keep the same fields when truncated
+	sort.Slice(keys, func(i, j int) bool {
+		return names[keys[i]] < names[keys[j]]
+	})
+
+	for _, k := range keys {
+		if state.truncated {
+			break
+		}
+
+		name := names[k]
+		if !visibleName(name, state.ignores) {
 			continue
 		}
 
@@ -230,8 +353,17 @@ func trimMap(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string]bo
 			continue
 		}
 
+		if state.redacted(name) {
+			if state.spend(name, redactedValue) {
+				m[name] = redactedValue
+			}
+			continue
+		}
+
 		if val, ok := valOfSupportType(fv, arrLmt, strLmt); ok {
-			m[k.String()] = val
+			if state.spend(name, val) {
+				m[name] = val
+			}
 			continue
 		}
 
@@ -239,26 +371,66 @@ func trimMap(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string]bo
 			fv = fv.Elem()
 		}
 
+		if !state.spend(name, nil) {
+			continue
+		}
+
 		switch fv.Kind() {
 		case reflect.Ptr:
 		// should never happen
 		case reflect.Map:
-			m[k.String()] = trimMap(fv, arrLmt, strLmt, deepLmt-1, ignores)
+			m[name] = trimMap(fv, arrLmt, strLmt, deepLmt-1, state)
 		case reflect.Struct:
-			m[k.String()] = trimStruct(fv, arrLmt, strLmt, deepLmt-1, ignores)
+			m[name] = trimStruct(fv, arrLmt, strLmt, deepLmt-1, state)
 		case reflect.Array, reflect.Slice:
-			m[k.String()] = trimSlice(fv, arrLmt, strLmt, deepLmt, ignores)
+			m[name] = trimSlice(fv, arrLmt, strLmt, deepLmt, state)
 		case reflect.Interface:
-			m[k.String()] = trimObject(fv.Interface(), arrLmt, strLmt, deepLmt-1, ignores)
+			m[name] = trimObject(fv.Interface(), arrLmt, strLmt, deepLmt-1, state)
 		default:
 			//ignore
 		}
 	}
 
+	if state.truncated {
+		m[truncatedFieldName] = true
+	}
 	return m
 }
 
-func trimSlice(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string]bool) []any {
+// mapKeyName returns the name of the map key,
+// the primary types are formatted by fmt and the structs are trimmed into json
+func mapKeyName(k reflect.Value, deepLmt, strLmt int, state *trimState) string {
+	if k.Kind() == reflect.Interface && !k.IsNil() {
+		k = k.Elem()
+	}
+
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+
+	if val, ok := valOfSupportType(k, 0, strLmt); ok {
+		return fmt.Sprint(val)
+	}
+
+	if k.Kind() == reflect.Ptr && !k.IsNil() {
+		k = k.Elem()
+	}
+
+	if k.Kind() == reflect.Struct {
+		// the keys are not counted in the budget
+		keyState := &trimState{
+			ignores: state.ignores,
+			redacts: state.redacts,
+		}
+		if j, err := json.Marshal(trimStruct(k, 0, strLmt, deepLmt-1, keyState)); err == nil {
+			return string(j)
+		}
+	}
+
+	return fmt.Sprint(k)
+}
+
+func trimSlice(v reflect.Value, arrLmt, strLmt, deepLmt int, state *trimState) []any {
 	var arr []any
 	l := v.Len()
 
@@ -271,6 +443,10 @@ func trimSlice(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string]
 	}
 
 	for i := 0; i < l; i++ {
+		if state.truncated {
+			break
+		}
+
 		fv := v.Index(i)
 
 		if isNonValuableType(fv) {
@@ -278,7 +454,9 @@ func trimSlice(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string]
 		}
 
 		if val, ok := valOfSupportType(fv, arrLmt, strLmt); ok {
-			arr = append(arr, val)
+			if state.spend("", val) {
+				arr = append(arr, val)
+			}
 			continue
 		}
 
@@ -290,20 +468,23 @@ func trimSlice(v reflect.Value, arrLmt, strLmt, deepLmt int, ignores map[string]
 		case reflect.Ptr:
 		// should never happen
 		case reflect.Struct:
-			arr = append(arr, trimStruct(fv, arrLmt, strLmt, deepLmt-1, ignores))
+			arr = append(arr, trimStruct(fv, arrLmt, strLmt, deepLmt-1, state))
 		case reflect.Map:
-			arr = append(arr, trimMap(fv, arrLmt, strLmt, deepLmt-1, ignores))
+			arr = append(arr, trimMap(fv, arrLmt, strLmt, deepLmt-1, state))
 		case reflect.Array, reflect.Slice:
 		// seems like a arr of arr
 		// ignore the inner arr
 		//arr = append(arr, trimSlice(fv, arrLmt))
 		case reflect.Interface:
-			arr = append(arr, trimObject(fv.Interface(), arrLmt, strLmt, deepLmt-1, ignores))
+			arr = append(arr, trimObject(fv.Interface(), arrLmt, strLmt, deepLmt-1, state))
 		default:
 			//ignore
 		}
 	}
 
+	if state.truncated {
+		arr = append(arr, truncatedValue)
+	}
 	return arr
 }
 
@@ -326,7 +507,7 @@ func valOfSpecialType(v reflect.Value, arrLmt, strLmt int) (val any, ok bool) {
 	switch v.Type() {
 	case stringType:
 		s := v.String()
-		return StringLimit(s, strLmt), true
+		return limitString(s, strLmt), true
 	case errType:
 		return v.Interface().(error).Error(), true
 	case timeType:
@@ -340,16 +521,61 @@ func valOfSpecialType(v reflect.Value, arrLmt, strLmt int) (val any, ok bool) {
 	return nil, false
 }
 
+// valOfMarshalerType returns the text of an encoding.TextMarshaler, e.g. decimal.Decimal,
+// or of a fmt.Stringer except structs, e.g. uuid.UUID and enums.
+// the structs implementing fmt.Stringer only, e.g. protobuf messages, are trimmed as structs
+func valOfMarshalerType(v reflect.Value, strLmt int) (val any, ok bool) {
+	if isNonValuableType(v) {
+		return nil, false
+	}
+
+	obj := v.Interface()
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		// the methods of pointer receiver
+		if _, isMarshaler := obj.(encoding.TextMarshaler); !isMarshaler {
+			if _, isStringer := obj.(fmt.Stringer); !isStringer {
+				obj = v.Addr().Interface()
+			}
+		}
+	}
+
+	if m, isMarshaler := obj.(encoding.TextMarshaler); isMarshaler {
+		text, err := m.MarshalText()
+		if err != nil {
+			return err.Error(), true
+		}
+		return limitString(string(text), strLmt), true
+	}
+
+	elem := v
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if s, isStringer := obj.(fmt.Stringer); isStringer && elem.Kind() != reflect.Struct {
+		return limitString(s.String(), strLmt), true
+	}
+
+	return nil, false
+}
+
 // valOfSupportType returns the value of a support type
 func valOfSupportType(v reflect.Value, arrLmt, strLmt int) (val any, ok bool) {
 	if isNonValuableType(v) {
 		return nil, false
 	}
 
+	if val, ok = valOfFormattedType(v); ok {
+		return val, true
+	}
+
 	if val, ok = valOfSpecialType(v, arrLmt, strLmt); ok {
 		return val, true
 	}
 
+	if val, ok = valOfMarshalerType(v, strLmt); ok {
+		return val, true
+	}
+
 	if val, ok = valOfPrimaryType(v, arrLmt, strLmt); ok {
 		return val, true
 	}
@@ -379,7 +605,7 @@ func valOfPrimaryType(v reflect.Value, arrLmt, strLmt int) (val any, ok bool) {
 	case reflect.Complex64, reflect.Complex128:
 		return v.Complex(), true
 	case reflect.String:
-		return StringLimit(v.String(), strLmt), true
+		return limitString(v.String(), strLmt), true
 	default:
 		//ignore
 	}
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+)
+
+type status int
+
+func (s status) String() string {
+	return [...]string{"pending", "paid"}[s]
+}
+
+type money struct {
+	cents int64
+}
+
+func (m *money) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100)), nil
+}
+
+type message struct {
+	Id int `json:"id"`
+}
+
+func (m *message) String() string {
+	return fmt.Sprintf("id:%d", m.Id)
+}
+
+func TestTrimObject_marshaler(t *testing.T) {
+	type order struct {
+		Status  status   `json:"status"`
+		Amount  money    `json:"amount"`
+		Ip      net.IP   `json:"ip"`
+		Message *message `json:"message"`
+	}
+
+	got := TrimObject(&order{
+		Status:  1,
+		Amount:  money{cents: 1234},
+		Ip:      net.IPv4(10, 0, 0, 1),
+		Message: &message{Id: 7},
+	})
+	want := map[string]any{
+		"status":  "paid",
+		"amount":  "12.34",
+		"ip":      "10.0.0.1",
+		"message": map[string]any{"id": int64(7)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TrimObject() = %v, want %v", got, want)
+	}
+}
+
+func TestTrimObject_wholeLimit(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	type order struct {
+		Id    int    `json:"id"`
+		Items []item `json:"items"`
+		Note  string `json:"note"`
+	}
+
+	obj := &order{Id: 1, Note: "done"}
+	for i := 0; i < 100; i++ {
+		obj.Items = append(obj.Items, item{Name: fmt.Sprintf("item-%03d", i)})
+	}
+
+	t.Run("when whole limit is exceeded then truncate with marker", func(t *testing.T) {
+		got := TrimObjectWithOpts(obj, WithArrLimit(100), WithWholeLimit(200)).(map[string]any)
+		if got["id"] != int64(1) {
+			t.Errorf("TrimObjectWithOpts() id = %v, want 1", got["id"])
+		}
+		if got[truncatedFieldName] != true {
+			t.Errorf("TrimObjectWithOpts() is not marked truncated: %v", got)
+		}
+		if _, ok := got["note"]; ok {
+			t.Errorf("TrimObjectWithOpts() note = %v, want omitted", got["note"])
+		}
+
+		items := got["items"].([]any)
+		if len(items) >= 100 || items[len(items)-1] != truncatedValue {
+			t.Errorf("TrimObjectWithOpts() items = %v, want truncated", items)
+		}
+
+		j, _ := json.Marshal(got)
+		if len(j) > 300 {
+			t.Errorf("TrimObjectWithOpts() output has %d bytes, want about 200", len(j))
+		}
+	})
+
+	t.Run("when whole limit is not positive then no truncation", func(t *testing.T) {
+		got := TrimObjectWithOpts(obj, WithArrLimit(100), WithWholeLimit(0)).(map[string]any)
+		if _, ok := got[truncatedFieldName]; ok {
+			t.Errorf("TrimObjectWithOpts() is marked truncated")
+		}
+		if len(got["items"].([]any)) != 100 {
+			t.Errorf("TrimObjectWithOpts() items has %d, want 100", len(got["items"].([]any)))
+		}
+	})
+}
+
+func TestTrimObject_logTag(t *testing.T) {
+	type user struct {
+		Name     string `json:"name"`
+		Password string `json:"password" log:"omit"`
+		CardNo   string `json:"card_no" log:"mask"`
+	}
+
+	t.Run("when field is tagged then mask or omit it", func(t *testing.T) {
+		got := TrimObject(&user{Name: "alice", Password: "secret", CardNo: "4111"})
+		want := map[string]any{
+			"name":    "alice",
+			"card_no": redactedValue,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TrimObject() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("when tagged field is nested then mask it", func(t *testing.T) {
+		got := TrimObjectWithOpts(map[string]any{"user": user{Name: "bob", CardNo: "4111"}}, WithIgnores("name"))
+		want := map[string]any{
+			"user": map[string]any{"card_no": redactedValue},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TrimObjectWithOpts() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestTrimObject_mapKeys(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+
+	t.Run("when keys are primary types then format them", func(t *testing.T) {
+		got := TrimObject(map[string]any{
+			"ints":  map[int]string{1: "a", 2: "b"},
+			"bools": map[bool]int{true: 1},
+			"enums": map[status]int{1: 3},
+		})
+		want := map[string]any{
+			"ints":  map[string]any{"1": "a", "2": "b"},
+			"bools": map[string]any{"true": int64(1)},
+			"enums": map[string]any{"paid": int64(3)},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TrimObject() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("when keys are structs then trim them", func(t *testing.T) {
+		got := TrimObject(map[point]string{{X: 1, Y: 2}: "a"})
+		want := map[string]any{`{"x":1,"y":2}`: "a"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TrimObject() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestRegisterTrimFormatter(t *testing.T) {
+	type blob struct {
+		Data []byte
+	}
+	RegisterTrimFormatter(reflect.TypeOf(blob{}), func(v any) any {
+		return fmt.Sprintf("blob(%d bytes)", len(v.(blob).Data))
+	})
+	defer RegisterTrimFormatter(reflect.TypeOf(blob{}), nil)
+
+	t.Run("when type is registered then render by formatter", func(t *testing.T) {
+		got := TrimObject(map[string]any{
+			"value": blob{Data: make([]byte, 10)},
+			"ptr":   &blob{Data: make([]byte, 20)},
+			"list":  []blob{{Data: make([]byte, 30)}},
+		})
+		want := map[string]any{
+			"value": "blob(10 bytes)",
+			"ptr":   "blob(20 bytes)",
+			"list":  []any{"blob(30 bytes)"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TrimObject() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("when formatter is removed then use reflection", func(t *testing.T) {
+		RegisterTrimFormatter(reflect.TypeOf(blob{}), nil)
+		got := TrimObject(&blob{})
+		if !reflect.DeepEqual(got, map[string]any{}) {
+			t.Errorf("TrimObject() = %v, want empty map", got)
+		}
+	})
+}
@@ -0,0 +1,278 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const layoutLogfmt = "logfmt"
+
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder encodes the logs as the key=value pairs of logfmt, e.g.
+//
+//	@t=2024-01-02T15:04:05.000+0800 lvl=INFO msg="req-1|hello world" user_id=1
+//
+// the values with the spaces, the quotes, the = or the control characters are quoted,
+// the arrays and the objects are quoted as json
+type logfmtEncoder struct {
+	cfg        *zapcore.EncoderConfig
+	buf        *buffer.Buffer // the encoded context fields
+	namespaces []string
+}
+
+func newLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	if cfg.LineEnding == "" {
+		cfg.LineEnding = zapcore.DefaultLineEnding
+	}
+	return &logfmtEncoder{cfg: &cfg, buf: logfmtPool.Get()}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	res := &logfmtEncoder{
+		cfg:        e.cfg,
+		buf:        logfmtPool.Get(),
+		namespaces: append([]string(nil), e.namespaces...),
+	}
+	_, _ = res.buf.Write(e.buf.Bytes())
+	return res
+}
+
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := logfmtPool.Get()
+
+	head := &logfmtEncoder{cfg: e.cfg, buf: line}
+	if e.cfg.TimeKey != "" && e.cfg.EncodeTime != nil {
+		head.addEncoded(e.cfg.TimeKey, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeTime(ent.Time, enc) })
+	}
+	if e.cfg.LevelKey != "" && e.cfg.EncodeLevel != nil {
+		head.addEncoded(e.cfg.LevelKey, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeLevel(ent.Level, enc) })
+	}
+	if ent.LoggerName != "" && e.cfg.NameKey != "" {
+		head.AddString(e.cfg.NameKey, ent.LoggerName)
+	}
+	if ent.Caller.Defined && e.cfg.CallerKey != "" && e.cfg.EncodeCaller != nil {
+		head.addEncoded(e.cfg.CallerKey, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeCaller(ent.Caller, enc) })
+	}
+	if e.cfg.MessageKey != "" {
+		head.AddString(e.cfg.MessageKey, ent.Message)
+	}
+
+	if e.buf.Len() > 0 {
+		head.sep()
+		_, _ = line.Write(e.buf.Bytes())
+	}
+	if len(fields) > 0 {
+		enc := e.Clone().(*logfmtEncoder)
+		enc.buf.Reset()
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		if enc.buf.Len() > 0 {
+			head.sep()
+			_, _ = line.Write(enc.buf.Bytes())
+		}
+		enc.buf.Free()
+	}
+
+	if ent.Stack != "" && e.cfg.StacktraceKey != "" {
+		head.AddString(e.cfg.StacktraceKey, ent.Stack)
+	}
+	line.AppendString(e.cfg.LineEnding)
+	return line, nil
+}
+
+// sep separates the pairs by a space
+func (e *logfmtEncoder) sep() {
+	if e.buf.Len() > 0 {
+		e.buf.AppendByte(' ')
+	}
+}
+
+func (e *logfmtEncoder) key(key string) {
+	e.sep()
+	for _, ns := range e.namespaces {
+		e.buf.AppendString(ns)
+		e.buf.AppendByte('.')
+	}
+	e.buf.AppendString(key)
+	e.buf.AppendByte('=')
+}
+
+func (e *logfmtEncoder) addRaw(key, val string) {
+	e.key(key)
+	e.buf.AppendString(val)
+}
+
+// addEncoded adds the values appended by encode, joined by a space if many
+func (e *logfmtEncoder) addEncoded(key string, encode func(zapcore.PrimitiveArrayEncoder)) {
+	var vals logfmtValues
+	encode(&vals)
+	e.AddString(key, strings.Join(vals, " "))
+}
+
+func (e *logfmtEncoder) addJSON(key string, v any) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.AddString(key, string(bs))
+	return nil
+}
+
+func (e *logfmtEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddArray(key, arr); err != nil {
+		return err
+	}
+	return e.addJSON(key, m.Fields[key])
+}
+
+func (e *logfmtEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddObject(key, obj); err != nil {
+		return err
+	}
+	return e.addJSON(key, m.Fields[key])
+}
+
+func (e *logfmtEncoder) AddReflected(key string, v any) error {
+	return e.addJSON(key, v)
+}
+
+func (e *logfmtEncoder) AddBinary(key string, v []byte) {
+	e.AddString(key, base64.StdEncoding.EncodeToString(v))
+}
+
+func (e *logfmtEncoder) AddByteString(key string, v []byte) {
+	e.AddString(key, string(v))
+}
+
+func (e *logfmtEncoder) AddBool(key string, v bool) {
+	e.addRaw(key, strconv.FormatBool(v))
+}
+
+func (e *logfmtEncoder) AddComplex128(key string, v complex128) {
+	e.addRaw(key, strconv.FormatComplex(v, 'g', -1, 128))
+}
+
+func (e *logfmtEncoder) AddComplex64(key string, v complex64) {
+	e.addRaw(key, strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+
+func (e *logfmtEncoder) AddDuration(key string, v time.Duration) {
+	if e.cfg.EncodeDuration == nil {
+		e.AddInt64(key, int64(v))
+		return
+	}
+	e.addEncoded(key, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeDuration(v, enc) })
+}
+
+func (e *logfmtEncoder) AddFloat64(key string, v float64) {
+	e.addRaw(key, formatFloat(v, 64))
+}
+
+func (e *logfmtEncoder) AddFloat32(key string, v float32) {
+	e.addRaw(key, formatFloat(float64(v), 32))
+}
+
+func (e *logfmtEncoder) AddInt(key string, v int)     { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder) AddInt32(key string, v int32) { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder) AddInt16(key string, v int16) { e.AddInt64(key, int64(v)) }
+func (e *logfmtEncoder) AddInt8(key string, v int8)   { e.AddInt64(key, int64(v)) }
+
+func (e *logfmtEncoder) AddInt64(key string, v int64) {
+	e.key(key)
+	e.buf.AppendInt(v)
+}
+
+func (e *logfmtEncoder) AddString(key, v string) {
+	e.key(key)
+	if needsQuote(v) {
+		e.buf.AppendString(strconv.Quote(v))
+		return
+	}
+	e.buf.AppendString(v)
+}
+
+func (e *logfmtEncoder) AddTime(key string, v time.Time) {
+	if e.cfg.EncodeTime == nil {
+		e.AddInt64(key, v.UnixNano())
+		return
+	}
+	e.addEncoded(key, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeTime(v, enc) })
+}
+
+func (e *logfmtEncoder) AddUint(key string, v uint)       { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder) AddUint32(key string, v uint32)   { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder) AddUint16(key string, v uint16)   { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder) AddUint8(key string, v uint8)     { e.AddUint64(key, uint64(v)) }
+func (e *logfmtEncoder) AddUintptr(key string, v uintptr) { e.AddUint64(key, uint64(v)) }
+
+func (e *logfmtEncoder) AddUint64(key string, v uint64) {
+	e.key(key)
+	e.buf.AppendUint(v)
+}
+
+func (e *logfmtEncoder) OpenNamespace(key string) {
+	e.namespaces = append(e.namespaces, key)
+}
+
+// needsQuote reports whether v is empty or has the spaces, the quotes, the = or the control characters
+func needsQuote(v string) bool {
+	if v == "" {
+		return true
+	}
+	for _, r := range v {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
+
+func formatFloat(v float64, bitSize int) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'f', -1, bitSize)
+}
+
+// logfmtValues collects the values appended by the zap encoders of the time, the level, etc.
+type logfmtValues []string
+
+func (v *logfmtValues) add(s string) { *v = append(*v, s) }
+
+func (v *logfmtValues) AppendBool(b bool)             { v.add(strconv.FormatBool(b)) }
+func (v *logfmtValues) AppendByteString(b []byte)     { v.add(string(b)) }
+func (v *logfmtValues) AppendComplex128(c complex128) { v.add(strconv.FormatComplex(c, 'g', -1, 128)) }
+func (v *logfmtValues) AppendComplex64(c complex64) {
+	v.add(strconv.FormatComplex(complex128(c), 'g', -1, 64))
+}
+func (v *logfmtValues) AppendFloat64(f float64) { v.add(formatFloat(f, 64)) }
+func (v *logfmtValues) AppendFloat32(f float32) { v.add(formatFloat(float64(f), 32)) }
+func (v *logfmtValues) AppendInt(i int)         { v.add(strconv.Itoa(i)) }
+func (v *logfmtValues) AppendInt64(i int64)     { v.add(strconv.FormatInt(i, 10)) }
+func (v *logfmtValues) AppendInt32(i int32)     { v.add(strconv.FormatInt(int64(i), 10)) }
+func (v *logfmtValues) AppendInt16(i int16)     { v.add(strconv.FormatInt(int64(i), 10)) }
+func (v *logfmtValues) AppendInt8(i int8)       { v.add(strconv.FormatInt(int64(i), 10)) }
+func (v *logfmtValues) AppendString(s string)   { v.add(s) }
+func (v *logfmtValues) AppendUint(u uint)       { v.add(strconv.FormatUint(uint64(u), 10)) }
+func (v *logfmtValues) AppendUint64(u uint64)   { v.add(strconv.FormatUint(u, 10)) }
+func (v *logfmtValues) AppendUint32(u uint32)   { v.add(strconv.FormatUint(uint64(u), 10)) }
+func (v *logfmtValues) AppendUint16(u uint16)   { v.add(strconv.FormatUint(uint64(u), 10)) }
+func (v *logfmtValues) AppendUint8(u uint8)     { v.add(strconv.FormatUint(uint64(u), 10)) }
+func (v *logfmtValues) AppendUintptr(u uintptr) { v.add(strconv.FormatUint(uint64(u), 10)) }
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_logfmtEncoder(t *testing.T) {
+	enc := newLogfmtEncoder(zapcore.EncoderConfig{
+		LevelKey:       defaultLevelKey,
+		MessageKey:     defaultMessageKey,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+	})
+	zap.String("svc", "user").AddTo(enc)
+
+	t.Run("when the fields are encoded then they are the key=value pairs", func(t *testing.T) {
+		buf, err := enc.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, Message: "hello world"}, []zapcore.Field{
+			zap.Int("id", 1),
+			zap.String("name", `say "hi"`),
+			zap.String("empty", ""),
+			zap.Bool("ok", true),
+			zap.Duration("cost", 1500*time.Millisecond),
+			zap.Any("tags", []string{"a", "b"}),
+			zap.Namespace("req"),
+			zap.String("path", "/users"),
+		})
+		if err != nil {
+			t.Fatalf("EncodeEntry() error = %v", err)
+		}
+		want := `lvl=INFO msg="hello world" svc=user id=1 name="say \"hi\"" empty="" ok=true cost=1.5s tags="[\"a\",\"b\"]" req.path=/users` + "\n"
+		if got := buf.String(); got != want {
+			t.Errorf("EncodeEntry() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("when cloned then the context fields are kept", func(t *testing.T) {
+		buf, _ := enc.Clone().EncodeEntry(zapcore.Entry{Level: zapcore.WarnLevel, Message: "bye"}, nil)
+		if got, want := buf.String(), "lvl=WARN msg=bye svc=user\n"; got != want {
+			t.Errorf("EncodeEntry() = %s, want %s", got, want)
+		}
+	})
+}
+
+func TestLogfmtLayout(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "info.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     out,
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    out,
+		Encoder:               EncoderConfig{Layout: "logfmt"},
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	WithFields(Fields{"user_id": 1}).Info("hello")
+	Sync()
+
+	b, _ := os.ReadFile(out.Name())
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	line := lines[len(lines)-1]
+	if !strings.HasPrefix(line, "@t=") || !strings.Contains(line, " lvl=INFO msg=") || !strings.HasSuffix(line, " user_id=1") {
+		t.Errorf("log = %s, want logfmt", line)
+	}
+}
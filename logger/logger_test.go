@@ -1,8 +1,16 @@
 package logger
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"go.uber.org/zap/zapcore"
 )
 
 func TestLogger(t *testing.T) {
@@ -43,3 +51,294 @@ func TestLogger(t *testing.T) {
 		Resp: "test response",
 	})
 }
+
+func TestStacktrace(t *testing.T) {
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "error.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     devNull(t),
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    devNull(t),
+		StacktraceEnabled:     true,
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	Warn("slow query")
+	WithField("k", "v").Error("query failed")
+
+	b, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatalf("read log file error = %v", err)
+	}
+	lines := strings.Split(string(b), "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "slow query") && i+1 < len(lines) && strings.Contains(lines[i+1], "TestStacktrace") {
+			t.Errorf("warn log has stacktrace: %s", b)
+		}
+	}
+	if !strings.Contains(string(b), "query failed") || !strings.Contains(string(b), "logger.TestStacktrace") {
+		t.Errorf("error log has no stacktrace: %s", b)
+	}
+}
+
+func TestEntry_WithFieldsLazy(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "info.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     out,
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    out,
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	entry := WithTracing("req-1").WithFields(Fields{"order_id": 7})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry.Debugf("skipped %d", i)
+			entry.WithField("i", i).Infof("created %d", i)
+		}(i)
+	}
+	wg.Wait()
+
+	b, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatalf("read log file error = %v", err)
+	}
+	lines := 0
+	for _, line := range strings.Split(string(b), "\n") {
+		if !strings.Contains(line, "|req-1|created") {
+			continue
+		}
+		lines++
+		if !strings.Contains(line, `"order_id": 7`) {
+			t.Errorf("line = %s, want order_id", line)
+		}
+	}
+	if lines != 10 || strings.Contains(string(b), "skipped") {
+		t.Errorf("log = %s, want 10 created", b)
+	}
+}
+
+func TestDevMode(t *testing.T) {
+	dir := t.TempDir()
+	info, err := os.Create(filepath.Join(dir, "info.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer info.Close()
+	errs, err := os.Create(filepath.Join(dir, "error.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer errs.Close()
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     info,
+		ConsoleErrorStream:    errs,
+		ConsoleDebugStream:    info,
+		DevMode:               true,
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	Info("order created")
+	Error("query failed")
+	Debug("skipped")
+
+	b, _ := os.ReadFile(info.Name())
+	if !strings.Contains(string(b), "\x1b[34mINFO\x1b[0m -:-:-|order created") || strings.Contains(string(b), "query failed") {
+		t.Errorf("info = %q", b)
+	}
+	if strings.Contains(string(b), "skipped") {
+		t.Errorf("info = %q, want debug skipped", b)
+	}
+	if line := strings.SplitN(string(b), "\n", 2)[0]; len(line) < 12 || line[2] != ':' || line[8] != '.' {
+		t.Errorf("info line = %q, want compact time", line)
+	}
+
+	b, _ = os.ReadFile(errs.Name())
+	if !strings.Contains(string(b), "\x1b[31mERROR\x1b[0m -:-:-|query failed") {
+		t.Errorf("error = %q", b)
+	}
+}
+
+func TestEntry_WithGroup(t *testing.T) {
+	entry, logs := NewTestEntry(t)
+	entry.WithField("svc", "orders").WithGroup("http").WithField("method", "GET").InfoWith("done", Fields{"status": 200})
+
+	records := logs.Records()
+	if len(records) != 1 {
+		t.Fatalf("Records() = %+v, want 1", records)
+	}
+	want := map[string]any{
+		"svc":  "orders",
+		"http": map[string]any{"method": "GET", "status": int64(200)},
+	}
+	if got := records[0].Fields; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields = %v, want %v", got, want)
+	}
+}
+
+func TestEntry_WithLevel(t *testing.T) {
+	old := GetLevel()
+	SetLevel(InfoLevel)
+	defer SetLevel(old)
+
+	t.Run("when level is above global then drop the lower logs", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		job := entry.WithField("job", "sync").WithLevel(WarnLevel)
+		job.Info("synced")
+		job.Warn("slow")
+
+		if records := logs.Records(); len(records) != 1 || records[0].Message != "slow" || records[0].Fields["job"] != "sync" {
+			t.Errorf("Records() = %+v, want the warn only", records)
+		}
+		if !entry.Enabled(InfoLevel) {
+			t.Errorf("Enabled(info) of the parent = false, want true")
+		}
+	})
+
+	t.Run("when level is below global then log the debug", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		entry.WithLevel(DebugLevel).WithField("k", 1).Debug("detail")
+		entry.Debug("skipped")
+
+		if records := logs.Records(); len(records) != 1 || records[0].Message != "detail" {
+			t.Errorf("Records() = %+v, want detail only", records)
+		}
+	})
+
+	t.Run("when named then override the module level", func(t *testing.T) {
+		job := Named("job.sync").WithLevel(ErrorLevel)
+		if job.Enabled(WarnLevel) || !job.Enabled(ErrorLevel) {
+			t.Errorf("Enabled() of error level entry is wrong")
+		}
+		if Named("job.sync").GetLevel() != InfoLevel {
+			t.Errorf("GetLevel() = %v, want the module level kept", Named("job.sync").GetLevel())
+		}
+	})
+}
+
+func TestEncoderConfig(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "info.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     out,
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    out,
+		Encoder: EncoderConfig{
+			Separator:  "\t",
+			TimeLayout: "rfc3339nano",
+		},
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	WithTracing("req-1").Info("hello")
+	Sync()
+
+	b, _ := os.ReadFile(out.Name())
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	cols := strings.Split(lines[len(lines)-1], "\t")
+	if len(cols) != 4 || cols[1] != "INFO" || cols[2] != "req-1" || cols[3] != "hello" {
+		t.Fatalf("log = %q, want tab separated", b)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, cols[0]); err != nil {
+		t.Errorf("time = %s, want rfc3339nano: %v", cols[0], err)
+	}
+}
+
+func TestEntry_Log(t *testing.T) {
+	old := GetLevel()
+	SetLevel(InfoLevel)
+	defer SetLevel(old)
+
+	entry, logs := NewTestEntry(t)
+	entry.Log(DebugLevel, "skipped")
+	entry.Log(InfoLevel, "info")
+	entry.Logf(WarnLevel, "warn %d", 1)
+	entry.LogWith(Level(zapcore.FatalLevel), "fatal as error", Fields{"k": "v"})
+
+	records := logs.Records()
+	if len(records) != 3 {
+		t.Fatalf("Records() = %+v, want 3", records)
+	}
+	want := []struct {
+		level Level
+		msg   string
+	}{
+		{InfoLevel, "info"},
+		{WarnLevel, "warn 1"},
+		{ErrorLevel, "fatal as error"},
+	}
+	for i, w := range want {
+		if records[i].Level != w.level || records[i].Message != w.msg {
+			t.Errorf("Records()[%d] = %+v, want %v %s", i, records[i], w.level, w.msg)
+		}
+	}
+	if records[2].Fields["k"] != "v" {
+		t.Errorf("Fields = %v, want k", records[2].Fields)
+	}
+}
+
+func TestEntry_WithKV(t *testing.T) {
+	old := GetLevel()
+	SetLevel(InfoLevel)
+	defer SetLevel(old)
+
+	t.Run("when the pairs are valid then they are the fields", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		entry.WithKV("user_id", 1).InfoKV("hello", "name", "alice")
+
+		records := logs.FilterMessage("hello")
+		if len(records) != 1 {
+			t.Fatalf("Records() = %+v, want 1", logs.Records())
+		}
+		fields := records[0].Fields
+		if fields["user_id"] != int64(1) || fields["name"] != "alice" {
+			t.Errorf("Fields = %v, want user_id and name", fields)
+		}
+		if _, ok := fields[mispairedFieldName]; ok {
+			t.Errorf("Fields = %v, want no %s", fields, mispairedFieldName)
+		}
+	})
+
+	t.Run("when the pairs are mis-paired then they are in the dedicated field", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		entry.WarnKV("bad", 42, "name", "alice", "dangling")
+
+		records := logs.FilterMessage("bad")
+		if len(records) != 1 || records[0].Level != WarnLevel {
+			t.Fatalf("Records() = %+v, want 1 warn", logs.Records())
+		}
+		fields := records[0].Fields
+		if fields["name"] != "alice" {
+			t.Errorf("Fields = %v, want name", fields)
+		}
+		if got := fmt.Sprint(fields[mispairedFieldName]); got != "[42 dangling]" {
+			t.Errorf("%s = %s, want [42 dangling]", mispairedFieldName, got)
+		}
+	})
+}
@@ -0,0 +1,379 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	namedSeparator = "." // separator of the hierarchical names, e.g. dao.user
+)
+
+// NamedEntry is an Entry of a module with its own level, e.g. logger.Named("dao").SetLevel(DebugLevel)
+type NamedEntry interface {
+	Entry
+
+	// Name returns the name of the module
+	Name() string
+	// SetLevel overrides the inherited level of the module and its descendants not set
+	SetLevel(level Level)
+	// GetLevel returns the effective level: the level set, else the level of the nearest ancestor set,
+	// else the global level
+	GetLevel() Level
+	// ResetLevel inherits the level again
+	ResetLevel()
+}
+
+var (
+	namedLock    sync.Mutex
+	namedLoggers = map[string]*namedLogger{}
+
+	// configGen is increased when the default logger is configured, the named entries are rebuilt from it
+	configGen atomic.Uint64
+)
+
+// namedLogger is a node of the module hierarchy holding the level of the module
+type namedLogger struct {
+	name   string
+	parent *namedLogger
+	lv     atomic.Int32
+	set    atomic.Bool
+}
+
+// Named returns the entry of the module name, the modules are hierarchical by '.',
+// e.g. dao.user inherits the level of dao unless set.
+// the entry logs to the default logger, reconfigured by Configure
+func Named(name string) NamedEntry {
+	return &namedEntry{
+		node: namedNode(name),
+	}
+}
+
+// NamedLevels returns the effective levels of the named modules
+func NamedLevels() map[string]Level {
+	namedLock.Lock()
+	defer namedLock.Unlock()
+
+	levels := make(map[string]Level, len(namedLoggers))
+	for name, node := range namedLoggers {
+		levels[name] = node.level()
+	}
+	return levels
+}
+
+// namedNode returns the node of name, creating it and its ancestors if missing
+func namedNode(name string) *namedLogger {
+	namedLock.Lock()
+	defer namedLock.Unlock()
+
+	return namedNodeLocked(name)
+}
+
+func namedNodeLocked(name string) *namedLogger {
+	if node, ok := namedLoggers[name]; ok {
+		return node
+	}
+
+	node := &namedLogger{name: name}
+	if idx := strings.LastIndex(name, namedSeparator); idx > 0 {
+		node.parent = namedNodeLocked(name[:idx])
+	}
+	namedLoggers[name] = node
+	return node
+}
+
+// levelNode returns a node of the name of parent fixed at level, not registered
+func levelNode(parent *namedLogger, level Level) *namedLogger {
+	node := &namedLogger{parent: parent}
+	if parent != nil {
+		node.name = parent.name
+	}
+	node.lv.Store(int32(level))
+	node.set.Store(true)
+	return node
+}
+
+// level returns the level set of the nearest node, else the global level
+func (n *namedLogger) level() Level {
+	for node := n; node != nil; node = node.parent {
+		if node.set.Load() {
+			return Level(node.lv.Load())
+		}
+	}
+	return GetLevel()
+}
+
+// namedEntry logs to the default logger at the level of node
+type namedEntry struct {
+	node      *namedLogger
+	groups    []namedGroup // the groups in order, the fields outside of any group have no name
+	requestId string
+	policy    Policy
+	cache     atomic.Pointer[namedCache]
+}
+
+// namedGroup is a group opened by WithGroup and the fields added after
+type namedGroup struct {
+	name   string
+	fields Fields
+}
+
+type namedCache struct {
+	gen   uint64
+	entry *LogEntry
+}
+
+// entry returns the LogEntry built from the default logger, rebuilt if the default logger is configured again
+func (e *namedEntry) entry() *LogEntry {
+	gen := configGen.Load()
+	if c := e.cache.Load(); c != nil && c.gen == gen {
+		return c.entry
+	}
+
+	wrap := zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &namedCore{Core: core, node: e.node}
+	})
+	le := &LogEntry{
+		infoLogger:  defaultLogger.infoLogger.WithOptions(wrap).Named(e.node.name),
+		errLogger:   defaultLogger.errLogger.WithOptions(wrap).Named(e.node.name),
+		debugLogger: defaultLogger.debugLogger.WithOptions(wrap).Named(e.node.name),
+		requestId:   e.requestId,
+		named:       e.node,
+
+		policy:        e.policy,
+		levelPolicies: defaultLogger.levelPolicies,
+	}
+	for _, g := range e.groups {
+		if g.name != "" {
+			le = le.with([]zapcore.Field{zap.Namespace(g.name)})
+		}
+		if len(g.fields) > 0 {
+			le = newLogEntry(le, g.fields)
+		}
+	}
+
+	e.cache.Store(&namedCache{gen: gen, entry: le})
+	return le
+}
+
+func (e *namedEntry) Debug(msg string) {
+	e.entry().Debug(msg)
+}
+
+func (e *namedEntry) Debugf(format string, args ...any) {
+	e.entry().Debugf(format, args...)
+}
+
+func (e *namedEntry) DebugWith(msg string, fields Fields) {
+	e.entry().DebugWith(msg, fields)
+}
+
+func (e *namedEntry) Info(msg string) {
+	e.entry().Info(msg)
+}
+
+func (e *namedEntry) Infof(format string, args ...any) {
+	e.entry().Infof(format, args...)
+}
+
+func (e *namedEntry) InfoWith(msg string, fields Fields) {
+	e.entry().InfoWith(msg, fields)
+}
+
+func (e *namedEntry) Warn(msg string) {
+	e.entry().Warn(msg)
+}
+
+func (e *namedEntry) Warnf(format string, args ...any) {
+	e.entry().Warnf(format, args...)
+}
+
+func (e *namedEntry) WarnWith(msg string, fields Fields) {
+	e.entry().WarnWith(msg, fields)
+}
+
+func (e *namedEntry) Error(msg string) {
+	e.entry().Error(msg)
+}
+
+func (e *namedEntry) Errorf(format string, args ...any) {
+	e.entry().Errorf(format, args...)
+}
+
+func (e *namedEntry) ErrorWith(msg string, fields Fields) {
+	e.entry().ErrorWith(msg, fields)
+}
+
+func (e *namedEntry) Log(level Level, msg string) {
+	logAt(e, level, msg)
+}
+
+func (e *namedEntry) Logf(level Level, format string, args ...any) {
+	logfAt(e, level, format, args...)
+}
+
+func (e *namedEntry) LogWith(level Level, msg string, fields Fields) {
+	logWithAt(e, level, msg, fields)
+}
+
+func (e *namedEntry) DebugKV(msg string, kvs ...any) {
+	e.entry().DebugWith(msg, kvFields(kvs))
+}
+
+func (e *namedEntry) InfoKV(msg string, kvs ...any) {
+	e.entry().InfoWith(msg, kvFields(kvs))
+}
+
+func (e *namedEntry) WarnKV(msg string, kvs ...any) {
+	e.entry().WarnWith(msg, kvFields(kvs))
+}
+
+func (e *namedEntry) ErrorKV(msg string, kvs ...any) {
+	e.entry().ErrorWith(msg, kvFields(kvs))
+}
+
+func (e *namedEntry) WithKV(kvs ...any) Entry {
+	return e.WithFields(kvFields(kvs))
+}
+
+func (e *namedEntry) WithFields(fields Fields) Entry {
+	groups := make([]namedGroup, len(e.groups), len(e.groups)+1)
+	copy(groups, e.groups)
+	if len(groups) == 0 {
+		groups = append(groups, namedGroup{})
+	}
+
+	last := &groups[len(groups)-1]
+	merged := copyFields(last.fields)
+	for k, v := range fields {
+		merged[k] = v
+	}
+	last.fields = merged
+
+	return &namedEntry{
+		node:      e.node,
+		groups:    groups,
+		requestId: e.requestId,
+		policy:    e.policy,
+	}
+}
+
+func (e *namedEntry) WithField(k string, v any) Entry {
+	return e.WithFields(Fields{k: v})
+}
+
+func (e *namedEntry) With(data any) Entry {
+	return e.WithField(defaultFieldName, data)
+}
+
+func (e *namedEntry) WithError(err error) Entry {
+	return e.WithFields(errorFields(err))
+}
+
+func (e *namedEntry) WithGroup(name string) Entry {
+	if name == "" {
+		return e
+	}
+	groups := make([]namedGroup, len(e.groups), len(e.groups)+1)
+	copy(groups, e.groups)
+	return &namedEntry{
+		node:      e.node,
+		groups:    append(groups, namedGroup{name: name}),
+		requestId: e.requestId,
+		policy:    e.policy,
+	}
+}
+
+func (e *namedEntry) WithLevel(level Level) Entry {
+	if !level.validate() {
+		return e
+	}
+	return &namedEntry{
+		node:      levelNode(e.node, level),
+		groups:    e.groups,
+		requestId: e.requestId,
+		policy:    e.policy,
+	}
+}
+
+func (e *namedEntry) WithTracing(requestId string) Entry {
+	return &namedEntry{
+		node:      e.node,
+		groups:    e.groups,
+		requestId: requestId,
+		policy:    e.policy,
+	}
+}
+
+func (e *namedEntry) WithPolicy(policy Policy) Entry {
+	if policy == nil {
+		return e
+	}
+	return &namedEntry{
+		node:      e.node,
+		groups:    e.groups,
+		requestId: e.requestId,
+		policy:    policy,
+	}
+}
+
+func (e *namedEntry) WithContext(ctx context.Context) Entry {
+	return withContext(e, ctx)
+}
+
+func (e *namedEntry) Enabled(level Level) bool {
+	return e.entry().Enabled(level)
+}
+
+func (e *namedEntry) Name() string {
+	return e.node.name
+}
+
+func (e *namedEntry) SetLevel(level Level) {
+	if !level.validate() {
+		return
+	}
+	e.node.lv.Store(int32(level))
+	e.node.set.Store(true)
+}
+
+func (e *namedEntry) GetLevel() Level {
+	return e.node.level()
+}
+
+func (e *namedEntry) ResetLevel() {
+	e.node.set.Store(false)
+}
+
+// namedCore filters the logs by the level of node instead of the level of the wrapped core
+type namedCore struct {
+	zapcore.Core
+	node *namedLogger
+}
+
+func (c *namedCore) Enabled(level zapcore.Level) bool {
+	return Level(level) >= c.node.level()
+}
+
+func (c *namedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &namedCore{
+		Core: c.Core.With(fields),
+		node: c.node,
+	}
+}
+
+func (c *namedCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if c.Core.Enabled(ent.Level) {
+		// the wrapped core routes the log, e.g. the tee of the level files
+		return c.Core.Check(ent, ce)
+	}
+	return ce.AddCore(ent, c)
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "named.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	dao := Named("dao.user")
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     out,
+		ConsoleErrorStream:    out,
+		ConsoleDebugStream:    out,
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	t.Run("when level not set then inherit", func(t *testing.T) {
+		if got := dao.GetLevel(); got != InfoLevel {
+			t.Errorf("GetLevel() = %v, want info", got)
+		}
+
+		Named("dao").SetLevel(DebugLevel)
+		if got := dao.GetLevel(); got != DebugLevel {
+			t.Errorf("GetLevel() = %v, want debug", got)
+		}
+		if got := NamedLevels()["dao"]; got != DebugLevel {
+			t.Errorf("NamedLevels() = %v", NamedLevels())
+		}
+	})
+
+	t.Run("when module level is debug then log debug of the module only", func(t *testing.T) {
+		dao.WithField("table", "user").Debug("query user")
+		Named("cache").Debug("get user")
+		Debug("global debug")
+
+		bs, _ := os.ReadFile(out.Name())
+		logs := string(bs)
+		if !strings.Contains(logs, "dao.user") || !strings.Contains(logs, "query user") {
+			t.Errorf("logs = %s, want query user of dao.user", logs)
+		}
+		if strings.Contains(logs, "get user") || strings.Contains(logs, "global debug") {
+			t.Errorf("logs = %s, want no debug of others", logs)
+		}
+	})
+
+	t.Run("when group then fields nested", func(t *testing.T) {
+		dao.WithField("table", "user").WithGroup("http").WithField("method", "GET").Error("query failed")
+
+		bs, _ := os.ReadFile(out.Name())
+		if logs := string(bs); !strings.Contains(logs, `"http": {"method": "GET"}`) || !strings.Contains(logs, `"table": "user"`) {
+			t.Errorf("logs = %s, want method nested in http", logs)
+		}
+	})
+
+	t.Run("when reset then inherit again", func(t *testing.T) {
+		dao.SetLevel(ErrorLevel)
+		if dao.Enabled(WarnLevel) {
+			t.Errorf("Enabled(warn) = true, want false")
+		}
+		dao.ResetLevel()
+		Named("dao").ResetLevel()
+		if got := dao.GetLevel(); got != InfoLevel {
+			t.Errorf("GetLevel() = %v, want info", got)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// PIIDetector is the name of a built-in detector of the personal data in the strings, see SetPIIDetectors
+type PIIDetector string
+
+const (
+	// PIIEmail masks the emails except the first letter and the domain, e.g. a***@example.com
+	PIIEmail PIIDetector = "email"
+	// PIIPhone masks the phone numbers of the country code, e.g. +65 9123 4567, or of the separated
+	// groups, e.g. (555) 123-4567, except the last 4 digits. the bare digits are left for the ids
+	PIIPhone PIIDetector = "phone"
+	// PIICard masks the card numbers of 13 to 19 digits passing the Luhn check except the last 4 digits
+	PIICard PIIDetector = "card"
+)
+
+var (
+	piiEmailRe = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	piiPhoneRe = regexp.MustCompile(`\+\d{1,3}[ -]?\d(?:[ -]?\d){6,13}|\(?\b\d{3}\)?[ .-]\d{3}[ .-]\d{4}\b`)
+	piiCardRe  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// piiMaskers are the maskers of the detectors set, in the order of card, phone and email
+var piiMaskers atomic.Pointer[[]func(string) string]
+
+// SetPIIDetectors masks the personal data found by detectors in the strings of the logs and traffic logs,
+// e.g. the json bodies of the requests, before the strings are limited. the detectors are replaced,
+// disabled if none, and are kept if any of them is unknown
+func SetPIIDetectors(detectors ...PIIDetector) error {
+	enabled := make(map[PIIDetector]bool, len(detectors))
+	for _, d := range detectors {
+		switch d {
+		case PIIEmail, PIIPhone, PIICard:
+			enabled[d] = true
+		default:
+			return fmt.Errorf("unknown pii detector %q", d)
+		}
+	}
+
+	// the cards go first, the phone pattern matches the groups of the card numbers
+	var maskers []func(string) string
+	if enabled[PIICard] {
+		maskers = append(maskers, maskCards)
+	}
+	if enabled[PIIPhone] {
+		maskers = append(maskers, maskPhones)
+	}
+	if enabled[PIIEmail] {
+		maskers = append(maskers, maskEmails)
+	}
+
+	if len(maskers) == 0 {
+		piiMaskers.Store(nil)
+		return nil
+	}
+	piiMaskers.Store(&maskers)
+	return nil
+}
+
+// maskPII returns s with the personal data masked by the detectors set
+func maskPII(s string) string {
+	maskers := piiMaskers.Load()
+	if maskers == nil || s == "" {
+		return s
+	}
+	for _, mask := range *maskers {
+		s = mask(s)
+	}
+	return s
+}
+
+// limitString returns s masked by the detectors set and limited to limit
+func limitString(s string, limit int) string {
+	return StringLimit(maskPII(s), limit)
+}
+
+func maskEmails(s string) string {
+	return piiEmailRe.ReplaceAllStringFunc(s, func(email string) string {
+		at := strings.LastIndexByte(email, '@')
+		return email[:1] + redactedValue + email[at:]
+	})
+}
+
+func maskPhones(s string) string {
+	return piiPhoneRe.ReplaceAllStringFunc(s, maskDigits)
+}
+
+func maskCards(s string) string {
+	return piiCardRe.ReplaceAllStringFunc(s, func(num string) string {
+		if !luhnValid(num) {
+			return num
+		}
+		return maskDigits(num)
+	})
+}
+
+// maskDigits returns s with the digits except the last 4 replaced by '*'
+func maskDigits(s string) string {
+	digits := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			digits++
+		}
+	}
+
+	bs := []byte(s)
+	for i := 0; i < len(bs) && digits > 4; i++ {
+		if bs[i] >= '0' && bs[i] <= '9' {
+			bs[i] = '*'
+			digits--
+		}
+	}
+	return string(bs)
+}
+
+// luhnValid returns true if the digits of num pass the Luhn check
+func luhnValid(num string) bool {
+	sum, double := 0, false
+	for i := len(num) - 1; i >= 0; i-- {
+		c := num[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
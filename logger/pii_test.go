@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSetPIIDetectors(t *testing.T) {
+	defer SetPIIDetectors()
+
+	t.Run("when detector is unknown then error", func(t *testing.T) {
+		if err := SetPIIDetectors(PIIEmail, "ssn"); err == nil {
+			t.Errorf("SetPIIDetectors() error = nil, want unknown")
+		}
+	})
+
+	t.Run("when detectors set then mask strings", func(t *testing.T) {
+		if err := SetPIIDetectors(PIIEmail, PIIPhone, PIICard); err != nil {
+			t.Fatalf("SetPIIDetectors() error = %v", err)
+		}
+
+		tests := []struct {
+			in   string
+			want string
+		}{
+			{"mail alice@example.com", "mail a***@example.com"},
+			{"call +65 9123 4567", "call +** **** 4567"},
+			{"call (555) 123-4567", "call (***) ***-4567"},
+			{"card 4111 1111 1111 1111", "card **** **** **** 1111"},
+			{"order 4111111111111112", "order 4111111111111112"},
+			{"id 1700000000", "id 1700000000"},
+		}
+		for _, tt := range tests {
+			if got := maskPII(tt.in); got != tt.want {
+				t.Errorf("maskPII(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("when payload is trimmed then mask nested strings", func(t *testing.T) {
+		if err := SetPIIDetectors(PIIEmail); err != nil {
+			t.Fatalf("SetPIIDetectors() error = %v", err)
+		}
+
+		type user struct {
+			Email string
+		}
+		got, _ := json.Marshal(TrimObject(map[string]any{"user": user{Email: "bob@example.com"}}))
+		if want := `{"user":{"Email":"b***@example.com"}}`; string(got) != want {
+			t.Errorf("TrimObject() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("when detectors reset then keep strings", func(t *testing.T) {
+		_ = SetPIIDetectors()
+		if got := maskPII("alice@example.com"); got != "alice@example.com" {
+			t.Errorf("maskPII() = %q", got)
+		}
+	})
+}
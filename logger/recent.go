@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	recentLevelTraffic = "TRAFFIC" // level of the recent records of the failed traffic
+)
+
+// RecentRecord is a warn or error log, or a failed traffic, kept in memory
+type RecentRecord struct {
+	Time      time.Time      `json:"time"`
+	Level     string         `json:"level"`
+	Msg       string         `json:"msg"`
+	RequestId string         `json:"request_id,omitempty"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// recentRing keeps the last records, the oldest is overwritten when full
+type recentRing struct {
+	lock    sync.Mutex
+	records []RecentRecord
+	next    int
+	full    bool
+}
+
+var recent = &recentRing{}
+
+// SetRecentSize keeps the last size warn and error logs and failed traffic in memory, see RecentErrors.
+// the kept records are dropped, disabled if size <= 0
+func SetRecentSize(size int) {
+	recent.lock.Lock()
+	defer recent.lock.Unlock()
+
+	if size < 0 {
+		size = 0
+	}
+	recent.records = make([]RecentRecord, size)
+	recent.next = 0
+	recent.full = false
+}
+
+// RecentErrors returns the kept records, the newest first
+func RecentErrors() []RecentRecord {
+	recent.lock.Lock()
+	defer recent.lock.Unlock()
+
+	n := recent.next
+	if recent.full {
+		n = len(recent.records)
+	}
+
+	res := make([]RecentRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		idx := (recent.next - i + len(recent.records)) % len(recent.records)
+		res = append(res, recent.records[idx])
+	}
+	return res
+}
+
+func (r *recentRing) add(rec RecentRecord) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.records) == 0 {
+		return
+	}
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *recentRing) enabled() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return len(r.records) > 0
+}
+
+// recordTraffic keeps tc if it is a failed response
+func recordTraffic(tc *Traffic, requestId string) {
+	if tc.Typ != TrafficTypResp || tc.Code == 0 || !recent.enabled() {
+		return
+	}
+	recent.add(RecentRecord{
+		Time:      time.Now(),
+		Level:     recentLevelTraffic,
+		Msg:       tc.Msg,
+		RequestId: requestId,
+		Fields: map[string]any{
+			"cmd":  tc.Cmd,
+			"code": tc.Code,
+			"cost": tc.Cost.String(),
+		},
+	})
+}
+
+// recentCore is a zapcore.Core keeping the warn and error logs in recent
+type recentCore struct {
+	fields []zapcore.Field
+}
+
+func (c *recentCore) Enabled(level zapcore.Level) bool {
+	return level >= zapcore.WarnLevel
+}
+
+func (c *recentCore) With(fields []zapcore.Field) zapcore.Core {
+	return &recentCore{
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *recentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) && recent.enabled() {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *recentCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	requestId, msg := splitTrace(ent.Message)
+	recent.add(RecentRecord{
+		Time:      ent.Time,
+		Level:     ent.Level.CapitalString(),
+		Msg:       msg,
+		RequestId: requestId,
+		Fields:    enc.Fields,
+	})
+	return nil
+}
+
+func (c *recentCore) Sync() error {
+	return nil
+}
+
+// splitTrace splits the requestId prefixed by withTrace from msg
+func splitTrace(msg string) (requestId, rest string) {
+	requestId, rest, ok := strings.Cut(msg, logSeparator())
+	if !ok {
+		return "", msg
+	}
+	if requestId == defaultTraceOccupy {
+		requestId = ""
+	}
+	return requestId, rest
+}
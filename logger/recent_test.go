@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRecentErrors(t *testing.T) {
+	SetRecentSize(2)
+	defer SetRecentSize(0)
+
+	entry := newEntry(defaultConfig, zapcore.AddSync(&discard{}), zapcore.AddSync(&discard{}), zapcore.AddSync(&discard{}), false)
+
+	t.Run("when info then not kept", func(t *testing.T) {
+		entry.Info("ok")
+		if got := RecentErrors(); len(got) != 0 {
+			t.Errorf("RecentErrors() = %+v, want none", got)
+		}
+	})
+
+	t.Run("when full then keep the newest first", func(t *testing.T) {
+		entry.WithTracing("req-1").WarnWith("first", Fields{"k": "v"})
+		entry.Error("second")
+		entry.Error("third")
+
+		got := RecentErrors()
+		if len(got) != 2 || got[0].Msg != "third" || got[1].Msg != "second" {
+			t.Fatalf("RecentErrors() = %+v", got)
+		}
+		if got[0].Level != "ERROR" || got[0].RequestId != "" {
+			t.Errorf("RecentErrors()[0] = %+v", got[0])
+		}
+	})
+
+	t.Run("when traffic failed then kept", func(t *testing.T) {
+		SetRecentSize(2)
+		recordTraffic(&Traffic{Typ: TrafficTypResp, Cmd: "get_user", Code: 404, Msg: "not found"}, "req-1")
+		recordTraffic(&Traffic{Typ: TrafficTypResp, Cmd: "get_user"}, "req-2")
+		entry.WithTracing("req-3").WarnWith("slow", Fields{"k": "v"})
+
+		got := RecentErrors()
+		if len(got) != 2 || got[1].Level != recentLevelTraffic || got[1].RequestId != "req-1" || got[1].Fields["code"] != 404 {
+			t.Fatalf("RecentErrors() = %+v", got)
+		}
+		if got[0].RequestId != "req-3" || got[0].Fields["k"] != "v" {
+			t.Errorf("RecentErrors()[0] = %+v", got[0])
+		}
+	})
+}
+
+type discard struct{}
+
+func (d *discard) Write(p []byte) (int, error) {
+	return len(p), nil
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"sync/atomic"
+)
+
+const (
+	redactedValue = "***"
+)
+
+// redactPatterns are the patterns of the field names whose values are redacted in all the logs
+var redactPatterns atomic.Pointer[[]*regexp.Regexp]
+
+// SetRedactPatterns redacts the values of the fields whose names match any of patterns,
+// e.g. (?i)token|secret|password, in the logs and traffic logs including the nested ones.
+// the patterns are replaced, and are kept if any of them is invalid
+func SetRedactPatterns(patterns ...string) error {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		res = append(res, re)
+	}
+
+	redactPatterns.Store(&res)
+	return nil
+}
+
+// RedactPatterns returns the patterns set by SetRedactPatterns
+func RedactPatterns() []*regexp.Regexp {
+	if res := redactPatterns.Load(); res != nil {
+		return *res
+	}
+	return nil
+}
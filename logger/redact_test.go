@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	if err := SetRedactPatterns(`(?i)token|secret|password`); err != nil {
+		t.Fatalf("SetRedactPatterns() error = %v", err)
+	}
+	defer SetRedactPatterns()
+
+	type credential struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+	}
+	type request struct {
+		Cred    *credential       `json:"cred"`
+		Headers map[string]string `json:"headers"`
+	}
+
+	t.Run("when nested names match then redact", func(t *testing.T) {
+		got := TrimObject(&request{
+			Cred:    &credential{User: "alice", Password: "p@ss"},
+			Headers: map[string]string{"X-Auth-Token": "abc", "Accept": "*/*"},
+		})
+		want := map[string]any{
+			"cred":    map[string]any{"user": "alice", "password": "***"},
+			"headers": map[string]any{"X-Auth-Token": "***", "Accept": "*/*"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TrimObject() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("when option adds pattern then redact", func(t *testing.T) {
+		got := TrimObjectWithOpts(map[string]any{"card_no": "4111", "user": "alice"},
+			WithRedactPatterns(regexp.MustCompile(`^card_`)))
+		want := map[string]any{"card_no": "***", "user": "alice"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TrimObjectWithOpts() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("when top level field matches then redact", func(t *testing.T) {
+		fields := toZapFields(Fields{"api_secret": "s3cr3t"})
+		if len(fields) != 1 || fields[0].String != "***" {
+			t.Errorf("toZapFields() = %+v", fields)
+		}
+	})
+
+	t.Run("when pattern invalid then error", func(t *testing.T) {
+		if err := SetRedactPatterns(`(`); err == nil {
+			t.Errorf("SetRedactPatterns() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"errors"
+	syslog "log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+var (
+	rollingLock sync.Mutex
+	// rollingFiles are the rolling files of the logs and the traffic logs by path, the latest of a path is kept
+	rollingFiles = map[string]*lumberjack.Logger{}
+)
+
+func registerRollingFile(f *lumberjack.Logger) {
+	rollingLock.Lock()
+	defer rollingLock.Unlock()
+
+	rollingFiles[f.Filename] = f
+}
+
+// Reopen flushes the logs and closes the log files, which are opened again by the next writes,
+// so that the files moved by the external rotation, e.g. logrotate without copytruncate, are created again
+func Reopen() error {
+	Sync()
+
+	rollingLock.Lock()
+	defer rollingLock.Unlock()
+
+	var errs []error
+	for _, f := range rollingFiles {
+		if err := f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReopenOnSignal calls Reopen on each of sigs, SIGHUP if none, until stop is called
+func ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	sigC := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigC, sigs...)
+	go func() {
+		for {
+			select {
+			case <-sigC:
+				if err := Reopen(); err != nil {
+					syslog.Println("[logger] reopen log files error: ", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigC)
+			close(done)
+		})
+	}
+}
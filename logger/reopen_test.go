@@ -0,0 +1,75 @@
+//go:build !windows
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	Configure(Config{
+		LoggingLevel:       InfoLevel,
+		FileLoggingEnabled: true,
+		Directory:          dir,
+		Filename:           "app.log",
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	name := filepath.Join(dir, getNameByLogLevel("app.log", InfoLevel))
+	rotate := func(t *testing.T, moved string) {
+		Sync()
+		if err := os.Rename(name, moved); err != nil {
+			t.Fatalf("rename error = %v", err)
+		}
+	}
+	contains := func(file, s string) bool {
+		b, _ := os.ReadFile(file)
+		return strings.Contains(string(b), s)
+	}
+
+	t.Run("when reopened then write to the new file", func(t *testing.T) {
+		Info("before rotation")
+		moved := name + ".1"
+		rotate(t, moved)
+
+		if err := Reopen(); err != nil {
+			t.Fatalf("Reopen() error = %v", err)
+		}
+		Info("after rotation")
+		Sync()
+
+		if !contains(moved, "before rotation") || contains(moved, "after rotation") {
+			t.Errorf("moved file has wrong logs")
+		}
+		if !contains(name, "after rotation") {
+			t.Errorf("new file has no logs after rotation")
+		}
+	})
+
+	t.Run("when SIGHUP then reopen", func(t *testing.T) {
+		stop := ReopenOnSignal()
+		defer stop()
+
+		moved := name + ".2"
+		rotate(t, moved)
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatalf("kill error = %v", err)
+		}
+
+		for i := 0; i < 100; i++ {
+			Info("after signal")
+			Sync()
+			if contains(name, "after signal") {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Errorf("new file has no logs after SIGHUP")
+	})
+}
@@ -2,13 +2,14 @@ package logger
 
 import (
 	"context"
-	"fmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
+	syslog "log"
 	"os"
 	"path"
 	"strings"
+	"time"
 )
 
 const (
@@ -20,6 +21,7 @@ type loggerCtxKeyType string
 const (
 	logCtxKey        = loggerCtxKeyType("_log_ctx_key")
 	trafficLogCtxKey = loggerCtxKeyType("_traffic_log_ctx_key")
+	pairIdCtxKey     = loggerCtxKeyType("_pair_id_ctx_key")
 )
 
 var (
@@ -30,32 +32,69 @@ var (
 // Config for logging
 type Config struct {
 	// LoggingLevel set log defaultLevel
-	LoggingLevel Level
+	LoggingLevel Level `yaml:"logging_level" json:"logging_level" default:"info"`
 	// FileLoggingEnabled makes the framework log to a file
 	// the fields below can be skipped if this value is false!
-	FileLoggingEnabled bool
+	FileLoggingEnabled bool `yaml:"file_logging_enabled" json:"file_logging_enabled"`
 	// ConsoleLoggingEnabled makes the framework log to console
-	ConsoleLoggingEnabled bool
+	ConsoleLoggingEnabled bool `yaml:"console_logging_enabled" json:"console_logging_enabled"`
 	// CallerEnabled makes the caller log to a file
-	CallerEnabled bool
+	CallerEnabled bool `yaml:"caller_enabled" json:"caller_enabled"`
 	// CallerSkip increases the number of callers skipped by caller
-	CallerSkip int
+	CallerSkip int `yaml:"caller_skip" json:"caller_skip" default:"1"`
+	// StacktraceEnabled attaches the stacktrace to the logs at or above StacktraceLevel
+	StacktraceEnabled bool `yaml:"stacktrace_enabled" json:"stacktrace_enabled"`
+	// StacktraceLevel the level the stacktrace is attached at or above, ErrorLevel if lower than WarnLevel
+	StacktraceLevel Level `yaml:"stacktrace_level" json:"stacktrace_level" default:"error"`
 	// Directory to log to to when filelogging is enabled
-	Directory string
+	Directory string `yaml:"directory" json:"directory" default:"log"`
 	// Filename is the name of the logfile which will be placed inside the directory
-	Filename string
+	Filename string `yaml:"filename" json:"filename"`
 	// MaxSize the max size in MB of the logfile before it's rolled
-	MaxSize int
+	MaxSize int `yaml:"max_size" json:"max_size" default:"100"`
 	// MaxBackups the max number of rolled files to keep
-	MaxBackups int
+	MaxBackups int `yaml:"max_backups" json:"max_backups" default:"10"`
 	// MaxAge the max age in days to keep a logfile
-	MaxAge int
+	MaxAge int `yaml:"max_age" json:"max_age"`
 	// ConsoleInfoStream
-	ConsoleInfoStream *os.File
+	ConsoleInfoStream *os.File `yaml:"-" json:"-"`
 	// ConsoleErrorStream
-	ConsoleErrorStream *os.File
+	ConsoleErrorStream *os.File `yaml:"-" json:"-"`
 	// ConsoleDebugStream
-	ConsoleDebugStream *os.File
+	ConsoleDebugStream *os.File `yaml:"-" json:"-"`
+	// RecentSize the number of the last warn and error logs and failed traffic kept in memory, see RecentErrors.
+	// disabled if 0
+	RecentSize int `yaml:"recent_size" json:"recent_size"`
+	// BufferSize the size in bytes of the buffer of each output, the logs are written when it's full or
+	// every FlushInterval in background. unbuffered if 0, call Close before exit if buffered
+	BufferSize int `yaml:"buffer_size" json:"buffer_size"`
+	// FlushInterval the interval of flushing the buffered logs, 1s if 0
+	FlushInterval time.Duration `yaml:"flush_interval" json:"flush_interval"`
+	// RedactPatterns the patterns of the field names whose values are redacted as "***", see SetRedactPatterns
+	RedactPatterns []string `yaml:"redact_patterns" json:"redact_patterns"`
+	// ExpandErrors makes WithError bind the root cause, its type and the ValError code of the errors, see SetExpandErrors
+	ExpandErrors bool `yaml:"expand_errors" json:"expand_errors"`
+	// Encoder overrides the keys, the separator and the time layout of the logs
+	Encoder EncoderConfig `yaml:"encoder" json:"encoder"`
+	// PIIDetectors the detectors of the personal data masked in the strings, e.g. email, phone and card,
+	// see SetPIIDetectors
+	PIIDetectors []PIIDetector `yaml:"pii_detectors" json:"pii_detectors"`
+	// Syslog writes the logs to the local or remote syslog as well, disabled if nil
+	Syslog *SyslogConfig `yaml:"syslog" json:"syslog"`
+	// LevelPolicies the policies asked before each log of the level, e.g. rate limit of ErrorLevel,
+	// for the default logger and the entries derived from it
+	LevelPolicies map[Level]Policy `yaml:"-" json:"-"`
+	// LevelFiles the filenames inside the directory the logs of each level are written to when filelogging is enabled,
+	// e.g. {WarnLevel: "warn.log"} separates warn from error, the levels of the same filename share one file.
+	// the levels not found are written to the default files, debug.log, info.log or error.log
+	LevelFiles map[Level]string `yaml:"level_files" json:"level_files"`
+	// DevMode writes the console logs in the colored and compact layout for local development,
+	// the files and the other outputs keep the machine-friendly layout
+	DevMode bool `yaml:"dev_mode" json:"dev_mode"`
+	// ExtraCores the cores the logs are written to as well, e.g. OTLP logs exporter or Sentry core
+	ExtraCores []zapcore.Core `yaml:"-" json:"-" log:"omit"`
+	// CoreWrapper wraps the core of each output after ExtraCores are added, kept if nil
+	CoreWrapper func(zapcore.Core) zapcore.Core `yaml:"-" json:"-" log:"omit"`
 }
 
 // Configure configures the default logger
@@ -70,7 +109,7 @@ var defaultLogger = newEntry(defaultConfig, os.Stdout, os.Stderr, os.Stdout, tru
 
 // Debug Log a message at the debug defaultLevel
 func Debug(msg string) {
-	if !Enabled(DebugLevel) {
+	if !Enabled(DebugLevel) || !defaultLogger.allow(DebugLevel) {
 		return
 	}
 	msg = withTrace(msg)
@@ -78,16 +117,16 @@ func Debug(msg string) {
 }
 
 func Debugf(format string, args ...any) {
-	if !Enabled(DebugLevel) {
+	if !Enabled(DebugLevel) || !defaultLogger.allow(DebugLevel) {
 		return
 	}
-	msg := withTrace(fmt.Sprintf(format, args...))
+	msg := withTracef(format, args...)
 	defaultLogger.debugLogger.Debug(msg)
 }
 
 // DebugWith Log a message with fields at the debug defaultLevel
 func DebugWith(msg string, fields Fields) {
-	if !Enabled(DebugLevel) {
+	if !Enabled(DebugLevel) || !defaultLogger.allow(DebugLevel) {
 		return
 	}
 	msg = withTrace(msg)
@@ -100,7 +139,7 @@ func DebugWith(msg string, fields Fields) {
 
 // Info Log a message at the info defaultLevel
 func Info(msg string) {
-	if !Enabled(InfoLevel) {
+	if !Enabled(InfoLevel) || !defaultLogger.allow(InfoLevel) {
 		return
 	}
 	msg = withTrace(msg)
@@ -108,16 +147,16 @@ func Info(msg string) {
 }
 
 func Infof(format string, args ...any) {
-	if !Enabled(InfoLevel) {
+	if !Enabled(InfoLevel) || !defaultLogger.allow(InfoLevel) {
 		return
 	}
-	msg := withTrace(fmt.Sprintf(format, args...))
+	msg := withTracef(format, args...)
 	defaultLogger.infoLogger.Info(msg)
 }
 
 // InfoWith Log a message with fields at the info defaultLevel
 func InfoWith(msg string, fields Fields) {
-	if !Enabled(InfoLevel) {
+	if !Enabled(InfoLevel) || !defaultLogger.allow(InfoLevel) {
 		return
 	}
 	msg = withTrace(msg)
@@ -130,7 +169,7 @@ func InfoWith(msg string, fields Fields) {
 
 // Warn Log a message at the warn defaultLevel
 func Warn(msg string) {
-	if !Enabled(WarnLevel) {
+	if !Enabled(WarnLevel) || !defaultLogger.allow(WarnLevel) {
 		return
 	}
 	msg = withTrace(msg)
@@ -138,16 +177,16 @@ func Warn(msg string) {
 }
 
 func Warnf(format string, args ...any) {
-	if !Enabled(WarnLevel) {
+	if !Enabled(WarnLevel) || !defaultLogger.allow(WarnLevel) {
 		return
 	}
-	msg := withTrace(fmt.Sprintf(format, args...))
+	msg := withTracef(format, args...)
 	defaultLogger.errLogger.Warn(msg)
 }
 
 // WarnWith Log a message with fields at the warn defaultLevel
 func WarnWith(msg string, fields Fields) {
-	if !Enabled(WarnLevel) {
+	if !Enabled(WarnLevel) || !defaultLogger.allow(WarnLevel) {
 		return
 	}
 	msg = withTrace(msg)
@@ -160,7 +199,7 @@ func WarnWith(msg string, fields Fields) {
 
 // Error Log a message at the error defaultLevel
 func Error(msg string) {
-	if !Enabled(ErrorLevel) {
+	if !Enabled(ErrorLevel) || !defaultLogger.allow(ErrorLevel) {
 		return
 	}
 	msg = withTrace(msg)
@@ -168,16 +207,16 @@ func Error(msg string) {
 }
 
 func Errorf(format string, args ...any) {
-	if !Enabled(ErrorLevel) {
+	if !Enabled(ErrorLevel) || !defaultLogger.allow(ErrorLevel) {
 		return
 	}
-	msg := withTrace(fmt.Sprintf(format, args...))
+	msg := withTracef(format, args...)
 	defaultLogger.errLogger.Error(msg)
 }
 
 // ErrorWith Log a message with fields at the error defaultLevel
 func ErrorWith(msg string, fields Fields) {
-	if !Enabled(ErrorLevel) {
+	if !Enabled(ErrorLevel) || !defaultLogger.allow(ErrorLevel) {
 		return
 	}
 	msg = withTrace(msg)
@@ -188,6 +227,69 @@ func ErrorWith(msg string, fields Fields) {
 	}
 }
 
+// Log Log a message at level
+func Log(level Level, msg string) {
+	level = clampLevel(level)
+	if !Enabled(level) || !defaultLogger.allow(level) {
+		return
+	}
+	defaultLogger.loggerOf(level).Log(zapcore.Level(level), withTrace(msg))
+}
+
+func Logf(level Level, format string, args ...any) {
+	level = clampLevel(level)
+	if !Enabled(level) || !defaultLogger.allow(level) {
+		return
+	}
+	defaultLogger.loggerOf(level).Log(zapcore.Level(level), withTracef(format, args...))
+}
+
+// LogWith Log a message with fields at level
+func LogWith(level Level, msg string, fields Fields) {
+	level = clampLevel(level)
+	if !Enabled(level) || !defaultLogger.allow(level) {
+		return
+	}
+	defaultLogger.loggerOf(level).Log(zapcore.Level(level), withTrace(msg), toZapFields(fields)...)
+}
+
+// DebugKV Log a message with the key/value pairs at the debug defaultLevel
+func DebugKV(msg string, kvs ...any) {
+	if !Enabled(DebugLevel) || !defaultLogger.allow(DebugLevel) {
+		return
+	}
+	defaultLogger.debugLogger.Debug(withTrace(msg), toZapFields(kvFields(kvs))...)
+}
+
+// InfoKV Log a message with the key/value pairs at the info defaultLevel
+func InfoKV(msg string, kvs ...any) {
+	if !Enabled(InfoLevel) || !defaultLogger.allow(InfoLevel) {
+		return
+	}
+	defaultLogger.infoLogger.Info(withTrace(msg), toZapFields(kvFields(kvs))...)
+}
+
+// WarnKV Log a message with the key/value pairs at the warn defaultLevel
+func WarnKV(msg string, kvs ...any) {
+	if !Enabled(WarnLevel) || !defaultLogger.allow(WarnLevel) {
+		return
+	}
+	defaultLogger.errLogger.Warn(withTrace(msg), toZapFields(kvFields(kvs))...)
+}
+
+// ErrorKV Log a message with the key/value pairs at the error defaultLevel
+func ErrorKV(msg string, kvs ...any) {
+	if !Enabled(ErrorLevel) || !defaultLogger.allow(ErrorLevel) {
+		return
+	}
+	defaultLogger.errLogger.Error(withTrace(msg), toZapFields(kvFields(kvs))...)
+}
+
+// WithKV binds the key/value pairs to a log message, e.g. WithKV("k1", v1, "k2", v2)
+func WithKV(kvs ...any) Entry {
+	return WithFields(kvFields(kvs))
+}
+
 // WithFields binds a set of fields to a log message
 func WithFields(fields Fields) Entry {
 	return newLogEntry(defaultLogger, fields)
@@ -205,7 +307,22 @@ func With(data any) Entry {
 
 // WithError binds an error to a log message
 func WithError(err error) Entry {
-	return WithField(defaultErrFieldName, err)
+	return WithFields(errorFields(err))
+}
+
+// WithGroup nests the fields added after in the group name
+func WithGroup(name string) Entry {
+	return defaultLogger.WithGroup(name)
+}
+
+// WithLevel logs at level instead of the global level
+func WithLevel(level Level) Entry {
+	return defaultLogger.WithLevel(level)
+}
+
+// WithPolicy binds a policy asked before each log
+func WithPolicy(policy Policy) Entry {
+	return defaultLogger.WithPolicy(policy)
 }
 
 // WithTracing create copy of LogEntry with tracing.Span
@@ -214,22 +331,11 @@ func WithTracing(requestId string) Entry {
 }
 
 func withTrace(msg string) string {
-	if defaultLogger == nil {
-		return strings.Join(append([]string{
-			defaultTraceOccupy,
-			msg,
-		}), defaultSeparator)
-	}
-	if defaultLogger.requestId == "" {
-		return strings.Join(append([]string{
-			defaultTraceOccupy,
-			msg,
-		}), defaultSeparator)
-	}
-	return strings.Join(append([]string{
-		defaultLogger.requestId,
-		msg,
-	}), defaultSeparator)
+	return defaultLogger.withTrace(msg)
+}
+
+func withTracef(format string, args ...any) string {
+	return defaultLogger.withTracef(format, args...)
 }
 
 // Configure sets up the defaultLogger
@@ -239,17 +345,21 @@ func Configure(config Config) {
 	var debugWriters []zapcore.WriteSyncer
 
 	if config.FileLoggingEnabled {
-		infoLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, InfoLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
-		errLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, ErrorLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
-		debugLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, DebugLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
-		infoWriters = append(infoWriters, infoLog)
-		errWriters = append(errWriters, errLog)
-		debugWriters = append(debugWriters, debugLog)
+		// the files of LevelFiles are routed by newLevelFilesCore
+		if len(config.LevelFiles) == 0 {
+			infoLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, InfoLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
+			errLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, ErrorLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
+			debugLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, DebugLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
+			infoWriters = append(infoWriters, infoLog)
+			errWriters = append(errWriters, errLog)
+			debugWriters = append(debugWriters, debugLog)
+		}
 	} else {
 		config.ConsoleLoggingEnabled = true
 	}
 
-	if config.ConsoleLoggingEnabled {
+	// the console of DevMode is written by newDevConsoleCore
+	if config.ConsoleLoggingEnabled && !config.DevMode {
 		if config.ConsoleInfoStream != nil {
 			infoWriters = append(infoWriters, config.ConsoleInfoStream)
 		} else {
@@ -267,13 +377,50 @@ func Configure(config Config) {
 		}
 	}
 
+	setLogEncoding(config.Encoder)
+	SetRecentSize(config.RecentSize)
+	SetExpandErrors(config.ExpandErrors)
+	if err := SetRedactPatterns(config.RedactPatterns...); err != nil {
+		syslog.Println("[logger] set redact patterns error: ", err)
+	}
+	if err := SetPIIDetectors(config.PIIDetectors...); err != nil {
+		syslog.Println("[logger] set pii detectors error: ", err)
+	}
+
+	var bufs []*zapcore.BufferedWriteSyncer
 	defaultLogger = newEntry(
 		config,
-		zapcore.NewMultiWriteSyncer(infoWriters...),
-		zapcore.NewMultiWriteSyncer(errWriters...),
-		zapcore.NewMultiWriteSyncer(debugWriters...),
+		newBuffered(zapcore.NewMultiWriteSyncer(infoWriters...), config.BufferSize, config.FlushInterval, &bufs),
+		newBuffered(zapcore.NewMultiWriteSyncer(errWriters...), config.BufferSize, config.FlushInterval, &bufs),
+		newBuffered(zapcore.NewMultiWriteSyncer(debugWriters...), config.BufferSize, config.FlushInterval, &bufs),
 		true,
 	)
+	if config.FileLoggingEnabled && len(config.LevelFiles) > 0 {
+		core := newLevelFilesCore(config, func(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+			return newBuffered(ws, config.BufferSize, config.FlushInterval, &bufs)
+		})
+		defaultLogger = withCores(defaultLogger, []zapcore.Core{core}, nil)
+	}
+	if config.ConsoleLoggingEnabled && config.DevMode {
+		core := newDevConsoleCore(config, func(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+			return newBuffered(ws, config.BufferSize, config.FlushInterval, &bufs)
+		})
+		defaultLogger = withCores(defaultLogger, []zapcore.Core{core}, nil)
+	}
+	defaultLogger.levelPolicies = config.LevelPolicies
+	stopBuffers(swapBuffers(&logBuffers, bufs))
+	closeSyslog(swapSyslog(nil))
+	if config.Syslog != nil {
+		sink, err := dialSyslog(config.Syslog)
+		if err != nil {
+			syslog.Println("[logger] dial syslog error: ", err)
+		} else {
+			defaultLogger = withSyslog(defaultLogger, sink)
+			swapSyslog(sink)
+		}
+	}
+	defaultLogger = withCores(defaultLogger, config.ExtraCores, config.CoreWrapper)
+	configGen.Add(1)
 
 	declareLogger(config, InfoWith)
 	declareLogger(config, ErrorWith)
@@ -288,13 +435,16 @@ func NewEntry(config Config) Entry {
 	var debugWriters []zapcore.WriteSyncer
 
 	if config.FileLoggingEnabled {
-		infoLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, InfoLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
-		errLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, ErrorLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
-		debugLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, DebugLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
-		infoWriters = append(infoWriters, infoLog)
-		errWriters = append(errWriters, errLog)
-		debugWriters = append(debugWriters, debugLog)
-	} else {
+		// the files of LevelFiles are routed by newLevelFilesCore
+		if len(config.LevelFiles) == 0 {
+			infoLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, InfoLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
+			errLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, ErrorLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
+			debugLog := newRollingFile(config.Directory, getNameByLogLevel(config.Filename, DebugLevel), config.MaxSize, config.MaxAge, config.MaxBackups)
+			infoWriters = append(infoWriters, infoLog)
+			errWriters = append(errWriters, errLog)
+			debugWriters = append(debugWriters, debugLog)
+		}
+	} else if !config.DevMode {
 		config.ConsoleLoggingEnabled = true
 		infoWriters = append(infoWriters, os.Stdout)
 		errWriters = append(errWriters, os.Stderr)
@@ -307,6 +457,13 @@ func NewEntry(config Config) Entry {
 		zapcore.NewMultiWriteSyncer(errWriters...),
 		zapcore.NewMultiWriteSyncer(debugWriters...),
 		true)
+	if config.FileLoggingEnabled && len(config.LevelFiles) > 0 {
+		logEntry = withCores(logEntry, []zapcore.Core{newLevelFilesCore(config, nil)}, nil)
+	}
+	if !config.FileLoggingEnabled && config.DevMode {
+		logEntry = withCores(logEntry, []zapcore.Core{newDevConsoleCore(Config{}, nil)}, nil)
+	}
+	logEntry = withCores(logEntry, config.ExtraCores, config.CoreWrapper)
 
 	declareLogger(config, logEntry.InfoWith)
 	declareLogger(config, logEntry.ErrorWith)
@@ -343,14 +500,17 @@ func newRollingFile(dir, filename string, maxSize, maxAge, maxBackups int) zapco
 		return nil
 	}
 
-	return zapcore.AddSync(&lumberjack.Logger{
+	f := &lumberjack.Logger{
 		Filename:   path.Join(dir, filename),
 		MaxSize:    maxSize,    //megabytes
 		MaxAge:     maxAge,     //days
 		MaxBackups: maxBackups, //files
 		Compress:   true,
 		LocalTime:  true,
-	})
+	}
+	// closed by Reopen
+	registerRollingFile(f)
+	return zapcore.AddSync(f)
 }
 
 func getNameByLogLevel(filename string, level Level) string {
@@ -370,13 +530,14 @@ func getNameByLogLevel(filename string, level Level) string {
 	return name
 }
 
-func newEntry(config Config, infoOutput, errOutput, debugOutput zapcore.WriteSyncer, isDefaultLogger bool) *LogEntry {
-	encCfg := zapcore.EncoderConfig{
-		TimeKey:          "@t",
-		LevelKey:         "lvl",
+// logEncoderConfig returns the encoder config of the logs
+func logEncoderConfig() zapcore.EncoderConfig {
+	cfg := zapcore.EncoderConfig{
+		TimeKey:          defaultTimeKey,
+		LevelKey:         defaultLevelKey,
 		NameKey:          "logger",
 		CallerKey:        "caller",
-		MessageKey:       "msg",
+		MessageKey:       defaultMessageKey,
 		StacktraceKey:    "stacktrace",
 		ConsoleSeparator: defaultSeparator,
 		EncodeDuration:   zapcore.NanosDurationEncoder,
@@ -384,8 +545,14 @@ func newEntry(config Config, infoOutput, errOutput, debugOutput zapcore.WriteSyn
 		EncodeLevel:      zapcore.CapitalLevelEncoder,
 		EncodeTime:       longTimeEncoder,
 	}
+	if c := logEncoding.Load(); c != nil {
+		c.apply(&cfg)
+	}
+	return cfg
+}
 
-	encoder := zapcore.NewConsoleEncoder(encCfg)
+func newEntry(config Config, infoOutput, errOutput, debugOutput zapcore.WriteSyncer, isDefaultLogger bool) *LogEntry {
+	encoder := newLogEncoder(logEncoderConfig())
 
 	// level setting
 	localLoglv := zap.NewAtomicLevelAt(zapcore.Level(config.LoggingLevel))
@@ -394,20 +561,32 @@ func newEntry(config Config, infoOutput, errOutput, debugOutput zapcore.WriteSyn
 		defaultLevel = config.LoggingLevel
 	}
 
+	// the warn and error logs are kept in recent as well
+	errCore := zapcore.NewTee(zapcore.NewCore(encoder, errOutput, localLoglv), &recentCore{})
+
+	var opts []zap.Option
 	if config.CallerEnabled {
-		return getLogEntry(
-			zap.New(zapcore.NewCore(encoder, infoOutput, localLoglv), zap.AddCaller(), zap.AddCallerSkip(config.CallerSkip)),
-			zap.New(zapcore.NewCore(encoder, errOutput, localLoglv), zap.AddCaller(), zap.AddCallerSkip(config.CallerSkip)),
-			zap.New(zapcore.NewCore(encoder, debugOutput, localLoglv), zap.AddCaller(), zap.AddCallerSkip(config.CallerSkip)),
-		)
+		opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(config.CallerSkip))
+	}
+	if config.StacktraceEnabled {
+		opts = append(opts, zap.AddStacktrace(zapcore.Level(stacktraceLevel(config.StacktraceLevel))))
 	}
+
 	return getLogEntry(
-		zap.New(zapcore.NewCore(encoder, infoOutput, localLoglv)),
-		zap.New(zapcore.NewCore(encoder, errOutput, localLoglv)),
-		zap.New(zapcore.NewCore(encoder, debugOutput, localLoglv)),
+		zap.New(zapcore.NewCore(encoder, infoOutput, localLoglv), opts...),
+		zap.New(errCore, opts...),
+		zap.New(zapcore.NewCore(encoder, debugOutput, localLoglv), opts...),
 	)
 }
 
+// stacktraceLevel returns the level the stacktrace is attached at or above, ErrorLevel if lower than WarnLevel
+func stacktraceLevel(l Level) Level {
+	if l < WarnLevel || !l.validate() {
+		return ErrorLevel
+	}
+	return l
+}
+
 // FromContext get Entry from context, if not found, return default logger
 func FromContext(ctx context.Context) Entry {
 	data := ctx.Value(logCtxKey)
@@ -439,3 +618,26 @@ func CopyToContext(srcCtx, dstCtx context.Context) context.Context {
 	dstCtx = WithLogger(dstCtx, FromContext(srcCtx))
 	return dstCtx
 }
+
+// Sync flushes the buffered logs of the default logger and the traffic logger, call it before exit.
+// the errors are ignored, syncing the console fails on some platforms
+func Sync() {
+	// the traffic logs are written in background
+	waitTraffic(pendingTrafficWait)
+
+	loggers := []*zap.Logger{
+		defaultLogger.infoLogger,
+		defaultLogger.errLogger,
+		defaultLogger.debugLogger,
+		defaultTrafficLogger.dataLogger,
+		defaultAuditLogger,
+	}
+	for _, r := range defaultTrafficLogger.routes {
+		loggers = append(loggers, r.logger)
+	}
+	for _, l := range loggers {
+		if l != nil {
+			_ = l.Sync()
+		}
+	}
+}
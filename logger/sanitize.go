@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// sanitizeEncoder escapes the CR/LF and the control characters of the messages,
+// so that the user input can't forge the log lines. the fields are escaped by the json of the console layout
+type sanitizeEncoder struct {
+	zapcore.Encoder
+}
+
+// newLogEncoder returns the encoder of the layout of the EncoderConfig of Configure with cfg,
+// sanitized unless disabled
+func newLogEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	var c EncoderConfig
+	if p := logEncoding.Load(); p != nil {
+		c = *p
+	}
+	enc := c.newEncoder(cfg)
+	if c.DisableSanitize {
+		return enc
+	}
+	return &sanitizeEncoder{Encoder: enc}
+}
+
+func (e *sanitizeEncoder) Clone() zapcore.Encoder {
+	return &sanitizeEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *sanitizeEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	ent.Message = sanitize(ent.Message)
+	return e.Encoder.EncodeEntry(ent, fields)
+}
+
+// sanitize escapes the control characters of s, e.g. \n, \r and \u001b, the tabs are kept as the separator
+func sanitize(s string) string {
+	i := strings.IndexFunc(s, isControl)
+	if i < 0 {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s) + 8)
+	sb.WriteString(s[:i])
+	for _, r := range s[i:] {
+		if !isControl(r) {
+			sb.WriteRune(r)
+			continue
+		}
+		switch r {
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			sb.WriteString(`\u00`)
+			sb.WriteByte(hexDigits[r>>4])
+			sb.WriteByte(hexDigits[r&0xf])
+		}
+	}
+	return sb.String()
+}
+
+func isControl(r rune) bool {
+	return (r < 0x20 && r != '\t') || r == 0x7f
+}
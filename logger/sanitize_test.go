@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_sanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "when no control characters then as is", s: "hello|world\tok", want: "hello|world\tok"},
+		{name: "when CR/LF then escaped", s: "a\r\nINFO|forged", want: `a\r\nINFO|forged`},
+		{name: "when other control characters then escaped as unicode", s: "a\x1b[31m\x7f", want: `a\u001b[31m\u007f`},
+		{name: "when multi-byte characters then kept", s: "héllo\n世界", want: `héllo\n世界`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitize(tt.s); got != tt.want {
+				t.Errorf("sanitize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeEncoder(t *testing.T) {
+	logTo := func(t *testing.T, encoder EncoderConfig) string {
+		out, err := os.Create(filepath.Join(t.TempDir(), "info.log"))
+		if err != nil {
+			t.Fatalf("create log file error = %v", err)
+		}
+		defer out.Close()
+
+		Configure(Config{
+			LoggingLevel:          InfoLevel,
+			ConsoleLoggingEnabled: true,
+			ConsoleInfoStream:     out,
+			ConsoleErrorStream:    out,
+			ConsoleDebugStream:    out,
+			Encoder:               encoder,
+		})
+		defer Configure(Config{LoggingLevel: InfoLevel})
+
+		Infof("user=%s", "bob\nINFO|forged")
+		Sync()
+
+		b, _ := os.ReadFile(out.Name())
+		return string(b)
+	}
+
+	t.Run("when sanitized by default then the message stays in one line", func(t *testing.T) {
+		got := logTo(t, EncoderConfig{})
+		if !strings.Contains(got, `user=bob\nINFO|forged`) || strings.Contains(got, "\nINFO|forged") {
+			t.Errorf("log = %q, want the line feed escaped", got)
+		}
+	})
+
+	t.Run("when sanitize disabled then the message is written as is", func(t *testing.T) {
+		got := logTo(t, EncoderConfig{DisableSanitize: true})
+		if !strings.Contains(got, "user=bob\nINFO|forged") {
+			t.Errorf("log = %q, want the line feed as is", got)
+		}
+	})
+}
+
+func TestTrafficSanitize(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	defaultTrafficLogger.Data(&Traffic{Typ: TrafficTypResp, Cmd: "get_user", Msg: "bad\r\nDATA|forged"})
+	Sync()
+
+	b, _ := os.ReadFile(out.Name())
+	if !strings.Contains(string(b), `bad\r\nDATA|forged`) {
+		t.Errorf("traffic = %q, want the CR/LF escaped", b)
+	}
+}
@@ -0,0 +1,126 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogHandler routes the slog records to an Entry, so the libraries using slog
+// share the rotation, trimming and tracing of the entry
+type slogHandler struct {
+	entry  Entry
+	fields Fields // attrs added by WithAttrs, keys prefixed by their groups
+	prefix string // groups opened by WithGroup, joined by '.'
+}
+
+// NewSlogHandler returns a slog.Handler writing to entry, e.g. slog.New(logger.NewSlogHandler(logger.FromContext(ctx))).
+// attrs map to fields and the keys of grouped attrs are prefixed by the group names, e.g. http.method.
+// the requestId of the Entry carried by the ctx of the record is added to the log
+func NewSlogHandler(entry Entry) slog.Handler {
+	if entry == nil {
+		entry = &empty{}
+	}
+	return &slogHandler{
+		entry: entry,
+	}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.entry.Enabled(fromSlogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		addSlogAttr(fields, h.prefix, attr)
+		return true
+	})
+
+	withContextTracing(ctx, h.entry).LogWith(fromSlogLevel(r.Level), r.Message, fields)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := make(Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, attr := range attrs {
+		addSlogAttr(fields, h.prefix, attr)
+	}
+	return &slogHandler{
+		entry:  h.entry,
+		fields: fields,
+		prefix: h.prefix,
+	}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{
+		entry:  h.entry,
+		fields: h.fields,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+// addSlogAttr adds attr to fields, the groups are flattened into prefixed keys
+func addSlogAttr(fields Fields, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() != slog.KindGroup {
+		if attr.Key == "" {
+			return
+		}
+		fields[prefix+attr.Key] = attr.Value.Any()
+		return
+	}
+
+	// a group without key is inlined
+	if attr.Key != "" {
+		prefix += attr.Key + "."
+	}
+	for _, ga := range attr.Value.Group() {
+		addSlogAttr(fields, prefix, ga)
+	}
+}
+
+// withContextTracing returns entry with the requestId of the Entry carried by ctx, if any
+func withContextTracing(ctx context.Context, entry Entry) Entry {
+	if ctx == nil {
+		return entry
+	}
+	ctxEntry, ok := ctx.Value(logCtxKey).(*LogEntry)
+	if !ok || ctxEntry == nil || ctxEntry.requestId == "" {
+		return entry
+	}
+	return entry.WithTracing(ctxEntry.requestId)
+}
+
+// fromSlogLevel maps the slog level to the nearest Level not above it
+func fromSlogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
@@ -0,0 +1,87 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+type slogRecord struct {
+	level     Level
+	msg       string
+	fields    Fields
+	requestId string
+}
+
+// recordEntry records the logs written through it
+type recordEntry struct {
+	empty
+	requestId string
+	records   *[]slogRecord
+}
+
+func (e *recordEntry) record(level Level, msg string, fields Fields) {
+	*e.records = append(*e.records, slogRecord{level: level, msg: msg, fields: fields, requestId: e.requestId})
+}
+
+func (e *recordEntry) DebugWith(msg string, fields Fields) { e.record(DebugLevel, msg, fields) }
+func (e *recordEntry) InfoWith(msg string, fields Fields)  { e.record(InfoLevel, msg, fields) }
+func (e *recordEntry) WarnWith(msg string, fields Fields)  { e.record(WarnLevel, msg, fields) }
+func (e *recordEntry) ErrorWith(msg string, fields Fields) { e.record(ErrorLevel, msg, fields) }
+func (e *recordEntry) Enabled(level Level) bool            { return level >= InfoLevel }
+
+func (e *recordEntry) LogWith(level Level, msg string, fields Fields) {
+	logWithAt(e, level, msg, fields)
+}
+
+func (e *recordEntry) WithTracing(requestId string) Entry {
+	return &recordEntry{requestId: requestId, records: e.records}
+}
+
+func TestSlogHandler(t *testing.T) {
+	var records []slogRecord
+	l := slog.New(NewSlogHandler(&recordEntry{records: &records}))
+
+	t.Run("when level disabled then skip", func(t *testing.T) {
+		records = nil
+		l.Debug("debug")
+		if len(records) != 0 {
+			t.Errorf("records = %+v, want none", records)
+		}
+	})
+
+	t.Run("when attrs and groups then map to fields", func(t *testing.T) {
+		records = nil
+		l.With("svc", "orders").WithGroup("http").Warn("slow request",
+			"method", "GET",
+			slog.Group("resp", "code", 200),
+			slog.Group("", "inline", true),
+		)
+
+		want := Fields{
+			"svc":            "orders",
+			"http.method":    "GET",
+			"http.resp.code": int64(200),
+			"http.inline":    true,
+		}
+		if len(records) != 1 || records[0].level != WarnLevel || records[0].msg != "slow request" {
+			t.Fatalf("records = %+v", records)
+		}
+		if !reflect.DeepEqual(records[0].fields, want) {
+			t.Errorf("fields = %v, want %v", records[0].fields, want)
+		}
+	})
+
+	t.Run("when ctx has traced entry then add requestId", func(t *testing.T) {
+		records = nil
+		ctx := WithLogger(context.Background(), &LogEntry{requestId: "req-1"})
+		l.ErrorContext(ctx, "failed")
+
+		if len(records) != 1 || records[0].level != ErrorLevel || records[0].requestId != "req-1" {
+			t.Errorf("records = %+v", records)
+		}
+	})
+}
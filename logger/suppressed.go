@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	suppressedLogMetric  = "log_suppressed"
+	droppedTrafficMetric = "traffic_dropped"
+	suppressedByPolicy   = "policy"       // rejected by the policy of the entry
+	suppressedByLevel    = "level_policy" // rejected by the policy of the level
+	droppedByFullQueue   = "queue_full"   // the traffic queue is full
+	unknownTrafficCmd    = "unknown"
+)
+
+// countSuppressedLog counts the log of level suppressed by reason,
+// labeled cmd of the level, dsCmd of log_suppressed and opt of the reason
+func countSuppressedLog(level Level, reason string) {
+	monitor.NewSingleFlight(level.String()).Count(context.Background(), suppressedLogMetric, 0, reason)
+}
+
+// countDroppedTraffic counts the traffic of cmd dropped by reason,
+// labeled cmd of the traffic cmd, dsCmd of traffic_dropped and opt of the reason
+func countDroppedTraffic(cmd, reason string) {
+	if cmd == "" {
+		cmd = unknownTrafficCmd
+	}
+	monitor.NewSingleFlight(cmd).Count(context.Background(), droppedTrafficMetric, 0, reason)
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// counterValue returns the value of the single flight counter of the labels
+func counterValue(t *testing.T, cmd, dsCmd, opt string) float64 {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather metrics error = %v", err)
+	}
+
+	want := map[string]string{"cmd": cmd, "dsCmd": dsCmd, "opt": opt}
+	for _, mf := range mfs {
+		if mf.GetName() != "trackingo_flight_singleFlightC" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			matched := 0
+			for _, l := range m.GetLabel() {
+				if v, ok := want[l.GetName()]; ok && v == l.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(want) {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestSuppressedCounters(t *testing.T) {
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     devNull(t),
+		ConsoleErrorStream:    devNull(t),
+		ConsoleDebugStream:    devNull(t),
+		LevelPolicies:         map[Level]Policy{WarnLevel: NewRejectAllPolicy()},
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	t.Run("when policy rejects log then count suppressed", func(t *testing.T) {
+		before := counterValue(t, "info", suppressedLogMetric, suppressedByPolicy)
+		WithPolicy(NewRejectAllPolicy()).Info("rejected")
+
+		if got := counterValue(t, "info", suppressedLogMetric, suppressedByPolicy) - before; got != 1 {
+			t.Errorf("suppressed = %v, want 1", got)
+		}
+	})
+
+	t.Run("when level policy rejects log then count suppressed", func(t *testing.T) {
+		before := counterValue(t, "warn", suppressedLogMetric, suppressedByLevel)
+		Warn("rejected")
+
+		if got := counterValue(t, "warn", suppressedLogMetric, suppressedByLevel) - before; got != 1 {
+			t.Errorf("suppressed = %v, want 1", got)
+		}
+	})
+
+	t.Run("when policy rejects traffic then count dropped", func(t *testing.T) {
+		ConfigureTrafficLog(TrafficLogConfig{
+			ConsoleLoggingEnabled: true,
+			ConsoleStream:         devNull(t),
+		})
+		defer ConfigureTrafficLog(TrafficLogConfig{})
+
+		before := counterValue(t, "get_user", droppedTrafficMetric, suppressedByPolicy)
+		defaultTrafficLogger.WithPolicy(NewRejectAllPolicy()).Data(&Traffic{Typ: TrafficTypReq, Cmd: "get_user"})
+
+		if got := counterValue(t, "get_user", droppedTrafficMetric, suppressedByPolicy) - before; got != 1 {
+			t.Errorf("dropped = %v, want 1", got)
+		}
+	})
+}
@@ -0,0 +1,249 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	syslogVersion     = 1
+	syslogDialTimeout = 3 * time.Second
+)
+
+var (
+	// localSyslogAddrs are the unix sockets of the local syslog daemon
+	localSyslogAddrs = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+	syslogFacilities = map[string]int{
+		"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+		"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+		"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+		"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+	}
+
+	syslogLock sync.Mutex
+	syslogSink *syslogWriter // syslog of the default logger
+)
+
+// SyslogConfig for the RFC5424 syslog output
+type SyslogConfig struct {
+	// Network is udp or tcp for the remote syslog, the local syslog if empty
+	Network string `yaml:"network" json:"network"`
+	// Address is the host:port of the remote syslog, ignored for the local syslog
+	Address string `yaml:"address" json:"address"`
+	// Facility is the facility name, e.g. daemon or local0, user if empty
+	Facility string `yaml:"facility" json:"facility"`
+	// Tag is the APP-NAME of the messages, the name of the executable if empty
+	Tag string `yaml:"tag" json:"tag"`
+}
+
+// syslogWriter writes the RFC5424 messages, reconnecting once on write errors
+type syslogWriter struct {
+	lock     sync.Mutex
+	network  string
+	address  string
+	conn     net.Conn
+	facility int
+	tag      string
+	hostname string
+	pid      int
+	closed   bool
+}
+
+// dialSyslog connects the syslog of cfg
+func dialSyslog(cfg *SyslogConfig) (*syslogWriter, error) {
+	facility := syslogFacilities["user"]
+	if cfg.Facility != "" {
+		f, ok := syslogFacilities[strings.ToLower(cfg.Facility)]
+		if !ok {
+			return nil, fmt.Errorf("unknown syslog facility: %s", cfg.Facility)
+		}
+		facility = f
+	}
+
+	tag := cfg.Tag
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	w := &syslogWriter{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		facility: facility,
+		tag:      tag,
+		hostname: hostname,
+		pid:      os.Getpid(),
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *syslogWriter) connect() (err error) {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	if w.network != "" {
+		w.conn, err = net.DialTimeout(w.network, w.address, syslogDialTimeout)
+		return err
+	}
+
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, addr := range localSyslogAddrs {
+			if w.conn, err = net.DialTimeout(network, addr, syslogDialTimeout); err == nil {
+				w.network, w.address = network, addr
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("local syslog not found: %w", err)
+}
+
+// write sends msg at severity, the stream connections frame the messages by octet counting of RFC6587
+func (w *syslogWriter) write(severity int, t time.Time, msg string) error {
+	line := fmt.Sprintf("<%d>%d %s %s %s %d - - %s",
+		w.facility*8+severity, syslogVersion, t.Format(time.RFC3339Nano), w.hostname, w.tag, w.pid,
+		strings.TrimRight(msg, "\n"),
+	)
+	if w.network == "tcp" || w.network == "unix" {
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	if w.conn != nil {
+		if _, err := w.conn.Write([]byte(line)); err == nil {
+			return nil
+		}
+	}
+	if err := w.connect(); err != nil {
+		return err
+	}
+	_, err := w.conn.Write([]byte(line))
+	return err
+}
+
+func (w *syslogWriter) close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// swapSyslog sets sink as the syslog of the default logger and returns the replaced one
+func swapSyslog(sink *syslogWriter) *syslogWriter {
+	syslogLock.Lock()
+	defer syslogLock.Unlock()
+
+	old := syslogSink
+	syslogSink = sink
+	return old
+}
+
+func closeSyslog(sink *syslogWriter) {
+	if sink != nil {
+		_ = sink.close()
+	}
+}
+
+// withSyslog returns le writing to sink as well
+func withSyslog(le *LogEntry, sink *syslogWriter) *LogEntry {
+	encCfg := logEncoderConfig()
+	// the time is in the header of the syslog message
+	encCfg.TimeKey = zapcore.OmitKey
+	core := &syslogCore{
+		LevelEnabler: loglv,
+		enc:          newLogEncoder(encCfg),
+		sink:         sink,
+	}
+	wrap := zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, core)
+	})
+
+	res := le.clone()
+	res.infoLogger = le.infoLogger.WithOptions(wrap)
+	res.errLogger = le.errLogger.WithOptions(wrap)
+	res.debugLogger = le.debugLogger.WithOptions(wrap)
+	return res
+}
+
+// syslogCore is a zapcore.Core writing to the syslog at the severity of the level
+type syslogCore struct {
+	zapcore.LevelEnabler
+	enc  zapcore.Encoder
+	sink *syslogWriter
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &syslogCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          enc,
+		sink:         c.sink,
+	}
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	return c.sink.write(syslogSeverity(ent.Level), ent.Time, buf.String())
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
+
+// syslogSeverity maps the level to the severity of RFC5424
+func syslogSeverity(level zapcore.Level) int {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return 7 // debug
+	case level == zapcore.InfoLevel:
+		return 6 // informational
+	case level == zapcore.WarnLevel:
+		return 4 // warning
+	case level == zapcore.ErrorLevel:
+		return 3 // error
+	default:
+		return 2 // critical
+	}
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen udp error = %v", err)
+	}
+	defer conn.Close()
+
+	Configure(Config{
+		LoggingLevel:          InfoLevel,
+		ConsoleLoggingEnabled: true,
+		ConsoleInfoStream:     devNull(t),
+		ConsoleErrorStream:    devNull(t),
+		ConsoleDebugStream:    devNull(t),
+		Syslog: &SyslogConfig{
+			Network:  "udp",
+			Address:  conn.LocalAddr().String(),
+			Facility: "local0",
+			Tag:      "orders",
+		},
+	})
+	defer Configure(Config{LoggingLevel: InfoLevel})
+
+	read := func() string {
+		buf := make([]byte, 4096)
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("read syslog error = %v", err)
+		}
+		return string(buf[:n])
+	}
+	// the info and error logs of Configure, local0 * 8 + info
+	if msg := read(); !strings.HasPrefix(msg, "<134>1 ") || !strings.Contains(msg, "logging configured") {
+		t.Fatalf("syslog message = %s", msg)
+	}
+	read()
+
+	t.Run("when error then severity err of facility", func(t *testing.T) {
+		WithField("k", "v").Error("query failed")
+
+		msg := read()
+		// local0 * 8 + err
+		if !strings.HasPrefix(msg, "<131>1 ") || !strings.Contains(msg, " orders ") || !strings.Contains(msg, "query failed") {
+			t.Errorf("syslog message = %s", msg)
+		}
+	})
+}
+
+func devNull(t testing.TB) *os.File {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open %s error = %v", os.DevNull, err)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+	return f
+}
+
+func TestSyslogConfig(t *testing.T) {
+	if _, err := dialSyslog(&SyslogConfig{Network: "udp", Address: "127.0.0.1:514", Facility: "unknown"}); err == nil {
+		t.Errorf("dialSyslog() error = nil, want unknown facility")
+	}
+}
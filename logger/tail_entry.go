@@ -0,0 +1,296 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	defaultTailLimit   = 256
+	tailLevelFieldName = "tail_level"
+)
+
+// TailEntry holds the logs below WarnLevel of a request in memory and writes them only if the request fails,
+// the logs at or above WarnLevel are written at once. the entries derived by the With methods share the buffer, e.g.
+//
+//	tail := logger.NewTailEntry(logger.FromContext(ctx))
+//	defer func() { tail.End(err) }()
+//	ctx = logger.WithLogger(ctx, tail)
+type TailEntry struct {
+	entry Entry
+	tail  *tailBuffer
+}
+
+type tailRecord struct {
+	entry  Entry
+	level  Level
+	msg    string
+	fields Fields
+}
+
+type tailBuffer struct {
+	mu      sync.Mutex
+	level   Level
+	limit   int
+	records []tailRecord
+	dropped int
+	failed  bool // an error is logged
+	ended   bool
+}
+
+type TailOpt func(*tailBuffer)
+
+// TailLevel sets the lowest level held, DebugLevel by default
+func TailLevel(level Level) TailOpt {
+	return func(b *tailBuffer) {
+		if level.validate() && level < WarnLevel {
+			b.level = level
+		}
+	}
+}
+
+// TailLimit sets the max number of the logs held, the earliest are dropped if exceeded, 256 by default
+func TailLimit(limit int) TailOpt {
+	return func(b *tailBuffer) {
+		if limit > 0 {
+			b.limit = limit
+		}
+	}
+}
+
+// NewTailEntry returns a TailEntry writing to entry, call End once the request is done
+func NewTailEntry(entry Entry, opts ...TailOpt) *TailEntry {
+	if entry == nil {
+		entry = &empty{}
+	}
+	b := &tailBuffer{
+		level: DebugLevel,
+		limit: defaultTailLimit,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return &TailEntry{
+		entry: entry,
+		tail:  b,
+	}
+}
+
+// End writes the held logs followed by err if err is not nil or an error was logged, drops them otherwise.
+// the held logs below the level of the entry are written at its lowest enabled level with the field tail_level.
+// the logs after End are written at once
+func (t *TailEntry) End(err error) {
+	b := t.tail
+	b.mu.Lock()
+	if b.ended {
+		b.mu.Unlock()
+		return
+	}
+	b.ended = true
+	records, dropped, failed := b.records, b.dropped, b.failed || err != nil
+	b.records = nil
+	b.mu.Unlock()
+
+	if !failed {
+		return
+	}
+
+	if dropped > 0 {
+		t.entry.Warnf("tail dropped %d earliest logs", dropped)
+	}
+	for _, r := range records {
+		r.flush()
+	}
+	if err != nil {
+		t.entry.WithError(err).Error("request failed")
+	}
+}
+
+// hold keeps the log until End, returns false if the log should be written at once
+func (t *TailEntry) hold(level Level, msg string, fields Fields) bool {
+	b := t.tail
+	if level < b.level {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ended {
+		return false
+	}
+	if len(b.records) >= b.limit {
+		b.records = b.records[1:]
+		b.dropped++
+	}
+	b.records = append(b.records, tailRecord{
+		entry:  t.entry,
+		level:  level,
+		msg:    msg,
+		fields: fields,
+	})
+	return true
+}
+
+func (t *TailEntry) markFailed() {
+	t.tail.mu.Lock()
+	t.tail.failed = true
+	t.tail.mu.Unlock()
+}
+
+// flush writes the record at its level, or at the lowest enabled level of the entry
+func (r tailRecord) flush() {
+	level := r.level
+	for !r.entry.Enabled(level) && level < ErrorLevel {
+		level++
+	}
+
+	fields := r.fields
+	if level != r.level {
+		fields = copyFields(r.fields)
+		fields[tailLevelFieldName] = r.level.String()
+	}
+
+	r.entry.LogWith(level, r.msg, fields)
+}
+
+func (t *TailEntry) Debug(msg string) {
+	if !t.hold(DebugLevel, msg, nil) {
+		t.entry.Debug(msg)
+	}
+}
+
+func (t *TailEntry) Debugf(format string, args ...any) {
+	if DebugLevel < t.tail.level {
+		t.entry.Debugf(format, args...)
+		return
+	}
+	t.Debug(fmt.Sprintf(format, args...))
+}
+
+func (t *TailEntry) DebugWith(msg string, fields Fields) {
+	if !t.hold(DebugLevel, msg, fields) {
+		t.entry.DebugWith(msg, fields)
+	}
+}
+
+func (t *TailEntry) Info(msg string) {
+	if !t.hold(InfoLevel, msg, nil) {
+		t.entry.Info(msg)
+	}
+}
+
+func (t *TailEntry) Infof(format string, args ...any) {
+	if InfoLevel < t.tail.level {
+		t.entry.Infof(format, args...)
+		return
+	}
+	t.Info(fmt.Sprintf(format, args...))
+}
+
+func (t *TailEntry) InfoWith(msg string, fields Fields) {
+	if !t.hold(InfoLevel, msg, fields) {
+		t.entry.InfoWith(msg, fields)
+	}
+}
+
+func (t *TailEntry) Warn(msg string) {
+	t.entry.Warn(msg)
+}
+
+func (t *TailEntry) Warnf(format string, args ...any) {
+	t.entry.Warnf(format, args...)
+}
+
+func (t *TailEntry) WarnWith(msg string, fields Fields) {
+	t.entry.WarnWith(msg, fields)
+}
+
+func (t *TailEntry) Error(msg string) {
+	t.markFailed()
+	t.entry.Error(msg)
+}
+
+func (t *TailEntry) Errorf(format string, args ...any) {
+	t.markFailed()
+	t.entry.Errorf(format, args...)
+}
+
+func (t *TailEntry) ErrorWith(msg string, fields Fields) {
+	t.markFailed()
+	t.entry.ErrorWith(msg, fields)
+}
+
+func (t *TailEntry) Log(level Level, msg string) {
+	logAt(t, level, msg)
+}
+
+func (t *TailEntry) Logf(level Level, format string, args ...any) {
+	logfAt(t, level, format, args...)
+}
+
+func (t *TailEntry) LogWith(level Level, msg string, fields Fields) {
+	logWithAt(t, level, msg, fields)
+}
+
+func (t *TailEntry) DebugKV(msg string, kvs ...any) {
+	t.DebugWith(msg, kvFields(kvs))
+}
+
+func (t *TailEntry) InfoKV(msg string, kvs ...any) {
+	t.InfoWith(msg, kvFields(kvs))
+}
+
+func (t *TailEntry) WarnKV(msg string, kvs ...any) {
+	t.WarnWith(msg, kvFields(kvs))
+}
+
+func (t *TailEntry) ErrorKV(msg string, kvs ...any) {
+	t.ErrorWith(msg, kvFields(kvs))
+}
+
+func (t *TailEntry) WithKV(kvs ...any) Entry {
+	return &TailEntry{entry: t.entry.WithKV(kvs...), tail: t.tail}
+}
+
+func (t *TailEntry) WithFields(fields Fields) Entry {
+	return &TailEntry{entry: t.entry.WithFields(fields), tail: t.tail}
+}
+
+func (t *TailEntry) WithField(k string, v any) Entry {
+	return &TailEntry{entry: t.entry.WithField(k, v), tail: t.tail}
+}
+
+func (t *TailEntry) With(data any) Entry {
+	return &TailEntry{entry: t.entry.With(data), tail: t.tail}
+}
+
+func (t *TailEntry) WithError(err error) Entry {
+	return &TailEntry{entry: t.entry.WithError(err), tail: t.tail}
+}
+
+func (t *TailEntry) WithGroup(name string) Entry {
+	return &TailEntry{entry: t.entry.WithGroup(name), tail: t.tail}
+}
+
+func (t *TailEntry) WithLevel(level Level) Entry {
+	return &TailEntry{entry: t.entry.WithLevel(level), tail: t.tail}
+}
+
+func (t *TailEntry) WithTracing(requestId string) Entry {
+	return &TailEntry{entry: t.entry.WithTracing(requestId), tail: t.tail}
+}
+
+func (t *TailEntry) WithPolicy(policy Policy) Entry {
+	return &TailEntry{entry: t.entry.WithPolicy(policy), tail: t.tail}
+}
+
+func (t *TailEntry) WithContext(ctx context.Context) Entry {
+	return withContext(t, ctx)
+}
+
+// Enabled is true for the levels held, the held logs are written if the request fails
+func (t *TailEntry) Enabled(level Level) bool {
+	return level >= t.tail.level || t.entry.Enabled(level)
+}
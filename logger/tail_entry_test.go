@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTailEntry(t *testing.T) {
+	old := GetLevel()
+	SetLevel(InfoLevel)
+	defer SetLevel(old)
+
+	t.Run("when request succeeds then held logs are dropped", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		tail := NewTailEntry(entry)
+		tail.Debug("load user")
+		tail.WithField("order_id", 7).Info("order created")
+		tail.Warn("slow query")
+		tail.End(nil)
+
+		records := logs.Records()
+		if len(records) != 1 || records[0].Message != "slow query" {
+			t.Errorf("Records() = %+v, want the warn only", records)
+		}
+	})
+
+	t.Run("when request fails then held logs are written with the error", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		tail := NewTailEntry(entry.WithTracing("req-1"))
+		tail.Debugf("load user %d", 1)
+		tail.WithField("order_id", 7).Info("order created")
+		tail.End(errors.New("pay failed"))
+
+		records := logs.Records()
+		if len(records) != 3 {
+			t.Fatalf("Records() = %+v, want 3", records)
+		}
+		if r := records[0]; r.Level != InfoLevel || r.Message != "load user 1" || r.Fields[tailLevelFieldName] != "debug" {
+			t.Errorf("Records()[0] = %+v, want debug written at info", r)
+		}
+		if r := records[1]; r.Fields["order_id"] != int64(7) || r.RequestId != "req-1" {
+			t.Errorf("Records()[1] = %+v", r)
+		}
+		if r := records[2]; r.Level != ErrorLevel || r.Fields[defaultErrFieldName] != "pay failed" {
+			t.Errorf("Records()[2] = %+v", r)
+		}
+	})
+
+	t.Run("when error is logged then held logs are written", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		tail := NewTailEntry(entry, TailLimit(1))
+		tail.Info("first")
+		tail.Info("second")
+		tail.Error("boom")
+		tail.End(nil)
+
+		if got := len(logs.FilterMessage("first")); got != 0 {
+			t.Errorf("first = %d, want dropped", got)
+		}
+		if got := len(logs.FilterMessage("second")); got != 1 {
+			t.Errorf("second = %d, want 1", got)
+		}
+		if got := len(logs.FilterMessage("tail dropped 1")); got != 1 {
+			t.Errorf("dropped = %d, want 1", got)
+		}
+	})
+}
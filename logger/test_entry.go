@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestRecord is a log written to the entry of NewTestEntry
+type TestRecord struct {
+	Level     Level
+	RequestId string // empty if not traced
+	Message   string
+	Fields    map[string]any
+}
+
+// TestLogs keeps the logs written to the entry of NewTestEntry in memory
+type TestLogs struct {
+	logs *observer.ObservedLogs
+}
+
+// NewTestEntry returns an Entry keeping the logs in memory for the assertions of unit tests, e.g.
+//
+//	entry, logs := logger.NewTestEntry(t)
+//	svc := NewService(entry)
+//	svc.Do()
+//	if len(logs.FilterField("order_id", 1)) == 0 { t.Errorf(...) }
+//
+// the levels are enabled by the global level, see SetLevel. the logs are printed if t fails
+func NewTestEntry(t testing.TB) (Entry, *TestLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	entry := getLogEntry(zap.New(core), zap.New(core), zap.New(core))
+
+	tl := &TestLogs{logs: logs}
+	t.Cleanup(func() {
+		if !t.Failed() {
+			return
+		}
+		for _, r := range tl.Records() {
+			t.Logf("%s|%s|%s|%v", r.Level, r.RequestId, r.Message, r.Fields)
+		}
+	})
+	return entry, tl
+}
+
+// Records returns all the logs in order
+func (l *TestLogs) Records() []TestRecord {
+	entries := l.logs.All()
+	records := make([]TestRecord, 0, len(entries))
+	for _, e := range entries {
+		requestId, msg, ok := strings.Cut(e.Message, logSeparator())
+		if !ok {
+			requestId, msg = "", e.Message
+		}
+		if requestId == defaultTraceOccupy {
+			requestId = ""
+		}
+		records = append(records, TestRecord{
+			Level:     Level(e.Level),
+			RequestId: requestId,
+			Message:   msg,
+			Fields:    e.ContextMap(),
+		})
+	}
+	return records
+}
+
+// Len returns the number of the logs
+func (l *TestLogs) Len() int {
+	return l.logs.Len()
+}
+
+// Reset removes all the logs
+func (l *TestLogs) Reset() {
+	l.logs.TakeAll()
+}
+
+// FilterLevel returns the logs of level
+func (l *TestLogs) FilterLevel(level Level) []TestRecord {
+	return l.filter(func(r TestRecord) bool {
+		return r.Level == level
+	})
+}
+
+// FilterMessage returns the logs whose message contains substr
+func (l *TestLogs) FilterMessage(substr string) []TestRecord {
+	return l.filter(func(r TestRecord) bool {
+		return strings.Contains(r.Message, substr)
+	})
+}
+
+// FilterField returns the logs with the field of key and val, any val if nil.
+// the val is compared as is, or by its printed form, e.g. 1 matches int64(1)
+func (l *TestLogs) FilterField(key string, val any) []TestRecord {
+	return l.filter(func(r TestRecord) bool {
+		v, ok := r.Fields[key]
+		if !ok {
+			return false
+		}
+		return val == nil || fieldEqual(v, val)
+	})
+}
+
+func (l *TestLogs) filter(match func(r TestRecord) bool) []TestRecord {
+	var records []TestRecord
+	for _, r := range l.Records() {
+		if match(r) {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+func fieldEqual(got, want any) bool {
+	return reflect.DeepEqual(got, want) || fmt.Sprint(got) == fmt.Sprint(want)
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewTestEntry(t *testing.T) {
+	t.Run("when entry logs then records have level, requestId and fields", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		entry.WithTracing("req-1").WithFields(Fields{"order_id": 7}).Infof("order created")
+		entry.WithError(errors.New("boom")).Error("pay failed")
+
+		records := logs.Records()
+		if len(records) != 2 {
+			t.Fatalf("Records() = %+v, want 2", records)
+		}
+		if r := records[0]; r.Level != InfoLevel || r.RequestId != "req-1" || r.Message != "order created" {
+			t.Errorf("Records()[0] = %+v", r)
+		}
+		if got := logs.FilterField("order_id", 7); len(got) != 1 {
+			t.Errorf("FilterField() = %+v, want 1", got)
+		}
+		if got := logs.FilterLevel(ErrorLevel); len(got) != 1 || got[0].Message != "pay failed" {
+			t.Errorf("FilterLevel() = %+v", got)
+		}
+		if got := logs.FilterMessage("created"); len(got) != 1 {
+			t.Errorf("FilterMessage() = %+v, want 1", got)
+		}
+	})
+
+	t.Run("when reset then records are empty", func(t *testing.T) {
+		entry, logs := NewTestEntry(t)
+		entry.Info("hello")
+		logs.Reset()
+		if logs.Len() != 0 {
+			t.Errorf("Len() = %d, want 0", logs.Len())
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+const (
+	maxPooledMsgSize = 64 * 1024 // the larger buffers are not put back to the pool
+)
+
+var msgBufPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// traceMsg returns msg prefixed by the requestId, or by the placeholder if the requestId is empty
+func traceMsg(requestId, msg string) string {
+	if requestId == "" {
+		requestId = defaultTraceOccupy
+	}
+	return requestId + logSeparator() + msg
+}
+
+// traceMsgf formats the msg prefixed by the requestId into a pooled buffer,
+// which saves the copy of fmt.Sprintf
+func traceMsgf(requestId, format string, args ...any) string {
+	if requestId == "" {
+		requestId = defaultTraceOccupy
+	}
+
+	buf := msgBufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.WriteString(requestId)
+	buf.WriteString(logSeparator())
+	_, _ = fmt.Fprintf(buf, format, args...)
+	msg := buf.String()
+
+	if buf.Cap() <= maxPooledMsgSize {
+		msgBufPool.Put(buf)
+	}
+	return msg
+}
@@ -0,0 +1,16 @@
+package logger
+
+import (
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+// TracingFromTraceparent returns the requestId of traceid:parentid built from the W3C traceparent header,
+// e.g. 4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7, for WithTracing and WithTrafficTracing
+// so that the logs are correlated with the upstream traces. ok is false if header is invalid
+func TracingFromTraceparent(header string) (requestId string, ok bool) {
+	tp, err := tracking.ParseTraceparent(header)
+	if err != nil {
+		return "", false
+	}
+	return tp.TraceId + ":" + tp.SpanId, true
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -26,8 +28,11 @@ type Traffic struct {
 
 // TrafficReq is provided by user when logging
 type TrafficReq struct {
-	Cmd string // Cmd: command
-	Req any
+	Cmd    string // Cmd: command
+	Req    any
+	PairId string // PairId: the pair id of the caller to log along with, generated if empty
+
+	StartTime time.Time // StartTime: the start of the call to count the cost from, now if zero
 }
 
 type TrafficResp struct {
@@ -41,6 +46,11 @@ type TrafficRec struct {
 	startTime time.Time
 	pairId    string
 	cmd       string
+
+	// the request held by the entry of min cost or errors only, written in End if the response is kept
+	held       *Traffic
+	heldFields Fields
+	heldBy     *LogTrafficEntry
 }
 
 func newTrafficRec(te TrafficEntry, cmd, pairId string) *TrafficRec {
@@ -63,14 +73,19 @@ func (t *TrafficRec) End(resp *TrafficResp, fields Fields) {
 
 	fields[defaultPairFieldName] = t.pairId
 
-	t.te.DataWith(&Traffic{
+	respTc := &Traffic{
 		Typ:  TrafficTypResp,
 		Cmd:  t.cmd,
 		Code: resp.Code,
 		Msg:  resp.Msg,
 		Cost: time.Since(t.startTime),
 		Resp: resp.Resp,
-	}, fields)
+	}
+	if t.held != nil && t.heldBy.keeps(respTc) {
+		t.heldBy.dataWith(t.held, t.heldFields)
+	}
+
+	t.te.DataWith(respTc, fields)
 
 }
 
@@ -88,6 +103,11 @@ type TrafficEntry interface {
 	// WithPolicy adds policy to traffic dataLogger
 	// disable: true: disable policy, false: enable policy
 	WithPolicy(policy Policy) TrafficEntry
+	// WithMinCost only logs the responses slower than d and the requests of them
+	// 0: log all the traffic
+	WithMinCost(d time.Duration) TrafficEntry
+	// WithErrorsOnly only logs the responses of Code != 0 and the requests of them
+	WithErrorsOnly(errorsOnly bool) TrafficEntry
 
 	Start(req *TrafficReq, fields Fields) *TrafficRec
 }
@@ -120,13 +140,50 @@ func convertToMessage(tb *Traffic, separator string) string {
 
 	var reqTyp = tb.Typ == TrafficTypReq
 
-	return strings.Join(append([]string{
+	return strings.Join([]string{
 		string(tb.Typ),
 		tb.Cmd,
 		ifThen(reqTyp, defaultFieldOccupied, fmt.Sprintf("%s", tb.Cost)).(string),
 		ifThen(reqTyp, defaultFieldOccupied, fmt.Sprintf("%d", tb.Code)).(string),
 		tb.Msg,
-	}), separator)
+	}, separator)
+}
+
+// prettyPayload renders the req and resp of tc as the indented json lines, e.g.
+//
+//	request: {
+//	  "id": 1
+//	}
+//
+// the json strings are indented as well
+func prettyPayload(tc *Traffic, ignores []string) string {
+	var sb strings.Builder
+	for _, p := range []struct {
+		name string
+		val  any
+	}{
+		{defaultReqFieldName, tc.Req},
+		{defaultRespFieldName, tc.Resp},
+	} {
+		if p.val == nil {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if s, ok := p.val.(string); ok && json.Valid([]byte(s)) {
+			_ = json.Indent(&buf, []byte(maskPII(s)), "", "  ")
+		} else if bs, err := json.MarshalIndent(TrimObjectWithOpts(p.val, WithIgnores(ignores...)), "", "  "); err == nil {
+			buf.Write(bs)
+		} else {
+			continue
+		}
+
+		sb.WriteString("\n")
+		sb.WriteString(p.name)
+		sb.WriteString(": ")
+		sb.Write(buf.Bytes())
+	}
+	return sb.String()
 }
 
 type emptyTrafficEntry struct{}
@@ -153,6 +210,14 @@ func (et *emptyTrafficEntry) WithPolicy(policy Policy) TrafficEntry {
 	return et
 }
 
+func (et *emptyTrafficEntry) WithMinCost(d time.Duration) TrafficEntry {
+	return et
+}
+
+func (et *emptyTrafficEntry) WithErrorsOnly(errorsOnly bool) TrafficEntry {
+	return et
+}
+
 func (et *emptyTrafficEntry) Start(req *TrafficReq, fields Fields) *TrafficRec {
 	return nil
 }
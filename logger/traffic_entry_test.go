@@ -1,6 +1,17 @@
 package logger
 
-import "testing"
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tenz-io/trackingo/tracking"
+)
 
 func Test_convertToMessage(t *testing.T) {
 	type args struct {
@@ -41,3 +52,307 @@ func Test_convertToMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestTrafficJSONFormat(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+		JSONFormat:            true,
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	rec := defaultTrafficLogger.WithTracing("req-1").Start(&TrafficReq{Cmd: "get_user", Req: "uid=1"}, Fields{"db": "users"})
+	Sync()
+	rec.End(&TrafficResp{Code: 404, Msg: "not found", Resp: "-"}, nil)
+	Sync()
+
+	f, _ := os.Open(out.Name())
+	defer f.Close()
+	var records []map[string]any
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("unmarshal %s error = %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("records = %v, want req and resp", records)
+	}
+	req, resp := records[0], records[1]
+	if req["typ"] != "req_to" || req["cmd"] != "get_user" || req["req"] != "uid=1" || req["request_id"] != "req-1" || req["db"] != "users" {
+		t.Errorf("req = %v", req)
+	}
+	if resp["typ"] != "resp_from" || resp["code"] != float64(404) || resp["msg"] != "not found" || resp["pair_id"] != req["pair_id"] {
+		t.Errorf("resp = %v", resp)
+	}
+	if _, ok := resp["cost_ms"]; !ok {
+		t.Errorf("resp = %v, want cost_ms", resp)
+	}
+}
+
+func TestTrafficCaller(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+		CallerEnabled:         true,
+		JSONFormat:            true,
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	rec := defaultTrafficLogger.WithFields(Fields{"k": "v"}).Start(&TrafficReq{Cmd: "get_user"}, nil)
+	rec.End(&TrafficResp{}, nil)
+	Sync()
+
+	f, _ := os.Open(out.Name())
+	defer f.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); lines++ {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("unmarshal %s error = %v", scanner.Text(), err)
+		}
+		if caller, _ := record["caller"].(string); !strings.HasPrefix(caller, "logger/traffic_entry_test.go:") {
+			t.Errorf("caller = %v, want the test file", record["caller"])
+		}
+	}
+	if lines != 2 {
+		t.Errorf("lines = %v, want 2", lines)
+	}
+}
+
+func TestTrafficPrettyPayload(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+		PrettyPayload:         true,
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	rec := defaultTrafficLogger.Start(&TrafficReq{Cmd: "get_user", Req: map[string]any{"id": 1}}, Fields{"db": "users"})
+	rec.End(&TrafficResp{Resp: `{"name":"alice"}`}, nil)
+	Sync()
+
+	b, _ := os.ReadFile(out.Name())
+	want := []string{
+		"|req_to|get_user|",
+		"\nrequest: {\n  \"id\": 1\n}",
+		"|resp_from|get_user|",
+		"\nresponse: {\n  \"name\": \"alice\"\n}",
+	}
+	for _, w := range want {
+		if !strings.Contains(string(b), w) {
+			t.Errorf("traffic = %s, want %q", b, w)
+		}
+	}
+	if strings.Contains(string(b), `"request":`) {
+		t.Errorf("traffic = %s, want request out of the fields", b)
+	}
+}
+
+func TestTrafficCmdFiles(t *testing.T) {
+	dir := t.TempDir()
+	ConfigureTrafficLog(TrafficLogConfig{
+		FileLoggingEnabled: true,
+		LoggingDirectory:   dir,
+		Filename:           "data.log",
+		CmdFiles: map[string]string{
+			"db_":       "db.log",
+			"db_cache_": "cache.log",
+			"cache_":    "cache.log",
+		},
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	entry := defaultTrafficLogger.WithFields(Fields{"svc": "orders"})
+	for _, cmd := range []string{"db_query", "db_cache_get", "cache_get", "http_get"} {
+		entry.Data(&Traffic{Typ: TrafficTypReq, Cmd: cmd})
+	}
+	Sync()
+
+	want := map[string][]string{
+		"data.log":  {"|http_get|"},
+		"db.log":    {"|db_query|"},
+		"cache.log": {"|db_cache_get|", "|cache_get|"},
+	}
+	for name, cmds := range want {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s error = %v", name, err)
+		}
+		if got := strings.Count(string(b), "\n"); got != len(cmds) {
+			t.Errorf("%s = %s, want %d lines", name, b, len(cmds))
+		}
+		for _, cmd := range cmds {
+			if !strings.Contains(string(b), cmd) || !strings.Contains(string(b), `"svc": "orders"`) {
+				t.Errorf("%s = %s, want %s with svc", name, b, cmd)
+			}
+		}
+	}
+}
+
+func TestTrafficEncoderConfig(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+		Encoder: EncoderConfig{
+			Separator:  "\t",
+			TimeLayout: "2006-01-02 15:04:05",
+		},
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	defaultTrafficLogger.Data(&Traffic{Typ: TrafficTypReq, Cmd: "get_user"})
+	Sync()
+
+	b, _ := os.ReadFile(out.Name())
+	cols := strings.Split(strings.TrimSpace(string(b)), "\t")
+	if len(cols) < 4 || cols[1] != defaultDataLevelName || cols[3] != string(TrafficTypReq) || cols[4] != "get_user" {
+		t.Fatalf("traffic = %q, want tab separated", b)
+	}
+	if _, err := time.Parse("2006-01-02 15:04:05", cols[0]); err != nil {
+		t.Errorf("time = %s, want the layout: %v", cols[0], err)
+	}
+}
+
+func TestTrafficMinCost(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	te := defaultTrafficLogger.WithMinCost(20 * time.Millisecond)
+
+	t.Run("when the call is fast then neither req nor resp is logged", func(t *testing.T) {
+		rec := te.Start(&TrafficReq{Cmd: "fast_call"}, nil)
+		rec.End(&TrafficResp{}, nil)
+		te.Data(&Traffic{Typ: TrafficTypReq, Cmd: "fast_data"})
+		Sync()
+
+		b, _ := os.ReadFile(out.Name())
+		if strings.Contains(string(b), "fast_") {
+			t.Errorf("traffic = %s, want no fast records", b)
+		}
+	})
+
+	t.Run("when the call is slow then both req and resp are logged", func(t *testing.T) {
+		rec := te.Start(&TrafficReq{Cmd: "slow_call"}, nil)
+		time.Sleep(25 * time.Millisecond)
+		rec.End(&TrafficResp{}, nil)
+		Sync()
+
+		b, _ := os.ReadFile(out.Name())
+		for _, w := range []string{"|req_to|slow_call|", "|resp_from|slow_call|"} {
+			if !strings.Contains(string(b), w) {
+				t.Errorf("traffic = %s, want %q", b, w)
+			}
+		}
+	})
+}
+
+func TestTrafficErrorsOnly(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+		ErrorsOnlyCmds:        []string{"cache_"},
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	read := func() string {
+		Sync()
+		b, _ := os.ReadFile(out.Name())
+		return string(b)
+	}
+
+	t.Run("when the call of errors only succeeds then nothing is logged", func(t *testing.T) {
+		defaultTrafficLogger.WithErrorsOnly(true).Start(&TrafficReq{Cmd: "ok_call"}, nil).End(&TrafficResp{}, nil)
+		defaultTrafficLogger.Start(&TrafficReq{Cmd: "cache_ok"}, nil).End(&TrafficResp{}, nil)
+
+		if got := read(); strings.Contains(got, "ok_call") || strings.Contains(got, "cache_ok") {
+			t.Errorf("traffic = %s, want no succeeded records", got)
+		}
+	})
+
+	t.Run("when the call of errors only fails then both req and resp are logged", func(t *testing.T) {
+		defaultTrafficLogger.WithErrorsOnly(true).Start(&TrafficReq{Cmd: "bad_call"}, nil).End(&TrafficResp{Code: 1}, nil)
+		defaultTrafficLogger.Start(&TrafficReq{Cmd: "cache_bad"}, nil).End(&TrafficResp{Code: 1}, nil)
+
+		got := read()
+		for _, w := range []string{"|req_to|bad_call|", "|resp_from|bad_call|", "|req_to|cache_bad|", "|resp_from|cache_bad|"} {
+			if !strings.Contains(got, w) {
+				t.Errorf("traffic = %s, want %q", got, w)
+			}
+		}
+	})
+
+	t.Run("when the cmd is out of the prefixes then it is logged", func(t *testing.T) {
+		defaultTrafficLogger.Start(&TrafficReq{Cmd: "db_ok"}, nil).End(&TrafficResp{}, nil)
+
+		if got := read(); !strings.Contains(got, "|resp_from|db_ok|") {
+			t.Errorf("traffic = %s, want db_ok logged", got)
+		}
+	})
+}
+
+func TestTrafficPairId(t *testing.T) {
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         devNull(t),
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	t.Run("when the req has the pair id of the caller then it is reused", func(t *testing.T) {
+		ctx := ExtractPairId(context.Background(), tracking.MapCarrier{"x-pair-id": "pair-1"})
+		rec := StartTrafficRec(ctx, &TrafficReq{Cmd: "get_user", PairId: PairIdFromContext(ctx)}, nil)
+		if got := rec.PairId(); got != "pair-1" {
+			t.Errorf("PairId() = %v, want pair-1", got)
+		}
+	})
+
+	t.Run("when the pair id is injected then the callee reads it", func(t *testing.T) {
+		rec := StartTrafficRec(context.Background(), &TrafficReq{Cmd: "get_user"}, nil)
+		carrier := tracking.MapCarrier{}
+		rec.Inject(carrier)
+		if got := PairIdFromContext(ExtractPairId(context.Background(), carrier)); got == "" || got != rec.PairId() {
+			t.Errorf("PairIdFromContext() = %v, want %v", got, rec.PairId())
+		}
+	})
+}
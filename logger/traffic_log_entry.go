@@ -1,9 +1,10 @@
 package logger
 
 import (
-	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"strings"
+	"time"
 )
 
 type LogTrafficEntry struct {
@@ -12,6 +13,16 @@ type LogTrafficEntry struct {
 	requestId  string
 	ignores    []string
 	allow      bool // for policy use, init true
+	json       bool // log each traffic as a json object instead of the separated message
+	pretty     bool // render the req and resp as the indented json after the message
+	caller     bool // log the caller outside of the logger package
+	callerSkip int  // the number of callers skipped beyond the logger package
+	queue      *trafficQueue
+	routes     trafficRoutes // the loggers of the cmd prefixes, dataLogger if not matched
+	minCost    time.Duration // only the responses slower than minCost are logged if > 0
+	errorsOnly bool          // only the responses of Code != 0 are logged
+	errorCmds  []string      // the cmd prefixes logged as errorsOnly
+	sanitize   bool          // escape the control characters of the message
 }
 
 func (le *LogTrafficEntry) Start(req *TrafficReq, fields Fields) *TrafficRec {
@@ -19,18 +30,32 @@ func (le *LogTrafficEntry) Start(req *TrafficReq, fields Fields) *TrafficRec {
 		return nil
 	}
 
-	pairId := strings.ReplaceAll(uuid.NewString(), "-", "")
+	pairId := req.PairId
+	if pairId == "" {
+		pairId = NewPairId()
+	}
 	if fields == nil {
 		fields = make(Fields)
 	}
 	fields[defaultPairFieldName] = pairId
 
-	le.DataWith(&Traffic{
+	reqTc := &Traffic{
 		Typ: TrafficTypReq,
 		Cmd: req.Cmd,
 		Req: req.Req,
-	}, fields)
-	return newTrafficRec(le, req.Cmd, pairId)
+	}
+	rec := newTrafficRec(le, req.Cmd, pairId)
+	if !req.StartTime.IsZero() {
+		rec.startTime = req.StartTime
+	}
+	if le.holds(req.Cmd) {
+		// the request is held until the response is known
+		rec.held, rec.heldFields, rec.heldBy = reqTc, fields, le
+		return rec
+	}
+
+	le.DataWith(reqTc, fields)
+	return rec
 }
 
 // Data Log a request
@@ -40,26 +65,98 @@ func (le *LogTrafficEntry) Data(tc *Traffic) {
 
 // DataWith Log a request with fields
 func (le *LogTrafficEntry) DataWith(tc *Traffic, fields Fields) {
-	if tc == nil || !le.validate() {
+	if tc == nil || le == nil {
+		return
+	}
+
+	if !le.keeps(tc) {
+		// the requests of the kept responses are written by TrafficRec.End along with them
+		recordTraffic(tc, le.requestId)
+		return
+	}
+	le.dataWith(tc, fields)
+}
+
+// dataWith logs tc regardless of the min cost
+func (le *LogTrafficEntry) dataWith(tc *Traffic, fields Fields) {
+	// the failures are kept in recent even if the traffic log is rejected by the policy
+	recordTraffic(tc, le.requestId)
+	if !le.validate() {
+		if le.dataLogger != nil && !le.allow {
+			countDroppedTraffic(tc.Cmd, suppressedByPolicy)
+		}
+		return
+	}
+
+	if le.caller {
+		// the traffic is logged in background, the caller is taken here
+		fields = copyFields(fields)
+		fields[callerFieldName] = callerOutside(le.callerSkip)
+	}
+
+	if le.json {
+		le.dataJSON(tc, fields)
 		return
 	}
 
 	newFields := copyFields(fields)
 
+	if !le.pretty {
+		if tc.Req != nil {
+			newFields[defaultReqFieldName] = tc.Req
+		}
+		if tc.Resp != nil {
+			newFields[defaultRespFieldName] = tc.Resp
+		}
+	}
+
+	// async log
+	le.queue.push(tc.Cmd, func() {
+		countTrafficRecord(tc)
+		msg := le.withMeta(convertToMessage(tc, le.sep))
+		if le.sanitize {
+			msg = sanitize(msg)
+		}
+		if le.pretty {
+			msg += prettyPayload(tc, le.ignores)
+		}
+		le.routes.loggerOf(tc.Cmd, le.dataLogger).Info(msg, toZapFields(newFields, le.ignores...)...)
+	})
+}
+
+// dataJSON logs tc as a json object of typ, request_id, cmd, code, msg, cost_ms, req, resp and pair_id
+// along with fields
+func (le *LogTrafficEntry) dataJSON(tc *Traffic, fields Fields) {
+	newFields := copyFields(fields)
+	if pairId, ok := newFields[defaultPairFieldName]; ok {
+		delete(newFields, defaultPairFieldName)
+		newFields[jsonPairFieldName] = pairId
+	}
 	if tc.Req != nil {
-		newFields[defaultReqFieldName] = tc.Req
+		newFields[jsonReqFieldName] = tc.Req
 	}
 	if tc.Resp != nil {
-		newFields[defaultRespFieldName] = tc.Resp
+		newFields[jsonRespFieldName] = tc.Resp
 	}
 
-	// async log
-	go func() {
-		le.dataLogger.Info(
-			le.withMeta(convertToMessage(tc, le.sep)),
-			toZapFields(newFields, le.ignores...)...,
+	args := []zapcore.Field{
+		zap.String("typ", string(tc.Typ)),
+		zap.String("request_id", le.requestId),
+		zap.String("cmd", tc.Cmd),
+	}
+	if tc.Typ != TrafficTypReq {
+		args = append(args,
+			zap.Int("code", tc.Code),
+			zap.String("msg", tc.Msg),
+			zap.Int64("cost_ms", tc.Cost.Milliseconds()),
 		)
-	}()
+	}
+
+	// async log
+	le.queue.push(tc.Cmd, func() {
+		countTrafficRecord(tc)
+		le.routes.loggerOf(tc.Cmd, le.dataLogger).Info("", append(args, toZapFields(newFields, le.ignores...)...)...)
+	})
 }
 
 // WithFields modifies an existing dataLogger with new fields (cannot be removed)
@@ -74,6 +171,16 @@ func (le *LogTrafficEntry) WithFields(fields Fields) TrafficEntry {
 		requestId:  le.requestId,
 		ignores:    le.ignores,
 		allow:      le.allow,
+		json:       le.json,
+		pretty:     le.pretty,
+		caller:     le.caller,
+		callerSkip: le.callerSkip,
+		queue:      le.queue,
+		routes:     le.routes.with(args),
+		minCost:    le.minCost,
+		errorsOnly: le.errorsOnly,
+		errorCmds:  le.errorCmds,
+		sanitize:   le.sanitize,
 	}
 }
 
@@ -88,6 +195,16 @@ func (le *LogTrafficEntry) WithTracing(requestId string) TrafficEntry {
 		ignores:    le.ignores,
 		requestId:  requestId,
 		allow:      le.allow,
+		json:       le.json,
+		pretty:     le.pretty,
+		caller:     le.caller,
+		callerSkip: le.callerSkip,
+		queue:      le.queue,
+		routes:     le.routes,
+		minCost:    le.minCost,
+		errorsOnly: le.errorsOnly,
+		errorCmds:  le.errorCmds,
+		sanitize:   le.sanitize,
 	}
 }
 
@@ -101,6 +218,16 @@ func (le *LogTrafficEntry) WithIgnores(ignores ...string) TrafficEntry {
 		requestId:  le.requestId,
 		ignores:    ignores,
 		allow:      le.allow,
+		json:       le.json,
+		pretty:     le.pretty,
+		caller:     le.caller,
+		callerSkip: le.callerSkip,
+		queue:      le.queue,
+		routes:     le.routes,
+		minCost:    le.minCost,
+		errorsOnly: le.errorsOnly,
+		errorCmds:  le.errorCmds,
+		sanitize:   le.sanitize,
 	}
 }
 
@@ -117,6 +244,101 @@ func (le *LogTrafficEntry) WithPolicy(policy Policy) TrafficEntry {
 		requestId:  le.requestId,
 		ignores:    le.ignores,
 		allow:      policy.Allow(),
+		json:       le.json,
+		pretty:     le.pretty,
+		caller:     le.caller,
+		callerSkip: le.callerSkip,
+		queue:      le.queue,
+		routes:     le.routes,
+		minCost:    le.minCost,
+		errorsOnly: le.errorsOnly,
+		errorCmds:  le.errorCmds,
+		sanitize:   le.sanitize,
+	}
+}
+
+// holds reports whether the requests of cmd are held until the response is known
+func (le *LogTrafficEntry) holds(cmd string) bool {
+	return le.minCost > 0 || le.errorsOnlyFor(cmd)
+}
+
+// keeps reports whether tc is logged, the responses are kept only if
+// slower than minCost and failed in the errors only mode
+func (le *LogTrafficEntry) keeps(tc *Traffic) bool {
+	if !le.holds(tc.Cmd) {
+		return true
+	}
+	if tc.Typ != TrafficTypResp {
+		return false
+	}
+	if le.minCost > 0 && tc.Cost < le.minCost {
+		return false
+	}
+	if le.errorsOnlyFor(tc.Cmd) && tc.Code == 0 {
+		return false
+	}
+	return true
+}
+
+func (le *LogTrafficEntry) errorsOnlyFor(cmd string) bool {
+	if le.errorsOnly {
+		return true
+	}
+	for _, prefix := range le.errorCmds {
+		if strings.HasPrefix(cmd, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithErrorsOnly only logs the responses of Code != 0 and the requests of them
+func (le *LogTrafficEntry) WithErrorsOnly(errorsOnly bool) TrafficEntry {
+	if !le.validate() {
+		return le
+	}
+
+	return &LogTrafficEntry{
+		dataLogger: le.dataLogger,
+		sep:        le.sep,
+		requestId:  le.requestId,
+		ignores:    le.ignores,
+		allow:      le.allow,
+		json:       le.json,
+		pretty:     le.pretty,
+		caller:     le.caller,
+		callerSkip: le.callerSkip,
+		queue:      le.queue,
+		routes:     le.routes,
+		minCost:    le.minCost,
+		errorsOnly: errorsOnly,
+		errorCmds:  le.errorCmds,
+		sanitize:   le.sanitize,
+	}
+}
+
+// WithMinCost only logs the responses slower than d and the requests of them, 0 to log all
+func (le *LogTrafficEntry) WithMinCost(d time.Duration) TrafficEntry {
+	if !le.validate() {
+		return le
+	}
+
+	return &LogTrafficEntry{
+		dataLogger: le.dataLogger,
+		sep:        le.sep,
+		requestId:  le.requestId,
+		ignores:    le.ignores,
+		allow:      le.allow,
+		json:       le.json,
+		pretty:     le.pretty,
+		caller:     le.caller,
+		callerSkip: le.callerSkip,
+		queue:      le.queue,
+		routes:     le.routes,
+		minCost:    d,
+		errorsOnly: le.errorsOnly,
+		errorCmds:  le.errorCmds,
+		sanitize:   le.sanitize,
 	}
 }
 
@@ -125,7 +347,7 @@ func (le *LogTrafficEntry) withMeta(msg string) string {
 		return msg
 	}
 
-	infos := append([]string{defaultDataLevelName})
+	infos := []string{defaultDataLevelName}
 	if le.requestId == "" {
 		infos = append(infos, defaultTraceOccupy)
 	} else {
@@ -144,6 +366,16 @@ func (le *LogTrafficEntry) clone() *LogTrafficEntry {
 		sep:        le.sep,
 		requestId:  le.requestId,
 		allow:      le.allow,
+		json:       le.json,
+		pretty:     le.pretty,
+		caller:     le.caller,
+		callerSkip: le.callerSkip,
+		queue:      le.queue,
+		routes:     le.routes,
+		minCost:    le.minCost,
+		errorsOnly: le.errorsOnly,
+		errorCmds:  le.errorCmds,
+		sanitize:   le.sanitize,
 	}
 }
 
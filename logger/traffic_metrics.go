@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/tenz-io/trackingo/monitor"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	trafficRecordsMetric    = "traffic_records"
+	trafficBytesMetric      = "traffic_bytes"
+	trafficQueueDepthMetric = "traffic_queue_depth"
+	trafficMetricCmd        = "traffic"
+	consoleOutputName       = "console"
+)
+
+// trafficFlight is the single flight of the metrics of the traffic logger itself
+var trafficFlight = monitor.NewSingleFlight(trafficMetricCmd)
+
+// countTrafficRecord counts the traffic written, labeled cmd of the traffic cmd, dsCmd of traffic_records
+// and opt of the traffic type
+func countTrafficRecord(tc *Traffic) {
+	cmd := tc.Cmd
+	if cmd == "" {
+		cmd = unknownTrafficCmd
+	}
+	monitor.NewSingleFlight(cmd).Count(context.Background(), trafficRecordsMetric, 0, string(tc.Typ))
+}
+
+// setTrafficQueueDepth sets the number of the traffic waiting in the queue,
+// labeled cmd of traffic and dsCmd of traffic_queue_depth
+func setTrafficQueueDepth(depth int) {
+	trafficFlight.Set(context.Background(), trafficQueueDepthMetric, 0, float64(depth), "")
+}
+
+// bytesCounter counts the bytes written to the output of name,
+// labeled cmd of traffic, dsCmd of traffic_bytes and opt of the name
+type bytesCounter struct {
+	zapcore.WriteSyncer
+	name string
+}
+
+// countBytes returns ws counting the bytes written to it
+func countBytes(ws zapcore.WriteSyncer, name string) zapcore.WriteSyncer {
+	return &bytesCounter{WriteSyncer: ws, name: name}
+}
+
+func (w *bytesCounter) Write(p []byte) (int, error) {
+	n, err := w.WriteSyncer.Write(p)
+	if n > 0 {
+		trafficFlight.CountDelta(context.Background(), trafficBytesMetric, 0, n, w.name)
+	}
+	return n, err
+}
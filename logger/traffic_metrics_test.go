@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"testing"
+)
+
+func TestTrafficMetrics(t *testing.T) {
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         devNull(t),
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	records := counterValue(t, "get_order", trafficRecordsMetric, string(TrafficTypReq))
+	bytes := counterValue(t, trafficMetricCmd, trafficBytesMetric, consoleOutputName)
+
+	defaultTrafficLogger.Data(&Traffic{Typ: TrafficTypReq, Cmd: "get_order", Req: "id=1"})
+	Sync()
+
+	if got := counterValue(t, "get_order", trafficRecordsMetric, string(TrafficTypReq)) - records; got != 1 {
+		t.Errorf("records = %v, want 1", got)
+	}
+	if got := counterValue(t, trafficMetricCmd, trafficBytesMetric, consoleOutputName) - bytes; got <= 0 {
+		t.Errorf("bytes = %v, want > 0", got)
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+// HeaderPairId is the header of the pair id of the traffic, so that the req_to and resp_from records
+// of the caller and of the callee can be joined
+const HeaderPairId = "X-Pair-Id"
+
+// NewPairId returns a new pair id, e.g. to send it to the callee before the traffic is logged
+func NewPairId() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// PairId returns the pair id of the req and resp records of t, empty if nil
+func (t *TrafficRec) PairId() string {
+	if t == nil {
+		return ""
+	}
+	return t.pairId
+}
+
+// Inject writes the pair id of t to carrier, e.g. tracking.HeaderCarrier(req.Header)
+func (t *TrafficRec) Inject(carrier tracking.Carrier) {
+	if pairId := t.PairId(); pairId != "" && carrier != nil {
+		carrier.Set(HeaderPairId, pairId)
+	}
+}
+
+// WithPairId returns a copy of ctx with the pair id of the caller
+func WithPairId(ctx context.Context, pairId string) context.Context {
+	if ctx == nil || pairId == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, pairIdCtxKey, pairId)
+}
+
+// PairIdFromContext returns the pair id of ctx, empty if not set
+func PairIdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	pairId, _ := ctx.Value(pairIdCtxKey).(string)
+	return pairId
+}
+
+// ExtractPairId reads the pair id of the caller from carrier into ctx, the callee logs its
+// access traffic with it by TrafficReq.PairId, e.g.
+//
+//	ctx = logger.ExtractPairId(ctx, tracking.HeaderCarrier(r.Header))
+//	rec := logger.StartTrafficRec(ctx, &logger.TrafficReq{Cmd: r.URL.Path, PairId: logger.PairIdFromContext(ctx)}, nil)
+func ExtractPairId(ctx context.Context, carrier tracking.Carrier) context.Context {
+	if carrier == nil {
+		return ctx
+	}
+	return WithPairId(ctx, carrier.Get(HeaderPairId))
+}
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultTrafficQueueSize = 4096
+)
+
+var (
+	// droppedTraffic counts the traffic logs dropped as the queue is full
+	droppedTraffic atomic.Uint64
+)
+
+// trafficQueue writes the traffic logs by a single worker in background, in the order of push
+type trafficQueue struct {
+	lock       sync.RWMutex
+	jobs       chan func()
+	dropOnFull bool
+	closed     bool
+	done       chan struct{}
+}
+
+// newTrafficQueue starts the worker of a queue of size, the traffic is dropped when the queue is full
+// if dropOnFull, else the caller is blocked
+func newTrafficQueue(size int, dropOnFull bool) *trafficQueue {
+	if size <= 0 {
+		size = defaultTrafficQueueSize
+	}
+	q := &trafficQueue{
+		jobs:       make(chan func(), size),
+		dropOnFull: dropOnFull,
+		done:       make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *trafficQueue) run() {
+	defer close(q.done)
+	for job := range q.jobs {
+		job()
+		pendingTraffic.Add(-1)
+		setTrafficQueueDepth(len(q.jobs))
+	}
+}
+
+// push queues job of the traffic of cmd, which is run by the caller if the queue is closed
+func (q *trafficQueue) push(cmd string, job func()) {
+	if q == nil {
+		job()
+		return
+	}
+
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if q.closed {
+		job()
+		return
+	}
+
+	pendingTraffic.Add(1)
+	if !q.dropOnFull {
+		q.jobs <- job
+		setTrafficQueueDepth(len(q.jobs))
+		return
+	}
+	select {
+	case q.jobs <- job:
+		setTrafficQueueDepth(len(q.jobs))
+	default:
+		pendingTraffic.Add(-1)
+		droppedTraffic.Add(1)
+		countDroppedTraffic(cmd, droppedByFullQueue)
+	}
+}
+
+// close drains the queue and stops the worker
+func (q *trafficQueue) close() {
+	if q == nil {
+		return
+	}
+
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.jobs)
+	q.lock.Unlock()
+
+	<-q.done
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrafficQueue(t *testing.T) {
+	t.Run("when queue is full then drop", func(t *testing.T) {
+		q := newTrafficQueue(1, true)
+		started, release := make(chan struct{}), make(chan struct{})
+		var ran []int
+
+		q.push("get_user", func() {
+			close(started)
+			<-release
+			ran = append(ran, 1)
+		})
+		<-started
+		q.push("get_user", func() { ran = append(ran, 2) })
+		before := droppedTraffic.Load()
+		q.push("get_user", func() { ran = append(ran, 3) })
+
+		close(release)
+		q.close()
+
+		if got := droppedTraffic.Load() - before; got != 1 {
+			t.Errorf("dropped = %v, want 1", got)
+		}
+		if fmt.Sprint(ran) != "[1 2]" {
+			t.Errorf("ran = %v, want [1 2]", ran)
+		}
+	})
+
+	t.Run("when closed then run by caller", func(t *testing.T) {
+		q := newTrafficQueue(1, false)
+		q.close()
+
+		ran := false
+		q.push("get_user", func() { ran = true })
+		if !ran {
+			t.Errorf("ran = false, want true")
+		}
+	})
+}
+
+func TestTrafficOrder(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "traffic.log"))
+	if err != nil {
+		t.Fatalf("create log file error = %v", err)
+	}
+	defer out.Close()
+
+	ConfigureTrafficLog(TrafficLogConfig{
+		ConsoleLoggingEnabled: true,
+		ConsoleStream:         out,
+	})
+	defer ConfigureTrafficLog(TrafficLogConfig{})
+
+	const total = 1000
+	for i := 0; i < total; i++ {
+		Data(&Traffic{Typ: TrafficTypReq, Cmd: fmt.Sprintf("cmd_%d", i)})
+	}
+	// reconfiguring drains the queue
+	ConfigureTrafficLog(TrafficLogConfig{})
+
+	f, _ := os.Open(out.Name())
+	defer f.Close()
+	i := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); i++ {
+		if want := fmt.Sprintf("|cmd_%d|", i); !strings.Contains(scanner.Text(), want) {
+			t.Fatalf("line %d = %s, want %s", i, scanner.Text(), want)
+		}
+	}
+	if i != total {
+		t.Errorf("lines = %v, want %v", i, total)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"os"
+	"time"
 )
 
 const (
@@ -13,6 +14,11 @@ const (
 	defaultPairFieldName = "__pair_id"
 	defaultDataLevelName = "DATA"
 	defaultFieldOccupied = "-"
+
+	jsonReqFieldName  = "req"
+	jsonRespFieldName = "resp"
+	jsonPairFieldName = "pair_id"
+	callerFieldName   = "caller"
 )
 
 var (
@@ -29,21 +35,50 @@ type TrafficLogConfig struct {
 
 	// FileLoggingEnabled makes the framework log to a file
 	// the fields below can be skipped if this value is false!
-	FileLoggingEnabled bool
+	FileLoggingEnabled bool `yaml:"file_logging_enabled" json:"file_logging_enabled"`
 	// ConsoleLoggingEnabled makes the framework log to console
-	ConsoleLoggingEnabled bool
+	ConsoleLoggingEnabled bool `yaml:"console_logging_enabled" json:"console_logging_enabled"`
 	// LoggingDirectory to log to to when filelogging is enabled
-	LoggingDirectory string
+	LoggingDirectory string `yaml:"logging_directory" json:"logging_directory" default:"log"`
 	// Filename is the name of the logfile which will be placed inside the directory
-	Filename string
+	Filename string `yaml:"filename" json:"filename" default:"data.log"`
 	// MaxSize the max size in MB of the logfile before it's rolled
-	MaxSize int
+	MaxSize int `yaml:"max_size" json:"max_size" default:"100"`
 	// MaxBackups the max number of rolled files to keep
-	MaxBackups int
+	MaxBackups int `yaml:"max_backups" json:"max_backups" default:"10"`
 	// MaxAge the max age in days to keep a logfile
-	MaxAge int
+	MaxAge int `yaml:"max_age" json:"max_age"`
 	// ConsoleStream
-	ConsoleStream *os.File
+	ConsoleStream *os.File `yaml:"-" json:"-"`
+	// BufferSize the size in bytes of the buffer of the output, the logs are written when it's full or
+	// every FlushInterval in background. unbuffered if 0, call Close before exit if buffered
+	BufferSize int `yaml:"buffer_size" json:"buffer_size"`
+	// FlushInterval the interval of flushing the buffered logs, 1s if 0
+	FlushInterval time.Duration `yaml:"flush_interval" json:"flush_interval"`
+	// CallerEnabled makes the caller, the file:line outside of the logger package, log to the traffic
+	CallerEnabled bool `yaml:"caller_enabled" json:"caller_enabled"`
+	// CallerSkip increases the number of callers skipped by caller, e.g. 1 for the wrappers of the traffic logger
+	CallerSkip int `yaml:"caller_skip" json:"caller_skip"`
+	// QueueSize the size of the queue of the traffic written in background, 4096 if 0
+	QueueSize int `yaml:"queue_size" json:"queue_size" default:"4096"`
+	// DropOnFull drops the traffic when the queue is full instead of blocking the caller,
+	// the drops are counted by the traffic_dropped metric
+	DropOnFull bool `yaml:"drop_on_full" json:"drop_on_full"`
+	// JSONFormat logs each traffic as a single json object of typ, request_id, cmd, code, msg, cost_ms,
+	// req, resp and pair_id instead of the separated message, for the log pipelines
+	JSONFormat bool `yaml:"json_format" json:"json_format"`
+	// CmdFiles routes the traffic of the cmds starting with each prefix to the file of the prefix instead of Filename,
+	// e.g. {"db_": "db.log", "cache_": "cache.log"}, the longest prefix wins. only if FileLoggingEnabled
+	CmdFiles map[string]string `yaml:"cmd_files" json:"cmd_files"`
+	// Encoder overrides the keys, the separator and the time layout of the traffic logs
+	Encoder EncoderConfig `yaml:"encoder" json:"encoder"`
+	// ErrorsOnly only logs the traffic of the calls failed with Code != 0
+	ErrorsOnly bool `yaml:"errors_only" json:"errors_only"`
+	// ErrorsOnlyCmds only logs the failed traffic of the cmds starting with each prefix, e.g. ["cache_"]
+	ErrorsOnlyCmds []string `yaml:"errors_only_cmds" json:"errors_only_cmds"`
+	// PrettyPayload renders the req and resp as the indented json lines after the message for local development,
+	// ignored if JSONFormat
+	PrettyPayload bool `yaml:"pretty_payload" json:"pretty_payload"`
 }
 
 // Data Log a request
@@ -105,41 +140,76 @@ func CopyTrafficToContext(srcCtx context.Context, dstCtx context.Context) contex
 
 // ConfigureTrafficLog sets up traffic logging
 func ConfigureTrafficLog(config TrafficLogConfig) {
-	var writers []zapcore.WriteSyncer
+	var (
+		writers []zapcore.WriteSyncer
+		console zapcore.WriteSyncer
+	)
 
 	if config.FileLoggingEnabled {
 		trafficLog := newRollingFile(config.LoggingDirectory, config.Filename, config.MaxSize, config.MaxAge, config.MaxBackups)
-		writers = append(writers, trafficLog)
+		writers = append(writers, countBytes(trafficLog, config.Filename))
 	} else {
 		config.ConsoleLoggingEnabled = true
 	}
 
 	if config.ConsoleLoggingEnabled {
 		if config.ConsoleStream != nil {
-			writers = append(writers, config.ConsoleStream)
+			console = config.ConsoleStream
 		} else {
-			writers = append(writers, os.Stdout)
+			console = os.Stdout
 		}
+		console = countBytes(console, consoleOutputName)
+		writers = append(writers, console)
+	}
+
+	var bufs []*zapcore.BufferedWriteSyncer
+	buffered := func(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+		return newBuffered(ws, config.BufferSize, config.FlushInterval, &bufs)
 	}
 
-	defaultTrafficLogger = newTrafficLogger(config, zapcore.NewMultiWriteSyncer(writers...))
+	old := defaultTrafficLogger
+	trafficLogger := newTrafficLogger(config, buffered(zapcore.NewMultiWriteSyncer(writers...)))
+	if config.FileLoggingEnabled {
+		trafficLogger.routes = newTrafficRoutes(config, console, buffered)
+	}
+	defaultTrafficLogger = trafficLogger
+	// the queued traffic is written before the old buffers stop
+	old.queue.close()
+	stopBuffers(swapBuffers(&trafficBuffers, bufs))
 }
 
 func newTrafficLogger(config TrafficLogConfig, logOutput zapcore.WriteSyncer) *LogTrafficEntry {
+	trafficEntry := &LogTrafficEntry{
+		dataLogger: zap.New(zapcore.NewCore(trafficEncoder(config), logOutput, zapcore.Level(InfoLevel))),
+		sep:        config.Encoder.separator(),
+		allow:      true, // default allow log print
+		json:       config.JSONFormat,
+		pretty:     config.PrettyPayload && !config.JSONFormat,
+		caller:     config.CallerEnabled,
+		callerSkip: config.CallerSkip,
+		queue:      newTrafficQueue(config.QueueSize, config.DropOnFull),
+		errorsOnly: config.ErrorsOnly,
+		errorCmds:  config.ErrorsOnlyCmds,
+		sanitize:   !config.Encoder.DisableSanitize,
+	}
+
+	return trafficEntry
+}
+
+// trafficEncoder returns the console encoder of the separated message, or the json encoder if JSONFormat
+func trafficEncoder(config TrafficLogConfig) zapcore.Encoder {
 	encCfg := zapcore.EncoderConfig{
-		TimeKey:          "@t",
-		MessageKey:       "msg",
+		TimeKey:          defaultTimeKey,
+		MessageKey:       defaultMessageKey,
 		ConsoleSeparator: defaultSeparator,
 		EncodeTime:       longTimeEncoder,
 		EncodeDuration:   zapcore.NanosDurationEncoder,
 	}
-	encoder := zapcore.NewConsoleEncoder(encCfg)
-
-	trafficEntry := &LogTrafficEntry{
-		dataLogger: zap.New(zapcore.NewCore(encoder, logOutput, zapcore.Level(InfoLevel))),
-		sep:        defaultSeparator,
-		allow:      true, // default allow log print
+	config.Encoder.apply(&encCfg)
+	if config.JSONFormat {
+		// the message is empty, the traffic is in the fields
+		encCfg.MessageKey = zapcore.OmitKey
+		return zapcore.NewJSONEncoder(encCfg)
 	}
-
-	return trafficEntry
+	return config.Encoder.newEncoder(encCfg)
 }
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// trafficRoute writes the traffic of the cmds starting with prefix to its own logger
+type trafficRoute struct {
+	prefix string
+	logger *zap.Logger
+}
+
+// trafficRoutes are sorted by the longest prefix first
+type trafficRoutes []trafficRoute
+
+// newTrafficRoutes returns the routes of CmdFiles, each file is a rolling file in LoggingDirectory
+// written along with the console if enabled. the files are wrapped by wrap if not nil, e.g. buffered
+func newTrafficRoutes(config TrafficLogConfig, console zapcore.WriteSyncer, wrap func(zapcore.WriteSyncer) zapcore.WriteSyncer) trafficRoutes {
+	if len(config.CmdFiles) == 0 {
+		return nil
+	}
+
+	// the prefixes of the same file share one rolling file
+	files := make(map[string]*zap.Logger)
+	routes := make(trafficRoutes, 0, len(config.CmdFiles))
+	for prefix, name := range config.CmdFiles {
+		if prefix == "" || name == "" {
+			continue
+		}
+		l, ok := files[name]
+		if !ok {
+			out := countBytes(newRollingFile(config.LoggingDirectory, name, config.MaxSize, config.MaxAge, config.MaxBackups), name)
+			if console != nil {
+				out = zapcore.NewMultiWriteSyncer(out, console)
+			}
+			if wrap != nil {
+				out = wrap(out)
+			}
+			l = zap.New(zapcore.NewCore(trafficEncoder(config), out, zapcore.Level(InfoLevel)))
+			files[name] = l
+		}
+		routes = append(routes, trafficRoute{prefix: prefix, logger: l})
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if len(routes[i].prefix) != len(routes[j].prefix) {
+			return len(routes[i].prefix) > len(routes[j].prefix)
+		}
+		return routes[i].prefix < routes[j].prefix
+	})
+	return routes
+}
+
+// loggerOf returns the logger of the longest prefix of cmd, or def if not found
+func (rs trafficRoutes) loggerOf(cmd string, def *zap.Logger) *zap.Logger {
+	for _, r := range rs {
+		if strings.HasPrefix(cmd, r.prefix) {
+			return r.logger
+		}
+	}
+	return def
+}
+
+// with returns the routes with the fields bound to the loggers
+func (rs trafficRoutes) with(fields []zapcore.Field) trafficRoutes {
+	if len(rs) == 0 || len(fields) == 0 {
+		return rs
+	}
+	res := make(trafficRoutes, len(rs))
+	for i, r := range rs {
+		res[i] = trafficRoute{prefix: r.prefix, logger: r.logger.With(fields...)}
+	}
+	return res
+}
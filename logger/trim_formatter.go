@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"reflect"
+	"sync"
+)
+
+// TrimFormatter renders the value of a registered type in the logs
+type TrimFormatter func(any) any
+
+// trimFormatters are the formatters of the types, reflect.Type -> TrimFormatter
+var trimFormatters sync.Map
+
+// RegisterTrimFormatter renders the values of typ by formatter in the logs and traffic logs
+// instead of the generic reflection, e.g. protobufs, money types or large blobs.
+// the formatter of typ is replaced, and is removed if formatter is nil
+func RegisterTrimFormatter(typ reflect.Type, formatter TrimFormatter) {
+	if typ == nil {
+		return
+	}
+	if formatter == nil {
+		trimFormatters.Delete(typ)
+		return
+	}
+	trimFormatters.Store(typ, formatter)
+}
+
+// valOfFormattedType returns the value rendered by the formatter registered for the type of v,
+// or for the type v points to
+func valOfFormattedType(v reflect.Value) (val any, ok bool) {
+	if isNonValuableType(v) {
+		return nil, false
+	}
+
+	if f, found := trimFormatters.Load(v.Type()); found {
+		return f.(TrimFormatter)(v.Interface()), true
+	}
+
+	if v.Kind() == reflect.Ptr {
+		elem := v.Elem()
+		if f, found := trimFormatters.Load(elem.Type()); found && elem.CanInterface() {
+			return f.(TrimFormatter)(elem.Interface()), true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,19 @@
+package mongodb
+
+import (
+	"time"
+)
+
+type Config struct {
+	URI            string        `yaml:"uri" json:"uri" required:"true"`
+	Database       string        `yaml:"database" json:"database" required:"true"`
+	MaxPoolSize    uint64        `yaml:"max_pool_size" json:"max_pool_size" default:"100"`
+	MinPoolSize    uint64        `yaml:"min_pool_size" json:"min_pool_size" default:"0"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout" json:"connect_timeout" default:"10s"`
+	EnableTracking bool          `yaml:"enable_tracking" json:"enable_tracking" default:"true"`
+	// MaxArrLen and MaxStrLen trim the documents in traffic logs
+	MaxArrLen int `yaml:"max_arr_len" json:"max_arr_len" default:"3"`
+	MaxStrLen int `yaml:"max_str_len" json:"max_str_len" default:"128"`
+	// Dependency is the declared dependency name, tags the metrics and traffic logs
+	Dependency string `yaml:"dependency" json:"dependency"`
+}
@@ -0,0 +1,278 @@
+// Package mongodb wraps the official mongo driver with metrics and traffic logs.
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	syslog "log"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/dependencies"
+	"github.com/tenz-io/trackingo/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrNotActive = fmt.Errorf("mongodb manager is not active")
+	ErrNotFound  = fmt.Errorf("document not found")
+)
+
+type Manager interface {
+	// GetDB returns the database of Config.Database
+	GetDB(ctx context.Context) (*mongo.Database, error)
+	// Find decodes the documents matching filter of collection to results, a pointer to slice
+	Find(ctx context.Context, collection string, filter any, results any, opts ...*options.FindOptions) error
+	// FindOne decodes the first document matching filter of collection to result, ErrNotFound if none
+	FindOne(ctx context.Context, collection string, filter any, result any, opts ...*options.FindOneOptions) error
+	// Insert inserts docs to collection and returns their ids
+	Insert(ctx context.Context, collection string, docs ...any) (ids []any, err error)
+	// Update updates the documents matching filter of collection and returns the number of modified documents
+	Update(ctx context.Context, collection string, filter any, update any, opts ...*options.UpdateOptions) (modified int64, err error)
+	// Aggregate runs pipeline on collection and decodes the results, a pointer to slice
+	Aggregate(ctx context.Context, collection string, pipeline any, results any, opts ...*options.AggregateOptions) error
+	// Active returns true when connected
+	Active() bool
+	// Close disconnects from the server
+	Close(ctx context.Context) error
+}
+
+type manager struct {
+	cfg        *Config
+	client     *mongo.Client
+	db         *mongo.Database
+	active     bool
+	dependency string // label of the declared dependency of Config.Dependency
+}
+
+// NewManager connects to the server of cfg, the manager is inactive if failed
+func NewManager(
+	cfg *Config,
+) (Manager, error) {
+	m := &manager{
+		cfg:        cfg,
+		dependency: dependencies.Resolve(cfg.Dependency, dependencies.TypeMongo).Label(),
+	}
+
+	if err := m.connect(); err != nil {
+		syslog.Println("[MongoDB] connect error: ", err)
+		return m, nil
+	}
+
+	m.active = true
+	return m, nil
+}
+
+func (m *manager) connect() (err error) {
+	syslog.Println("[manager] connect mongodb...")
+
+	clientOpts := options.Client().
+		ApplyURI(m.cfg.URI).
+		SetMaxPoolSize(m.cfg.MaxPoolSize).
+		SetMinPoolSize(m.cfg.MinPoolSize)
+	if m.cfg.ConnectTimeout > 0 {
+		clientOpts.SetConnectTimeout(m.cfg.ConnectTimeout)
+	}
+	if m.cfg.EnableTracking {
+		clientOpts.SetMonitor(newCommandMonitor(m.dependency))
+	}
+
+	ctx := context.Background()
+	if m.cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.cfg.ConnectTimeout)
+		defer cancel()
+	}
+
+	m.client, err = mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		return fmt.Errorf("connect mongodb error: %w", err)
+	}
+
+	if err = m.client.Ping(ctx, nil); err != nil {
+		return fmt.Errorf("ping mongodb error: %w", err)
+	}
+
+	m.db = m.client.Database(m.cfg.Database)
+	return nil
+}
+
+func (m *manager) GetDB(ctx context.Context) (*mongo.Database, error) {
+	if m == nil {
+		return nil, fmt.Errorf("mongodb manager is nil")
+	}
+
+	if !m.Active() {
+		return nil, ErrNotActive
+	}
+
+	return m.db, nil
+}
+
+func (m *manager) Active() bool {
+	if m == nil {
+		return false
+	}
+	return m.active
+}
+
+func (m *manager) Close(ctx context.Context) error {
+	if !m.Active() {
+		return nil
+	}
+	m.active = false
+	return m.client.Disconnect(ctx)
+}
+
+func (m *manager) Find(ctx context.Context, collection string, filter any, results any, opts ...*options.FindOptions) (err error) {
+	end := m.traffic(ctx, "mongo_find", collection, filter)
+	defer func() {
+		end(err, results, logger.Fields{})
+	}()
+
+	if !m.Active() {
+		return ErrNotActive
+	}
+
+	cursor, err := m.db.Collection(collection).Find(ctx, filter, opts...)
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, results)
+}
+
+func (m *manager) FindOne(ctx context.Context, collection string, filter any, result any, opts ...*options.FindOneOptions) (err error) {
+	end := m.traffic(ctx, "mongo_find_one", collection, filter)
+	defer func() {
+		end(err, result, logger.Fields{})
+	}()
+
+	if !m.Active() {
+		return ErrNotActive
+	}
+
+	return toNotFound(m.db.Collection(collection).FindOne(ctx, filter, opts...).Decode(result))
+}
+
+func (m *manager) Insert(ctx context.Context, collection string, docs ...any) (ids []any, err error) {
+	end := m.traffic(ctx, "mongo_insert", collection, docs)
+	defer func() {
+		end(err, ids, logger.Fields{})
+	}()
+
+	if !m.Active() {
+		return nil, ErrNotActive
+	}
+
+	if len(docs) == 1 {
+		res, err := m.db.Collection(collection).InsertOne(ctx, docs[0])
+		if err != nil {
+			return nil, err
+		}
+		return []any{res.InsertedID}, nil
+	}
+
+	res, err := m.db.Collection(collection).InsertMany(ctx, docs)
+	if err != nil {
+		return nil, err
+	}
+	return res.InsertedIDs, nil
+}
+
+func (m *manager) Update(ctx context.Context, collection string, filter any, update any, opts ...*options.UpdateOptions) (modified int64, err error) {
+	end := m.traffic(ctx, "mongo_update", collection, map[string]any{
+		"filter": filter,
+		"update": update,
+	})
+	defer func() {
+		end(err, nil, logger.Fields{
+			"modified": modified,
+		})
+	}()
+
+	if !m.Active() {
+		return 0, ErrNotActive
+	}
+
+	res, err := m.db.Collection(collection).UpdateMany(ctx, filter, update, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+func (m *manager) Aggregate(ctx context.Context, collection string, pipeline any, results any, opts ...*options.AggregateOptions) (err error) {
+	end := m.traffic(ctx, "mongo_aggregate", collection, pipeline)
+	defer func() {
+		end(err, results, logger.Fields{})
+	}()
+
+	if !m.Active() {
+		return ErrNotActive
+	}
+
+	cursor, err := m.db.Collection(collection).Aggregate(ctx, pipeline, opts...)
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, results)
+}
+
+// toNotFound converts mongo.ErrNoDocuments to ErrNotFound
+func toNotFound(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// traffic starts the traffic record of an operation, the returned func ends it,
+// documents are trimmed by Config.MaxArrLen and Config.MaxStrLen
+func (m *manager) traffic(ctx context.Context, cmd, collection string, req any) func(err error, resp any, fields logger.Fields) {
+	if m == nil || !m.cfg.EnableTracking {
+		return func(err error, resp any, fields logger.Fields) {}
+	}
+
+	trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
+		Cmd: cmd,
+		Req: m.trim(req),
+	}, m.fields(logger.Fields{
+		"collection": collection,
+	}))
+
+	return func(err error, resp any, fields logger.Fields) {
+		fields = m.fields(fields)
+		fields["collection"] = collection
+		trafficRec.End(&logger.TrafficResp{
+			Code: common.ErrorCode(err),
+			Msg:  common.ErrorMsg(err),
+			Resp: m.trim(resp),
+		}, fields)
+	}
+}
+
+// fields adds the dependency to the traffic log fields
+func (m *manager) fields(fields logger.Fields) logger.Fields {
+	if m.dependency != "" {
+		fields["dependency"] = m.dependency
+	}
+	return fields
+}
+
+func (m *manager) trim(doc any) any {
+	if doc == nil {
+		return nil
+	}
+
+	var opts []logger.TrimOption
+	if m.cfg.MaxArrLen > 0 {
+		opts = append(opts, logger.WithArrLimit(m.cfg.MaxArrLen))
+	}
+	if m.cfg.MaxStrLen > 0 {
+		opts = append(opts, logger.WithStrLimit(m.cfg.MaxStrLen))
+	}
+	return logger.TrimObjectWithOpts(doc, opts...)
+}
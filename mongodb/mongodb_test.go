@@ -0,0 +1,72 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func Test_toNotFound(t *testing.T) {
+	t.Run("when no documents then ErrNotFound", func(t *testing.T) {
+		if err := toNotFound(mongo.ErrNoDocuments); !errors.Is(err, ErrNotFound) {
+			t.Errorf("toNotFound() = %v, want ErrNotFound", err)
+		}
+		if err := toNotFound(fmt.Errorf("decode: %w", mongo.ErrNoDocuments)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("toNotFound() = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("when other errors then kept as is", func(t *testing.T) {
+		other := errors.New("connection reset")
+		if err := toNotFound(other); err != other {
+			t.Errorf("toNotFound() = %v, want %v", err, other)
+		}
+		if err := toNotFound(nil); err != nil {
+			t.Errorf("toNotFound() = %v, want nil", err)
+		}
+	})
+}
+
+func Test_manager_trim(t *testing.T) {
+	m := &manager{cfg: &Config{MaxArrLen: 2, MaxStrLen: 5}}
+
+	t.Run("when the document is long then the arrays and strings are trimmed", func(t *testing.T) {
+		got, _ := json.Marshal(m.trim(bson.M{
+			"name": "abcdefghij",
+			"tags": bson.A{"a", "b", "c", "d"},
+		}))
+		if want := `{"name":"abcde...","tags":["a","b"]}`; string(got) != want {
+			t.Errorf("trim() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("when the documents are many then only the first ones are kept", func(t *testing.T) {
+		got, _ := json.Marshal(m.trim([]any{bson.M{"x": 1}, bson.M{"x": 2}, bson.M{"x": 3}}))
+		if want := `[{"x":1},{"x":2}]`; string(got) != want {
+			t.Errorf("trim() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("when nil then nil", func(t *testing.T) {
+		if got := m.trim(nil); got != nil {
+			t.Errorf("trim() = %v, want nil", got)
+		}
+	})
+}
+
+func Test_manager_inactive(t *testing.T) {
+	m := &manager{cfg: &Config{}}
+	ctx := context.Background()
+
+	if err := m.FindOne(ctx, "users", bson.M{}, &bson.M{}); !errors.Is(err, ErrNotActive) {
+		t.Errorf("FindOne() error = %v, want ErrNotActive", err)
+	}
+	if _, err := m.GetDB(ctx); !errors.Is(err, ErrNotActive) {
+		t.Errorf("GetDB() error = %v, want ErrNotActive", err)
+	}
+}
@@ -0,0 +1,77 @@
+package mongodb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/monitor"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// commandMonitor records the latency of every command sent to the server,
+// labeled with the command name as dsCmd and the collection as opt, prefixed with the dependency if any
+type commandMonitor struct {
+	dependency string
+	records    sync.Map // request id -> *commandRecord
+}
+
+type commandRecord struct {
+	rec *monitor.Recorder
+	opt string
+}
+
+func newCommandMonitor(dependency string) *event.CommandMonitor {
+	cm := &commandMonitor{dependency: dependency}
+	return &event.CommandMonitor{
+		Started:   cm.started,
+		Succeeded: cm.succeeded,
+		Failed:    cm.failed,
+	}
+}
+
+func (cm *commandMonitor) started(ctx context.Context, evt *event.CommandStartedEvent) {
+	opt := collectionOf(evt.Command, evt.CommandName)
+	if cm.dependency != "" {
+		opt = cm.dependency + "/" + opt
+	}
+	cm.records.Store(evt.RequestID, &commandRecord{
+		rec: monitor.BeginRecord(ctx, "mongo_"+evt.CommandName),
+		opt: opt,
+	})
+}
+
+func (cm *commandMonitor) succeeded(ctx context.Context, evt *event.CommandSucceededEvent) {
+	cm.end(evt.RequestID, nil)
+}
+
+func (cm *commandMonitor) failed(ctx context.Context, evt *event.CommandFailedEvent) {
+	cm.end(evt.RequestID, common.NewValError(1, errFailure(evt.Failure)))
+}
+
+func (cm *commandMonitor) end(requestId int64, err error) {
+	val, ok := cm.records.LoadAndDelete(requestId)
+	if !ok {
+		return
+	}
+	cr := val.(*commandRecord)
+	cr.rec.EndWithErrorOpt(err, cr.opt)
+}
+
+// collectionOf returns the collection of the command, which is the value of the command name key,
+// e.g. {"find": "users", "filter": {...}}
+func collectionOf(cmd bson.Raw, commandName string) string {
+	val, err := cmd.LookupErr(commandName)
+	if err != nil {
+		return ""
+	}
+	collection, _ := val.StringValueOK()
+	return collection
+}
+
+type errFailure string
+
+func (e errFailure) Error() string {
+	return string(e)
+}
@@ -0,0 +1,143 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tenz-io/trackingo/monitor"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// counterValue returns the value of the counter of the single flight monitor with the labels
+func counterValue(t *testing.T, cmd, dsCmd, code, opt string) float64 {
+	t.Helper()
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather metrics error = %v", err)
+	}
+
+	want := map[string]string{"cmd": cmd, "dsCmd": dsCmd, "code": code, "opt": opt}
+	for _, mf := range mfs {
+		if mf.GetName() != "trackingo_flight_singleFlightC" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			matched := 0
+			for _, l := range m.GetLabel() {
+				if v, ok := want[l.GetName()]; ok && v == l.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(want) {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+// eventually waits the counter to be want, the recorders count asynchronously
+func eventually(t *testing.T, cmd, dsCmd, code, opt string, want float64) {
+	t.Helper()
+	for i := 0; i < 100 && counterValue(t, cmd, dsCmd, code, opt) != want; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := counterValue(t, cmd, dsCmd, code, opt); got != want {
+		t.Errorf("counter %s/%s/%s = %v, want %v", dsCmd, code, opt, got, want)
+	}
+}
+
+func started(t *testing.T, requestId int64, commandName, collection string) *event.CommandStartedEvent {
+	t.Helper()
+	cmd, err := bson.Marshal(bson.D{{Key: commandName, Value: collection}})
+	if err != nil {
+		t.Fatalf("marshal command error = %v", err)
+	}
+	return &event.CommandStartedEvent{
+		Command:     cmd,
+		CommandName: commandName,
+		RequestID:   requestId,
+	}
+}
+
+func pending(cm *commandMonitor) int {
+	n := 0
+	cm.records.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func Test_commandMonitor(t *testing.T) {
+	ctx := monitor.InitSingleFlight(context.Background(), "mongo_test")
+	cm := &commandMonitor{}
+
+	t.Run("when succeeded then the started record ends with code 0 and the collection", func(t *testing.T) {
+		before := counterValue(t, "mongo_test", "mongo_find", "0", "users")
+		cm.started(ctx, started(t, 1, "find", "users"))
+		if n := pending(cm); n != 1 {
+			t.Fatalf("pending = %v, want 1", n)
+		}
+
+		cm.succeeded(ctx, &event.CommandSucceededEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 1, CommandName: "find"},
+		})
+		if n := pending(cm); n != 0 {
+			t.Errorf("pending = %v, want 0", n)
+		}
+		eventually(t, "mongo_test", "mongo_find", "0", "users", before+1)
+	})
+
+	t.Run("when failed then the started record ends with code 1", func(t *testing.T) {
+		before := counterValue(t, "mongo_test", "mongo_insert", "1", "orders")
+		cm.started(ctx, started(t, 2, "insert", "orders"))
+		cm.failed(ctx, &event.CommandFailedEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 2, CommandName: "insert"},
+			Failure:              "duplicate key",
+		})
+		if n := pending(cm); n != 0 {
+			t.Errorf("pending = %v, want 0", n)
+		}
+		eventually(t, "mongo_test", "mongo_insert", "1", "orders", before+1)
+	})
+
+	t.Run("when the events interleave then each ends its own record", func(t *testing.T) {
+		beforeA := counterValue(t, "mongo_test", "mongo_find", "0", "a")
+		beforeB := counterValue(t, "mongo_test", "mongo_find", "1", "b")
+		cm.started(ctx, started(t, 3, "find", "a"))
+		cm.started(ctx, started(t, 4, "find", "b"))
+		cm.failed(ctx, &event.CommandFailedEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 4, CommandName: "find"},
+			Failure:              "timeout",
+		})
+		cm.succeeded(ctx, &event.CommandSucceededEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 3, CommandName: "find"},
+		})
+		eventually(t, "mongo_test", "mongo_find", "0", "a", beforeA+1)
+		eventually(t, "mongo_test", "mongo_find", "1", "b", beforeB+1)
+	})
+
+	t.Run("when finished without started then ignored", func(t *testing.T) {
+		cm.succeeded(ctx, &event.CommandSucceededEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 99, CommandName: "find"},
+		})
+		if n := pending(cm); n != 0 {
+			t.Errorf("pending = %v, want 0", n)
+		}
+	})
+
+	t.Run("when a dependency then the collection is prefixed with it", func(t *testing.T) {
+		cm := &commandMonitor{dependency: "mongodb:users-db"}
+		before := counterValue(t, "mongo_test", "mongo_find", "0", "mongodb:users-db/users")
+		cm.started(ctx, started(t, 5, "find", "users"))
+		cm.succeeded(ctx, &event.CommandSucceededEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 5, CommandName: "find"},
+		})
+		eventually(t, "mongo_test", "mongo_find", "0", "mongodb:users-db/users", before+1)
+	})
+}
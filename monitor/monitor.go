@@ -2,7 +2,6 @@ package monitor
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tenz-io/trackingo/common"
@@ -149,20 +148,9 @@ func (r *Recorder) EndWithError(err error) {
 }
 
 // EndWithErrorOpt end the recorder with error and opt
-// if error is ValError, use ValError.Code as code
+// the code is common.ErrorCode of error, e.g. ValError.Code or the worst code of MultiError
 func (r *Recorder) EndWithErrorOpt(err error, opt string) {
-	var code int
-
-	if err != nil {
-		var valErr *common.ValError
-		if match := errors.As(err, &valErr); match {
-			code = valErr.Code
-		} else {
-			code = defaultCodeErr
-		}
-	}
-
-	r.EndWithCodeOpt(code, opt)
+	r.EndWithCodeOpt(common.ErrorCode(err), opt)
 }
 
 // EndWithCodeOpt end the recorder with code and opt
@@ -175,6 +163,13 @@ func (r *Recorder) EndWithCodeOpt(code int, opt string) {
 	}()
 }
 
+// Discard drops the recorder without the metrics, e.g. the call is not made
+func (r *Recorder) Discard() {
+	go func() {
+		r.singleFlight.Decr(r.ctx, r.dsCmd, defaultCodeOk, activeKey)
+	}()
+}
+
 // exporter is the default implementation of SingleFlight
 type exporter struct {
 	cmd string
@@ -333,6 +328,18 @@ func HasSingleFlight(ctx context.Context) bool {
 	return true
 }
 
+// Cmd returns the cmd of the single flight monitor in ctx, empty if not found
+func Cmd(ctx context.Context) string {
+	mon, err := fromContext(ctx)
+	if err != nil {
+		return ""
+	}
+	if e, ok := mon.(*exporter); ok {
+		return e.cmd
+	}
+	return ""
+}
+
 // fromContext get single flight monitor from ctx
 // return error and empty monitor if not found
 // return empty monitor if found but wrong type
@@ -379,7 +386,7 @@ func CopyToContext(srcCtx, dstCtx context.Context) context.Context {
 
 	singleFlight, err := fromContext(srcCtx)
 	if err != nil {
-		return srcCtx
+		return dstCtx
 	}
 
 	dstCtx = WithMonitor(dstCtx, singleFlight)
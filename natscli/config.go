@@ -0,0 +1,25 @@
+package natscli
+
+import (
+	"time"
+)
+
+type Config struct {
+	URL  string `yaml:"url" json:"url" required:"true"`
+	Name string `yaml:"name" json:"name"`
+	// MaxReconnects is the number of reconnect attempts before the connection is closed, -1 for forever, the default if 0
+	MaxReconnects int `yaml:"max_reconnects" json:"max_reconnects" default:"-1"`
+	// ReconnectWait doubles after every failed reconnect attempt with jitter, up to MaxReconnectWait
+	ReconnectWait    time.Duration `yaml:"reconnect_wait" json:"reconnect_wait" default:"1s"`
+	MaxReconnectWait time.Duration `yaml:"max_reconnect_wait" json:"max_reconnect_wait" default:"30s"`
+	// AckWait is how long JetStream waits for the ack of a message before redelivering it
+	AckWait time.Duration `yaml:"ack_wait" json:"ack_wait" default:"30s"`
+	// NakDelay doubles with the deliveries of a message failed by its handler, up to AckWait
+	NakDelay time.Duration `yaml:"nak_delay" json:"nak_delay" default:"1s"`
+	// DisableTracking turns off the metrics and the traffic logs, so that the zero Config is tracked
+	DisableTracking bool `yaml:"disable_tracking" json:"disable_tracking"`
+	// MaxPayloadLen trims the payloads in traffic logs
+	MaxPayloadLen int `yaml:"max_payload_len" json:"max_payload_len" default:"1024"`
+	// Dependency is the declared dependency name, tags the metrics and traffic logs
+	Dependency string `yaml:"dependency" json:"dependency"`
+}
@@ -0,0 +1,310 @@
+// Package natscli wraps the NATS client with metrics, traffic logs and tracking info propagated in message headers.
+package natscli
+
+import (
+	"context"
+	"fmt"
+	syslog "log"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/dependencies"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/retry"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+const (
+	dsCmdPublish   = "nats_publish"
+	dsCmdRequest   = "nats_request"
+	dsCmdJSPublish = "nats_js_publish"
+	dsCmdConsume   = "nats_consume"
+
+	defaultMaxReconnects    = -1
+	defaultReconnectWait    = time.Second
+	defaultMaxReconnectWait = 30 * time.Second
+	defaultAckWait          = 30 * time.Second
+	defaultNakDelay         = time.Second
+	defaultMaxPayloadLen    = 1024
+)
+
+var (
+	ErrNotActive = fmt.Errorf("nats client is not active")
+)
+
+// Handler handles a received message, ctx carries the tracking info of the publisher.
+// for JetStream the message is acked if nil is returned, otherwise it's redelivered with backoff
+type Handler func(ctx context.Context, msg *nats.Msg) error
+
+type Client interface {
+	// Publish publishes data to subject with the tracking info of ctx in headers.
+	Publish(ctx context.Context, subject string, data []byte) error
+	// Request sends data to subject and waits for the reply until ctx is done.
+	Request(ctx context.Context, subject string, data []byte) (*nats.Msg, error)
+	// Subscribe handles the messages of subject, queue subscribers share the messages, no queue if empty.
+	Subscribe(subject, queue string, handler Handler) (*nats.Subscription, error)
+	// JetStreamPublish publishes data to the stream of subject and waits for the ack of the server.
+	JetStreamPublish(ctx context.Context, subject string, data []byte) (*nats.PubAck, error)
+	// JetStreamSubscribe handles the messages of subject with the durable consumer, acked manually.
+	JetStreamSubscribe(subject, durable string, handler Handler, opts ...nats.SubOpt) (*nats.Subscription, error)
+	// Active returns true when connected.
+	Active() bool
+	// Close drains the subscriptions and the pending messages until ctx is done, then closes the connection.
+	Close(ctx context.Context) error
+}
+
+type client struct {
+	cfg        *Config
+	mon        monitor.SingleFlight
+	conn       *nats.Conn
+	js         nats.JetStreamContext
+	dependency string // label of the declared dependency of Config.Dependency
+}
+
+// NewClient connects to the server of cfg, reconnecting with exponential backoff once connected
+func NewClient(cfg *Config) (Client, error) {
+	if cfg.MaxReconnects == 0 {
+		cfg.MaxReconnects = defaultMaxReconnects
+	}
+	if cfg.ReconnectWait <= 0 {
+		cfg.ReconnectWait = defaultReconnectWait
+	}
+	if cfg.MaxReconnectWait <= 0 {
+		cfg.MaxReconnectWait = defaultMaxReconnectWait
+	}
+	if cfg.AckWait <= 0 {
+		cfg.AckWait = defaultAckWait
+	}
+	if cfg.NakDelay <= 0 {
+		cfg.NakDelay = defaultNakDelay
+	}
+	if cfg.MaxPayloadLen <= 0 {
+		cfg.MaxPayloadLen = defaultMaxPayloadLen
+	}
+
+	c := &client{
+		cfg:        cfg,
+		mon:        monitor.NewSingleFlight("natscli"),
+		dependency: dependencies.Resolve(cfg.Dependency, dependencies.TypeNATS).Label(),
+	}
+
+	syslog.Println("[natscli] connect nats...")
+	conn, err := nats.Connect(cfg.URL,
+		nats.Name(cfg.Name),
+		nats.MaxReconnects(cfg.MaxReconnects),
+		nats.CustomReconnectDelay(func(attempts int) time.Duration {
+			return retry.Jitter(retry.Exponential(cfg.ReconnectWait, cfg.MaxReconnectWait, attempts))
+		}),
+		nats.DisconnectErrHandler(func(conn *nats.Conn, err error) {
+			c.mon.Count(context.Background(), "nats_disconnect", common.ErrorCode(err), "")
+			logger.WithError(err).Warn("nats disconnected")
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			c.mon.Count(context.Background(), "nats_reconnect", 0, "")
+			logger.WithFields(logger.Fields{
+				"url": conn.ConnectedUrl(),
+			}).Info("nats reconnected")
+		}),
+		nats.ClosedHandler(func(conn *nats.Conn) {
+			logger.WithError(conn.LastError()).Warn("nats connection closed")
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connect nats error: %w", err)
+	}
+	c.conn = conn
+
+	c.js, err = conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("jetstream context error: %w", err)
+	}
+
+	return c, nil
+}
+
+func (c *client) Active() bool {
+	return c != nil && c.conn != nil && c.conn.IsConnected()
+}
+
+func (c *client) Close(ctx context.Context) error {
+	if c == nil || c.conn == nil {
+		return nil
+	}
+
+	if err := c.conn.Drain(); err != nil {
+		c.conn.Close()
+		return fmt.Errorf("drain nats error: %w", err)
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for !c.conn.IsClosed() {
+		select {
+		case <-ctx.Done():
+			c.conn.Close()
+			return fmt.Errorf("drain nats error: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func (c *client) Publish(ctx context.Context, subject string, data []byte) (err error) {
+	msg := c.newMsg(ctx, subject, data)
+	end := c.track(ctx, dsCmdPublish, msg)
+	defer func() {
+		end(err, nil)
+	}()
+
+	if c.conn == nil {
+		return ErrNotActive
+	}
+	return c.conn.PublishMsg(msg)
+}
+
+func (c *client) Request(ctx context.Context, subject string, data []byte) (reply *nats.Msg, err error) {
+	msg := c.newMsg(ctx, subject, data)
+	end := c.track(ctx, dsCmdRequest, msg)
+	defer func() {
+		end(err, reply)
+	}()
+
+	if c.conn == nil {
+		return nil, ErrNotActive
+	}
+	return c.conn.RequestMsgWithContext(ctx, msg)
+}
+
+func (c *client) JetStreamPublish(ctx context.Context, subject string, data []byte) (ack *nats.PubAck, err error) {
+	msg := c.newMsg(ctx, subject, data)
+	end := c.track(ctx, dsCmdJSPublish, msg)
+	defer func() {
+		end(err, nil)
+	}()
+
+	if c.js == nil {
+		return nil, ErrNotActive
+	}
+	return c.js.PublishMsg(msg, nats.Context(ctx))
+}
+
+func (c *client) Subscribe(subject, queue string, handler Handler) (*nats.Subscription, error) {
+	if c.conn == nil {
+		return nil, ErrNotActive
+	}
+
+	cb := func(msg *nats.Msg) {
+		_ = c.handle(msg, handler)
+	}
+	if queue == "" {
+		return c.conn.Subscribe(subject, cb)
+	}
+	return c.conn.QueueSubscribe(subject, queue, cb)
+}
+
+func (c *client) JetStreamSubscribe(subject, durable string, handler Handler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+	if c.js == nil {
+		return nil, ErrNotActive
+	}
+
+	opts = append([]nats.SubOpt{
+		nats.Durable(durable),
+		nats.ManualAck(),
+		nats.AckWait(c.cfg.AckWait),
+	}, opts...)
+
+	return c.js.Subscribe(subject, func(msg *nats.Msg) {
+		if err := c.handle(msg, handler); err != nil {
+			_ = msg.NakWithDelay(c.nakDelay(msg))
+			return
+		}
+		_ = msg.Ack()
+	}, opts...)
+}
+
+// handle calls handler with a ctx carrying the tracking info of msg, a panic fails the message
+func (c *client) handle(msg *nats.Msg, handler Handler) (err error) {
+	ctx, cancel := tracking.Extract(context.Background(), tracking.HeaderCarrier(msg.Header))
+	defer cancel()
+
+	requestId := tracking.RequestId(ctx)
+	ctx = monitor.InitSingleFlight(ctx, "nats_"+msg.Subject)
+	ctx = logger.WithLogger(ctx, logger.WithFields(logger.Fields{
+		"subject": msg.Subject,
+	}).WithTracing(requestId))
+	te := logger.WithTrafficTracing(ctx, requestId)
+	if tracking.SamplingOf(ctx) == tracking.SamplingDrop {
+		te = te.WithPolicy(logger.NewRejectAllPolicy())
+	}
+	ctx = logger.WithTrafficEntry(ctx, te)
+
+	end := c.track(ctx, dsCmdConsume, msg)
+	defer func() {
+		if r := recover(); r != nil {
+			err = common.Internal(fmt.Errorf("nats handler panic: %v", r))
+		}
+		end(err, nil)
+	}()
+
+	return handler(ctx, msg)
+}
+
+// nakDelay returns the delay before redelivering msg, doubling NakDelay with its deliveries
+func (c *client) nakDelay(msg *nats.Msg) time.Duration {
+	delivered := 1
+	if meta, err := msg.Metadata(); err == nil {
+		delivered = int(meta.NumDelivered)
+	}
+	return retry.Exponential(c.cfg.NakDelay, c.cfg.AckWait, delivered)
+}
+
+// newMsg returns the message of data with the tracking info of ctx in headers
+func (c *client) newMsg(ctx context.Context, subject string, data []byte) *nats.Msg {
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	ctx, _ = tracking.EnsureRequestId(ctx)
+	tracking.Inject(ctx, tracking.HeaderCarrier(msg.Header))
+	return msg
+}
+
+// track starts the metrics and traffic records of msg, labeled with its subject and the dependency,
+// the returned func ends them
+func (c *client) track(ctx context.Context, dsCmd string, msg *nats.Msg) func(err error, reply *nats.Msg) {
+	if c.cfg.DisableTracking {
+		return func(err error, reply *nats.Msg) {}
+	}
+
+	opt := msg.Subject
+	fields := logger.Fields{
+		"subject": msg.Subject,
+		"headers": msg.Header,
+		"size":    len(msg.Data),
+	}
+	if c.dependency != "" {
+		opt = c.dependency + "/" + msg.Subject
+		fields["dependency"] = c.dependency
+	}
+
+	rec := monitor.BeginRecord(ctx, dsCmd)
+	trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
+		Cmd: dsCmd,
+		Req: logger.StringLimit(string(msg.Data), c.cfg.MaxPayloadLen),
+	}, fields)
+
+	return func(err error, reply *nats.Msg) {
+		rec.EndWithErrorOpt(err, opt)
+
+		var resp any
+		if reply != nil {
+			resp = logger.StringLimit(string(reply.Data), c.cfg.MaxPayloadLen)
+		}
+		trafficRec.End(&logger.TrafficResp{
+			Code: common.ErrorCode(err),
+			Msg:  common.ErrorMsg(err),
+			Resp: resp,
+		}, logger.Fields{})
+	}
+}
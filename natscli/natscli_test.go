@@ -0,0 +1,263 @@
+package natscli
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+// newTestServer returns an embedded nats server with JetStream enabled
+func newTestServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("new nats server error = %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatalf("nats server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
+
+// newTestClient returns the client of an embedded nats server
+func newTestClient(t *testing.T) *client {
+	t.Helper()
+
+	srv := newTestServer(t)
+	c, err := NewClient(&Config{
+		URL:              srv.ClientURL(),
+		MaxReconnects:    -1,
+		ReconnectWait:    10 * time.Millisecond,
+		MaxReconnectWait: 100 * time.Millisecond,
+		AckWait:          30 * time.Second,
+		NakDelay:         10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = c.Close(ctx)
+	})
+	return c.(*client)
+}
+
+// counterValue returns the value of the counter of the single flight monitor with the labels
+func counterValue(t *testing.T, cmd, dsCmd, code, opt string) float64 {
+	t.Helper()
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather metrics error = %v", err)
+	}
+
+	want := map[string]string{"cmd": cmd, "dsCmd": dsCmd, "code": code, "opt": opt}
+	for _, mf := range mfs {
+		if mf.GetName() != "trackingo_flight_singleFlightC" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			matched := 0
+			for _, l := range m.GetLabel() {
+				if v, ok := want[l.GetName()]; ok && v == l.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(want) {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("nothing received in 5s")
+		var zero T
+		return zero
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	srv := newTestServer(t)
+
+	t.Run("when the config has only the url then the defaults are applied", func(t *testing.T) {
+		cfg := &Config{URL: srv.ClientURL()}
+		c, err := NewClient(cfg)
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		defer c.Close(context.Background())
+
+		if cfg.MaxReconnects != -1 || cfg.ReconnectWait != time.Second || cfg.MaxReconnectWait != 30*time.Second ||
+			cfg.AckWait != 30*time.Second || cfg.NakDelay != time.Second || cfg.MaxPayloadLen != 1024 || cfg.DisableTracking {
+			t.Errorf("NewClient() cfg = %+v, want the defaults", cfg)
+		}
+	})
+}
+
+func Test_client_propagation(t *testing.T) {
+	c := newTestClient(t)
+
+	t.Run("when publish then the handler gets the tracking info of the publisher", func(t *testing.T) {
+		got := make(chan *tracking.Info, 1)
+		if _, err := c.Subscribe("orders.created", "", func(ctx context.Context, msg *nats.Msg) error {
+			got <- tracking.FromContext(ctx)
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		ctx := tracking.WithRequestId(context.Background(), "req-nats")
+		ctx = tracking.WithTenant(ctx, "tenant-1")
+		if err := c.Publish(ctx, "orders.created", []byte("1")); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+
+		info := receive(t, got)
+		if info.RequestId != "req-nats" || info.Tenant != "tenant-1" {
+			t.Errorf("info = %+v, want req-nats of tenant-1", info)
+		}
+	})
+
+	t.Run("when publish without request id then one is generated", func(t *testing.T) {
+		got := make(chan string, 1)
+		if _, err := c.Subscribe("orders.paid", "", func(ctx context.Context, msg *nats.Msg) error {
+			got <- msg.Header.Get(tracking.HeaderRequestId)
+			return nil
+		}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+
+		if err := c.Publish(context.Background(), "orders.paid", []byte("1")); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if requestId := receive(t, got); requestId == "" {
+			t.Errorf("request id header is empty")
+		}
+	})
+}
+
+func Test_client_handle_panic(t *testing.T) {
+	c := newTestClient(t)
+	subject := "orders.panic"
+	code := strconv.Itoa(common.CodeInternal)
+	before := counterValue(t, "nats_"+subject, dsCmdConsume, code, subject)
+
+	got := make(chan string, 2)
+	if _, err := c.Subscribe(subject, "", func(ctx context.Context, msg *nats.Msg) error {
+		if string(msg.Data) == "boom" {
+			panic("boom")
+		}
+		got <- string(msg.Data)
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	t.Run("when the handler panics then the message fails and the next ones are handled", func(t *testing.T) {
+		_ = c.Publish(context.Background(), subject, []byte("boom"))
+		_ = c.Publish(context.Background(), subject, []byte("ok"))
+
+		if data := receive(t, got); data != "ok" {
+			t.Errorf("received = %v, want ok", data)
+		}
+		for i := 0; i < 100 && counterValue(t, "nats_"+subject, dsCmdConsume, code, subject) != before+1; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := counterValue(t, "nats_"+subject, dsCmdConsume, code, subject); n != before+1 {
+			t.Errorf("internal errors = %v, want %v", n, before+1)
+		}
+	})
+
+	t.Run("when handle panics then internal error", func(t *testing.T) {
+		err := c.handle(nats.NewMsg(subject), func(ctx context.Context, msg *nats.Msg) error {
+			panic("boom")
+		})
+		if common.ErrorCode(err) != common.CodeInternal {
+			t.Errorf("handle() error = %v, want internal", err)
+		}
+	})
+}
+
+func Test_client_JetStream_nak(t *testing.T) {
+	c := newTestClient(t)
+	if _, err := c.js.AddStream(&nats.StreamConfig{
+		Name:     "ORDERS",
+		Subjects: []string{"js.orders"},
+	}); err != nil {
+		t.Fatalf("AddStream() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var deliveries []uint64
+	done := make(chan struct{})
+	if _, err := c.JetStreamSubscribe("js.orders", "worker", func(ctx context.Context, msg *nats.Msg) error {
+		meta, _ := msg.Metadata()
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries = append(deliveries, meta.NumDelivered)
+		if meta.NumDelivered == 1 {
+			return errors.New("db down")
+		}
+		close(done)
+		return nil
+	}); err != nil {
+		t.Fatalf("JetStreamSubscribe() error = %v", err)
+	}
+
+	t.Run("when the handler fails then the message is naked and redelivered before the ack wait", func(t *testing.T) {
+		if _, err := c.JetStreamPublish(context.Background(), "js.orders", []byte("1")); err != nil {
+			t.Fatalf("JetStreamPublish() error = %v", err)
+		}
+		receive(t, done)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(deliveries) != 2 || deliveries[0] != 1 || deliveries[1] != 2 {
+			t.Errorf("deliveries = %v, want [1 2]", deliveries)
+		}
+	})
+
+	t.Run("when the handler succeeds then the message is acked", func(t *testing.T) {
+		var pending int
+		for i := 0; i < 100; i++ {
+			info, err := c.js.ConsumerInfo("ORDERS", "worker")
+			if err != nil {
+				t.Fatalf("ConsumerInfo() error = %v", err)
+			}
+			if pending = info.NumAckPending; pending == 0 {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if pending != 0 {
+			t.Errorf("ack pending = %v, want 0", pending)
+		}
+	})
+}
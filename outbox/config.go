@@ -0,0 +1,17 @@
+package outbox
+
+import (
+	"time"
+)
+
+type RelayConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" json:"poll_interval" default:"1s"`
+	BatchSize    int           `yaml:"batch_size" json:"batch_size" default:"100"`
+	MaxAttempts  int           `yaml:"max_attempts" json:"max_attempts" default:"10"`
+	// BaseBackoff doubles after every failed attempt of an event, up to MaxBackoff
+	BaseBackoff time.Duration `yaml:"base_backoff" json:"base_backoff" default:"1s"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" json:"max_backoff" default:"5m"`
+	// ClaimTimeout holds the events claimed by a relay from the others while publishing,
+	// they are claimed again after it if the relay is gone before saving them
+	ClaimTimeout time.Duration `yaml:"claim_timeout" json:"claim_timeout" default:"1m"`
+}
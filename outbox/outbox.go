@@ -0,0 +1,113 @@
+// Package outbox implements the transactional outbox: events are written in the same
+// database transaction as the business data, and a relay publishes them afterwards
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tenz-io/trackingo/tracking"
+	"gorm.io/gorm"
+)
+
+type Status int
+
+const (
+	StatusPending Status = iota
+	StatusPublished
+	StatusFailed
+)
+
+// Event is a row of the outbox_events table
+type Event struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement"`
+	Topic         string    `gorm:"size:255;not null"`
+	Key           string    `gorm:"size:255"`
+	Payload       []byte    `gorm:"type:mediumblob"`
+	Headers       string    `gorm:"type:text"`
+	Status        Status    `gorm:"not null;default:0;index:idx_outbox_pending,priority:1"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_outbox_pending,priority:2"`
+	Attempts      int       `gorm:"not null;default:0"`
+	LastError     string    `gorm:"size:1024"`
+	CreatedAt     time.Time `gorm:"not null"`
+	PublishedAt   *time.Time
+}
+
+func (Event) TableName() string {
+	return "outbox_events"
+}
+
+// Message is what the Publisher sends to the broker
+type Message struct {
+	Topic   string
+	Key     string
+	Payload []byte
+	Headers map[string]string
+}
+
+// Publisher publishes messages to the broker, e.g. a kafka or amqp producer
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// Migrate creates the outbox_events table
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Event{})
+}
+
+// Write adds an event to the outbox with tx, the transaction of the business data, so that the event
+// is published if and only if the data is committed. payload is sent as is if []byte, json otherwise.
+// the tracking info of ctx, e.g. requestId, is sent as message headers
+func Write(ctx context.Context, tx *gorm.DB, topic, key string, payload any) error {
+	var (
+		bs  []byte
+		err error
+	)
+	switch p := payload.(type) {
+	case []byte:
+		bs = p
+	case string:
+		bs = []byte(p)
+	default:
+		if bs, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("marshal payload error: %w", err)
+		}
+	}
+
+	// the tracking info of the writer without its deadline, the event is published asynchronously
+	carrier := tracking.MapCarrier{}
+	tracking.Inject(tracking.CopyToContext(ctx, context.Background()), carrier)
+	headers, err := json.Marshal(carrier)
+	if err != nil {
+		return fmt.Errorf("marshal headers error: %w", err)
+	}
+
+	now := time.Now()
+	err = tx.WithContext(ctx).Create(&Event{
+		Topic:         topic,
+		Key:           key,
+		Payload:       bs,
+		Headers:       string(headers),
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("write outbox event error: %w", err)
+	}
+	return nil
+}
+
+func (e *Event) message() *Message {
+	msg := &Message{
+		Topic:   e.Topic,
+		Key:     e.Key,
+		Payload: e.Payload,
+	}
+	if e.Headers != "" {
+		_ = json.Unmarshal([]byte(e.Headers), &msg.Headers)
+	}
+	return msg
+}
@@ -0,0 +1,72 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/tenz-io/trackingo/tracking"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "outbox.db")), &gorm.Config{
+		Logger: gormlogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite error = %v", err)
+	}
+	if err = Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return db
+}
+
+func TestWrite(t *testing.T) {
+	db := newTestDB(t)
+	ctx := tracking.WithRequestId(context.Background(), "req-outbox")
+
+	t.Run("when the transaction commits then the event is pending with the tracking headers", func(t *testing.T) {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return Write(ctx, tx, "orders", "o1", map[string]int{"id": 1})
+		})
+		if err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+
+		var event Event
+		if err = db.Where("topic = ?", "orders").First(&event).Error; err != nil {
+			t.Fatalf("find event error = %v", err)
+		}
+		if event.Status != StatusPending || event.Key != "o1" || string(event.Payload) != `{"id":1}` {
+			t.Errorf("event = %+v", event)
+		}
+		if got := tracking.MapCarrier(event.message().Headers).Get(tracking.HeaderRequestId); got != "req-outbox" {
+			t.Errorf("headers requestId = %v, want req-outbox", got)
+		}
+	})
+
+	t.Run("when the transaction rolls back then no event is written", func(t *testing.T) {
+		errAbort := errors.New("abort")
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := Write(ctx, tx, "refunds", "r1", []byte("raw")); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("Transaction() error = %v, want %v", err, errAbort)
+		}
+
+		var count int64
+		db.Model(&Event{}).Where("topic = ?", "refunds").Count(&count)
+		if count != 0 {
+			t.Errorf("events = %v, want 0", count)
+		}
+	})
+}
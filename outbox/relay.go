@@ -0,0 +1,263 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	syslog "log"
+	"sync"
+	"time"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/dborm"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/retry"
+	"github.com/tenz-io/trackingo/tracking"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	relayCmd = "outbox_relay"
+
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultMaxAttempts  = 10
+	defaultBaseBackoff  = time.Second
+	defaultMaxBackoff   = 5 * time.Minute
+	defaultClaimTimeout = time.Minute
+	maxErrorLength      = 1024
+)
+
+// Relay publishes the pending events of the outbox
+type Relay interface {
+	// Start polls and publishes the pending events in background
+	Start()
+	// Stop stops polling and waits for the current batch until ctx is done
+	Stop(ctx context.Context) error
+	// RelayOnce publishes one batch of pending events and returns the number of published ones
+	RelayOnce(ctx context.Context) (published int, err error)
+}
+
+type relay struct {
+	cfg       RelayConfig
+	manager   dborm.Manager
+	publisher Publisher
+	nowFunc   func() time.Time
+
+	stopC    chan struct{}
+	stopOnce sync.Once
+	doneC    chan struct{}
+}
+
+// NewRelay creates the relay of the outbox of manager, several instances may run at the same time,
+// batches are claimed with SKIP LOCKED for ClaimTimeout so that an event is published by one of them
+func NewRelay(manager dborm.Manager, publisher Publisher, cfg *RelayConfig) Relay {
+	r := &relay{
+		manager:   manager,
+		publisher: publisher,
+		nowFunc:   time.Now,
+		stopC:     make(chan struct{}),
+		doneC:     make(chan struct{}),
+	}
+	if cfg != nil {
+		r.cfg = *cfg
+	}
+	if r.cfg.PollInterval <= 0 {
+		r.cfg.PollInterval = defaultPollInterval
+	}
+	if r.cfg.BatchSize <= 0 {
+		r.cfg.BatchSize = defaultBatchSize
+	}
+	if r.cfg.MaxAttempts <= 0 {
+		r.cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if r.cfg.BaseBackoff <= 0 {
+		r.cfg.BaseBackoff = defaultBaseBackoff
+	}
+	if r.cfg.MaxBackoff <= 0 {
+		r.cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if r.cfg.ClaimTimeout <= 0 {
+		r.cfg.ClaimTimeout = defaultClaimTimeout
+	}
+	return r
+}
+
+func (r *relay) Start() {
+	syslog.Println("[outbox] start relay")
+
+	go func() {
+		defer close(r.doneC)
+
+		ticker := time.NewTicker(r.cfg.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stopC:
+				return
+			case <-ticker.C:
+				r.poll()
+			}
+		}
+	}()
+}
+
+func (r *relay) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() {
+		close(r.stopC)
+	})
+
+	select {
+	case <-r.doneC:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stop outbox relay error: %w", ctx.Err())
+	}
+}
+
+// poll relays batches until no more pending events, every batch with a fresh requestId
+func (r *relay) poll() {
+	for {
+		select {
+		case <-r.stopC:
+			return
+		default:
+		}
+
+		ctx := monitor.InitSingleFlight(context.Background(), relayCmd)
+		ctx, requestId := tracking.EnsureRequestId(ctx)
+		ctx = logger.WithLogger(ctx, logger.WithTracing(requestId))
+		ctx = logger.WithTrafficEntry(ctx, logger.WithTrafficTracing(ctx, requestId))
+
+		published, err := r.RelayOnce(ctx)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Warn("relay outbox events error")
+			return
+		}
+		if published < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+func (r *relay) RelayOnce(ctx context.Context) (published int, err error) {
+	rec := monitor.BeginRecord(ctx, "outbox_batch")
+	defer func() {
+		rec.EndWithError(err)
+	}()
+
+	db, err := r.manager.GetDB(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	events, err := r.claim(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	// the events are published out of the transaction of the claim, and saved one by one
+	var errs []error
+	for _, event := range events {
+		if pubErr := r.publish(ctx, event); pubErr == nil {
+			published++
+		}
+		if saveErr := db.WithContext(ctx).Save(event).Error; saveErr != nil {
+			errs = append(errs, fmt.Errorf("save event %d error: %w", event.ID, saveErr))
+		}
+	}
+
+	return published, errors.Join(errs...)
+}
+
+// claim locks a batch of pending events and delays their next attempt by ClaimTimeout,
+// so that the other relays skip them until they are saved
+func (r *relay) claim(ctx context.Context, db *gorm.DB) (events []*Event, err error) {
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := r.nowFunc()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+			Order("id").
+			Limit(r.cfg.BatchSize).
+			Find(&events).Error
+		if err != nil {
+			return fmt.Errorf("find pending events error: %w", err)
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		err = tx.Model(&Event{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(r.cfg.ClaimTimeout)).Error
+		if err != nil {
+			return fmt.Errorf("claim events error: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// publish publishes one event and updates its status, failed attempts are retried with backoff
+func (r *relay) publish(ctx context.Context, event *Event) (err error) {
+	msg := event.message()
+
+	rec := monitor.BeginRecord(ctx, "outbox_publish")
+	trafficRec := logger.StartTrafficRec(ctx, &logger.TrafficReq{
+		Cmd: "outbox_publish",
+		Req: string(msg.Payload),
+	}, logger.Fields{
+		"event_id": event.ID,
+		"topic":    msg.Topic,
+		"key":      msg.Key,
+		"headers":  msg.Headers,
+		"attempt":  event.Attempts + 1,
+	})
+	defer func() {
+		rec.EndWithErrorOpt(err, msg.Topic)
+		trafficRec.End(&logger.TrafficResp{
+			Code: common.ErrorCode(err),
+			Msg:  common.ErrorMsg(err),
+		}, logger.Fields{
+			"status": event.Status,
+		})
+	}()
+
+	event.Attempts++
+	err = r.publisher.Publish(ctx, msg)
+	now := r.nowFunc()
+
+	if err == nil {
+		event.Status = StatusPublished
+		event.PublishedAt = &now
+		event.LastError = ""
+		return nil
+	}
+
+	event.LastError = logger.StringLimit(err.Error(), maxErrorLength)
+	if event.Attempts >= r.cfg.MaxAttempts {
+		event.Status = StatusFailed
+		monitor.FromContext(ctx).Count(ctx, "outbox_failed", common.ErrorCode(err), msg.Topic)
+		logger.FromContext(ctx).WithError(err).WithFields(logger.Fields{
+			"event_id": event.ID,
+			"topic":    msg.Topic,
+		}).Error("outbox event failed after max attempts")
+		return err
+	}
+
+	event.NextAttemptAt = now.Add(r.backoff(event.Attempts))
+	return err
+}
+
+// backoff returns the delay before the next attempt, doubling BaseBackoff after every attempt
+func (r *relay) backoff(attempts int) time.Duration {
+	return retry.Exponential(r.cfg.BaseBackoff, r.cfg.MaxBackoff, attempts)
+}
@@ -0,0 +1,154 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tenz-io/trackingo/dborm"
+	"github.com/tenz-io/trackingo/tracking"
+	"gorm.io/gorm"
+)
+
+type stubPublisher struct {
+	err  error
+	msgs []*Message
+	// failing fails the messages of the topics with err
+	failing map[string]bool
+}
+
+func (p *stubPublisher) Publish(ctx context.Context, msg *Message) error {
+	p.msgs = append(p.msgs, msg)
+	if p.failing != nil && !p.failing[msg.Topic] {
+		return nil
+	}
+	return p.err
+}
+
+// stubManager returns db
+type stubManager struct {
+	dborm.Manager
+	db *gorm.DB
+}
+
+func (m *stubManager) GetDB(ctx context.Context) (*gorm.DB, error) {
+	return m.db, nil
+}
+
+func TestRelay_publish(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newRelay := func(pub Publisher) *relay {
+		r := NewRelay(nil, pub, &RelayConfig{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: 3 * time.Second}).(*relay)
+		r.nowFunc = func() time.Time { return now }
+		return r
+	}
+
+	t.Run("when published then mark it published", func(t *testing.T) {
+		pub := &stubPublisher{}
+		event := &Event{ID: 1, Topic: "orders", Key: "o1", Payload: []byte(`{}`), Headers: `{"x-request-id":"req-1"}`}
+		if err := newRelay(pub).publish(context.Background(), event); err != nil {
+			t.Fatalf("publish() error = %v", err)
+		}
+		if event.Status != StatusPublished || event.PublishedAt == nil || event.Attempts != 1 {
+			t.Errorf("publish() event = %+v", event)
+		}
+		if len(pub.msgs) != 1 || tracking.MapCarrier(pub.msgs[0].Headers).Get(tracking.HeaderRequestId) != "req-1" {
+			t.Errorf("publish() msgs = %+v", pub.msgs)
+		}
+	})
+
+	t.Run("when publish fails then retry with backoff", func(t *testing.T) {
+		pub := &stubPublisher{err: errors.New("broker down")}
+		event := &Event{ID: 1, Topic: "orders", Attempts: 1}
+		_ = newRelay(pub).publish(context.Background(), event)
+		if event.Status != StatusPending || event.Attempts != 2 || !event.NextAttemptAt.Equal(now.Add(2*time.Second)) {
+			t.Errorf("publish() event = %+v", event)
+		}
+		if event.LastError != "broker down" {
+			t.Errorf("publish() last error = %v", event.LastError)
+		}
+	})
+
+	t.Run("when max attempts reached then mark it failed", func(t *testing.T) {
+		pub := &stubPublisher{err: errors.New("broker down")}
+		event := &Event{ID: 1, Topic: "orders", Attempts: 2}
+		_ = newRelay(pub).publish(context.Background(), event)
+		if event.Status != StatusFailed {
+			t.Errorf("publish() status = %v, want %v", event.Status, StatusFailed)
+		}
+	})
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	for _, topic := range []string{"orders", "refunds", "orders", "users"} {
+		if err := Write(ctx, db, topic, "", "{}"); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	now := time.Now()
+	pub := &stubPublisher{err: errors.New("broker down"), failing: map[string]bool{"refunds": true}}
+	r := NewRelay(&stubManager{db: db}, pub, &RelayConfig{BatchSize: 10}).(*relay)
+	r.nowFunc = func() time.Time { return now }
+
+	// the update of the users event fails
+	errSave := errors.New("save failed")
+	_ = db.Callback().Update().Before("gorm:update").Register("test:fail_users", func(tx *gorm.DB) {
+		if event, ok := tx.Statement.Dest.(*Event); ok && event.Topic == "users" {
+			_ = tx.AddError(errSave)
+		}
+	})
+
+	published, err := r.RelayOnce(ctx)
+
+	t.Run("when an event fails to save then the others are still saved", func(t *testing.T) {
+		if published != 3 || !errors.Is(err, errSave) {
+			t.Errorf("RelayOnce() = %v, %v, want 3 and the save error", published, err)
+		}
+
+		var events []*Event
+		db.Order("id").Find(&events)
+		statuses := make([]Status, len(events))
+		for i, event := range events {
+			statuses[i] = event.Status
+		}
+		if len(events) != 4 || statuses[0] != StatusPublished || statuses[1] != StatusPending || statuses[2] != StatusPublished {
+			t.Errorf("statuses = %v, want the orders published and the refund pending", statuses)
+		}
+		if events[1].Attempts != 1 || events[1].LastError != "broker down" {
+			t.Errorf("refund = %+v, want 1 attempt with the error", events[1])
+		}
+	})
+
+	t.Run("when an event is claimed and not saved then it is skipped until the claim times out", func(t *testing.T) {
+		var users Event
+		db.Where("topic = ?", "users").First(&users)
+		if users.Status != StatusPending || !users.NextAttemptAt.After(now) {
+			t.Fatalf("users = %+v, want pending and claimed", users)
+		}
+
+		pub.msgs = nil
+		r.nowFunc = func() time.Time { return now.Add(2 * time.Second) }
+		if _, _ = r.RelayOnce(ctx); len(pub.msgs) != 1 || pub.msgs[0].Topic != "refunds" {
+			t.Errorf("published = %+v, want the refund retried only", pub.msgs)
+		}
+
+		pub.msgs = nil
+		r.nowFunc = func() time.Time { return now.Add(2 * r.cfg.ClaimTimeout) }
+		if _, _ = r.RelayOnce(ctx); len(pub.msgs) != 2 {
+			t.Errorf("published = %+v, want the refund and the users", pub.msgs)
+		}
+	})
+}
+
+func TestRelay_backoff(t *testing.T) {
+	r := NewRelay(nil, nil, &RelayConfig{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second}).(*relay)
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 20: 5 * time.Second} {
+		if got := r.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBaseBackoff = 50 * time.Millisecond
+	defaultMaxBackoff  = time.Second
+)
+
+// Func is the operation to retry, it must be idempotent
+type Func func(ctx context.Context) error
+
+// Hook is called before sleeping for the next attempt, attempt is the one just failed
+type Hook func(ctx context.Context, attempt int, err error, delay time.Duration)
+
+type retrier struct {
+	maxAttempts int
+	maxElapsed  time.Duration
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	retryable   func(err error) bool
+	hooks       []Hook
+}
+
+type Opt func(r *retrier)
+
+// WithMaxAttempts sets the max attempts including the first one, 0 for no limit
+func WithMaxAttempts(attempts int) Opt {
+	return func(r *retrier) {
+		r.maxAttempts = attempts
+	}
+}
+
+// WithMaxElapsed stops retrying once the next attempt would start after elapsed since the first one
+func WithMaxElapsed(elapsed time.Duration) Opt {
+	return func(r *retrier) {
+		r.maxElapsed = elapsed
+	}
+}
+
+// WithBackoff sets the initial and the max backoff between attempts,
+// backoff doubles on every retry with jitter
+func WithBackoff(base, max time.Duration) Opt {
+	return func(r *retrier) {
+		r.baseBackoff = base
+		r.maxBackoff = max
+	}
+}
+
+// WithRetryable decides which errors are retried, all but Permanent ones by default
+func WithRetryable(retryable func(err error) bool) Opt {
+	return func(r *retrier) {
+		r.retryable = retryable
+	}
+}
+
+// WithHook adds a hook called on every retry, e.g. to record more metrics
+func WithHook(hook Hook) Opt {
+	return func(r *retrier) {
+		r.hooks = append(r.hooks, hook)
+	}
+}
+
+// Do calls fn until it succeeds, fails with a non retryable error, the attempts or elapsed time
+// run out, or ctx is done. the last error of fn is returned.
+// name is the metrics dsCmd, retry_<name> counts the retries by attempt
+// and retry_<name>_attempts samples the attempts of every call
+func Do(ctx context.Context, name string, fn Func, opts ...Opt) (err error) {
+	r := &retrier{
+		maxAttempts: defaultMaxAttempts,
+		baseBackoff: defaultBaseBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		retryable:   Retryable,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	var (
+		attempt int
+		begin   = time.Now()
+		mon     = monitor.FromContext(ctx)
+	)
+	defer func() {
+		mon.Sample(ctx, "retry_"+name+"_attempts", common.ErrorCode(err), float64(attempt), "")
+	}()
+
+	for attempt = 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+
+		var pe *permanentError
+		if errors.As(err, &pe) {
+			return unwrapPermanent(err)
+		}
+		if r.maxAttempts > 0 && attempt >= r.maxAttempts {
+			return err
+		}
+		if !r.retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := Jitter(Exponential(r.baseBackoff, r.maxBackoff, attempt))
+		if r.maxElapsed > 0 && time.Since(begin)+delay > r.maxElapsed {
+			return err
+		}
+
+		mon.Count(ctx, "retry_"+name, common.ErrorCode(err), strconv.Itoa(attempt))
+		logger.FromContext(ctx).WithError(err).WithFields(logger.Fields{
+			"name":    name,
+			"attempt": attempt,
+			"delay":   delay.String(),
+		}).Warn("retry on error")
+		for _, hook := range r.hooks {
+			hook(ctx, attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Exponential returns base doubled for every attempt after the first one, at most max
+func Exponential(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		if max > 0 && delay >= max {
+			break
+		}
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// Jitter returns a random duration in [d/2, d], so that the callers failing together don't retry together
+func Jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as not retryable, Do returns err itself
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retryable is the default classifier, every error is retried except the context errors.
+// Permanent errors are never retried whatever the classifier
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func unwrapPermanent(err error) error {
+	var pe *permanentError
+	if errors.As(err, &pe) && err == error(pe) {
+		return pe.err
+	}
+	return err
+}
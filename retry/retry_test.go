@@ -0,0 +1,127 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	errTemp := errors.New("temporary")
+	errBad := errors.New("bad request")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		opts      []Opt
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "when first attempt succeeds then call once",
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "when error is retryable then retry until success",
+			errs:      []error{errTemp, errTemp, nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "when error persists then stop at max attempts",
+			errs:      []error{errTemp, errTemp, errTemp, nil},
+			wantCalls: 3,
+			wantErr:   errTemp,
+		},
+		{
+			name:      "when error is permanent then return it unwrapped",
+			errs:      []error{Permanent(errBad)},
+			wantCalls: 1,
+			wantErr:   errBad,
+		},
+		{
+			name:      "when classifier rejects error then return without retry",
+			errs:      []error{errBad},
+			opts:      []Opt{WithRetryable(func(err error) bool { return err == errTemp })},
+			wantCalls: 1,
+			wantErr:   errBad,
+		},
+		{
+			name:      "when max elapsed is exceeded then stop",
+			errs:      []error{errTemp, errTemp, nil},
+			opts:      []Opt{WithBackoff(time.Second, time.Second), WithMaxElapsed(10 * time.Millisecond)},
+			wantCalls: 1,
+			wantErr:   errTemp,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			opts := append([]Opt{WithBackoff(time.Millisecond, time.Millisecond)}, tt.opts...)
+			err := Do(context.Background(), "test", func(ctx context.Context) error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			}, opts...)
+			if err != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Do() calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDo_Hook(t *testing.T) {
+	var attempts []int
+	_ = Do(context.Background(), "test", func(ctx context.Context) error {
+		return errors.New("temporary")
+	}, WithBackoff(time.Millisecond, time.Millisecond), WithHook(func(ctx context.Context, attempt int, err error, delay time.Duration) {
+		attempts = append(attempts, attempt)
+	}))
+
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("hook attempts = %v, want [1 2]", attempts)
+	}
+}
+
+func TestDo_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Do(ctx, "test", func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errors.New("temporary")
+	}, WithMaxAttempts(0))
+
+	if err == nil || calls != 1 {
+		t.Errorf("Do() error = %v, calls = %v, want error after 1 call", err, calls)
+	}
+}
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 3, want: 4 * time.Second},
+		{attempt: 10, want: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := Exponential(time.Second, 5*time.Second, tt.attempt); got != tt.want {
+			t.Errorf("Exponential(%v) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if got := Jitter(time.Second); got < time.Second/2 || got > time.Second {
+			t.Fatalf("Jitter() = %v, want in [500ms, 1s]", got)
+		}
+	}
+}
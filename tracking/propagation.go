@@ -0,0 +1,138 @@
+package tracking
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderRequestId = "X-Request-Id"
+	HeaderTraceId   = "X-Trace-Id"
+	HeaderSpanId    = "X-Span-Id"
+	HeaderTenant    = "X-Tenant-Id"
+	// HeaderSampled is the sampling decision, 1 to keep and 0 to drop
+	HeaderSampled = "X-Sampled"
+	// HeaderBudget is the remaining time of the deadline in milliseconds
+	HeaderBudget = "X-Deadline-Budget"
+)
+
+// Carrier carries the propagated values, e.g. http headers or message headers
+type Carrier interface {
+	Get(key string) string
+	Set(key, val string)
+}
+
+// HeaderCarrier carries the values in http headers
+type HeaderCarrier http.Header
+
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+func (c HeaderCarrier) Set(key, val string) {
+	http.Header(c).Set(key, val)
+}
+
+// MetadataCarrier carries the values in lower case keys of multi-valued maps, e.g. grpc metadata.MD
+type MetadataCarrier map[string][]string
+
+func (c MetadataCarrier) Get(key string) string {
+	if vals := c[strings.ToLower(key)]; len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+func (c MetadataCarrier) Set(key, val string) {
+	c[strings.ToLower(key)] = []string{val}
+}
+
+// MapCarrier carries the values in lower case keys of maps, e.g. kafka or nats message headers
+type MapCarrier map[string]string
+
+func (c MapCarrier) Get(key string) string {
+	return c[strings.ToLower(key)]
+}
+
+func (c MapCarrier) Set(key, val string) {
+	c[strings.ToLower(key)] = val
+}
+
+// Inject writes the Info of ctx to carrier, and the remaining time of the deadline of ctx as budget.
+// the span id of ctx is sent as the parent span of the callee
+func Inject(ctx context.Context, carrier Carrier) {
+	info := FromContext(ctx)
+
+	set := func(key, val string) {
+		if val != "" {
+			carrier.Set(key, val)
+		}
+	}
+	set(HeaderRequestId, info.RequestId)
+	set(HeaderTraceId, info.TraceId)
+	set(HeaderSpanId, info.SpanId)
+	set(HeaderTenant, info.Tenant)
+	switch info.Sampling {
+	case SamplingKeep:
+		carrier.Set(HeaderSampled, "1")
+	case SamplingDrop:
+		carrier.Set(HeaderSampled, "0")
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if budget := time.Until(deadline).Milliseconds(); budget > 0 {
+			carrier.Set(HeaderBudget, strconv.FormatInt(budget, 10))
+		}
+	}
+}
+
+// Extract reads the Info from carrier into ctx, starting a new span whose parent is the caller's one.
+// the trace and the parent span are read from the W3C traceparent if the carrier has no trace id.
+// a requestId is generated if the carrier has none. when the carrier has a budget, the returned ctx
+// has it as timeout and cancel must be called, otherwise cancel is a no-op
+func Extract(ctx context.Context, carrier Carrier) (context.Context, context.CancelFunc) {
+	info := &Info{
+		RequestId:    carrier.Get(HeaderRequestId),
+		TraceId:      carrier.Get(HeaderTraceId),
+		ParentSpanId: carrier.Get(HeaderSpanId),
+		SpanId:       NewSpanId(),
+		Tenant:       carrier.Get(HeaderTenant),
+	}
+	switch carrier.Get(HeaderSampled) {
+	case "1":
+		info.Sampling = SamplingKeep
+	case "0":
+		info.Sampling = SamplingDrop
+	}
+	if info.TraceId == "" {
+		// the caller behind Envoy or an OpenTelemetry sdk
+		if tp, err := ParseTraceparent(carrier.Get(HeaderTraceparent)); err == nil {
+			info.TraceId, info.ParentSpanId = tp.TraceId, tp.SpanId
+		}
+	}
+	if info.RequestId == "" {
+		info.RequestId = NewRequestId()
+	}
+	if info.TraceId == "" {
+		info.TraceId = info.RequestId
+	}
+	ctx = WithInfo(ctx, info)
+
+	budget, err := strconv.ParseInt(carrier.Get(HeaderBudget), 10, 64)
+	if err != nil || budget <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Duration(budget)*time.Millisecond)
+}
+
+// Budget returns the remaining time of the deadline of ctx
+func Budget(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
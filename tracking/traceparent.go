@@ -0,0 +1,85 @@
+package tracking
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// HeaderTraceparent is the W3C trace context header, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	HeaderTraceparent = "traceparent"
+
+	traceparentSampled = 0x01
+)
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+// Traceparent is the W3C trace context of the caller, e.g. sent by Envoy or the OpenTelemetry sdks
+type Traceparent struct {
+	Version string
+	TraceId string // 32 lower hex chars
+	SpanId  string // 16 lower hex chars, the parent span of the callee
+	Flags   byte
+}
+
+// Sampled returns true if the caller records the trace
+func (tp Traceparent) Sampled() bool {
+	return tp.Flags&traceparentSampled != 0
+}
+
+// ParseTraceparent parses the traceparent header of version-traceid-parentid-flags.
+// the versions after 00 may have more fields, which are ignored
+func ParseTraceparent(header string) (Traceparent, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return Traceparent{}, errInvalidTraceparent
+	}
+
+	version, traceId, spanId, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return Traceparent{}, errInvalidTraceparent
+	}
+	if !isLowerHex(traceId, 32) || isZeros(traceId) || !isLowerHex(spanId, 16) || isZeros(spanId) {
+		return Traceparent{}, errInvalidTraceparent
+	}
+	if !isLowerHex(flags, 2) {
+		return Traceparent{}, errInvalidTraceparent
+	}
+
+	return Traceparent{
+		Version: version,
+		TraceId: traceId,
+		SpanId:  spanId,
+		Flags:   hexByte(flags),
+	}, nil
+}
+
+func isLowerHex(s string, size int) bool {
+	if len(s) != size {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeros(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+func hexByte(s string) byte {
+	var b byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= '9' {
+			b = b<<4 | (c - '0')
+		} else {
+			b = b<<4 | (c - 'a' + 10)
+		}
+	}
+	return b
+}
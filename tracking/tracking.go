@@ -0,0 +1,133 @@
+// Package tracking defines the canonical request context shared by all trackingo packages:
+// requestId, trace and span ids, tenant, the sampling decision and the deadline budget, with the helpers propagating
+// them through http headers, grpc metadata and message headers
+package tracking
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type trackingCtxKeyType string
+
+const (
+	trackingCtxKey = trackingCtxKeyType("_tracking_ctx_key")
+)
+
+// Sampling is the traffic log sampling decision, made once by the first service of the request
+// and followed by the downstream ones, so that a request is logged by all of them or none
+type Sampling int8
+
+const (
+	SamplingUnset Sampling = 0
+	SamplingKeep  Sampling = 1
+	SamplingDrop  Sampling = -1
+)
+
+// Info is the request context propagated across services
+type Info struct {
+	RequestId    string
+	TraceId      string
+	SpanId       string
+	ParentSpanId string
+	Tenant       string
+	Sampling     Sampling
+}
+
+// FromContext returns a copy of the Info of ctx, empty if not found, so it's never nil
+func FromContext(ctx context.Context) *Info {
+	if ctx == nil {
+		return &Info{}
+	}
+	if info, ok := ctx.Value(trackingCtxKey).(*Info); ok {
+		infoCopy := *info
+		return &infoCopy
+	}
+	return &Info{}
+}
+
+// WithInfo returns a copy of ctx carrying info
+func WithInfo(ctx context.Context, info *Info) context.Context {
+	if ctx == nil || info == nil {
+		return ctx
+	}
+	infoCopy := *info
+	return context.WithValue(ctx, trackingCtxKey, &infoCopy)
+}
+
+// RequestId returns the requestId of ctx, empty if not set
+func RequestId(ctx context.Context) string {
+	return FromContext(ctx).RequestId
+}
+
+// WithRequestId returns a copy of ctx with the requestId
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	info := FromContext(ctx)
+	info.RequestId = requestId
+	return WithInfo(ctx, info)
+}
+
+// EnsureRequestId returns ctx with a requestId, a new one is generated if ctx has none
+func EnsureRequestId(ctx context.Context) (context.Context, string) {
+	info := FromContext(ctx)
+	if info.RequestId != "" {
+		return ctx, info.RequestId
+	}
+	info.RequestId = NewRequestId()
+	if info.TraceId == "" {
+		info.TraceId = info.RequestId
+	}
+	return WithInfo(ctx, info), info.RequestId
+}
+
+// Tenant returns the tenant of ctx, empty if not set
+func Tenant(ctx context.Context) string {
+	return FromContext(ctx).Tenant
+}
+
+// WithTenant returns a copy of ctx with the tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	info := FromContext(ctx)
+	info.Tenant = tenant
+	return WithInfo(ctx, info)
+}
+
+// SamplingOf returns the sampling decision of ctx, SamplingUnset if not made yet
+func SamplingOf(ctx context.Context) Sampling {
+	return FromContext(ctx).Sampling
+}
+
+// WithSampling returns a copy of ctx with the sampling decision
+func WithSampling(ctx context.Context, sampling Sampling) context.Context {
+	info := FromContext(ctx)
+	info.Sampling = sampling
+	return WithInfo(ctx, info)
+}
+
+// NewRequestId generates a requestId, an uuid without '-'
+func NewRequestId() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// NewSpanId generates a span id of 16 hex chars
+func NewSpanId() string {
+	bs := make([]byte, 8)
+	_, _ = rand.Read(bs)
+	return hex.EncodeToString(bs)
+}
+
+// CopyToContext copies the Info of srcCtx to dstCtx, without the deadline and cancellation of srcCtx
+func CopyToContext(srcCtx, dstCtx context.Context) context.Context {
+	if srcCtx == nil || dstCtx == nil {
+		return dstCtx
+	}
+	info, ok := srcCtx.Value(trackingCtxKey).(*Info)
+	if !ok {
+		return dstCtx
+	}
+	return WithInfo(dstCtx, info)
+}
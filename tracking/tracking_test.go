@@ -0,0 +1,143 @@
+package tracking
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestInjectExtract(t *testing.T) {
+	t.Run("when caller has info then callee continues the trace", func(t *testing.T) {
+		ctx := WithInfo(context.Background(), &Info{
+			RequestId: "req-1",
+			TraceId:   "trace-1",
+			SpanId:    "span-1",
+			Tenant:    "tenant-1",
+		})
+
+		header := http.Header{}
+		Inject(ctx, HeaderCarrier(header))
+
+		got, cancel := Extract(context.Background(), HeaderCarrier(header))
+		defer cancel()
+
+		info := FromContext(got)
+		if info.RequestId != "req-1" || info.TraceId != "trace-1" || info.Tenant != "tenant-1" {
+			t.Errorf("Extract() info = %+v", info)
+		}
+		if info.ParentSpanId != "span-1" {
+			t.Errorf("Extract() ParentSpanId = %v, want span-1", info.ParentSpanId)
+		}
+		if info.SpanId == "" || info.SpanId == "span-1" {
+			t.Errorf("Extract() SpanId = %v, want a new span", info.SpanId)
+		}
+	})
+
+	t.Run("when carrier is empty then generate requestId", func(t *testing.T) {
+		got, cancel := Extract(context.Background(), MapCarrier{})
+		defer cancel()
+
+		info := FromContext(got)
+		if info.RequestId == "" {
+			t.Errorf("Extract() RequestId is empty")
+		}
+		if info.TraceId != info.RequestId {
+			t.Errorf("Extract() TraceId = %v, want %v", info.TraceId, info.RequestId)
+		}
+		if _, ok := got.Deadline(); ok {
+			t.Errorf("Extract() has deadline without budget")
+		}
+	})
+
+	t.Run("when caller has deadline then callee gets the budget", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(WithRequestId(context.Background(), "req-1"), time.Minute)
+		defer cancel()
+
+		md := MetadataCarrier{}
+		Inject(ctx, md)
+		if len(md["x-deadline-budget"]) == 0 {
+			t.Fatalf("Inject() budget is missing: %v", md)
+		}
+
+		got, cancel2 := Extract(context.Background(), md)
+		defer cancel2()
+
+		budget, ok := Budget(got)
+		if !ok || budget <= 0 || budget > time.Minute {
+			t.Errorf("Budget() = %v, %v", budget, ok)
+		}
+	})
+}
+
+func TestEnsureRequestId(t *testing.T) {
+	t.Run("when requestId exists then keep it", func(t *testing.T) {
+		ctx, requestId := EnsureRequestId(WithRequestId(context.Background(), "req-1"))
+		if requestId != "req-1" || RequestId(ctx) != "req-1" {
+			t.Errorf("EnsureRequestId() = %v, want req-1", requestId)
+		}
+	})
+
+	t.Run("when requestId is missing then generate one", func(t *testing.T) {
+		ctx, requestId := EnsureRequestId(context.Background())
+		if requestId == "" || RequestId(ctx) != requestId {
+			t.Errorf("EnsureRequestId() = %v, ctx has %v", requestId, RequestId(ctx))
+		}
+	})
+}
+
+func TestCopyToContext(t *testing.T) {
+	src := WithTenant(WithRequestId(context.Background(), "req-1"), "tenant-1")
+	dst := CopyToContext(src, context.Background())
+
+	if RequestId(dst) != "req-1" || Tenant(dst) != "tenant-1" {
+		t.Errorf("CopyToContext() info = %+v", FromContext(dst))
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    Traceparent
+		wantErr bool
+	}{
+		{
+			name:   "when header is valid then parse it",
+			header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:   Traceparent{Version: "00", TraceId: "4bf92f3577b34da6a3ce929d0e0e4736", SpanId: "00f067aa0ba902b7", Flags: 1},
+		},
+		{
+			name:   "when version is future then ignore the extra fields",
+			header: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra",
+			want:   Traceparent{Version: "cc", TraceId: "4bf92f3577b34da6a3ce929d0e0e4736", SpanId: "00f067aa0ba902b7"},
+		},
+		{name: "when trace id is zeros then error", header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{name: "when hex is upper then error", header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "when version 00 has extra fields then error", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x", wantErr: true},
+		{name: "when header is empty then error", header: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTraceparent(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTraceparent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTraceparent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("when carrier has traceparent only then continue its trace", func(t *testing.T) {
+		ctx, cancel := Extract(context.Background(), MapCarrier{
+			HeaderTraceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		})
+		defer cancel()
+
+		info := FromContext(ctx)
+		if info.TraceId != "4bf92f3577b34da6a3ce929d0e0e4736" || info.ParentSpanId != "00f067aa0ba902b7" || info.RequestId == "" {
+			t.Errorf("Extract() info = %+v", info)
+		}
+	})
+}
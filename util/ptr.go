@@ -0,0 +1,56 @@
+package util
+
+// Ptr returns the pointer of v, e.g. Ptr(10) for an optional *int field.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value of p, or the zero value of T if p is nil.
+func Deref[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
+
+// DerefOr returns the value of p, or def if p is nil.
+func DerefOr[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+// Coalesce returns the first non zero value of vals, or the zero value if all are zero.
+func Coalesce[T comparable](vals ...T) T {
+	var zero T
+	for _, v := range vals {
+		if v != zero {
+			return v
+		}
+	}
+	return zero
+}
+
+// Must returns v, it panics if err is not nil. only for the initialization which can't fail.
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// As asserts v to T, returns the zero value and false if v is not a T.
+func As[T any](v any) (T, bool) {
+	t, ok := v.(T)
+	return t, ok
+}
+
+// AsOr asserts v to T, returns def if v is not a T.
+func AsOr[T any](v any, def T) T {
+	if t, ok := v.(T); ok {
+		return t
+	}
+	return def
+}
@@ -0,0 +1,53 @@
+package util
+
+// Map returns the results of fn on every element of s.
+func Map[T, R any](s []T, fn func(T) R) []R {
+	if s == nil {
+		return nil
+	}
+	res := make([]R, len(s))
+	for i, v := range s {
+		res[i] = fn(v)
+	}
+	return res
+}
+
+// Filter returns the elements of s which fn returns true for.
+func Filter[T any](s []T, fn func(T) bool) []T {
+	if s == nil {
+		return nil
+	}
+	res := make([]T, 0, len(s))
+	for _, v := range s {
+		if fn(v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// Reduce folds s into one value with fn, starting from init.
+func Reduce[T, R any](s []T, init R, fn func(acc R, v T) R) R {
+	acc := init
+	for _, v := range s {
+		acc = fn(acc, v)
+	}
+	return acc
+}
+
+// Chunk splits s into chunks of size, the last one may be shorter, e.g. for batch queries.
+// the chunks share the memory of s. it panics if size is not positive
+func Chunk[T any](s []T, size int) [][]T {
+	if size <= 0 {
+		panic("util: chunk size must be positive")
+	}
+	if len(s) == 0 {
+		return nil
+	}
+
+	chunks := make([][]T, 0, (len(s)+size-1)/size)
+	for size < len(s) {
+		s, chunks = s[size:], append(chunks, s[:size:size])
+	}
+	return append(chunks, s)
+}
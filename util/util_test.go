@@ -0,0 +1,124 @@
+package util
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestPtr(t *testing.T) {
+	p := Ptr(10)
+	if *p != 10 {
+		t.Errorf("Ptr() = %v, want 10", *p)
+	}
+	if got := Deref(p); got != 10 {
+		t.Errorf("Deref() = %v, want 10", got)
+	}
+	if got := Deref[int](nil); got != 0 {
+		t.Errorf("Deref(nil) = %v, want 0", got)
+	}
+	if got := DerefOr(nil, "def"); got != "def" {
+		t.Errorf("DerefOr(nil) = %v, want def", got)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	if got := Coalesce("", "a", "b"); got != "a" {
+		t.Errorf("Coalesce() = %v, want a", got)
+	}
+	if got := Coalesce(0, 0); got != 0 {
+		t.Errorf("Coalesce() = %v, want 0", got)
+	}
+}
+
+func TestMust(t *testing.T) {
+	if got := Must(strconv.Atoi("1")); got != 1 {
+		t.Errorf("Must() = %v, want 1", got)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Must() didn't panic on error")
+		}
+	}()
+	Must(0, errors.New("boom"))
+}
+
+func TestAs(t *testing.T) {
+	var v any = "a"
+	if got, ok := As[string](v); !ok || got != "a" {
+		t.Errorf("As[string]() = %v, %v", got, ok)
+	}
+	if _, ok := As[int](v); ok {
+		t.Errorf("As[int]() ok = true, want false")
+	}
+	if got := AsOr(v, 1); got != 1 {
+		t.Errorf("AsOr() = %v, want 1", got)
+	}
+}
+
+func TestMapFilterReduce(t *testing.T) {
+	s := []int{1, 2, 3, 4}
+	if got := Map(s, strconv.Itoa); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+		t.Errorf("Map() = %v", got)
+	}
+	if got := Filter(s, func(v int) bool { return v%2 == 0 }); !reflect.DeepEqual(got, []int{2, 4}) {
+		t.Errorf("Filter() = %v", got)
+	}
+	if got := Reduce(s, 0, func(acc, v int) int { return acc + v }); got != 10 {
+		t.Errorf("Reduce() = %v, want 10", got)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		name string
+		s    []int
+		size int
+		want [][]int
+	}{
+		{
+			name: "when empty then nil",
+			s:    nil,
+			size: 2,
+			want: nil,
+		},
+		{
+			name: "when not divisible then last chunk is shorter",
+			s:    []int{1, 2, 3, 4, 5},
+			size: 2,
+			want: [][]int{{1, 2}, {3, 4}, {5}},
+		},
+		{
+			name: "when size exceeds length then one chunk",
+			s:    []int{1, 2},
+			size: 5,
+			want: [][]int{{1, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Chunk(tt.s, tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunk() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysValues(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2}
+
+	keys := Keys(m)
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("Keys() = %v", keys)
+	}
+
+	vals := Values(m)
+	sort.Ints(vals)
+	if !reflect.DeepEqual(vals, []int{1, 2}) {
+		t.Errorf("Values() = %v", vals)
+	}
+}
@@ -0,0 +1,7 @@
+package workerpool
+
+type Config struct {
+	Name      string `yaml:"name" json:"name" default:"default"`
+	Workers   int    `yaml:"workers" json:"workers" default:"10"`
+	QueueSize int    `yaml:"queue_size" json:"queue_size" default:"100"`
+}
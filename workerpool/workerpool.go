@@ -0,0 +1,203 @@
+package workerpool
+
+import (
+	"context"
+	"fmt"
+	syslog "log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/tenz-io/trackingo/common"
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/monitor"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+const (
+	// CodeTaskPanic is the metrics code of a task which panicked
+	CodeTaskPanic = common.CodeInternal
+
+	defaultWorkers   = 10
+	defaultQueueSize = 100
+)
+
+var (
+	ErrQueueFull = fmt.Errorf("worker pool queue is full")
+	ErrClosed    = fmt.Errorf("worker pool is closed")
+)
+
+// Task is the function run by the workers, ctx carries the requestId, logger and monitor
+// of the submitter but not its cancellation, so the task outlives the request
+type Task func(ctx context.Context) error
+
+type Pool interface {
+	// Submit queues the task, it blocks until the task is queued or ctx is done
+	Submit(ctx context.Context, name string, task Task) error
+	// TrySubmit queues the task, ErrQueueFull if the queue is full
+	TrySubmit(ctx context.Context, name string, task Task) error
+	// Close stops accepting tasks and waits for the queued and running ones until ctx is done
+	Close(ctx context.Context) error
+}
+
+type queuedTask struct {
+	ctx      context.Context
+	name     string
+	task     Task
+	queuedAt time.Time
+}
+
+type pool struct {
+	cfg     *Config
+	queue   chan *queuedTask
+	mon     monitor.SingleFlight
+	lock    sync.RWMutex
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// NewPool starts the workers of cfg
+func NewPool(cfg *Config) Pool {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	p := &pool{
+		cfg:   cfg,
+		queue: make(chan *queuedTask, queueSize),
+		mon:   monitor.NewSingleFlight("workerpool_" + cfg.Name),
+	}
+
+	syslog.Printf("[workerpool] start %s with %d workers\n", cfg.Name, workers)
+	for i := 0; i < workers; i++ {
+		p.workers.Add(1)
+		go p.work()
+	}
+
+	return p
+}
+
+func (p *pool) Submit(ctx context.Context, name string, task Task) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.queue <- p.newTask(ctx, name, task):
+		p.reportDepth()
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("submit task %s error: %w", name, ctx.Err())
+	}
+}
+
+func (p *pool) TrySubmit(ctx context.Context, name string, task Task) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.queue <- p.newTask(ctx, name, task):
+		p.reportDepth()
+		return nil
+	default:
+		p.mon.Count(ctx, "task_rejected", common.ErrorCode(ErrQueueFull), name)
+		return ErrQueueFull
+	}
+}
+
+func (p *pool) Close(ctx context.Context) error {
+	p.lock.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.lock.Unlock()
+
+	doneC := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(doneC)
+	}()
+
+	select {
+	case <-doneC:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("drain worker pool %s error: %w", p.cfg.Name, ctx.Err())
+	}
+}
+
+// newTask copies the tracking values of ctx to a new context without its deadline and cancellation
+func (p *pool) newTask(ctx context.Context, name string, task Task) *queuedTask {
+	taskCtx := context.Background()
+	taskCtx = logger.CopyToContext(ctx, taskCtx)
+	taskCtx = logger.CopyTrafficToContext(ctx, taskCtx)
+	taskCtx = monitor.CopyToContext(ctx, taskCtx)
+	taskCtx = monitor.InitSingleFlight(taskCtx, "workerpool_"+p.cfg.Name)
+	taskCtx = tracking.CopyToContext(ctx, taskCtx)
+	taskCtx, _ = tracking.EnsureRequestId(taskCtx)
+
+	return &queuedTask{
+		ctx:      taskCtx,
+		name:     name,
+		task:     task,
+		queuedAt: time.Now(),
+	}
+}
+
+func (p *pool) work() {
+	defer p.workers.Done()
+
+	for t := range p.queue {
+		p.reportDepth()
+		p.run(t)
+	}
+}
+
+// run runs the task with panic recovery, the latency is recorded as task_<name>
+func (p *pool) run(t *queuedTask) {
+	ctx := t.ctx
+	monitor.FromContext(ctx).Sample(ctx, "task_wait", 0, float64(time.Since(t.queuedAt).Milliseconds()), t.name)
+
+	rec := monitor.BeginRecord(ctx, "task_"+t.name)
+	err := safeRun(ctx, t)
+	rec.EndWithError(err)
+
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).WithFields(logger.Fields{
+			"task": t.name,
+			"pool": p.cfg.Name,
+		}).Warn("task failed")
+	}
+}
+
+func safeRun(ctx context.Context, t *queuedTask) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.FromContext(ctx).ErrorWith("task panic recovery", logger.Fields{
+				"task":  t.name,
+				"panic": fmt.Sprintf("%v", r),
+				"stack": string(debug.Stack()),
+			})
+			err = common.NewValError(CodeTaskPanic, fmt.Errorf("task panic: %v", r))
+		}
+	}()
+
+	return t.task(ctx)
+}
+
+func (p *pool) reportDepth() {
+	p.mon.Set(context.Background(), "queue_depth", 0, float64(len(p.queue)), p.cfg.Name)
+}
@@ -0,0 +1,119 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tenz-io/trackingo/logger"
+	"github.com/tenz-io/trackingo/tracking"
+)
+
+func TestPool_Submit(t *testing.T) {
+	t.Run("when tasks submitted then run all before close returns", func(t *testing.T) {
+		p := NewPool(&Config{Name: "test", Workers: 2, QueueSize: 10})
+		var calls atomic.Int32
+		for i := 0; i < 10; i++ {
+			err := p.Submit(context.Background(), "count", func(ctx context.Context) error {
+				calls.Add(1)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+		}
+		if err := p.Close(context.Background()); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if calls.Load() != 10 {
+			t.Errorf("calls = %v, want 10", calls.Load())
+		}
+	})
+
+	t.Run("when submitter ctx canceled then task still runs with its requestId", func(t *testing.T) {
+		p := NewPool(&Config{Name: "test", Workers: 1, QueueSize: 1})
+		ctx, cancel := context.WithCancel(tracking.WithRequestId(context.Background(), "req-1"))
+
+		gotC := make(chan [2]any, 1)
+		_ = p.Submit(ctx, "detached", func(ctx context.Context) error {
+			gotC <- [2]any{tracking.RequestId(ctx), ctx.Err()}
+			return nil
+		})
+		cancel()
+		_ = p.Close(context.Background())
+
+		got := <-gotC
+		if got[0] != "req-1" || got[1] != nil {
+			t.Errorf("task ctx requestId = %v, err = %v, want req-1 and nil", got[0], got[1])
+		}
+	})
+
+	t.Run("when task panics then worker keeps running and the stack is logged", func(t *testing.T) {
+		out, err := os.Create(filepath.Join(t.TempDir(), "info.log"))
+		if err != nil {
+			t.Fatalf("create log file error = %v", err)
+		}
+		defer out.Close()
+		logger.Configure(logger.Config{
+			LoggingLevel:          logger.InfoLevel,
+			ConsoleLoggingEnabled: true,
+			ConsoleInfoStream:     out,
+			ConsoleErrorStream:    out,
+			ConsoleDebugStream:    out,
+		})
+		defer logger.Configure(logger.Config{LoggingLevel: logger.InfoLevel})
+
+		p := NewPool(&Config{Name: "test", Workers: 1, QueueSize: 2})
+		var calls atomic.Int32
+		_ = p.Submit(context.Background(), "panic", func(ctx context.Context) error {
+			panic("boom")
+		})
+		_ = p.Submit(context.Background(), "after_panic", func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		})
+		_ = p.Close(context.Background())
+		if calls.Load() != 1 {
+			t.Errorf("calls = %v, want 1", calls.Load())
+		}
+
+		logger.Sync()
+		bs, _ := os.ReadFile(out.Name())
+		if log := string(bs); !strings.Contains(log, "task panic recovery") || !strings.Contains(log, "workerpool.safeRun") {
+			t.Errorf("log = %s, want the panic with the stack", log)
+		}
+	})
+
+	t.Run("when queue full then TrySubmit returns ErrQueueFull", func(t *testing.T) {
+		p := NewPool(&Config{Name: "test", Workers: 1, QueueSize: 1})
+		blockC := make(chan struct{})
+		block := func(ctx context.Context) error {
+			<-blockC
+			return nil
+		}
+		_ = p.Submit(context.Background(), "block", block)
+		// wait the worker to take the first task
+		time.Sleep(10 * time.Millisecond)
+		_ = p.Submit(context.Background(), "block", block)
+
+		if err := p.TrySubmit(context.Background(), "block", block); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("TrySubmit() error = %v, want %v", err, ErrQueueFull)
+		}
+		close(blockC)
+		_ = p.Close(context.Background())
+	})
+
+	t.Run("when closed then Submit returns ErrClosed", func(t *testing.T) {
+		p := NewPool(&Config{Name: "test"})
+		_ = p.Close(context.Background())
+		err := p.Submit(context.Background(), "late", func(ctx context.Context) error { return nil })
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Submit() error = %v, want %v", err, ErrClosed)
+		}
+	})
+}